@@ -0,0 +1,234 @@
+package binance
+
+import (
+	"context"
+	"time"
+
+	api "github.com/adshao/go-binance/v2/delivery"
+	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/aulaleslie/trade-exchanges/binance/delivery"
+	"github.com/aulaleslie/trade-exchanges/utils"
+	"github.com/cockroachdb/apd"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// BinanceCoinFutures is COIN-M futures: inverse contracts margined in the base coin.
+// Quantities are number of contracts. Order ID in the methods input and output is clientOrderID internally.
+type BinanceCoinFutures struct {
+	Client         *api.Client
+	canceller      *delivery.BinanceOrderCanceller
+	orderGetter    *delivery.OrderGetter
+	orderPlacer    *delivery.OrderPlacer
+	positionGetter *delivery.PositionGetter
+	listenKeys     *ListenKeyManager
+	userData       *UserDataStream
+	rateLimiter    *BinanceRateLimiter
+	urls           BinanceURLs
+	lg             *zap.Logger
+}
+
+var _ exchanges.Exchange = (*BinanceCoinFutures)(nil) // Type check
+
+func NewBinanceCoinFutures(urls BinanceURLs, apiKey, secretKey string, lg *zap.Logger) *BinanceCoinFutures {
+	lg = lg.Named("BinanceCoinFutures")
+
+	b := &BinanceCoinFutures{}
+
+	b.rateLimiter = hostRateLimiter(urls.DeliveryAPIURL, DeliveryRateLimits, lg)
+	b.Client = NewBinanceDeliveryClient(urls.DeliveryAPIURL, apiKey, secretKey, b.rateLimiter, lg)
+	b.canceller = delivery.NewBinanceOrderCanceller(b.Client)
+	b.orderGetter = delivery.NewOrderGetter(b.Client)
+	b.orderPlacer = delivery.NewOrderPlacer(b.Client)
+	b.positionGetter = delivery.NewPositionGetter(b.Client)
+	b.listenKeys = NewListenKeyManager(deliveryListenKeys{b.Client}, lg)
+	b.userData = NewUserDataStream(b.listenKeys, urls.WSDeliveryUserDataURL, BINANCE_COIN_FUTURES_PREFIX, lg)
+	b.urls = urls
+	b.lg = lg
+	return b
+}
+
+func (b *BinanceCoinFutures) RoundPrice(
+	ctx context.Context,
+	symbol string,
+	price *apd.Decimal,
+	tickSize *string,
+) (*apd.Decimal, error) {
+	return (&BinanceFutures{}).RoundPrice(ctx, symbol, price, tickSize)
+}
+
+// RoundQuantity floors quantity to whole contracts
+func (b *BinanceCoinFutures) RoundQuantity(_ context.Context, symbol string, qty *apd.Decimal) (*apd.Decimal, error) {
+	result := &apd.Decimal{}
+	if _, err := apd.BaseContext.Floor(result, qty); err != nil {
+		return nil, errors.Wrapf(err, "can't round quantity %v", qty)
+	}
+	if result.Sign() <= 0 {
+		return nil, errors.Errorf("quantity %v is less than one contract", qty)
+	}
+	return result, nil
+}
+
+func (b *BinanceCoinFutures) GetPrefix() string {
+	return BINANCE_COIN_FUTURES_PREFIX
+}
+
+// StreamOrderKey returns the client order ID and full symbol, as WatchOrdersStatuses sends them
+func (b *BinanceCoinFutures) StreamOrderKey(o exchanges.OrderDetailInfo) (string, string) {
+	return streamOrderKey(b.GetPrefix(), o)
+}
+
+func (b *BinanceCoinFutures) GetName() string {
+	return "Binance COIN-M Futures"
+}
+
+func (b *BinanceCoinFutures) PlaceBuyOrder(ctx context.Context,
+	_ bool, symbol string, price, quantity *apd.Decimal, prefferedID string,
+) (id string, e error) {
+	binanceSymbol := ToBinanceCoinFuturesSymbol(symbol)
+	return b.orderPlacer.PlaceOrder(ctx, binanceSymbol, price, quantity, prefferedID, api.SideTypeBuy, api.OrderTypeLimit)
+}
+
+func (b *BinanceCoinFutures) PlaceSellOrder(ctx context.Context,
+	_ bool, symbol string, price, quantity *apd.Decimal, prefferedID string,
+) (id string, e error) {
+	binanceSymbol := ToBinanceCoinFuturesSymbol(symbol)
+	return b.orderPlacer.PlaceOrder(ctx, binanceSymbol, price, quantity, prefferedID, api.SideTypeSell, api.OrderTypeLimit)
+}
+
+// PlaceBuyOrderV2 Place Buy Order with OrderType param
+func (b *BinanceCoinFutures) PlaceBuyOrderV2(ctx context.Context, _ bool, symbol string, price, qty *apd.Decimal, preferredID string, orderType string) (id string, e error) {
+	binanceSymbol := ToBinanceCoinFuturesSymbol(symbol)
+	return b.orderPlacer.PlaceOrder(ctx, binanceSymbol, price, qty, preferredID, api.SideTypeBuy, api.OrderType(orderType))
+}
+
+// PlaceSellOrderV2 Place Sell Order with OrderType param
+func (b *BinanceCoinFutures) PlaceSellOrderV2(ctx context.Context, _ bool, symbol string, price, qty *apd.Decimal, preferredID string, orderType string) (id string, e error) {
+	binanceSymbol := ToBinanceCoinFuturesSymbol(symbol)
+	return b.orderPlacer.PlaceOrder(ctx, binanceSymbol, price, qty, preferredID, api.SideTypeSell, api.OrderType(orderType))
+}
+
+func (b *BinanceCoinFutures) CancelOrder(ctx context.Context, symbol, id string) error {
+	binanceSymbol := ToBinanceCoinFuturesSymbol(symbol)
+	return b.canceller.CancelOrder(ctx, binanceSymbol, id)
+}
+
+func (b *BinanceCoinFutures) ReleaseOrder(_ context.Context, symbol, id string) error {
+	return nil
+}
+
+func (b *BinanceCoinFutures) GetOrderInfo(ctx context.Context, symbol, id string, _ *time.Time) (exchanges.OrderInfo, error) {
+	return b.GetOrderInfoByClientOrderID(ctx, symbol, id, nil)
+}
+
+func (b *BinanceCoinFutures) GetOrderInfoByClientOrderID(ctx context.Context, symbol, clientOrderID string, _ *time.Time) (exchanges.OrderInfo, error) {
+	binanceSymbol := ToBinanceCoinFuturesSymbol(symbol)
+	return b.orderGetter.GetOrderInfoByClientOrderID(ctx, binanceSymbol, clientOrderID)
+}
+
+func (b *BinanceCoinFutures) GetOpenOrders(ctx context.Context) ([]exchanges.OrderDetailInfo, error) {
+	return b.orderGetter.GetOpenOrders(ctx, BINANCE_COIN_FUTURES_PREFIX)
+}
+
+func (b *BinanceCoinFutures) GetOrders(ctx context.Context, filter exchanges.OrderFilter) ([]exchanges.OrderDetailInfo, error) {
+	var symbol *string
+	if filter.Symbol != nil {
+		binanceSymbol := ToBinanceCoinFuturesSymbol(*filter.Symbol)
+		symbol = &binanceSymbol
+	}
+	return b.orderGetter.GetHistoryOrders(
+		ctx,
+		BINANCE_COIN_FUTURES_PREFIX,
+		symbol,
+		nil,
+		filter.ClientOrderID,
+		filter.Since,
+	)
+}
+
+func (b *BinanceCoinFutures) GetAccount(ctx context.Context) (exchanges.Account, error) {
+	return b.positionGetter.GetAccountPosition(ctx, BINANCE_COIN_FUTURES_PREFIX)
+}
+
+func (b *BinanceCoinFutures) GetPrice(ctx context.Context, symbol string) (*apd.Decimal, error) {
+	binanceSymbol := ToBinanceCoinFuturesSymbol(symbol)
+
+	result, err := b.Client.NewListPriceChangeStatsService().Symbol(binanceSymbol).Do(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't do request")
+	}
+	if len(result) == 0 {
+		return nil, errors.New("empty result")
+	}
+	stats := result[0]
+	if stats.Symbol != binanceSymbol {
+		return nil, errors.Errorf("got result for another symbol: %s", ToFullCoinFuturesSymbol(stats.Symbol))
+	}
+	price, _, err := apd.NewFromString(stats.LastPrice)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't convert price from '%s'", stats.LastPrice)
+	}
+	return price, nil
+}
+
+// GetTradableSymbols lists perpetual (BTCUSD_PERP) and quarterly (BTCUSD_241227) contracts
+func (b *BinanceCoinFutures) GetTradableSymbols(ctx context.Context) ([]exchanges.SymbolInfo, error) {
+	info, err := b.Client.NewExchangeInfoService().Do(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to do ExchangeInfo request")
+	}
+
+	result := []exchanges.SymbolInfo{}
+	for _, symbol := range info.Symbols {
+		instrument := deliveryInstrument(symbol)
+		fullSymbol := exchanges.Instruments.Register(BINANCE_COIN_FUTURES_PREFIX, symbol.Symbol, instrument)
+		sInfo := exchanges.SymbolInfo{
+			DisplayName:    fullSymbol,
+			Symbol:         fullSymbol,
+			OriginalSymbol: symbol.Symbol,
+			Filters:        symbol.Filters,
+			Instrument:     &instrument,
+		}
+		result = append(result, sInfo)
+	}
+	return result, nil
+}
+
+// WatchOrdersStatuses listens ORDER_TRADE_UPDATE events of user data stream
+func (b *BinanceCoinFutures) WatchOrdersStatuses(ctx context.Context) (<-chan exchanges.OrderEvent, error) {
+	return b.userData.WatchOrders(ctx)
+}
+
+// WatchAccountPositions listens ACCOUNT_UPDATE events of user data stream
+func (b *BinanceCoinFutures) WatchAccountPositions(ctx context.Context) (<-chan exchanges.PositionEvent, error) {
+	return b.userData.WatchPositions(ctx)
+}
+
+// WatchBalances shares user data socket with WatchOrdersStatuses and WatchAccountPositions
+func (b *BinanceCoinFutures) WatchBalances(ctx context.Context) (<-chan BalanceEvent, error) {
+	return b.userData.WatchBalances(ctx)
+}
+
+// WatchMarginCalls shares user data socket with WatchOrdersStatuses and WatchAccountPositions
+func (b *BinanceCoinFutures) WatchMarginCalls(ctx context.Context) (<-chan MarginCallEvent, error) {
+	return b.userData.WatchMarginCalls(ctx)
+}
+
+// WatchAccountConfig sends leverage and multi-assets mode changes, it shares user data socket too
+func (b *BinanceCoinFutures) WatchAccountConfig(ctx context.Context) (<-chan AccountConfigEvent, error) {
+	return b.userData.WatchAccountConfig(ctx)
+}
+
+// WatchSymbolPrice sends mark price of the contract every second
+func (b *BinanceCoinFutures) WatchSymbolPrice(ctx context.Context, symbol string) (<-chan exchanges.PriceEvent, error) {
+	binanceSymbol := ToBinanceCoinFuturesSymbol(symbol)
+	return SubscribeToMarkPrice(ctx, b.urls.WSDeliveryMarkPriceURL(binanceSymbol), binanceSymbol, b.lg)
+}
+
+func (b *BinanceCoinFutures) GenerateClientOrderID(ctx context.Context, identifierID string) (string, error) {
+	generatedID, err := utils.GenClientOrderID(identifierID)
+	if err != nil {
+		return "", err
+	}
+	return BINANCE_FUTURES_LINK_ID_PREFIX + "_" + generatedID, nil
+}
@@ -0,0 +1,48 @@
+package binance
+
+import (
+	"context"
+	"testing"
+
+	deliveryApi "github.com/adshao/go-binance/v2/delivery"
+	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/aulaleslie/trade-exchanges/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeliveryInstrument(t *testing.T) {
+	perp := deliveryInstrument(deliveryApi.Symbol{
+		Symbol: "BTCUSD_PERP", ContractType: "PERPETUAL",
+		BaseAsset: "BTC", QuoteAsset: "USD", MarginAsset: "BTC",
+	})
+	assert.Equal(t, exchanges.PerpetualProduct, perp.Product)
+	assert.Equal(t, "BTC", perp.Settle)
+	assert.Nil(t, perp.Expiry)
+
+	quarter := deliveryInstrument(deliveryApi.Symbol{
+		Symbol: "BTCUSD_241227", ContractType: "CURRENT_QUARTER", DeliveryDate: 1735286400000,
+		BaseAsset: "BTC", QuoteAsset: "USD", MarginAsset: "BTC",
+	})
+	assert.Equal(t, exchanges.FutureProduct, quarter.Product)
+	require.NotNil(t, quarter.Expiry)
+	assert.Equal(t, "2024-12-27", quarter.Expiry.Format("2006-01-02"))
+}
+
+func TestBinanceCoinFuturesRoundQuantity(t *testing.T) {
+	b := &BinanceCoinFutures{}
+	qty, err := b.RoundQuantity(context.Background(), "BINANCECOINM-BTCUSD_PERP", utils.FromString("3.7"))
+	require.NoError(t, err)
+	assert.Equal(t, "3", qty.Text('f'))
+
+	_, err = b.RoundQuantity(context.Background(), "BINANCECOINM-BTCUSD_PERP", utils.FromString("0.5"))
+	assert.Error(t, err)
+}
+
+func TestMapWSMarkPriceEvent(t *testing.T) {
+	msg := `{"e":"markPriceUpdate","E":1591261236000,"s":"BTCUSD_PERP","p":"9636.57860000","P":"9634.41923330","r":"0.00010000","T":1591267200000}`
+	event, err := mapWSMarkPriceEvent([]byte(msg))
+	require.NoError(t, err)
+	assert.Equal(t, "BTCUSD_PERP", event.Symbol)
+	assert.Equal(t, "9636.57860000", event.MarkPrice)
+}
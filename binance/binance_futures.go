@@ -4,12 +4,12 @@ import (
 	"context"
 	"fmt"
 	"regexp"
-	"strings"
 	"time"
 
 	api "github.com/adshao/go-binance/v2/futures"
 	exchanges "github.com/aulaleslie/trade-exchanges"
 	"github.com/aulaleslie/trade-exchanges/binance/futures"
+	"github.com/aulaleslie/trade-exchanges/binance/wsapi"
 	"github.com/aulaleslie/trade-exchanges/utils"
 	"github.com/cockroachdb/apd"
 	"github.com/pkg/errors"
@@ -25,31 +25,51 @@ const (
 type BinanceFutures struct {
 	Client         *api.Client
 	canceller      *futures.BinanceOrderCanceller
+	countdown      *futures.CountdownCanceller
 	orderGetter    *futures.OrderGetter
 	orderPlacer    *futures.OrderPlacer
 	positionGetter *futures.PositionGetter
+	listenKeys     *ListenKeyManager
+	userData       *UserDataStream
+	rateLimiter    *BinanceRateLimiter
 	urls           BinanceURLs
 	lg             *zap.Logger
 }
 
 // TODO: don't forget to check time
 var _ exchanges.Exchange = (*BinanceFutures)(nil) // Type check
+var _ exchanges.DeadMansSwitchExchange = (*BinanceFutures)(nil)
 
 func NewBinanceFutures(urls BinanceURLs, apiKey, secretKey string, lg *zap.Logger) *BinanceFutures {
 	lg = lg.Named("BinanceFutures")
 
 	b := &BinanceFutures{}
 
-	b.Client = NewBinanceFuturesClient(urls.FutureAPIURL, apiKey, secretKey, lg)
+	b.rateLimiter = hostRateLimiter(urls.FutureAPIURL, FuturesRateLimits, lg)
+	b.Client = NewBinanceFuturesClient(urls.FutureAPIURL, apiKey, secretKey, b.rateLimiter, lg)
 	b.canceller = futures.NewBinanceOrderCanceller(b.Client)
+	b.countdown = futures.NewCountdownCanceller(b.Client)
 	b.orderGetter = futures.NewOrderGetter(b.Client)
 	b.orderPlacer = futures.NewOrderPlacer(b.Client)
 	b.positionGetter = futures.NewPositionGetter(b.Client)
+	b.listenKeys = NewListenKeyManager(futuresListenKeys{b.Client}, lg)
+	b.userData = NewUserDataStream(b.listenKeys, urls.WSFuturesUserDataURL, BINANCE_FUTURES_PREFIX, lg)
 	b.urls = urls
 	b.lg = lg
 	return b
 }
 
+// EnableWSAPI places, cancels and queries orders by WebSocket API, REST is used while the socket is down.
+// The client can be configured (e.g. PrivateKey) before the first order.
+func (b *BinanceFutures) EnableWSAPI() *wsapi.Client {
+	ws := wsapi.NewClient(b.urls.FutureWSAPIURL, b.Client.APIKey, b.Client.SecretKey, b.lg)
+	ws.Limiter = b.rateLimiter
+	b.orderPlacer.UseWSAPI(ws)
+	b.canceller.UseWSAPI(ws)
+	b.orderGetter.UseWSAPI(ws)
+	return ws
+}
+
 func (b *BinanceFutures) RoundPrice(
 	_ context.Context,
 	symbol string,
@@ -83,7 +103,12 @@ func (b *BinanceFutures) RoundQuantity(_ context.Context, symbol string, qty *ap
 }
 
 func (b *BinanceFutures) GetPrefix() string {
-	return BINANCE_PREFIX
+	return BINANCE_FUTURES_PREFIX
+}
+
+// StreamOrderKey returns the client order ID and full symbol, as WatchOrdersStatuses sends them
+func (b *BinanceFutures) StreamOrderKey(o exchanges.OrderDetailInfo) (string, string) {
+	return streamOrderKey(b.GetPrefix(), o)
 }
 
 func (b *BinanceFutures) GetName() string {
@@ -93,22 +118,30 @@ func (b *BinanceFutures) GetName() string {
 func (b *BinanceFutures) PlaceBuyOrder(ctx context.Context,
 	_ bool, symbol string, price, quantity *apd.Decimal, prefferedID string,
 ) (id string, e error) {
-	binanceSymbol := ToBinanceSymbol(symbol)
+	binanceSymbol := ToBinanceFuturesSymbol(symbol)
 	return b.orderPlacer.PlaceOrder(ctx, binanceSymbol, price, quantity, prefferedID, api.SideTypeBuy)
 }
 
 func (b *BinanceFutures) PlaceSellOrder(ctx context.Context,
 	_ bool, symbol string, price, quantity *apd.Decimal, prefferedID string,
 ) (id string, e error) {
-	binanceSymbol := ToBinanceSymbol(symbol)
+	binanceSymbol := ToBinanceFuturesSymbol(symbol)
 	return b.orderPlacer.PlaceOrder(ctx, binanceSymbol, price, quantity, prefferedID, api.SideTypeSell)
 }
 
 func (b *BinanceFutures) CancelOrder(ctx context.Context, symbol, id string) error {
-	binanceSymbol := ToBinanceSymbol(symbol)
+	binanceSymbol := ToBinanceFuturesSymbol(symbol)
 	return b.canceller.CancelOrder(ctx, binanceSymbol, id)
 }
 
+// CancelAllAfter arms `countdownCancelAll` of the symbol, empty symbol arms symbols with open orders
+func (b *BinanceFutures) CancelAllAfter(ctx context.Context, symbol string, timeout time.Duration) error {
+	if symbol != "" {
+		symbol = ToBinanceFuturesSymbol(symbol)
+	}
+	return b.countdown.CancelAllAfter(ctx, symbol, timeout)
+}
+
 func (b *BinanceFutures) ReleaseOrder(_ context.Context, symbol, id string) error {
 	return nil
 }
@@ -118,7 +151,7 @@ func (b *BinanceFutures) GetOrderInfo(ctx context.Context, symbol, id string, _
 }
 
 func (b *BinanceFutures) GetOrderInfoByClientOrderID(ctx context.Context, symbol, clientOrderID string, _ *time.Time) (exchanges.OrderInfo, error) {
-	binanceSymbol := ToBinanceSymbol(symbol)
+	binanceSymbol := ToBinanceFuturesSymbol(symbol)
 	return b.orderGetter.GetOrderInfoByClientOrderID(ctx, binanceSymbol, clientOrderID)
 }
 
@@ -127,20 +160,37 @@ func (b *BinanceFutures) GetOpenOrders(ctx context.Context) ([]exchanges.OrderDe
 }
 
 func (b *BinanceFutures) GetOrders(ctx context.Context, filter exchanges.OrderFilter) ([]exchanges.OrderDetailInfo, error) {
+	var symbol *string
+	if filter.Symbol != nil {
+		binanceSymbol := ToBinanceFuturesSymbol(*filter.Symbol)
+		symbol = &binanceSymbol
+	}
 	return b.orderGetter.GetHistoryOrders(
 		ctx,
-		filter.Symbol,
+		symbol,
 		nil,
 		filter.ClientOrderID,
+		filter.Since,
 	)
 }
 
+// GetAccount returns wallet balances and non-zero positions
 func (b *BinanceFutures) GetAccount(ctx context.Context) (exchanges.Account, error) {
-	return b.positionGetter.GetAccountPosition(ctx)
+	return b.positionGetter.GetAccountPosition(ctx, BINANCE_FUTURES_PREFIX)
+}
+
+// GetPositions returns positions of the symbol or of all symbols if it's empty.
+// Zero-size positions are skipped unless `includeEmpty` is set.
+func (b *BinanceFutures) GetPositions(ctx context.Context, symbol string, includeEmpty bool) ([]exchanges.AccountPosition, error) {
+	binanceSymbol := ""
+	if symbol != "" {
+		binanceSymbol = ToBinanceFuturesSymbol(symbol)
+	}
+	return b.positionGetter.GetPositions(ctx, BINANCE_FUTURES_PREFIX, binanceSymbol, includeEmpty)
 }
 
 func (b *BinanceFutures) GetPrice(ctx context.Context, symbol string) (*apd.Decimal, error) {
-	binanceSymbol := ToBinanceSymbol(symbol)
+	binanceSymbol := ToBinanceFuturesSymbol(symbol)
 
 	result, err := b.Client.NewListPriceChangeStatsService().Symbol(binanceSymbol).Do(ctx)
 	if err != nil {
@@ -151,7 +201,7 @@ func (b *BinanceFutures) GetPrice(ctx context.Context, symbol string) (*apd.Deci
 	}
 	stats := result[0]
 	if stats.Symbol != binanceSymbol {
-		return nil, errors.Errorf("got result for another symbol: %s", ToFullSymbol(stats.Symbol))
+		return nil, errors.Errorf("got result for another symbol: %s", ToFullFuturesSymbol(stats.Symbol))
 	}
 	price, _, err := apd.NewFromString(stats.LastPrice)
 	if err != nil {
@@ -168,12 +218,14 @@ func (b *BinanceFutures) GetTradableSymbols(ctx context.Context) ([]exchanges.Sy
 
 	result := []exchanges.SymbolInfo{}
 	for _, symbol := range info.Symbols {
-		fullSymbol := ToFullSymbol(symbol.Symbol)
+		instrument := futuresInstrument(symbol)
+		fullSymbol := exchanges.Instruments.Register(BINANCE_FUTURES_PREFIX, symbol.Symbol, instrument)
 		sInfo := exchanges.SymbolInfo{
 			DisplayName:    fullSymbol,
 			Symbol:         fullSymbol,
 			OriginalSymbol: symbol.Symbol,
 			Filters:        symbol.Filters,
+			Instrument:     &instrument,
 		}
 		result = append(result, sInfo)
 	}
@@ -181,64 +233,55 @@ func (b *BinanceFutures) GetTradableSymbols(ctx context.Context) ([]exchanges.Sy
 }
 
 func (b *BinanceFutures) WatchOrdersStatuses(ctx context.Context) (<-chan exchanges.OrderEvent, error) {
-	listenKey, err := b.Client.NewStartUserStreamService().Do(ctx)
-	if err != nil {
-		return nil, errors.Wrap(err, "can't take listen key")
-	}
-
-	// Add capability to keep alive the listen key
-	ticker := time.NewTicker(20 * time.Minute)
-	go func() {
-		defer ticker.Stop()
-		for {
-			<-ticker.C
-			err := b.Client.NewKeepaliveUserStreamService().ListenKey(listenKey).Do(context.Background())
-			if err != nil {
-				b.lg.Sugar().Errorf("Error to keep alive user stream websocket.. wait until next ticker... reason..", err)
-			}
-		}
-	}()
-
-	wsEndpoint := b.urls.WSFuturesUserDataURL(listenKey)
-
-	if strings.Contains(wsEndpoint, b.urls.FutureWebSocketBaseURL) {
-		return SubscribeToOrdersFutures(ctx, wsEndpoint, b.lg)
-	}
-
-	return SubscribeToOrdersV2(ctx, wsEndpoint, b.lg)
+	return b.userData.WatchOrders(ctx)
 }
 
 func (b *BinanceFutures) WatchSymbolPrice(ctx context.Context, symbol string) (<-chan exchanges.PriceEvent, error) {
-	binanceSymbol := ToBinanceSymbol(symbol)
+	binanceSymbol := ToBinanceFuturesSymbol(symbol)
 	b.lg.Sugar().Infof("ws address : %v", b.urls.WSFuturesAllMiniMarketStatsURL())
 	return SubscribeToPriceV2(ctx, b.urls.WSFuturesAllMiniMarketStatsURL(), binanceSymbol, b.lg)
 }
 
 // PlaceBuyOrderV2 Place Buy Order with OrderType param
 func (b *BinanceFutures) PlaceBuyOrderV2(ctx context.Context, _ bool, symbol string, price, qty *apd.Decimal, preferredID string, orderType string) (id string, e error) {
-	binanceSymbol := ToBinanceSymbol(symbol)
+	binanceSymbol := ToBinanceFuturesSymbol(symbol)
 	return b.orderPlacer.PlaceOrderV2(ctx, binanceSymbol, price, qty, preferredID, api.SideTypeBuy, api.OrderType(orderType))
 }
 
 // PlaceSellOrderV2 Place Sell Order with OrderType param
 func (b *BinanceFutures) PlaceSellOrderV2(ctx context.Context, _ bool, symbol string, price, qty *apd.Decimal, preferredID string, orderType string) (id string, e error) {
-	binanceSymbol := ToBinanceSymbol(symbol)
+	binanceSymbol := ToBinanceFuturesSymbol(symbol)
 	return b.orderPlacer.PlaceOrderV2(ctx, binanceSymbol, price, qty, preferredID, api.SideTypeSell, api.OrderType(orderType))
 }
 
+// PlaceConditionalOrder places STOP, STOP_MARKET, TAKE_PROFIT, TAKE_PROFIT_MARKET or TRAILING_STOP_MARKET order.
+// `price` is used by STOP and TAKE_PROFIT only, `qty` is ignored if params.ClosePosition is set.
+func (b *BinanceFutures) PlaceConditionalOrder(
+	ctx context.Context, symbol string, side exchanges.OrderSide, orderType exchanges.OrderType,
+	price, qty *apd.Decimal, preferredID string, params futures.ConditionalOrderParams,
+) (id string, e error) {
+	binanceSymbol := ToBinanceFuturesSymbol(symbol)
+	return b.orderPlacer.PlaceConditionalOrder(
+		ctx, binanceSymbol, price, qty, preferredID, api.SideType(side), api.OrderType(orderType), params)
+}
+
 func (b *BinanceFutures) WatchAccountPositions(ctx context.Context) (<-chan exchanges.PositionEvent, error) {
-	listenKey, err := b.Client.NewStartUserStreamService().Do(ctx)
-	if err != nil {
-		return nil, errors.Wrap(err, "can't take listen key")
-	}
+	return b.userData.WatchPositions(ctx)
+}
 
-	wsEndpoint := b.urls.WSFuturesUserDataURL(listenKey)
+// WatchBalances shares user data socket with WatchOrdersStatuses and WatchAccountPositions
+func (b *BinanceFutures) WatchBalances(ctx context.Context) (<-chan BalanceEvent, error) {
+	return b.userData.WatchBalances(ctx)
+}
 
-	if strings.Contains(wsEndpoint, b.urls.FutureWebSocketBaseURL) {
-		return SubscribeToPositionsFutures(ctx, wsEndpoint, b.lg)
-	}
+// WatchMarginCalls shares user data socket with WatchOrdersStatuses and WatchAccountPositions
+func (b *BinanceFutures) WatchMarginCalls(ctx context.Context) (<-chan MarginCallEvent, error) {
+	return b.userData.WatchMarginCalls(ctx)
+}
 
-	return SubscribeToPositions(ctx, wsEndpoint, b.lg)
+// WatchAccountConfig sends leverage and multi-assets mode changes, it shares user data socket too
+func (b *BinanceFutures) WatchAccountConfig(ctx context.Context) (<-chan AccountConfigEvent, error) {
+	return b.userData.WatchAccountConfig(ctx)
 }
 
 func (b *BinanceFutures) GenerateClientOrderID(ctx context.Context, identifierID string) (string, error) {
@@ -4,11 +4,11 @@ import (
 	"context"
 	"fmt"
 	"regexp"
-	"strings"
 	"time"
 
 	api "github.com/adshao/go-binance/v2"
 	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/aulaleslie/trade-exchanges/binance/wsapi"
 	"github.com/aulaleslie/trade-exchanges/utils"
 	"github.com/cockroachdb/apd"
 	"github.com/pkg/errors"
@@ -27,6 +27,10 @@ type BinanceLong struct {
 	orderGetter    *OrderGetter
 	orderPlacer    *OrderPlacer
 	positionGetter *PositionGetter
+	listenKeys     *ListenKeyManager
+	userData       *UserDataStream
+	wsEndpoints    *EndpointSelector
+	rateLimiter    *BinanceRateLimiter
 	urls           BinanceURLs
 	lg             *zap.Logger
 }
@@ -39,22 +43,33 @@ func NewBinanceLong(urls BinanceURLs, apiKey, secretKey string, lg *zap.Logger)
 
 	b := &BinanceLong{}
 
-	b.client = NewBinanceClient(urls.APIURL, apiKey, secretKey, lg)
+	b.rateLimiter = hostRateLimiter(urls.APIURL, SpotRateLimits, lg)
+	b.client = NewBinanceClient(urls.APIURL, apiKey, secretKey, b.rateLimiter, lg)
+	if endpoints := newAPIEndpointSelector(urls, lg); endpoints != nil {
+		b.client.HTTPClient = newFailoverHTTPClient(endpoints, b.rateLimiter)
+	}
 	b.canceller = NewBinanceOrderCanceller(b.client)
-	b.orderGetter = &OrderGetter{b.client}
+	b.orderGetter = &OrderGetter{client: b.client}
 	b.orderPlacer = NewOrderPlacer(b.client)
 	b.positionGetter = &PositionGetter{b.client}
+	b.listenKeys = NewListenKeyManager(spotListenKeys{b.client}, lg)
+	b.wsEndpoints = NewEndpointSelector(urls.WebSocketEndpoints(), lg.Named("WS"))
+	b.userData = NewUserDataStream(b.listenKeys, b.wsUserDataURL, BINANCE_PREFIX, lg)
+	b.userData.Endpoints = b.wsEndpoints
 	b.urls = urls
 	b.lg = lg
 	return b
 }
 
-func ToBinanceSymbol(symbol string) string {
-	return strings.TrimPrefix(symbol, BINANCE_PREFIX)
-}
-
-func ToFullSymbol(binanceSymbol string) string {
-	return BINANCE_PREFIX + binanceSymbol
+// EnableWSAPI places, cancels and queries orders by WebSocket API, REST is used while the socket is down.
+// The client can be configured (e.g. PrivateKey) before the first order.
+func (b *BinanceLong) EnableWSAPI() *wsapi.Client {
+	ws := wsapi.NewClient(b.urls.WSAPIURL, b.client.APIKey, b.client.SecretKey, b.lg)
+	ws.Limiter = b.rateLimiter
+	b.orderPlacer.UseWSAPI(ws)
+	b.canceller.UseWSAPI(ws)
+	b.orderGetter.UseWSAPI(ws)
+	return ws
 }
 
 func (b *BinanceLong) RoundPrice(_ context.Context, symbol string, price *apd.Decimal, tickSize *string) (*apd.Decimal, error) {
@@ -88,6 +103,11 @@ func (b *BinanceLong) GetPrefix() string {
 	return BINANCE_PREFIX
 }
 
+// StreamOrderKey returns the client order ID and full symbol, as WatchOrdersStatuses sends them
+func (b *BinanceLong) StreamOrderKey(o exchanges.OrderDetailInfo) (string, string) {
+	return streamOrderKey(b.GetPrefix(), o)
+}
+
 func (b *BinanceLong) GetName() string {
 	return "Binance Spot"
 }
@@ -138,6 +158,7 @@ func (b *BinanceLong) GetOrders(ctx context.Context, filter exchanges.OrderFilte
 		binanceSymbol,
 		nil,
 		filter.ClientOrderID,
+		filter.Since,
 	)
 }
 
@@ -175,12 +196,14 @@ func (b *BinanceLong) GetTradableSymbols(ctx context.Context) ([]exchanges.Symbo
 	result := []exchanges.SymbolInfo{}
 	for _, symbol := range info.Symbols {
 		if symbol.IsSpotTradingAllowed {
-			fullSymbol := ToFullSymbol(symbol.Symbol)
+			instrument := spotInstrument(symbol)
+			fullSymbol := exchanges.Instruments.Register(BINANCE_PREFIX, symbol.Symbol, instrument)
 			sInfo := exchanges.SymbolInfo{
 				DisplayName:    fullSymbol,
 				Symbol:         fullSymbol,
 				OriginalSymbol: symbol.Symbol,
 				Filters:        symbol.Filters,
+				Instrument:     &instrument,
 			}
 			result = append(result, sInfo)
 		}
@@ -190,13 +213,24 @@ func (b *BinanceLong) GetTradableSymbols(ctx context.Context) ([]exchanges.Symbo
 
 // WatchOrdersStatuses Returns control immediately
 func (b *BinanceLong) WatchOrdersStatuses(ctx context.Context) (<-chan exchanges.OrderEvent, error) {
-	return SubscribeToOrders(ctx, b.urls, b.client, b.lg)
+	return b.userData.WatchOrders(ctx)
 }
 
 // WatchSymbolPrice OPTIMIZATION: subscribe to single symbol on client side not to all symbols.
 func (b *BinanceLong) WatchSymbolPrice(ctx context.Context, symbol string) (<-chan exchanges.PriceEvent, error) {
 	binanceSymbol := ToBinanceSymbol(symbol)
-	return SubscribeToPrice(ctx, b.urls, binanceSymbol, b.lg)
+	urls := b.urls
+	urls.WebSocketBaseURL = b.wsEndpoints.Pick()
+	events, err := SubscribeToPrice(ctx, urls, binanceSymbol, b.lg)
+	b.wsEndpoints.Report(urls.WebSocketBaseURL, err != nil && ctx.Err() == nil)
+	return events, err
+}
+
+// wsUserDataURL returns user data socket URL of the healthy endpoint
+func (b *BinanceLong) wsUserDataURL(listenKey string) string {
+	urls := b.urls
+	urls.WebSocketBaseURL = b.wsEndpoints.Pick()
+	return urls.WSUserDataURL(listenKey)
 }
 
 // PlaceBuyOrderV2 Place Buy Order with OrderType param
@@ -212,14 +246,17 @@ func (b *BinanceLong) PlaceSellOrderV2(ctx context.Context, _ bool, symbol strin
 }
 
 func (b *BinanceLong) WatchAccountPositions(ctx context.Context) (<-chan exchanges.PositionEvent, error) {
-	listenKey, err := b.client.NewStartUserStreamService().Do(ctx)
-	if err != nil {
-		return nil, errors.Wrap(err, "can't take listen key")
-	}
+	return b.userData.WatchPositions(ctx)
+}
 
-	wsEndpoint := b.urls.WSUserDataURL(listenKey)
+// StreamPositions returns free balances, the position stream sends them instead of positions
+func (b *BinanceLong) StreamPositions(acc exchanges.Account) []*exchanges.PositionPayload {
+	return streamBalances(acc)
+}
 
-	return SubscribeToPositions(ctx, wsEndpoint, b.lg)
+// WatchBalances shares user data socket with WatchOrdersStatuses and WatchAccountPositions
+func (b *BinanceLong) WatchBalances(ctx context.Context) (<-chan BalanceEvent, error) {
+	return b.userData.WatchBalances(ctx)
 }
 
 func (b *BinanceLong) GenerateClientOrderID(ctx context.Context, identifierID string) (string, error) {
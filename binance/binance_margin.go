@@ -0,0 +1,402 @@
+package binance
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	api "github.com/adshao/go-binance/v2"
+	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/aulaleslie/trade-exchanges/utils"
+	"github.com/cockroachdb/apd"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// BinanceMargin trades spot pairs on cross or isolated margin account.
+// Order ID in the methods input and output is clientOrderID internally.
+type BinanceMargin struct {
+	// Side effect of orders placed by Exchange methods, AUTO_BORROW_REPAY by default
+	SideEffectType api.SideEffectType
+
+	client         *api.Client
+	isolated       bool
+	prefix         string
+	canceller      *MarginOrderCanceller
+	orderGetter    *MarginOrderGetter
+	orderPlacer    *MarginOrderPlacer
+	positionGetter *MarginPositionGetter
+	loans          *MarginLoans
+	rateLimiter    *BinanceRateLimiter
+	urls           BinanceURLs
+	lg             *zap.Logger
+
+	userDataMu sync.Mutex
+	userData   map[string]*UserDataStream // By isolated symbol, "" for cross margin
+}
+
+var _ exchanges.Exchange = (*BinanceMargin)(nil) // Type check
+
+// NewBinanceMargin creates cross margin exchange or isolated one if `isolated` is set
+func NewBinanceMargin(urls BinanceURLs, apiKey, secretKey string, isolated bool, lg *zap.Logger) *BinanceMargin {
+	b := &BinanceMargin{
+		SideEffectType: SideEffectTypeAutoBorrowRepay,
+		isolated:       isolated,
+		prefix:         BINANCE_MARGIN_PREFIX,
+		userData:       map[string]*UserDataStream{},
+	}
+	if isolated {
+		b.prefix = BINANCE_ISOLATED_MARGIN_PREFIX
+		lg = lg.Named("BinanceIsolatedMargin")
+	} else {
+		lg = lg.Named("BinanceMargin")
+	}
+
+	b.rateLimiter = hostRateLimiter(urls.APIURL, MarginRateLimits, lg)
+	b.client = NewBinanceClient(urls.APIURL, apiKey, secretKey, b.rateLimiter, lg)
+	if endpoints := newAPIEndpointSelector(urls, lg); endpoints != nil {
+		b.client.HTTPClient = newFailoverHTTPClient(endpoints, b.rateLimiter)
+	}
+	b.canceller = NewMarginOrderCanceller(b.client, isolated)
+	b.orderGetter = NewMarginOrderGetter(b.client, isolated)
+	b.orderPlacer = NewMarginOrderPlacer(b.client, isolated)
+	b.positionGetter = NewMarginPositionGetter(b.client, isolated)
+	b.loans = NewMarginLoans(b.client, isolated)
+	b.urls = urls
+	b.lg = lg
+	return b
+}
+
+func (b *BinanceMargin) toBinanceSymbol(symbol string) string {
+	return exchanges.NativeSymbol(b.prefix, symbol)
+}
+
+func (b *BinanceMargin) RoundPrice(ctx context.Context, symbol string, price *apd.Decimal, tickSize *string) (*apd.Decimal, error) {
+	return (&BinanceLong{}).RoundPrice(ctx, symbol, price, tickSize)
+}
+
+func (b *BinanceMargin) RoundQuantity(ctx context.Context, symbol string, qty *apd.Decimal) (*apd.Decimal, error) {
+	return (&BinanceLong{}).RoundQuantity(ctx, symbol, qty)
+}
+
+func (b *BinanceMargin) GetPrefix() string {
+	return b.prefix
+}
+
+// StreamOrderKey returns the client order ID and full symbol, as WatchOrdersStatuses sends them
+func (b *BinanceMargin) StreamOrderKey(o exchanges.OrderDetailInfo) (string, string) {
+	return streamOrderKey(b.GetPrefix(), o)
+}
+
+func (b *BinanceMargin) GetName() string {
+	if b.isolated {
+		return "Binance Isolated Margin"
+	}
+	return "Binance Cross Margin"
+}
+
+func (b *BinanceMargin) PlaceBuyOrder(ctx context.Context,
+	_ bool, symbol string, price, quantity *apd.Decimal, prefferedID string,
+) (id string, e error) {
+	return b.PlaceMarginOrder(ctx, symbol, price, quantity, prefferedID, api.SideTypeBuy, api.OrderTypeLimit, b.SideEffectType)
+}
+
+func (b *BinanceMargin) PlaceSellOrder(ctx context.Context,
+	_ bool, symbol string, price, quantity *apd.Decimal, prefferedID string,
+) (id string, e error) {
+	return b.PlaceMarginOrder(ctx, symbol, price, quantity, prefferedID, api.SideTypeSell, api.OrderTypeLimit, b.SideEffectType)
+}
+
+// PlaceBuyOrderV2 Place Buy Order with OrderType param
+func (b *BinanceMargin) PlaceBuyOrderV2(ctx context.Context, _ bool, symbol string, price, qty *apd.Decimal, preferredID string, orderType string) (id string, e error) {
+	return b.PlaceMarginOrder(ctx, symbol, price, qty, preferredID, api.SideTypeBuy, api.OrderType(orderType), b.SideEffectType)
+}
+
+// PlaceSellOrderV2 Place Sell Order with OrderType param
+func (b *BinanceMargin) PlaceSellOrderV2(ctx context.Context, _ bool, symbol string, price, qty *apd.Decimal, preferredID string, orderType string) (id string, e error) {
+	return b.PlaceMarginOrder(ctx, symbol, price, qty, preferredID, api.SideTypeSell, api.OrderType(orderType), b.SideEffectType)
+}
+
+// PlaceMarginOrder places order with explicit side effect, e.g. MARGIN_BUY or AUTO_REPAY
+func (b *BinanceMargin) PlaceMarginOrder(ctx context.Context,
+	symbol string, price, qty *apd.Decimal, preferredID string,
+	side api.SideType, orderType api.OrderType, sideEffect api.SideEffectType,
+) (id string, e error) {
+	binanceSymbol := b.toBinanceSymbol(symbol)
+	return b.orderPlacer.PlaceOrder(ctx, binanceSymbol, price, qty, preferredID, side, orderType, sideEffect)
+}
+
+func (b *BinanceMargin) CancelOrder(ctx context.Context, symbol, id string) error {
+	binanceSymbol := b.toBinanceSymbol(symbol)
+	return b.canceller.CancelOrder(ctx, binanceSymbol, id)
+}
+
+func (b *BinanceMargin) ReleaseOrder(_ context.Context, symbol, id string) error {
+	return nil
+}
+
+func (b *BinanceMargin) GetOrderInfo(ctx context.Context, symbol, id string, _ *time.Time) (exchanges.OrderInfo, error) {
+	return b.GetOrderInfoByClientOrderID(ctx, symbol, id, nil)
+}
+
+func (b *BinanceMargin) GetOrderInfoByClientOrderID(ctx context.Context, symbol, clientOrderID string, _ *time.Time) (exchanges.OrderInfo, error) {
+	binanceSymbol := b.toBinanceSymbol(symbol)
+	return b.orderGetter.GetOrderInfoByClientOrderID(ctx, binanceSymbol, clientOrderID)
+}
+
+// GetOpenOrders lists orders of every isolated pair in isolated mode
+func (b *BinanceMargin) GetOpenOrders(ctx context.Context) ([]exchanges.OrderDetailInfo, error) {
+	if !b.isolated {
+		return b.orderGetter.GetOpenOrders(ctx, b.prefix)
+	}
+	symbols, err := b.positionGetter.GetIsolatedSymbols(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(symbols) == 0 {
+		return []exchanges.OrderDetailInfo{}, nil
+	}
+	return b.orderGetter.GetOpenOrders(ctx, b.prefix, symbols...)
+}
+
+func (b *BinanceMargin) GetOrders(ctx context.Context, filter exchanges.OrderFilter) ([]exchanges.OrderDetailInfo, error) {
+	if filter.Symbol == nil {
+		return nil, errors.New("symbol is empty!")
+	}
+	return b.orderGetter.GetHistoryOrders(
+		ctx,
+		b.prefix,
+		b.toBinanceSymbol(*filter.Symbol),
+		filter.OrderID,
+		filter.ClientOrderID,
+		filter.Since,
+	)
+}
+
+// GetAccount returns net asset, borrowed and interest per coin
+func (b *BinanceMargin) GetAccount(ctx context.Context) (exchanges.Account, error) {
+	return b.positionGetter.GetAccountBalances(ctx, b.prefix)
+}
+
+func (b *BinanceMargin) GetPrice(ctx context.Context, symbol string) (*apd.Decimal, error) {
+	binanceSymbol := b.toBinanceSymbol(symbol)
+
+	result, err := b.client.NewListPriceChangeStatsService().Symbol(binanceSymbol).Do(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't do request")
+	}
+	if len(result) == 0 {
+		return nil, errors.New("empty result")
+	}
+	stats := result[0]
+	if stats.Symbol != binanceSymbol {
+		return nil, errors.Errorf("got result for another symbol: %s", exchanges.FullSymbol(b.prefix, stats.Symbol))
+	}
+	price, _, err := apd.NewFromString(stats.LastPrice)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't convert price from '%s'", stats.LastPrice)
+	}
+	return price, nil
+}
+
+func (b *BinanceMargin) GetTradableSymbols(ctx context.Context) ([]exchanges.SymbolInfo, error) {
+	info, err := b.client.NewExchangeInfoService().Do(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to do ExchangeInfo request")
+	}
+
+	var isolatedPairs map[string]struct{}
+	if b.isolated {
+		pairs, err := b.client.NewGetIsolatedMarginAllPairsService().Do(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to get isolated margin pairs")
+		}
+		isolatedPairs = map[string]struct{}{}
+		for _, pair := range pairs {
+			if pair.IsMarginTrade {
+				isolatedPairs[pair.Symbol] = struct{}{}
+			}
+		}
+	}
+
+	result := []exchanges.SymbolInfo{}
+	for _, symbol := range info.Symbols {
+		if !symbol.IsMarginTradingAllowed {
+			continue
+		}
+		if _, ok := isolatedPairs[symbol.Symbol]; b.isolated && !ok {
+			continue
+		}
+		instrument := spotInstrument(symbol)
+		fullSymbol := exchanges.Instruments.Register(b.prefix, symbol.Symbol, instrument)
+		result = append(result, exchanges.SymbolInfo{
+			DisplayName:    fullSymbol,
+			Symbol:         fullSymbol,
+			OriginalSymbol: symbol.Symbol,
+			Filters:        symbol.Filters,
+			Instrument:     &instrument,
+		})
+	}
+	return result, nil
+}
+
+// WatchOrdersStatuses listens executionReport of margin user data stream,
+// one stream per isolated pair in isolated mode
+func (b *BinanceMargin) WatchOrdersStatuses(ctx context.Context) (<-chan exchanges.OrderEvent, error) {
+	streams, err := b.userDataStreams(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	wsCtx, cancel := context.WithCancel(ctx)
+	ins := []<-chan exchanges.OrderEvent{}
+	for _, stream := range streams {
+		in, err := stream.WatchOrders(wsCtx)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		ins = append(ins, in)
+	}
+	return mergeEvents(ctx, cancel, ins, func(ev exchanges.OrderEvent) bool { return ev.DisconnectedWithErr != nil }), nil
+}
+
+// WatchAccountPositions listens outboundAccountPosition of margin user data stream
+func (b *BinanceMargin) WatchAccountPositions(ctx context.Context) (<-chan exchanges.PositionEvent, error) {
+	streams, err := b.userDataStreams(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	wsCtx, cancel := context.WithCancel(ctx)
+	ins := []<-chan exchanges.PositionEvent{}
+	for _, stream := range streams {
+		in, err := stream.WatchPositions(wsCtx)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		ins = append(ins, in)
+	}
+	return mergeEvents(ctx, cancel, ins, func(ev exchanges.PositionEvent) bool { return ev.DisconnectedWithErr != nil }), nil
+}
+
+// StreamPositions returns free balances, the position stream sends them instead of positions
+func (b *BinanceMargin) StreamPositions(acc exchanges.Account) []*exchanges.PositionPayload {
+	return streamBalances(acc)
+}
+
+// WatchSymbolPrice OPTIMIZATION: subscribe to single symbol on client side not to all symbols.
+func (b *BinanceMargin) WatchSymbolPrice(ctx context.Context, symbol string) (<-chan exchanges.PriceEvent, error) {
+	binanceSymbol := b.toBinanceSymbol(symbol)
+	return SubscribeToPrice(ctx, b.urls, binanceSymbol, b.lg)
+}
+
+func (b *BinanceMargin) GenerateClientOrderID(ctx context.Context, identifierID string) (string, error) {
+	generatedID, err := utils.GenClientOrderID(identifierID)
+	if err != nil {
+		return "", err
+	}
+	return BINANCE_SPOT_LINK_ID_PREFIX + "_" + generatedID, nil
+}
+
+// Borrow takes a loan, `symbol` is used by isolated margin only
+func (b *BinanceMargin) Borrow(ctx context.Context, symbol, asset string, amount *apd.Decimal) (int64, error) {
+	return b.loans.Borrow(ctx, b.toBinanceSymbol(symbol), asset, amount)
+}
+
+// Repay returns a loan with interest, `symbol` is used by isolated margin only
+func (b *BinanceMargin) Repay(ctx context.Context, symbol, asset string, amount *apd.Decimal) (int64, error) {
+	return b.loans.Repay(ctx, b.toBinanceSymbol(symbol), asset, amount)
+}
+
+// GetMaxBorrowable `symbol` is used by isolated margin only
+func (b *BinanceMargin) GetMaxBorrowable(ctx context.Context, symbol, asset string) (*apd.Decimal, error) {
+	return b.loans.GetMaxBorrowable(ctx, b.toBinanceSymbol(symbol), asset)
+}
+
+// GetMarginLevel returns total asset / total liability, `symbol` is used by isolated margin only
+func (b *BinanceMargin) GetMarginLevel(ctx context.Context, symbol string) (*apd.Decimal, error) {
+	return b.positionGetter.GetMarginLevel(ctx, b.toBinanceSymbol(symbol))
+}
+
+// GetInterestHistory empty `symbol` or `asset` means all of them
+func (b *BinanceMargin) GetInterestHistory(ctx context.Context, symbol, asset string, startTime time.Time) ([]MarginInterest, error) {
+	binanceSymbol := ""
+	if symbol != "" {
+		binanceSymbol = b.toBinanceSymbol(symbol)
+	}
+	return b.loans.GetInterestHistory(ctx, b.prefix, binanceSymbol, asset, startTime)
+}
+
+// userDataStreams returns the stream of cross margin account or streams of every isolated pair
+func (b *BinanceMargin) userDataStreams(ctx context.Context) ([]*UserDataStream, error) {
+	symbols := []string{""}
+	if b.isolated {
+		var err error
+		symbols, err = b.positionGetter.GetIsolatedSymbols(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(symbols) == 0 {
+			return nil, errors.New("no isolated margin accounts")
+		}
+	}
+
+	b.userDataMu.Lock()
+	defer b.userDataMu.Unlock()
+
+	streams := []*UserDataStream{}
+	for _, symbol := range symbols {
+		stream, ok := b.userData[symbol]
+		if !ok {
+			var service ListenKeyService = marginListenKeys{b.client}
+			if b.isolated {
+				service = isolatedMarginListenKeys{b.client, symbol}
+			}
+			lg := b.lg.With(zap.String("symbol", symbol))
+			stream = NewUserDataStream(NewListenKeyManager(service, lg), b.urls.WSUserDataURL, b.prefix, lg)
+			b.userData[symbol] = stream
+		}
+		streams = append(streams, stream)
+	}
+	return streams, nil
+}
+
+// mergeEvents forwards events of all streams until `ctx` is done and stops them after the first disconnection
+func mergeEvents[T any](
+	ctx context.Context, cancel context.CancelFunc, ins []<-chan T, disconnected func(T) bool,
+) <-chan T {
+	out := make(chan T, 100) // TODO: move to config
+	send := func(ev T) {
+		select {
+		case out <- ev:
+		case <-ctx.Done():
+		}
+	}
+
+	var once sync.Once
+	var wg sync.WaitGroup
+	for _, in := range ins {
+		wg.Add(1)
+		go func(in <-chan T) {
+			defer wg.Done()
+			for ev := range in {
+				if !disconnected(ev) {
+					send(ev)
+					continue
+				}
+				once.Do(func() {
+					send(ev)
+					cancel()
+				})
+			}
+		}(in)
+	}
+	go func() {
+		wg.Wait()
+		cancel()
+		close(out)
+	}()
+	return out
+}
@@ -0,0 +1,149 @@
+package binance
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	api "github.com/adshao/go-binance/v2"
+	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/aulaleslie/trade-exchanges/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestMargin(t *testing.T, isolated bool, handler http.HandlerFunc) *BinanceMargin {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return NewBinanceMargin(BinanceURLs{APIURL: srv.URL}, "key", "secret", isolated, zap.NewNop())
+}
+
+func TestBinanceMarginPlaceOrder(t *testing.T) {
+	b := newTestMargin(t, true, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/sapi/v1/margin/order", r.URL.Path)
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "BTCUSDT", r.Form.Get("symbol"))
+		assert.Equal(t, "TRUE", r.Form.Get("isIsolated"))
+		assert.Equal(t, "SELL", r.Form.Get("side"))
+		assert.Equal(t, "AUTO_BORROW_REPAY", r.Form.Get("sideEffectType"))
+		assert.Equal(t, "GTC", r.Form.Get("timeInForce"))
+		assert.NotEmpty(t, r.Form.Get("signature"))
+		w.Write([]byte(`{"symbol":"BTCUSDT","clientOrderId":"id1","status":"NEW"}`))
+	})
+
+	id, err := b.PlaceSellOrder(context.Background(), false, BINANCE_ISOLATED_MARGIN_PREFIX+"BTCUSDT",
+		utils.FromString("30000"), utils.FromString("0.01"), "id1")
+	require.NoError(t, err)
+	assert.Equal(t, "id1", id)
+
+	_, err = b.PlaceMarginOrder(context.Background(), BINANCE_ISOLATED_MARGIN_PREFIX+"BTCUSDT",
+		nil, utils.FromString("0.01"), "id2", api.SideTypeSell, api.OrderTypeLimit, api.SideEffectTypeNoSideEffect)
+	assert.Error(t, err, "limit order without price")
+}
+
+func TestBinanceMarginGetAccount(t *testing.T) {
+	b := newTestMargin(t, false, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/sapi/v1/margin/account", r.URL.Path)
+		w.Write([]byte(`{"marginLevel":"2.5","userAssets":[
+			{"asset":"BTC","borrowed":"0.1","free":"1","interest":"0.001","locked":"0.5","netAsset":"1.399"}
+		]}`))
+	})
+
+	account, err := b.GetAccount(context.Background())
+	require.NoError(t, err)
+	require.Len(t, account.AccountBalances, 1)
+	balance := account.AccountBalances[0]
+	assert.Equal(t, "BTC", balance.Coin)
+	assert.Equal(t, "0.1", balance.Borrowed.String())
+	assert.Equal(t, "0.001", balance.Interest.String())
+	assert.Equal(t, "1.399", balance.NetAsset.String())
+	assert.Empty(t, balance.IsolatedSymbol)
+
+	level, err := b.GetMarginLevel(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, "2.5", level.String())
+}
+
+func TestBinanceMarginInterestHistory(t *testing.T) {
+	pages := 0
+	b := newTestMargin(t, true, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/sapi/v1/margin/interestHistory", r.URL.Path)
+		assert.Equal(t, "key", r.Header.Get("X-MBX-APIKEY"))
+		assert.Equal(t, "ETHUSDT", r.URL.Query().Get("isolatedSymbol"))
+		assert.NotEmpty(t, r.URL.Query().Get("signature"))
+		pages++
+		w.Write([]byte(`{"rows":[{"asset":"USDT","isolatedSymbol":"ETHUSDT","principal":"100",
+			"interest":"0.01","interestRate":"0.0001","type":"PERIODIC","interestAccuredTime":1700000000000}],"total":1}`))
+	})
+
+	history, err := b.GetInterestHistory(context.Background(), BINANCE_ISOLATED_MARGIN_PREFIX+"ETHUSDT", "", time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, pages)
+	require.Len(t, history, 1)
+	assert.Equal(t, exchanges.FullSymbol(BINANCE_ISOLATED_MARGIN_PREFIX, "ETHUSDT"), history[0].IsolatedSymbol)
+	assert.Equal(t, "0.01", history[0].Interest.String())
+	assert.Equal(t, "PERIODIC", history[0].Type)
+}
+
+func TestMergeEventsStopsAfterDisconnect(t *testing.T) {
+	disconnected := func(ev exchanges.OrderEvent) bool { return ev.DisconnectedWithErr != nil }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first, second := make(chan exchanges.OrderEvent, 10), make(chan exchanges.OrderEvent, 10)
+	go func() {
+		<-ctx.Done()
+		close(first)
+		close(second)
+	}()
+	first <- exchanges.OrderEvent{Payload: &exchanges.OrderEventPayload{OrderID: "1"}}
+	first <- exchanges.OrderEvent{DisconnectedWithErr: errors.New("disconnected")}
+	second <- exchanges.OrderEvent{DisconnectedWithErr: errors.New("disconnected too")}
+
+	out := mergeEvents(ctx, cancel, []<-chan exchanges.OrderEvent{first, second}, disconnected)
+	events := []exchanges.OrderEvent{}
+	for ev := range out {
+		events = append(events, ev)
+	}
+	disconnects := 0
+	for _, ev := range events {
+		if ev.DisconnectedWithErr != nil {
+			disconnects++
+		}
+	}
+	assert.Equal(t, 1, disconnects)
+	assert.Error(t, ctx.Err())
+}
+
+func TestMergeEventsDoesNotBlockAfterCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan exchanges.OrderEvent, 200)
+	for i := 0; i < 200; i++ {
+		in <- exchanges.OrderEvent{Payload: &exchanges.OrderEventPayload{}}
+	}
+	close(in)
+
+	cancel()
+	out := mergeEvents(ctx, cancel, []<-chan exchanges.OrderEvent{in},
+		func(ev exchanges.OrderEvent) bool { return ev.DisconnectedWithErr != nil })
+
+	// Events which don't fit the buffer are dropped instead of blocking
+	received := 0
+	timeout := time.After(time.Second)
+	for done := false; !done; {
+		select {
+		case _, ok := <-out:
+			if !ok {
+				done = true
+				break
+			}
+			received++
+		case <-timeout:
+			t.Fatal("stream isn't closed")
+		}
+	}
+	assert.Less(t, received, 200)
+}
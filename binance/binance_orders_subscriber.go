@@ -8,6 +8,7 @@ import (
 	api "github.com/adshao/go-binance/v2"
 	exchanges "github.com/aulaleslie/trade-exchanges"
 	"github.com/aulaleslie/trade-exchanges/binance/adshao_binance"
+	"github.com/aulaleslie/trade-exchanges/binance/futures"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 )
@@ -37,6 +38,8 @@ type OrderUpdateDataFutures struct {
 	Symbol string `json:"s"` // "s": "ETHBTC", // Symbol
 	Side   string `json:"S"` // "S": "BUY",    // Side
 
+	OrderType         string `json:"o"`  // "o": "MARKET",      // Order type, changed after trigger of conditional order
+	OriginalOrderType string `json:"ot"` // "ot": "STOP_MARKET", // Original order type
 }
 
 type OrderUpdate struct {
@@ -85,89 +88,25 @@ type OrderUpdate struct {
 	// QuoteOrderQty                          string `json:"Q"` // "Q": "0.00000000"              // Quote Order Qty
 }
 
+// SubscribeToOrders takes own listen key, it's kept alive until ctx is done
 func SubscribeToOrders(
 	ctx context.Context,
 	urls BinanceURLs,
 	client *api.Client,
 	lg *zap.Logger,
 ) (<-chan exchanges.OrderEvent, error) {
-	listenKey, err := client.NewStartUserStreamService().Do(ctx)
-	if err != nil {
-		return nil, errors.Wrap(err, "can't take listen key")
-	}
-
-	// Add capability to keep alive the listen key
-	ticker := time.NewTicker(20 * time.Minute)
-	go func() {
-		defer ticker.Stop()
-		for {
-			<-ticker.C
-			err := client.NewKeepaliveUserStreamService().ListenKey(listenKey).Do(context.Background())
-			if err != nil {
-				lg.Sugar().Errorf("Error to keep alive user stream websocket.. wait until next ticker... reason..", err)
-			}
-		}
-	}()
-
-	cfg := adshao_binance.WSConfig{
-		Endpoint:  urls.WSUserDataURL(listenKey),
-		KeepAlive: true,
-		Timeout:   30 * time.Second,
-	}
-
-	wsServeCtx, cancel := context.WithCancel(ctx)
-	in, err := adshao_binance.WSServe(wsServeCtx, &cfg, lg.Named("Orders"))
-	if err != nil {
-		cancel()
-		return nil, errors.Wrap(err, "can't start websocket")
-	}
-
-	out := make(chan exchanges.OrderEvent, 100) // TODO: move to config
-	go func() {
-		defer cancel()
-		defer close(out)
-
-		for msg := range in {
-			if msg.DisconnectedWithErr != nil {
-				out <- exchanges.OrderEvent{
-					DisconnectedWithErr: msg.DisconnectedWithErr,
-				}
-				return
-			}
-
-			ok, err := isOrderEventPayload(msg.Payload)
-			if err != nil {
-				out <- exchanges.OrderEvent{
-					DisconnectedWithErr: errors.Wrap(err, "can't understand type of user datastream event"),
-				}
-				return
-			}
-
-			if !ok {
-				continue
-			}
-
-			result, err := mapToOrderEventPayload(msg.Payload)
-			if err != nil {
-				out <- exchanges.OrderEvent{
-					DisconnectedWithErr: errors.Wrap(err, "can't parse OrderEvent"),
-				}
-				return
-			}
-
-			out <- exchanges.OrderEvent{
-				Payload: result,
-			}
-		}
-	}()
-
-	return out, nil
+	listenKeys := NewListenKeyManager(spotListenKeys{client}, lg)
+	return listenKeys.watchOrders(ctx, func(ctx context.Context, listenKey string) (<-chan exchanges.OrderEvent, error) {
+		return SubscribeToOrdersV2(ctx, urls.WSUserDataURL(listenKey), BINANCE_PREFIX, lg)
+	})
 }
 
-// Similar with SubscribeToOrders but it accepts ws endpoint instead of urls object
+// Similar with SubscribeToOrders but it accepts ws endpoint instead of urls object.
+// Symbols of the events are prefixed with `prefix`.
 func SubscribeToOrdersV2(
 	ctx context.Context,
 	wsEndpoint string,
+	prefix string,
 	lg *zap.Logger,
 ) (<-chan exchanges.OrderEvent, error) {
 	cfg := adshao_binance.WSConfig{
@@ -196,6 +135,11 @@ func SubscribeToOrdersV2(
 				return
 			}
 
+			if err := listenKeyExpiredError(msg.Payload); err != nil {
+				out <- exchanges.OrderEvent{DisconnectedWithErr: err}
+				return
+			}
+
 			ok, err := isOrderEventPayload(msg.Payload)
 			if err != nil {
 				out <- exchanges.OrderEvent{
@@ -208,7 +152,7 @@ func SubscribeToOrdersV2(
 				continue
 			}
 
-			result, err := mapToOrderEventPayload(msg.Payload)
+			result, err := mapToOrderEventPayload(prefix, msg.Payload)
 			if err != nil {
 				out <- exchanges.OrderEvent{
 					DisconnectedWithErr: errors.Wrap(err, "can't parse OrderEvent"),
@@ -228,6 +172,7 @@ func SubscribeToOrdersV2(
 func SubscribeToOrdersFutures(
 	ctx context.Context,
 	wsEndpoint string,
+	prefix string,
 	lg *zap.Logger,
 ) (<-chan exchanges.OrderEvent, error) {
 	cfg := adshao_binance.WSConfig{
@@ -257,6 +202,11 @@ func SubscribeToOrdersFutures(
 				return
 			}
 
+			if err := listenKeyExpiredError(msg.Payload); err != nil {
+				out <- exchanges.OrderEvent{DisconnectedWithErr: err}
+				return
+			}
+
 			ok, err := isOrderEventFuturesPayload(msg.Payload)
 			if err != nil {
 				lg.Sugar().Errorf("error while checking order update event type: %s", msg.DisconnectedWithErr)
@@ -271,7 +221,7 @@ func SubscribeToOrdersFutures(
 				continue
 			}
 
-			result, err := mapToOrderFuturesEventPayload(msg.Payload)
+			result, err := mapToOrderFuturesEventPayload(prefix, msg.Payload)
 			if err != nil {
 				out <- exchanges.OrderEvent{
 					DisconnectedWithErr: errors.Wrap(err, "can't parse OrderEvent"),
@@ -288,7 +238,7 @@ func SubscribeToOrdersFutures(
 	return out, nil
 }
 
-func mapToOrderEventPayload(message []byte) (p *exchanges.OrderEventPayload, e error) {
+func mapToOrderEventPayload(prefix string, message []byte) (p *exchanges.OrderEventPayload, e error) {
 	orderUpdate := OrderUpdate{}
 	err := json.Unmarshal(message, &orderUpdate)
 	if err != nil {
@@ -303,7 +253,7 @@ func mapToOrderEventPayload(message []byte) (p *exchanges.OrderEventPayload, e e
 		result.OrderID = orderUpdate.ClientOrderID
 	}
 
-	fullSymbol := ToFullSymbol(orderUpdate.Symbol)
+	fullSymbol := exchanges.FullSymbol(prefix, orderUpdate.Symbol)
 	result.Symbol = &fullSymbol
 
 	result.OrderStatus = mapOrderStatusType(orderUpdate.CurrentOrderStatus)
@@ -330,7 +280,7 @@ func isOrderEventFuturesPayload(message []byte) (bool, error) {
 	return data.EventType == orderUpdateFuturesEventType, nil
 }
 
-func mapToOrderFuturesEventPayload(message []byte) (p *exchanges.OrderEventPayload, e error) {
+func mapToOrderFuturesEventPayload(prefix string, message []byte) (p *exchanges.OrderEventPayload, e error) {
 	orderUpdate := OrderUpdateFutures{}
 	err := json.Unmarshal(message, &orderUpdate)
 	if err != nil {
@@ -341,9 +291,10 @@ func mapToOrderFuturesEventPayload(message []byte) (p *exchanges.OrderEventPaylo
 
 	result.OrderID = orderUpdate.OrderData.ClientOrderID
 
-	fullSymbol := ToFullSymbol(orderUpdate.OrderData.Symbol)
+	fullSymbol := exchanges.FullSymbol(prefix, orderUpdate.OrderData.Symbol)
 	result.Symbol = &fullSymbol
 
-	result.OrderStatus = mapOrderStatusType(orderUpdate.OrderData.CurrentOrderStatus)
+	result.OrderStatus = futures.MapOrderStatusType(
+		orderUpdate.OrderData.CurrentOrderStatus, orderUpdate.OrderData.OrderType, orderUpdate.OrderData.OriginalOrderType)
 	return result, nil
 }
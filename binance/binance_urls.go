@@ -2,6 +2,7 @@ package binance
 
 import (
 	"fmt"
+	"strings"
 )
 
 type BinanceURLs struct {
@@ -11,6 +12,19 @@ type BinanceURLs struct {
 	FutureAPIURL           string
 	USWebSocketBaseURL     string
 	FutureWebSocketBaseURL string
+
+	// COIN-M futures
+	DeliveryAPIURL           string
+	DeliveryWebSocketBaseURL string
+
+	// WebSocket API for order entry, optional
+	WSAPIURL       string
+	FutureWSAPIURL string
+
+	// Spot alternates of APIURL and WebSocketBaseURL in order of preference, optional.
+	// Traffic is moved to them when the main endpoint is unhealthy, see EndpointSelector.
+	AltAPIURLs           []string
+	AltWebSocketBaseURLs []string
 }
 
 var OriginalBinanceURLs BinanceURLs = BinanceURLs{
@@ -20,6 +34,21 @@ var OriginalBinanceURLs BinanceURLs = BinanceURLs{
 	FutureAPIURL:           "https://fapi.binance.com",
 	USWebSocketBaseURL:     "wss://stream.binance.us:9443/ws",
 	FutureWebSocketBaseURL: "wss://fstream.binance.com/ws",
+
+	DeliveryAPIURL:           "https://dapi.binance.com",
+	DeliveryWebSocketBaseURL: "wss://dstream.binance.com/ws",
+
+	WSAPIURL:       "wss://ws-api.binance.com:443/ws-api/v3",
+	FutureWSAPIURL: "wss://ws-fapi.binance.com/ws-fapi/v1",
+
+	AltAPIURLs: []string{
+		"https://api-gcp.binance.com",
+		"https://api1.binance.com",
+		"https://api2.binance.com",
+		"https://api3.binance.com",
+		"https://api4.binance.com",
+	},
+	AltWebSocketBaseURLs: []string{"wss://stream.binance.com:443/ws"},
 }
 
 var TestnetBinanceURLs BinanceURLs = BinanceURLs{
@@ -27,6 +56,22 @@ var TestnetBinanceURLs BinanceURLs = BinanceURLs{
 	WebSocketBaseURL:       "wss://testnet.binance.vision/ws",
 	FutureWebSocketBaseURL: "wss://stream.binancefuture.com/ws",
 	FutureAPIURL:           "https://testnet.binancefuture.com",
+
+	DeliveryAPIURL:           "https://testnet.binancefuture.com",
+	DeliveryWebSocketBaseURL: "wss://dstream.binancefuture.com/ws",
+
+	WSAPIURL:       "wss://testnet.binance.vision/ws-api/v3",
+	FutureWSAPIURL: "wss://testnet.binancefuture.com/ws-fapi/v1",
+}
+
+// APIEndpoints returns APIURL followed by its alternates
+func (u BinanceURLs) APIEndpoints() []string {
+	return append([]string{u.APIURL}, u.AltAPIURLs...)
+}
+
+// WebSocketEndpoints returns WebSocketBaseURL followed by its alternates
+func (u BinanceURLs) WebSocketEndpoints() []string {
+	return append([]string{u.WebSocketBaseURL}, u.AltWebSocketBaseURLs...)
 }
 
 // WSUserDataServe serve user data handler with listen key
@@ -60,3 +105,14 @@ func (u BinanceURLs) WSFuturesAllMiniMarketStatsURL() string {
 	endpoint := fmt.Sprintf("%s/!ticker@arr", u.FutureWebSocketBaseURL)
 	return endpoint
 }
+
+func (u BinanceURLs) WSDeliveryUserDataURL(listenKey string) string {
+	endpoint := fmt.Sprintf("%s/%s", u.DeliveryWebSocketBaseURL, listenKey)
+	return endpoint
+}
+
+// WSDeliveryMarkPriceURL pushes mark price of the symbol every second
+func (u BinanceURLs) WSDeliveryMarkPriceURL(symbol string) string {
+	endpoint := fmt.Sprintf("%s/%s@markPrice@1s", u.DeliveryWebSocketBaseURL, strings.ToLower(symbol))
+	return endpoint
+}
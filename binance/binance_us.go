@@ -22,6 +22,9 @@ type BinanceUS struct {
 	orderGetter    *OrderGetter
 	orderPlacer    *OrderPlacer
 	positionGetter *PositionGetter
+	listenKeys     *ListenKeyManager
+	userData       *UserDataStream
+	rateLimiter    *BinanceRateLimiter
 	urls           BinanceURLs
 	lg             *zap.Logger
 }
@@ -34,11 +37,14 @@ func NewBinanceUS(urls BinanceURLs, apiKey, secretKey string, lg *zap.Logger) *B
 
 	b := &BinanceUS{}
 
-	b.Client = NewBinanceClient(urls.USAPIURL, apiKey, secretKey, lg)
+	b.rateLimiter = hostRateLimiter(urls.USAPIURL, USRateLimits, lg)
+	b.Client = NewBinanceClient(urls.USAPIURL, apiKey, secretKey, b.rateLimiter, lg)
 	b.canceller = NewBinanceOrderCanceller(b.Client)
-	b.orderGetter = &OrderGetter{b.Client}
+	b.orderGetter = &OrderGetter{client: b.Client}
 	b.orderPlacer = NewOrderPlacer(b.Client)
 	b.positionGetter = &PositionGetter{b.Client}
+	b.listenKeys = NewListenKeyManager(spotListenKeys{b.Client}, lg)
+	b.userData = NewUserDataStream(b.listenKeys, urls.WSUSUserDataURL, BINANCE_US_PREFIX, lg)
 	b.urls = urls
 	b.lg = lg
 	return b
@@ -72,7 +78,12 @@ func (b *BinanceUS) RoundQuantity(_ context.Context, symbol string, qty *apd.Dec
 }
 
 func (b *BinanceUS) GetPrefix() string {
-	return BINANCE_PREFIX
+	return BINANCE_US_PREFIX
+}
+
+// StreamOrderKey returns the client order ID and full symbol, as WatchOrdersStatuses sends them
+func (b *BinanceUS) StreamOrderKey(o exchanges.OrderDetailInfo) (string, string) {
+	return streamOrderKey(b.GetPrefix(), o)
 }
 
 func (b *BinanceUS) GetName() string {
@@ -82,19 +93,19 @@ func (b *BinanceUS) GetName() string {
 func (b *BinanceUS) PlaceBuyOrder(ctx context.Context,
 	_ bool, symbol string, price, quantity *apd.Decimal, prefferedID string,
 ) (id string, e error) {
-	binanceSymbol := ToBinanceSymbol(symbol)
+	binanceSymbol := ToBinanceUSSymbol(symbol)
 	return b.orderPlacer.PlaceOrder(ctx, binanceSymbol, price, quantity, prefferedID, api.SideTypeBuy)
 }
 
 func (b *BinanceUS) PlaceSellOrder(ctx context.Context,
 	_ bool, symbol string, price, quantity *apd.Decimal, prefferedID string,
 ) (id string, e error) {
-	binanceSymbol := ToBinanceSymbol(symbol)
+	binanceSymbol := ToBinanceUSSymbol(symbol)
 	return b.orderPlacer.PlaceOrder(ctx, binanceSymbol, price, quantity, prefferedID, api.SideTypeSell)
 }
 
 func (b *BinanceUS) CancelOrder(ctx context.Context, symbol, id string) error {
-	binanceSymbol := ToBinanceSymbol(symbol)
+	binanceSymbol := ToBinanceUSSymbol(symbol)
 	return b.canceller.CancelOrder(ctx, binanceSymbol, id)
 }
 
@@ -107,7 +118,7 @@ func (b *BinanceUS) GetOrderInfo(ctx context.Context, symbol, id string, _ *time
 }
 
 func (b *BinanceUS) GetOrderInfoByClientOrderID(ctx context.Context, symbol, clientOrderID string, _ *time.Time) (exchanges.OrderInfo, error) {
-	binanceSymbol := ToBinanceSymbol(symbol)
+	binanceSymbol := ToBinanceUSSymbol(symbol)
 	return b.orderGetter.GetOrderInfoByClientOrderID(ctx, binanceSymbol, clientOrderID)
 }
 
@@ -119,12 +130,13 @@ func (b *BinanceUS) GetOrders(ctx context.Context, filter exchanges.OrderFilter)
 	if filter.Symbol == nil {
 		return res, errors.New("symbol is empty!")
 	}
-	binanceSymbol := ToBinanceSymbol(*filter.Symbol)
+	binanceSymbol := ToBinanceUSSymbol(*filter.Symbol)
 	return b.orderGetter.GetHistoryOrders(
 		ctx,
 		binanceSymbol,
 		nil,
 		filter.ClientOrderID,
+		filter.Since,
 	)
 }
 
@@ -133,7 +145,7 @@ func (b *BinanceUS) GetAccount(ctx context.Context) (exchanges.Account, error) {
 }
 
 func (b *BinanceUS) GetPrice(ctx context.Context, symbol string) (*apd.Decimal, error) {
-	binanceSymbol := ToBinanceSymbol(symbol)
+	binanceSymbol := ToBinanceUSSymbol(symbol)
 
 	result, err := b.Client.NewListPriceChangeStatsService().Symbol(binanceSymbol).Do(ctx)
 	if err != nil {
@@ -144,7 +156,7 @@ func (b *BinanceUS) GetPrice(ctx context.Context, symbol string) (*apd.Decimal,
 	}
 	stats := result[0]
 	if stats.Symbol != binanceSymbol {
-		return nil, errors.Errorf("got result for another symbol: %s", ToFullSymbol(stats.Symbol))
+		return nil, errors.Errorf("got result for another symbol: %s", ToFullUSSymbol(stats.Symbol))
 	}
 	price, _, err := apd.NewFromString(stats.LastPrice)
 	if err != nil {
@@ -162,12 +174,14 @@ func (b *BinanceUS) GetTradableSymbols(ctx context.Context) ([]exchanges.SymbolI
 	result := []exchanges.SymbolInfo{}
 	for _, symbol := range info.Symbols {
 		if symbol.IsSpotTradingAllowed {
-			fullSymbol := ToFullSymbol(symbol.Symbol)
+			instrument := spotInstrument(symbol)
+			fullSymbol := exchanges.Instruments.Register(BINANCE_US_PREFIX, symbol.Symbol, instrument)
 			sInfo := exchanges.SymbolInfo{
 				DisplayName:    fullSymbol,
 				Symbol:         fullSymbol,
 				OriginalSymbol: symbol.Symbol,
 				Filters:        symbol.Filters,
+				Instrument:     &instrument,
 			}
 			result = append(result, sInfo)
 		}
@@ -176,55 +190,38 @@ func (b *BinanceUS) GetTradableSymbols(ctx context.Context) ([]exchanges.SymbolI
 }
 
 func (b *BinanceUS) WatchOrdersStatuses(ctx context.Context) (<-chan exchanges.OrderEvent, error) {
-	listenKey, err := b.Client.NewStartUserStreamService().Do(ctx)
-	if err != nil {
-		return nil, errors.Wrap(err, "can't take listen key")
-	}
-
-	// Add capability to keep alive the listen key
-	ticker := time.NewTicker(20 * time.Minute)
-	go func() {
-		defer ticker.Stop()
-		for {
-			<-ticker.C
-			b.lg.Sugar().Debugf("Update keep alive listen key websocket...")
-			err := b.Client.NewKeepaliveUserStreamService().ListenKey(listenKey).Do(context.Background())
-			if err != nil {
-				b.lg.Sugar().Errorf("Error to keep alive user stream websocket.. wait until next ticker... reason..", err)
-			}
-		}
-	}()
-
-	wsEndpoint := b.urls.WSUSUserDataURL(listenKey)
-	return SubscribeToOrdersV2(ctx, wsEndpoint, b.lg)
+	return b.userData.WatchOrders(ctx)
 }
 
 func (b *BinanceUS) WatchSymbolPrice(ctx context.Context, symbol string) (<-chan exchanges.PriceEvent, error) {
-	binanceSymbol := ToBinanceSymbol(symbol)
+	binanceSymbol := ToBinanceUSSymbol(symbol)
 	return SubscribeToPriceV2(ctx, b.urls.WSUSAllMiniMarketsStatURL(), binanceSymbol, b.lg)
 }
 
 // PlaceBuyOrderV2 Place Buy Order with OrderType param
 func (b *BinanceUS) PlaceBuyOrderV2(ctx context.Context, _ bool, symbol string, price, qty *apd.Decimal, preferredID string, orderType string) (id string, e error) {
-	binanceSymbol := ToBinanceSymbol(symbol)
+	binanceSymbol := ToBinanceUSSymbol(symbol)
 	return b.orderPlacer.PlaceOrderV2(ctx, binanceSymbol, price, qty, preferredID, api.SideTypeBuy, api.OrderType(orderType))
 }
 
 // PlaceSellOrderV2 Place Sell Order with OrderType param
 func (b *BinanceUS) PlaceSellOrderV2(ctx context.Context, _ bool, symbol string, price, qty *apd.Decimal, preferredID string, orderType string) (id string, e error) {
-	binanceSymbol := ToBinanceSymbol(symbol)
+	binanceSymbol := ToBinanceUSSymbol(symbol)
 	return b.orderPlacer.PlaceOrderV2(ctx, binanceSymbol, price, qty, preferredID, api.SideTypeSell, api.OrderType(orderType))
 }
 
 func (b *BinanceUS) WatchAccountPositions(ctx context.Context) (<-chan exchanges.PositionEvent, error) {
-	listenKey, err := b.Client.NewStartUserStreamService().Do(ctx)
-	if err != nil {
-		return nil, errors.Wrap(err, "can't take listen key")
-	}
+	return b.userData.WatchPositions(ctx)
+}
 
-	wsEndpoint := b.urls.WSUSUserDataURL(listenKey)
+// StreamPositions returns free balances, the position stream sends them instead of positions
+func (b *BinanceUS) StreamPositions(acc exchanges.Account) []*exchanges.PositionPayload {
+	return streamBalances(acc)
+}
 
-	return SubscribeToPositions(ctx, wsEndpoint, b.lg)
+// WatchBalances shares user data socket with WatchOrdersStatuses and WatchAccountPositions
+func (b *BinanceUS) WatchBalances(ctx context.Context) (<-chan BalanceEvent, error) {
+	return b.userData.WatchBalances(ctx)
 }
 
 func (b *BinanceUS) GenerateClientOrderID(ctx context.Context, identifierID string) (string, error) {
@@ -6,6 +6,7 @@ import (
 	api "github.com/adshao/go-binance/v2"
 	"github.com/adshao/go-binance/v2/common"
 	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/aulaleslie/trade-exchanges/binance/wsapi"
 	"github.com/aulaleslie/trade-exchanges/utils"
 	"github.com/pkg/errors"
 )
@@ -13,6 +14,7 @@ import (
 type BinanceOrderCanceller struct {
 	client      *api.Client
 	orderGetter *OrderGetter
+	wsAPI       *wsapi.Client
 }
 
 func NewBinanceOrderCanceller(client *api.Client) *BinanceOrderCanceller {
@@ -57,6 +59,13 @@ func (b *BinanceOrderCanceller) CancelOrder(ctx context.Context, symbol, clientO
 }
 
 func (b *BinanceOrderCanceller) tryToCancel(ctx context.Context, symbol, clientOrderID string) error {
+	if b.wsAPI != nil {
+		fallback, err := b.cancelWS(ctx, symbol, clientOrderID)
+		if !fallback {
+			return err
+		}
+	}
+
 	result, err := b.client.NewCancelOrderService().
 		Symbol(symbol).
 		OrigClientOrderID(clientOrderID).
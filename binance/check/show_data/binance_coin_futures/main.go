@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+
+	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/aulaleslie/trade-exchanges/binance"
+	"go.uber.org/zap"
+)
+
+func main() {
+	key, _ := os.LookupEnv("KEY")
+	secret, _ := os.LookupEnv("SECRET")
+
+	ctx := context.Background()
+	ex := binance.NewBinanceCoinFutures(binance.TestnetBinanceURLs, key, secret, zap.NewExample())
+
+	symbols, err := ex.GetTradableSymbols(ctx)
+	printAsJSON(symbols, err)
+
+	openOrders, err := ex.GetOpenOrders(ctx)
+	printAsJSON(openOrders, err)
+
+	symbol := "BINANCECOINM-BTCUSD_PERP"
+	orders, err := ex.GetOrders(ctx, exchanges.OrderFilter{Symbol: &symbol})
+	printAsJSON(orders, err)
+
+	account, err := ex.GetAccount(ctx)
+	printAsJSON(account, err)
+
+	price, err := ex.GetPrice(ctx, symbol)
+	printAsJSON(price, err)
+}
+
+func fatalIfErr(err error) {
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+}
+
+func printAsJSON(x interface{}, err error) {
+	fatalIfErr(err)
+
+	text, err := json.MarshalIndent(x, "", " ")
+	if err != nil {
+		log.Fatalf("JSON Error: %v", err)
+	}
+	log.Print(string(text))
+}
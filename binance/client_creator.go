@@ -4,28 +4,58 @@ import (
 	"net/http"
 
 	api "github.com/adshao/go-binance/v2"
+	apiDelivery "github.com/adshao/go-binance/v2/delivery"
 	apiFutures "github.com/adshao/go-binance/v2/futures"
 	"go.uber.org/zap"
 )
 
-func NewBinanceClient(baseURL, apiKey, secretKey string, l *zap.Logger) *api.Client {
+// newHTTPClient returns http.DefaultClient if there is no limiter
+func newHTTPClient(lim *BinanceRateLimiter) *http.Client {
+	if lim == nil {
+		return http.DefaultClient
+	}
+	return &http.Client{Transport: lim.Transport(http.DefaultTransport)}
+}
+
+// newFailoverHTTPClient sends requests to the endpoint picked by `selector`.
+// Every attempt is counted by the limiter.
+func newFailoverHTTPClient(selector *EndpointSelector, lim *BinanceRateLimiter) *http.Client {
+	var next http.RoundTripper = http.DefaultTransport
+	if lim != nil {
+		next = lim.Transport(next)
+	}
+	return &http.Client{Transport: &failoverTransport{selector: selector, next: next}}
+}
+
+func NewBinanceClient(baseURL, apiKey, secretKey string, lim *BinanceRateLimiter, l *zap.Logger) *api.Client {
 	return &api.Client{
 		APIKey:     apiKey,
 		SecretKey:  secretKey,
 		BaseURL:    baseURL,
 		UserAgent:  "Binance/golang",
-		HTTPClient: http.DefaultClient,
+		HTTPClient: newHTTPClient(lim),
 		Logger:     zap.NewStdLog(l.Named("adshao-binance")),
 	}
 }
 
-func NewBinanceFuturesClient(baseURL, apiKey, secretKey string, l *zap.Logger) *apiFutures.Client {
+func NewBinanceFuturesClient(baseURL, apiKey, secretKey string, lim *BinanceRateLimiter, l *zap.Logger) *apiFutures.Client {
 	return &apiFutures.Client{
 		APIKey:     apiKey,
 		SecretKey:  secretKey,
 		BaseURL:    baseURL,
 		UserAgent:  "Binance/golang",
-		HTTPClient: http.DefaultClient,
+		HTTPClient: newHTTPClient(lim),
+		Logger:     zap.NewStdLog(l.Named("adshao-binance")),
+	}
+}
+
+func NewBinanceDeliveryClient(baseURL, apiKey, secretKey string, lim *BinanceRateLimiter, l *zap.Logger) *apiDelivery.Client {
+	return &apiDelivery.Client{
+		APIKey:     apiKey,
+		SecretKey:  secretKey,
+		BaseURL:    baseURL,
+		UserAgent:  "Binance/golang",
+		HTTPClient: newHTTPClient(lim),
 		Logger:     zap.NewStdLog(l.Named("adshao-binance")),
 	}
 }
@@ -6,7 +6,18 @@ import (
 	exchanges "github.com/aulaleslie/trade-exchanges"
 )
 
-const BINANCE_PREFIX = "BINANCE-"
+// Every product has own prefix so the same native symbol (e.g. BTCUSDT)
+// on spot and futures doesn't collide.
+const (
+	BINANCE_PREFIX         = "BINANCE-"
+	BINANCE_US_PREFIX      = "BINANCEUS-"
+	BINANCE_FUTURES_PREFIX = "BINANCEFUTURES-"
+	// COIN-M (delivery) futures, e.g. BTCUSD_PERP or quarterly BTCUSD_241227
+	BINANCE_COIN_FUTURES_PREFIX = "BINANCECOINM-"
+	// Margin trades spot pairs, cross and isolated accounts are separate venues
+	BINANCE_MARGIN_PREFIX          = "BINANCEMARGIN-"
+	BINANCE_ISOLATED_MARGIN_PREFIX = "BINANCEISOLATED-"
+)
 
 // TODO: legal range is '^([0-9]{1,20})(\.[0-9]{1,20})?$' -- ?
 var binancePriceFloorRE = regexp.MustCompile(`^[0-9]{1,20}(\.[0-9]{1,6})?`)
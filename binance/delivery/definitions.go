@@ -0,0 +1,67 @@
+package delivery
+
+import exchanges "github.com/aulaleslie/trade-exchanges"
+
+var orderStatusTypeMap map[string]exchanges.OrderStatusType = map[string]exchanges.OrderStatusType{
+	"NEW":              exchanges.NewOST,             // NEW - The order has been accepted by the engine.
+	"PARTIALLY_FILLED": exchanges.PartiallyFilledOST, // PARTIALLY_FILLED - A part of the order has been filled.
+	"FILLED":           exchanges.FilledOST,          // FILLED - The order has been completely filled.
+	"CANCELED":         exchanges.CanceledOST,        // CANCELED - The order has been canceled by the user.
+	"REJECTED":         exchanges.RejectedOST,        // REJECTED - The order was not accepted by the engine and not processed.
+	"EXPIRED":          exchanges.ExpiredOST,         // EXPIRED - The order was canceled by the exchange, e.g. during liquidation or delivery
+}
+
+func mapOrderStatusType(orderStatus string) exchanges.OrderStatusType {
+	result, ok := orderStatusTypeMap[orderStatus]
+	if ok {
+		return result
+	}
+	return exchanges.UnknownOST
+}
+
+// Mapping OrderType
+var orderTypeMap map[string]exchanges.OrderType = map[string]exchanges.OrderType{
+	"LIMIT":       exchanges.LIMIT,
+	"MARKET":      exchanges.MARKET,
+	"STOP":        exchanges.STOP_LOSS_LIMIT,
+	"STOP_MARKET": exchanges.STOP_LOSS,
+	"TAKE_PROFIT": exchanges.TAKE_PROFIT_LIMIT,
+	// TAKE_PROFIT_MARKET and TRAILING_STOP_MARKET have no equivalent
+}
+
+func mapOrderType(orderType string) *exchanges.OrderType {
+	result, ok := orderTypeMap[orderType]
+	if ok {
+		return &result
+	}
+	return nil
+}
+
+// Mapping OrderSide
+var orderSideMap map[string]exchanges.OrderSide = map[string]exchanges.OrderSide{
+	"BUY":  exchanges.BUY,
+	"SELL": exchanges.SELL,
+}
+
+func mapOrderSide(orderSide string) exchanges.OrderSide {
+	result, ok := orderSideMap[orderSide]
+	if ok {
+		return result
+	}
+	return exchanges.UNKNOWN_ORDER_SIDE
+}
+
+// Mapping TimeInForce
+var orderTimeInForceMap map[string]exchanges.OrderTimeInForce = map[string]exchanges.OrderTimeInForce{
+	"GTC": exchanges.GTC_TIME_IN_FORCE,
+	"IOC": exchanges.IOC_TIME_IN_FORCE,
+	"FOK": exchanges.FOK_TIME_IN_FORCE,
+}
+
+func mapOrderTimeInForce(orderInForce string) *exchanges.OrderTimeInForce {
+	result, ok := orderTimeInForceMap[orderInForce]
+	if ok {
+		return &result
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package delivery
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/adshao/go-binance/v2/common"
+	api "github.com/adshao/go-binance/v2/delivery"
+	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/aulaleslie/trade-exchanges/utils"
+	"github.com/cockroachdb/apd"
+	"github.com/pkg/errors"
+)
+
+type OrderGetter struct {
+	client *api.Client
+}
+
+func NewOrderGetter(client *api.Client) *OrderGetter {
+	return &OrderGetter{
+		client: client,
+	}
+}
+
+func (og *OrderGetter) GetOrderInfoByClientOrderID(ctx context.Context, symbol, clientOrderID string) (exchanges.OrderInfo, error) {
+	orderInfo := exchanges.OrderInfo{}
+
+	data, err := og.GetBinanceOrder(ctx, symbol, clientOrderID)
+	if err != nil {
+		return orderInfo, errors.Wrap(err, "can't query order")
+	}
+
+	orderInfo.ClientOrderID = &data.ClientOrderID
+	orderInfo.ID = data.ClientOrderID
+	orderInfo.Status = mapOrderStatusType(string(data.Status))
+	return orderInfo, nil
+}
+
+func (og *OrderGetter) GetOrderBinanceStatus(ctx context.Context, symbol, clientOrderID string) (api.OrderStatusType, error) {
+	order, err := og.GetBinanceOrder(ctx, symbol, clientOrderID)
+	if err != nil {
+		return "", err
+	}
+
+	return order.Status, nil
+}
+
+func (og *OrderGetter) GetBinanceOrder(ctx context.Context, symbol, clientOrderID string) (*api.Order, error) {
+	order, err := og.client.NewGetOrderService().
+		Symbol(symbol).
+		OrigClientOrderID(clientOrderID).
+		Do(ctx)
+	if err != nil {
+		if og.isNotFoundDuringGetOrderStatus(err) {
+			return nil, utils.ReplaceError(exchanges.OrderNotFoundError, err)
+		}
+		return nil, err
+	}
+
+	return order, nil
+}
+
+func (og *OrderGetter) isNotFoundDuringGetOrderStatus(err error) bool {
+	apiErr, ok := err.(*common.APIError)
+	if !ok {
+		return false
+	}
+
+	// https://binance-docs.github.io/apidocs/delivery/en/#error-codes
+	return apiErr.Code == -2013
+}
+
+// buildOrderDetailInfo returns quantities in contracts
+func (og *OrderGetter) buildOrderDetailInfo(prefix string, order *api.Order) (res exchanges.OrderDetailInfo, err error) {
+	price, _, err := apd.NewFromString(order.Price)
+	if err != nil {
+		return res, errors.Wrapf(err, "invalid price %s", order.Price)
+	}
+	quantity, _, err := apd.NewFromString(order.OrigQuantity)
+	if err != nil {
+		return res, errors.Wrapf(err, "invalid quantity %s", order.OrigQuantity)
+	}
+	executedQuantity, _, err := apd.NewFromString(order.ExecutedQuantity)
+	if err != nil {
+		return res, errors.Wrapf(err, "invalid executed quantity %s", order.ExecutedQuantity)
+	}
+	stopPrice, _, err := apd.NewFromString(order.StopPrice)
+	if err != nil {
+		return res, errors.Wrapf(err, "invalid stop price %s", order.StopPrice)
+	}
+
+	res = exchanges.OrderDetailInfo{
+		Symbol:        exchanges.FullSymbol(prefix, order.Symbol),
+		ID:            strconv.FormatInt(order.OrderID, 10),
+		ClientOrderID: &order.ClientOrderID,
+		Price:         price,
+		Quantity:      quantity,
+		ExecutedQty:   executedQuantity,
+		Status:        mapOrderStatusType(string(order.Status)),
+		OrderType:     mapOrderType(string(order.Type)),
+		Time:          order.Time,
+		OrderSide:     mapOrderSide(string(order.Side)),
+		TimeInForce:   mapOrderTimeInForce(string(order.TimeInForce)),
+		StopPrice:     stopPrice,
+		QuoteQuantity: nil,
+	}
+	return res, nil
+}
+
+// GetOpenOrders returns open orders, symbols are prefixed with `prefix`
+func (og *OrderGetter) GetOpenOrders(ctx context.Context, prefix string) ([]exchanges.OrderDetailInfo, error) {
+	openOrders, err := og.client.NewListOpenOrdersService().Do(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't query open orders")
+	}
+
+	res := []exchanges.OrderDetailInfo{}
+	for _, openOrder := range openOrders {
+		info, err := og.buildOrderDetailInfo(prefix, openOrder)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, info)
+	}
+	return res, nil
+}
+
+// GetHistoryOrders returns the order by ID or client order ID or orders of the symbol.
+// Binance requires symbol or pair to list orders of COIN-M futures.
+func (og *OrderGetter) GetHistoryOrders(
+	ctx context.Context,
+	prefix string,
+	symbol *string,
+	orderID *string,
+	clientOrderID *string,
+	since *time.Time,
+) ([]exchanges.OrderDetailInfo, error) {
+	if symbol == nil || *symbol == "" {
+		return nil, errors.New("symbol is required to list COIN-M orders")
+	}
+
+	if orderID != nil || clientOrderID != nil {
+		orderService := og.client.NewGetOrderService().Symbol(*symbol)
+		if orderID != nil {
+			id, err := strconv.ParseInt(*orderID, 10, 64)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid order ID %s", *orderID)
+			}
+			orderService.OrderID(id)
+		}
+		if clientOrderID != nil {
+			orderService.OrigClientOrderID(*clientOrderID)
+		}
+
+		order, err := orderService.Do(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "can't query order")
+		}
+		info, err := og.buildOrderDetailInfo(prefix, order)
+		if err != nil {
+			return nil, err
+		}
+		return []exchanges.OrderDetailInfo{info}, nil
+	}
+
+	listService := og.client.NewListOrdersService().Symbol(*symbol)
+	if since != nil {
+		listService.StartTime(since.UnixMilli())
+	}
+	orders, err := listService.Do(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't query orders")
+	}
+	res := []exchanges.OrderDetailInfo{}
+	for _, order := range orders {
+		info, err := og.buildOrderDetailInfo(prefix, order)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, info)
+	}
+	return res, nil
+}
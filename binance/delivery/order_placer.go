@@ -0,0 +1,170 @@
+package delivery
+
+import (
+	"context"
+	"strings"
+
+	"github.com/adshao/go-binance/v2/common"
+	api "github.com/adshao/go-binance/v2/delivery"
+	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/aulaleslie/trade-exchanges/utils"
+	"github.com/cockroachdb/apd"
+	"github.com/pkg/errors"
+)
+
+// orderFields of COIN-M order, quantity is a number of contracts
+type orderFields struct {
+	Symbol           string
+	Side             api.SideType
+	Type             api.OrderType
+	TimeInForce      api.TimeInForceType // Empty for market orders
+	Quantity         string
+	Price            string // Empty for market orders
+	NewClientOrderID string
+	NewOrderRespType api.NewOrderRespType
+}
+
+func (of *orderFields) ToAPI(c *api.Client) *api.CreateOrderService {
+	s := c.
+		NewCreateOrderService().
+		Symbol(of.Symbol).
+		Side(of.Side).
+		Type(of.Type).
+		Quantity(of.Quantity).
+		NewClientOrderID(of.NewClientOrderID).
+		NewOrderResponseType(of.NewOrderRespType)
+	if of.TimeInForce != "" {
+		s.TimeInForce(of.TimeInForce)
+	}
+	if of.Price != "" {
+		s.Price(of.Price)
+	}
+	return s
+}
+
+func (of *orderFields) equalStringNumber(x string, y string) bool {
+	xx, _, err := apd.NewFromString(x)
+	if err != nil {
+		return false
+	}
+
+	yy, _, err := apd.NewFromString(y)
+	if err != nil {
+		return false
+	}
+
+	return xx.Cmp(yy) == 0
+}
+
+func (of *orderFields) Equal(x *api.Order) bool {
+	eq := of.equalStringNumber
+	return (of.Symbol == x.Symbol &&
+		of.Side == x.Side &&
+		of.Type == x.Type &&
+		eq(of.Quantity, x.OrigQuantity) &&
+		(of.Price == "" || eq(of.Price, x.Price)) &&
+		of.NewClientOrderID == x.ClientOrderID)
+}
+
+type OrderPlacer struct {
+	orderGetter *OrderGetter
+	client      *api.Client
+}
+
+func NewOrderPlacer(client *api.Client) *OrderPlacer {
+	return &OrderPlacer{
+		client:      client,
+		orderGetter: &OrderGetter{client: client},
+	}
+}
+
+// CreateOrderRequest Don't forget to floor `price`, `quantity` is a whole number of contracts
+func (op *OrderPlacer) CreateOrderRequest(
+	symbol string, price, quantity *apd.Decimal, preferredID string,
+	side api.SideType, orderType api.OrderType,
+) (*orderFields, error) {
+	contracts, err := quantity.Int64()
+	if err != nil || contracts <= 0 {
+		return nil, errors.Errorf("quantity %v isn't a positive number of contracts", quantity)
+	}
+
+	req := &orderFields{
+		Symbol:           symbol,
+		Side:             side,
+		Type:             orderType,
+		Quantity:         utils.ToFlatString(quantity),
+		NewClientOrderID: preferredID,
+		NewOrderRespType: api.NewOrderRespTypeACK,
+	}
+	if orderType != api.OrderTypeMarket {
+		req.TimeInForce = api.TimeInForceTypeGTC
+		req.Price = utils.ToFlatString(price)
+	}
+	return req, nil
+}
+
+func (op *OrderPlacer) PlaceOrder(ctx context.Context,
+	symbol string, price, quantity *apd.Decimal, preferredID string,
+	side api.SideType, orderType api.OrderType,
+) (id string, e error) {
+	orderReq, err := op.CreateOrderRequest(symbol, price, quantity, preferredID, side, orderType)
+	if err != nil {
+		return "", errors.Wrapf(err, "can't create order req")
+	}
+
+	id, placeErr := op.tryToPlaceOrder(ctx, orderReq)
+	if placeErr == nil {
+		return id, nil
+	}
+	if !errors.Is(placeErr, exchanges.NewOrderRejectedError) {
+		return "", placeErr
+	}
+
+	binanceOrder, getErr := op.orderGetter.GetBinanceOrder(ctx, symbol, preferredID)
+	if errors.Is(getErr, exchanges.OrderNotFoundError) {
+		return "", placeErr // Order rejected by another reason
+	}
+	if getErr != nil {
+		return "", errors.Wrapf(placeErr, "[Subreason: can't fetch order: %v]", getErr)
+	}
+
+	if orderReq.Equal(binanceOrder) {
+		return binanceOrder.ClientOrderID, nil
+	}
+	return "", errors.Errorf("different order with same ClientOrderID (%s) was placed", preferredID)
+}
+
+func (op *OrderPlacer) tryToPlaceOrder(ctx context.Context, req *orderFields) (id string, e error) {
+	order, err := req.ToAPI(op.client).Do(ctx)
+	if err != nil {
+		err = op.castToOrderRejecterErrorIfCan(err)
+		return "", errors.Wrapf(err, "can't place %s order", string(req.Side))
+	}
+
+	if order.Status == api.OrderStatusTypeRejected {
+		return "", errors.Wrap(
+			exchanges.NewOrderRejectedError, "order have status = REJECTED")
+	}
+
+	return order.ClientOrderID, nil
+}
+
+func (op *OrderPlacer) castToOrderRejecterErrorIfCan(err error) error {
+	if op.isOrderRejectedError(err) {
+		return utils.ReplaceError(exchanges.NewOrderRejectedError, err)
+	}
+	return err
+}
+
+func (op *OrderPlacer) isOrderRejectedError(err error) bool {
+	apiErr, ok := err.(*common.APIError)
+	if !ok {
+		return false
+	}
+
+	// https://binance-docs.github.io/apidocs/delivery/en/#error-codes
+	if apiErr.Code == -2010 {
+		return true
+	}
+	return apiErr.Code == -1013 && strings.HasPrefix(apiErr.Message, "Filter failure:")
+}
@@ -0,0 +1,89 @@
+package delivery
+
+import (
+	"context"
+
+	api "github.com/adshao/go-binance/v2/delivery"
+	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/cockroachdb/apd"
+	"github.com/pkg/errors"
+)
+
+type PositionGetter struct {
+	client *api.Client
+}
+
+func NewPositionGetter(client *api.Client) *PositionGetter {
+	return &PositionGetter{
+		client: client,
+	}
+}
+
+// GetAccountPosition returns wallet balances per margin coin and open positions in contracts,
+// symbols are prefixed with `prefix`
+func (pg *PositionGetter) GetAccountPosition(ctx context.Context, prefix string) (exchanges.Account, error) {
+	account, err := pg.client.NewGetAccountService().Do(ctx)
+	if err != nil {
+		return exchanges.Account{}, errors.Wrap(err, "can't get account")
+	}
+
+	res := exchanges.Account{
+		AccountBalances:  []exchanges.AccountBalance{},
+		AccountPositions: []exchanges.AccountPosition{},
+	}
+	for _, asset := range account.Assets {
+		wallet, _, err := apd.NewFromString(asset.WalletBalance)
+		if err != nil {
+			return exchanges.Account{}, errors.Wrapf(err, "invalid wallet balance of %s", asset.Asset)
+		}
+		if wallet.IsZero() {
+			continue
+		}
+		available, _, err := apd.NewFromString(asset.AvailableBalance)
+		if err != nil {
+			return exchanges.Account{}, errors.Wrapf(err, "invalid available balance of %s", asset.Asset)
+		}
+		// Wallet minus available is negative on positive unrealized PnL
+		locked, _, err := apd.NewFromString(asset.InitialMargin)
+		if err != nil {
+			return exchanges.Account{}, errors.Wrapf(err, "invalid initial margin of %s", asset.Asset)
+		}
+		res.AccountBalances = append(res.AccountBalances, exchanges.AccountBalance{
+			Coin:   asset.Asset,
+			Free:   available,
+			Locked: locked,
+		})
+	}
+
+	for _, position := range account.Positions {
+		size, _, err := apd.NewFromString(position.PositionAmt)
+		if err != nil {
+			return exchanges.Account{}, errors.Wrapf(err, "invalid position amount of %s", position.Symbol)
+		}
+		if size.IsZero() {
+			continue
+		}
+		unrealizedProfit, _, err := apd.NewFromString(position.UnrealizedProfit)
+		if err != nil {
+			return exchanges.Account{}, errors.Wrapf(err, "invalid unrealized profit of %s", position.Symbol)
+		}
+		leverage, _, err := apd.NewFromString(position.Leverage)
+		if err != nil {
+			return exchanges.Account{}, errors.Wrapf(err, "invalid leverage of %s", position.Symbol)
+		}
+		entryPrice, _, err := apd.NewFromString(position.EntryPrice)
+		if err != nil {
+			return exchanges.Account{}, errors.Wrapf(err, "invalid entry price of %s", position.Symbol)
+		}
+
+		res.AccountPositions = append(res.AccountPositions, exchanges.AccountPosition{
+			Symbol:           exchanges.FullSymbol(prefix, position.Symbol),
+			UnrealizedProfit: unrealizedProfit,
+			Leverage:         leverage,
+			EntryPrice:       entryPrice,
+			Size:             size,
+			Side:             position.PositionSide,
+		})
+	}
+	return res, nil
+}
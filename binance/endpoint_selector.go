@@ -0,0 +1,276 @@
+package binance
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// TODO: move to config
+const (
+	defaultEndpointMaxFailures = 2
+	defaultEndpointCooldown    = 30 * time.Second
+	endpointProbeTimeout       = 5 * time.Second
+)
+
+type endpointHealth struct {
+	url       string
+	failures  int // Consecutive
+	downUntil time.Time
+	probing   bool
+}
+
+// EndpointSelector picks the first healthy endpoint of the ordered list.
+// An endpoint is taken out after `MaxFailures` consecutive failures (timeouts or 5xx errors)
+// and is probed for recovery after `Cooldown`.
+type EndpointSelector struct {
+	MaxFailures int           // optional
+	Cooldown    time.Duration // optional
+	// Checks if the endpoint is recovered. If it's nil the endpoint gets real traffic after `Cooldown`
+	// and is taken out again by the first failure.
+	Probe func(ctx context.Context, endpoint string) error // optional
+
+	endpoints []*endpointHealth
+	lg        *zap.Logger
+	mu        sync.Mutex
+
+	now func() time.Time
+}
+
+func NewEndpointSelector(endpoints []string, lg *zap.Logger) *EndpointSelector {
+	s := &EndpointSelector{
+		lg:  lg.Named("EndpointSelector"),
+		now: time.Now,
+	}
+	for _, e := range endpoints {
+		s.endpoints = append(s.endpoints, &endpointHealth{url: e})
+	}
+	return s
+}
+
+func (s *EndpointSelector) Len() int {
+	return len(s.endpoints)
+}
+
+func (s *EndpointSelector) maxFailures() int {
+	if s.MaxFailures <= 0 {
+		return defaultEndpointMaxFailures
+	}
+	return s.MaxFailures
+}
+
+func (s *EndpointSelector) cooldown() time.Duration {
+	if s.Cooldown <= 0 {
+		return defaultEndpointCooldown
+	}
+	return s.Cooldown
+}
+
+// Pick returns the first healthy endpoint.
+// The one recovering soonest is returned if all endpoints are down.
+func (s *EndpointSelector) Pick() string {
+	return s.pickExcept(nil)
+}
+
+func (s *EndpointSelector) pickExcept(tried map[string]bool) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	var soonest *endpointHealth
+	for _, e := range s.endpoints {
+		if tried[e.url] {
+			continue
+		}
+		if !e.downUntil.IsZero() && !now.Before(e.downUntil) {
+			s.recover(e)
+		}
+		if e.downUntil.IsZero() {
+			return e.url
+		}
+		if soonest == nil || e.downUntil.Before(soonest.downUntil) {
+			soonest = e
+		}
+	}
+	if soonest == nil {
+		return ""
+	}
+	return soonest.url
+}
+
+// recover must be called under the lock
+func (s *EndpointSelector) recover(e *endpointHealth) {
+	if s.Probe == nil {
+		// Half-open: the next failure takes it out again
+		e.downUntil = time.Time{}
+		e.failures = s.maxFailures() - 1
+		return
+	}
+	if e.probing {
+		return
+	}
+	e.probing = true
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), endpointProbeTimeout)
+		defer cancel()
+		err := s.Probe(ctx, e.url)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		e.probing = false
+		if err != nil {
+			s.lg.Warn("endpoint isn't recovered", zap.String("endpoint", e.url), zap.Error(err))
+			e.downUntil = s.now().Add(s.cooldown())
+			return
+		}
+		s.lg.Info("endpoint is recovered", zap.String("endpoint", e.url))
+		e.downUntil = time.Time{}
+		e.failures = 0
+	}()
+}
+
+// Report records result of a request, `endpoint` can be any URL of the endpoint
+func (s *EndpointSelector) Report(endpoint string, failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := s.find(endpoint)
+	if e == nil {
+		return
+	}
+	if !failed {
+		e.failures = 0
+		return
+	}
+	e.failures++
+	if e.failures >= s.maxFailures() && e.downUntil.IsZero() {
+		s.lg.Warn("endpoint is down", zap.String("endpoint", e.url), zap.Int("failures", e.failures))
+		e.downUntil = s.now().Add(s.cooldown())
+	}
+}
+
+// Healthy reports if the endpoint gets traffic
+func (s *EndpointSelector) Healthy(endpoint string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := s.find(endpoint)
+	return e != nil && e.downUntil.IsZero()
+}
+
+// find must be called under the lock, the longest matching endpoint is returned
+func (s *EndpointSelector) find(u string) *endpointHealth {
+	var res *endpointHealth
+	for _, e := range s.endpoints {
+		if strings.HasPrefix(u, e.url) && (res == nil || len(e.url) > len(res.url)) {
+			res = e
+		}
+	}
+	return res
+}
+
+// newAPIEndpointSelector returns selector of spot REST endpoints, nil if there are no alternates
+func newAPIEndpointSelector(urls BinanceURLs, lg *zap.Logger) *EndpointSelector {
+	if len(urls.AltAPIURLs) == 0 {
+		return nil
+	}
+	s := NewEndpointSelector(urls.APIEndpoints(), lg.Named("REST"))
+	s.Probe = pingProbe("/api/v3/ping")
+	return s
+}
+
+// pingProbe checks the endpoint by unsigned ping request, `path` is like "/api/v3/ping"
+func pingProbe(path string) func(ctx context.Context, endpoint string) error {
+	return func(ctx context.Context, endpoint string) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+path, nil)
+		if err != nil {
+			return errors.Wrap(err, "can't create request")
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return errors.Wrap(err, "can't ping")
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return errors.Errorf("unexpected ping status %d", resp.StatusCode)
+		}
+		return nil
+	}
+}
+
+// failoverTransport sends requests to the endpoint picked by the selector.
+// Endpoints should differ only by scheme and host.
+//
+// Only idempotent requests are retried on another endpoint. Others (like order placement)
+// return the failure: they are retried by the caller with the same client order ID and
+// the result is post-checked by it (see OrderPlacer.PlaceOrder).
+type failoverTransport struct {
+	selector *EndpointSelector
+	next     http.RoundTripper
+}
+
+func (t *failoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	attempts := 1
+	if isIdempotentRequest(req) {
+		attempts = t.selector.Len()
+	}
+
+	tried := map[string]bool{}
+	for i := 0; ; i++ {
+		endpoint := t.selector.pickExcept(tried)
+		tried[endpoint] = true
+
+		r, err := withEndpoint(req, endpoint)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := t.next.RoundTrip(r)
+		failed := isEndpointFailure(req.Context(), resp, err)
+		t.selector.Report(endpoint, failed)
+		if !failed || i+1 >= attempts {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+	}
+}
+
+func isIdempotentRequest(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return req.Body == nil || req.Body == http.NoBody
+	}
+	return false
+}
+
+// isEndpointFailure is true for network errors and 5xx responses.
+// Client errors like rate limits aren't failures of the endpoint.
+func isEndpointFailure(ctx context.Context, resp *http.Response, err error) bool {
+	if err != nil {
+		return ctx.Err() == nil
+	}
+	return resp.StatusCode >= http.StatusInternalServerError
+}
+
+func withEndpoint(req *http.Request, endpoint string) (*http.Request, error) {
+	if endpoint == "" {
+		return req, nil
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid endpoint %s", endpoint)
+	}
+	r := req.Clone(req.Context())
+	r.URL.Scheme = u.Scheme
+	r.URL.Host = u.Host
+	r.Host = ""
+	return r, nil
+}
@@ -0,0 +1,124 @@
+package binance
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type countingServer struct {
+	*httptest.Server
+	mu     sync.Mutex
+	status int
+	hits   map[string]int // By method
+}
+
+func newCountingServer(t *testing.T, status int) *countingServer {
+	s := &countingServer{status: status, hits: map[string]int{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.hits[r.Method]++
+		w.WriteHeader(s.status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *countingServer) count(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hits[method]
+}
+
+func TestFailoverTransportRetriesOnlyIdempotentRequests(t *testing.T) {
+	primary := newCountingServer(t, http.StatusServiceUnavailable)
+	alternate := newCountingServer(t, http.StatusOK)
+
+	selector := NewEndpointSelector([]string{primary.URL, alternate.URL}, zap.NewNop())
+	selector.MaxFailures = 2
+	client := newFailoverHTTPClient(selector, nil)
+
+	// Non-idempotent request isn't sent to another endpoint
+	resp, err := client.Post(primary.URL+"/api/v3/order", "application/x-www-form-urlencoded", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, 0, alternate.count(http.MethodPost))
+
+	// GET is retried on the alternate, the primary is taken out after the 2nd failure
+	resp, err = client.Get(primary.URL + "/api/v3/openOrders")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.False(t, selector.Healthy(primary.URL))
+	assert.Equal(t, alternate.URL, selector.Pick())
+
+	// Traffic goes to the alternate while the primary is down
+	resp, err = client.Post(primary.URL+"/api/v3/order", "application/x-www-form-urlencoded", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 1, primary.count(http.MethodPost))
+	assert.Equal(t, 1, primary.count(http.MethodGet))
+}
+
+func TestEndpointSelectorProbesForRecovery(t *testing.T) {
+	now := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+	var mu sync.Mutex
+	probeErr := errors.New("still down")
+
+	selector := NewEndpointSelector([]string{"https://api.binance.com", "https://api1.binance.com"}, zap.NewNop())
+	selector.MaxFailures = 1
+	selector.Cooldown = time.Minute
+	selector.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	probes := make(chan struct{}, 10)
+	selector.Probe = func(_ context.Context, endpoint string) error {
+		assert.Equal(t, "https://api.binance.com", endpoint)
+		mu.Lock()
+		defer mu.Unlock()
+		probes <- struct{}{}
+		return probeErr
+	}
+	advance := func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(d)
+	}
+
+	selector.Report("https://api.binance.com/api/v3/order", true)
+	assert.Equal(t, "https://api1.binance.com", selector.Pick())
+
+	// Failed probe keeps it out for another cooldown
+	advance(time.Minute)
+	assert.Equal(t, "https://api1.binance.com", selector.Pick())
+	<-probes
+	require.Eventually(t, func() bool {
+		selector.mu.Lock()
+		defer selector.mu.Unlock()
+		return !selector.endpoints[0].probing
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, "https://api1.binance.com", selector.Pick())
+	assert.Len(t, probes, 0)
+
+	mu.Lock()
+	probeErr = nil
+	mu.Unlock()
+	advance(time.Minute)
+	selector.Pick()
+	<-probes
+	require.Eventually(t, func() bool { return selector.Healthy("https://api.binance.com") }, time.Second, time.Millisecond)
+	assert.Equal(t, "https://api.binance.com", selector.Pick())
+}
@@ -0,0 +1,129 @@
+package futures
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/common"
+	api "github.com/adshao/go-binance/v2/futures"
+	"github.com/pkg/errors"
+)
+
+const countdownCancelAllEndpoint = "/fapi/v1/countdownCancelAll"
+
+// CountdownCanceller arms `countdownCancelAll`: Binance cancels all orders of the symbol
+// when the countdown isn't renewed in time. The countdown is per symbol.
+type CountdownCanceller struct {
+	client *api.Client
+
+	mu    sync.Mutex
+	armed map[string]bool
+}
+
+func NewCountdownCanceller(client *api.Client) *CountdownCanceller {
+	return &CountdownCanceller{
+		client: client,
+		armed:  map[string]bool{},
+	}
+}
+
+// CancelAllAfter arms countdown of `symbol`, zero `timeout` disarms it.
+// Empty `symbol` means symbols with open orders and all symbols armed before.
+func (cc *CountdownCanceller) CancelAllAfter(ctx context.Context, symbol string, timeout time.Duration) error {
+	symbols := []string{symbol}
+	if symbol == "" {
+		var err error
+		if symbols, err = cc.symbolsToArm(ctx); err != nil {
+			return err
+		}
+	}
+
+	for _, s := range symbols {
+		if err := cc.countdown(ctx, s, timeout); err != nil {
+			return errors.Wrapf(err, "can't set countdown of %s", s)
+		}
+		cc.mu.Lock()
+		if timeout == 0 {
+			delete(cc.armed, s)
+		} else {
+			cc.armed[s] = true
+		}
+		cc.mu.Unlock()
+	}
+	return nil
+}
+
+func (cc *CountdownCanceller) symbolsToArm(ctx context.Context) ([]string, error) {
+	orders, err := cc.client.NewListOpenOrdersService().Do(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't list open orders")
+	}
+
+	cc.mu.Lock()
+	set := map[string]bool{}
+	for s := range cc.armed {
+		set[s] = true
+	}
+	cc.mu.Unlock()
+	for _, o := range orders {
+		set[o.Symbol] = true
+	}
+
+	res := make([]string, 0, len(set))
+	for s := range set {
+		res = append(res, s)
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+// countdown sends signed request, go-binance doesn't support the endpoint
+func (cc *CountdownCanceller) countdown(ctx context.Context, symbol string, timeout time.Duration) error {
+	c := cc.client
+	query := url.Values{}
+	query.Set("symbol", symbol)
+	query.Set("countdownTime", strconv.FormatInt(timeout.Milliseconds(), 10))
+	query.Set("timestamp", strconv.FormatInt(time.Now().UnixMilli()-c.TimeOffset, 10))
+	mac := hmac.New(sha256.New, []byte(c.SecretKey))
+	mac.Write([]byte(query.Encode()))
+	fullURL := c.BaseURL + countdownCancelAllEndpoint + "?" + query.Encode() + "&signature=" + hex.EncodeToString(mac.Sum(nil))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fullURL, nil)
+	if err != nil {
+		return errors.Wrap(err, "can't create request")
+	}
+	req.Header.Set("X-MBX-APIKEY", c.APIKey)
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "can't do request")
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "can't read response")
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		apiErr := &common.APIError{}
+		if err := json.Unmarshal(data, apiErr); err != nil {
+			return errors.Errorf("unexpected status %d: %s", resp.StatusCode, data)
+		}
+		return apiErr
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package futures
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	api "github.com/adshao/go-binance/v2/futures"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountdownCancellerArmsSymbolsWithOpenOrders(t *testing.T) {
+	var mu sync.Mutex
+	countdowns := map[string]string{}
+	openOrders := `[{"symbol":"ETHUSDT","status":"NEW"},{"symbol":"BTCUSDT","status":"NEW"}]`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fapi/v1/openOrders":
+			mu.Lock()
+			w.Write([]byte(openOrders))
+			mu.Unlock()
+		case "/fapi/v1/countdownCancelAll":
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.NotEmpty(t, r.URL.Query().Get("signature"))
+			mu.Lock()
+			countdowns[r.URL.Query().Get("symbol")] = r.URL.Query().Get("countdownTime")
+			mu.Unlock()
+			w.Write([]byte(`{"symbol":"` + r.URL.Query().Get("symbol") + `","countdownTime":"1"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	client := api.NewClient("key", "secret")
+	client.BaseURL = server.URL
+	cc := NewCountdownCanceller(client)
+
+	require.NoError(t, cc.CancelAllAfter(context.Background(), "", time.Minute))
+	assert.Equal(t, map[string]string{"BTCUSDT": "60000", "ETHUSDT": "60000"}, countdowns)
+
+	// Armed symbols are renewed even without open orders
+	mu.Lock()
+	openOrders = `[]`
+	mu.Unlock()
+	require.NoError(t, cc.CancelAllAfter(context.Background(), "", 30*time.Second))
+	assert.Equal(t, map[string]string{"BTCUSDT": "30000", "ETHUSDT": "30000"}, countdowns)
+
+	require.NoError(t, cc.CancelAllAfter(context.Background(), "", 0))
+	assert.Equal(t, map[string]string{"BTCUSDT": "0", "ETHUSDT": "0"}, countdowns)
+	assert.Empty(t, cc.armed)
+}
@@ -9,6 +9,18 @@ var orderStatusTypeMap map[string]exchanges.OrderStatusType = map[string]exchang
 	"CANCELED":         exchanges.CanceledOST,        // CANCELED - The order has been canceled by the user.
 	"REJECTED":         exchanges.RejectedOST,        // REJECTED - The order was not accepted by the engine and not processed.
 	"EXPIRED":          exchanges.ExpiredOST,         // EXPIRED - The order was canceled according to the order type's rules (e.g. LIMIT FOK orders with no fill, LIMIT IOC or MARKET orders that partially fill) or by the exchange, (e.g. orders canceled during liquidation, orders canceled during maintenance)
+	"NEW_INSURANCE":    exchanges.NewOST,             // NEW_INSURANCE - Liquidation with insurance fund
+	"NEW_ADL":          exchanges.NewOST,             // NEW_ADL - Counterparty liquidation (auto-deleveraging)
+}
+
+// MapOrderStatusType returns TriggeredOST for conditional order which is working as market
+// or limit one, i.e. its `orderType` isn't the original one
+func MapOrderStatusType(orderStatus, orderType, origType string) exchanges.OrderStatusType {
+	result := mapOrderStatusType(orderStatus)
+	if result == exchanges.NewOST && origType != "" && orderType != origType {
+		return exchanges.TriggeredOST
+	}
+	return result
 }
 
 func mapOrderStatusType(orderStatus string) exchanges.OrderStatusType {
@@ -29,6 +41,11 @@ var orderTypeMap map[string]exchanges.OrderType = map[string]exchanges.OrderType
 	"STOP_LOSS_LIMIT":   exchanges.STOP_LOSS_LIMIT,
 	"TAKE_PROFIT":       exchanges.TAKE_PROFIT,
 	"TAKE_PROFIT_LIMIT": exchanges.TAKE_PROFIT_LIMIT,
+
+	"STOP":                 exchanges.STOP,
+	"STOP_MARKET":          exchanges.STOP_MARKET,
+	"TAKE_PROFIT_MARKET":   exchanges.TAKE_PROFIT_MARKET,
+	"TRAILING_STOP_MARKET": exchanges.TRAILING_STOP_MARKET,
 }
 
 func mapOrderType(orderType string) *exchanges.OrderType {
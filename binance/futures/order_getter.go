@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/adshao/go-binance/v2/common"
 	api "github.com/adshao/go-binance/v2/futures"
 	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/aulaleslie/trade-exchanges/binance/wsapi"
 	"github.com/aulaleslie/trade-exchanges/utils"
 	"github.com/cockroachdb/apd"
 	"github.com/pkg/errors"
@@ -15,6 +17,7 @@ import (
 
 type OrderGetter struct {
 	client *api.Client
+	wsAPI  *wsapi.Client
 }
 
 func NewOrderGetter(client *api.Client) *OrderGetter {
@@ -33,7 +36,7 @@ func (og *OrderGetter) GetOrderInfoByClientOrderID(ctx context.Context, symbol,
 
 	orderInfo.ClientOrderID = &data.ClientOrderID
 	orderInfo.ID = data.ClientOrderID
-	orderInfo.Status = mapOrderStatusType(string(data.Status))
+	orderInfo.Status = MapOrderStatusType(string(data.Status), string(data.Type), data.OrigType)
 	return orderInfo, nil
 }
 
@@ -47,6 +50,13 @@ func (og *OrderGetter) GetOrderBinanceStatus(ctx context.Context, symbol, client
 }
 
 func (og *OrderGetter) GetBinanceOrder(ctx context.Context, symbol, clientOrderID string) (*api.Order, error) {
+	if og.wsAPI != nil {
+		order, fallback, err := og.getOrderWS(ctx, symbol, clientOrderID)
+		if !fallback {
+			return order, err
+		}
+	}
+
 	order, err := og.client.NewGetOrderService().
 		Symbol(symbol).
 		OrigClientOrderID(clientOrderID).
@@ -102,7 +112,7 @@ func (og *OrderGetter) buildOrderDetailInfo(order *api.Order) (res exchanges.Ord
 		return res, err
 	}
 
-	orderStatusType := mapOrderStatusType(string(order.Status))
+	orderStatusType := MapOrderStatusType(string(order.Status), string(order.Type), order.OrigType)
 
 	orderType := mapOrderType(string(order.Type))
 
@@ -114,6 +124,13 @@ func (og *OrderGetter) buildOrderDetailInfo(order *api.Order) (res exchanges.Ord
 	if err != nil {
 		return res, err
 	}
+	// Trailing stop has no stop price until it's activated
+	if order.ActivatePrice != "" && stopPrice.IsZero() {
+		stopPrice, _, err = apd.NewFromString(order.ActivatePrice)
+		if err != nil {
+			return res, err
+		}
+	}
 
 	res = exchanges.OrderDetailInfo{
 		Symbol:        order.Symbol,
@@ -155,6 +172,7 @@ func (og *OrderGetter) GetHistoryOrders(
 	symbol *string,
 	orderID *string,
 	clientOrderID *string,
+	since *time.Time,
 ) (res []exchanges.OrderDetailInfo, err error) {
 	if orderID != nil || clientOrderID != nil {
 		orderService := og.client.NewGetOrderService()
@@ -190,7 +208,14 @@ func (og *OrderGetter) GetHistoryOrders(
 		return res, err
 	}
 
-	orders, err := og.client.NewListOrdersService().Do(ctx)
+	listService := og.client.NewListOrdersService()
+	if symbol != nil {
+		listService.Symbol(*symbol)
+	}
+	if since != nil {
+		listService.StartTime(since.UnixMilli())
+	}
+	orders, err := listService.Do(ctx)
 	if err != nil {
 		err = errors.Wrap(err, "can't query open order")
 		fmt.Println(err)
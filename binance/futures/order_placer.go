@@ -7,32 +7,87 @@ import (
 	"github.com/adshao/go-binance/v2/common"
 	api "github.com/adshao/go-binance/v2/futures"
 	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/aulaleslie/trade-exchanges/binance/wsapi"
 	"github.com/aulaleslie/trade-exchanges/utils"
 	"github.com/cockroachdb/apd"
 	"github.com/pkg/errors"
 )
 
+// ConditionalOrderParams are fields of STOP, STOP_MARKET, TAKE_PROFIT, TAKE_PROFIT_MARKET
+// and TRAILING_STOP_MARKET orders
+type ConditionalOrderParams struct {
+	StopPrice       *apd.Decimal         // Required by all conditional types except trailing stop
+	WorkingType     api.WorkingType      // optional, CONTRACT_PRICE by default
+	PriceProtect    bool                 // optional
+	CallbackRate    *apd.Decimal         // Trailing stop only, percent from 0.1 to 5
+	ActivationPrice *apd.Decimal         // optional, trailing stop only, current price by default
+	ReduceOnly      bool                 // optional
+	ClosePosition   bool                 // optional, STOP_MARKET and TAKE_PROFIT_MARKET only, quantity is ignored
+	PositionSide    api.PositionSideType // optional, hedge mode only
+}
+
 type orderFields struct {
 	Symbol           string
 	Side             api.SideType
 	Type             api.OrderType
-	TimeInForce      api.TimeInForceType
-	Quantity         string
-	Price            string
+	TimeInForce      api.TimeInForceType // Empty for orders without price
+	Quantity         string              // Empty for closePosition orders
+	Price            string              // Empty for market orders
 	NewClientOrderID string
 	NewOrderRespType api.NewOrderRespType
+
+	// Conditional orders only
+	StopPrice       string
+	WorkingType     api.WorkingType
+	PriceProtect    bool
+	CallbackRate    string
+	ActivationPrice string
+	ReduceOnly      bool
+	ClosePosition   bool
+	PositionSide    api.PositionSideType
 }
 
 func (of *orderFields) ToAPI(c *api.Client) *api.CreateOrderService {
-	return c.
+	s := c.
 		NewCreateOrderService().
 		Symbol(of.Symbol).
 		Side(of.Side).
 		Type(of.Type).
-		TimeInForce(of.TimeInForce).
-		Quantity(of.Quantity).
-		Price(of.Price).
 		NewClientOrderID(of.NewClientOrderID)
+	if of.TimeInForce != "" {
+		s.TimeInForce(of.TimeInForce)
+	}
+	if of.Quantity != "" {
+		s.Quantity(of.Quantity)
+	}
+	if of.Price != "" {
+		s.Price(of.Price)
+	}
+	if of.StopPrice != "" {
+		s.StopPrice(of.StopPrice)
+	}
+	if of.WorkingType != "" {
+		s.WorkingType(of.WorkingType)
+	}
+	if of.PriceProtect {
+		s.PriceProtect(true)
+	}
+	if of.CallbackRate != "" {
+		s.CallbackRate(of.CallbackRate)
+	}
+	if of.ActivationPrice != "" {
+		s.ActivationPrice(of.ActivationPrice)
+	}
+	if of.ReduceOnly {
+		s.ReduceOnly(true)
+	}
+	if of.ClosePosition {
+		s.ClosePosition(true)
+	}
+	if of.PositionSide != "" {
+		s.PositionSide(of.PositionSide)
+	}
+	return s
 }
 
 func (of *orderFields) equalStringNumber(x string, y string) bool {
@@ -51,13 +106,21 @@ func (of *orderFields) equalStringNumber(x string, y string) bool {
 
 func (of *orderFields) Equal(x *api.Order) bool {
 	eq := of.equalStringNumber
+	origType := x.Type
+	if x.OrigType != "" {
+		origType = api.OrderType(x.OrigType) // Type is changed after trigger
+	}
 	return (true &&
 		of.Symbol == x.Symbol &&
 		of.Side == x.Side &&
-		of.Type == x.Type &&
-		of.TimeInForce == x.TimeInForce &&
-		eq(of.Quantity, x.OrigQuantity) &&
-		eq(of.Price, x.Price) &&
+		of.Type == origType &&
+		(of.TimeInForce == "" || of.TimeInForce == x.TimeInForce) &&
+		(of.Quantity == "" || eq(of.Quantity, x.OrigQuantity)) &&
+		(of.Price == "" || eq(of.Price, x.Price)) &&
+		(of.StopPrice == "" || eq(of.StopPrice, x.StopPrice)) &&
+		(of.CallbackRate == "" || eq(of.CallbackRate, x.PriceRate)) &&
+		of.ReduceOnly == x.ReduceOnly &&
+		of.ClosePosition == x.ClosePosition &&
 		of.NewClientOrderID == x.ClientOrderID)
 	// of.NewOrderRespType can be ignored
 }
@@ -65,6 +128,7 @@ func (of *orderFields) Equal(x *api.Order) bool {
 type OrderPlacer struct {
 	orderGetter *OrderGetter
 	client      *api.Client
+	wsAPI       *wsapi.Client
 }
 
 func NewOrderPlacer(client *api.Client) *OrderPlacer {
@@ -126,7 +190,13 @@ func (op *OrderPlacer) PlaceOrder(ctx context.Context,
 
 func (op *OrderPlacer) PlaceOrderV2(ctx context.Context, symbol string, price, quantity *apd.Decimal, preferredID string,
 	side api.SideType, orderType api.OrderType) (id string, e error) {
-	orderReq, err := op.CreateOrderRequestV2(symbol, price, quantity, preferredID, side, orderType)
+	return op.PlaceConditionalOrder(ctx, symbol, price, quantity, preferredID, side, orderType, ConditionalOrderParams{})
+}
+
+// PlaceConditionalOrder places order of any type, `price` is used by LIMIT, STOP and TAKE_PROFIT only
+func (op *OrderPlacer) PlaceConditionalOrder(ctx context.Context, symbol string, price, quantity *apd.Decimal, preferredID string,
+	side api.SideType, orderType api.OrderType, params ConditionalOrderParams) (id string, e error) {
+	orderReq, err := op.CreateConditionalOrderRequest(symbol, price, quantity, preferredID, side, orderType, params)
 	if err != nil {
 		return "", errors.Wrapf(err, "can't create order req")
 	}
@@ -155,6 +225,13 @@ func (op *OrderPlacer) PlaceOrderV2(ctx context.Context, symbol string, price, q
 }
 
 func (op *OrderPlacer) tryToPlaceOrder(ctx context.Context, req *orderFields) (id string, e error) {
+	if op.wsAPI != nil {
+		id, fallback, err := op.placeOrderWS(ctx, req)
+		if !fallback {
+			return id, err
+		}
+	}
+
 	order, err := req.ToAPI(op.client).Do(ctx)
 	if err != nil {
 		err = op.castToOrderRejecterErrorIfCan(err)
@@ -206,15 +283,58 @@ func (op *OrderPlacer) isOrderRejectedError(err error) bool {
 }
 
 func (op *OrderPlacer) CreateOrderRequestV2(symbol string, price, quantity *apd.Decimal, preferredID string, side api.SideType, orderType api.OrderType) (*orderFields, error) {
+	return op.CreateConditionalOrderRequest(symbol, price, quantity, preferredID, side, orderType, ConditionalOrderParams{})
+}
+
+// CreateConditionalOrderRequest Don't forget to floor `price`, `quantity` and stop prices
+func (op *OrderPlacer) CreateConditionalOrderRequest(
+	symbol string, price, quantity *apd.Decimal, preferredID string,
+	side api.SideType, orderType api.OrderType, params ConditionalOrderParams,
+) (*orderFields, error) {
 	req := &orderFields{
 		Symbol:           symbol,
 		Side:             side,
 		Type:             orderType,
-		TimeInForce:      api.TimeInForceTypeGTC,
-		Quantity:         utils.ToFlatString(quantity),
-		Price:            utils.ToFlatString(price),
 		NewClientOrderID: preferredID,
 		NewOrderRespType: api.NewOrderRespTypeACK,
+		WorkingType:      params.WorkingType,
+		PriceProtect:     params.PriceProtect,
+		ReduceOnly:       params.ReduceOnly,
+		ClosePosition:    params.ClosePosition,
+		PositionSide:     params.PositionSide,
+	}
+
+	switch orderType {
+	case api.OrderTypeLimit, api.OrderTypeStop, api.OrderTypeTakeProfit:
+		req.TimeInForce = api.TimeInForceTypeGTC
+		req.Price = utils.ToFlatString(price)
+	}
+
+	switch orderType {
+	case api.OrderTypeStop, api.OrderTypeTakeProfit, api.OrderTypeStopMarket, api.OrderTypeTakeProfitMarket:
+		if params.StopPrice == nil {
+			return nil, errors.Errorf("stop price is required by %s order", orderType)
+		}
+		req.StopPrice = utils.ToFlatString(params.StopPrice)
+	case api.OrderTypeTrailingStopMarket:
+		if params.CallbackRate == nil {
+			return nil, errors.New("callback rate is required by trailing stop order")
+		}
+		req.CallbackRate = utils.ToFlatString(params.CallbackRate)
+		if params.ActivationPrice != nil {
+			req.ActivationPrice = utils.ToFlatString(params.ActivationPrice)
+		}
+	}
+
+	if params.ClosePosition {
+		if orderType != api.OrderTypeStopMarket && orderType != api.OrderTypeTakeProfitMarket {
+			return nil, errors.Errorf("close position isn't supported by %s order", orderType)
+		}
+		if params.ReduceOnly {
+			return nil, errors.New("reduce only can't be used with close position")
+		}
+	} else {
+		req.Quantity = utils.ToFlatString(quantity)
 	}
 	return req, nil
 }
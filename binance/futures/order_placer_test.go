@@ -0,0 +1,84 @@
+package futures
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	api "github.com/adshao/go-binance/v2/futures"
+	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/aulaleslie/trade-exchanges/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateConditionalOrderRequest(t *testing.T) {
+	op := &OrderPlacer{}
+
+	req, err := op.CreateConditionalOrderRequest("BTCUSDT", nil, nil, "id1", api.SideTypeSell, api.OrderTypeStopMarket,
+		ConditionalOrderParams{StopPrice: utils.FromString("29000.5"), ClosePosition: true, WorkingType: api.WorkingTypeMarkPrice})
+	require.NoError(t, err)
+	assert.Equal(t, "29000.5", req.StopPrice)
+	assert.Empty(t, req.Price)
+	assert.Empty(t, req.TimeInForce)
+	assert.Empty(t, req.Quantity)
+
+	req, err = op.CreateConditionalOrderRequest("BTCUSDT", nil, utils.FromString("0.01"), "id2", api.SideTypeSell, api.OrderTypeTrailingStopMarket,
+		ConditionalOrderParams{CallbackRate: utils.FromString("1.5"), ActivationPrice: utils.FromString("31000"), ReduceOnly: true})
+	require.NoError(t, err)
+	assert.Equal(t, "1.5", req.CallbackRate)
+	assert.Equal(t, "31000", req.ActivationPrice)
+	assert.Equal(t, "0.01", req.Quantity)
+
+	_, err = op.CreateConditionalOrderRequest("BTCUSDT", utils.FromString("30000"), utils.FromString("0.01"), "id3", api.SideTypeBuy, api.OrderTypeStop,
+		ConditionalOrderParams{})
+	assert.Error(t, err, "stop price is required")
+
+	_, err = op.CreateConditionalOrderRequest("BTCUSDT", utils.FromString("30000"), nil, "id4", api.SideTypeBuy, api.OrderTypeLimit,
+		ConditionalOrderParams{ClosePosition: true})
+	assert.Error(t, err, "close position of limit order")
+}
+
+func TestPlaceConditionalOrderSendsStopFields(t *testing.T) {
+	var form url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		form = r.PostForm
+		w.Write([]byte(`{"clientOrderId":"id1","status":"NEW"}`))
+	}))
+	defer server.Close()
+	client := api.NewClient("key", "secret")
+	client.BaseURL = server.URL
+
+	id, err := NewOrderPlacer(client).PlaceConditionalOrder(context.Background(), "BTCUSDT",
+		utils.FromString("31000"), utils.FromString("0.01"), "id1", api.SideTypeBuy, api.OrderTypeTakeProfit,
+		ConditionalOrderParams{StopPrice: utils.FromString("30500"), PriceProtect: true, ReduceOnly: true})
+	require.NoError(t, err)
+	assert.Equal(t, "id1", id)
+	assert.Equal(t, "TAKE_PROFIT", form.Get("type"))
+	assert.Equal(t, "31000", form.Get("price"))
+	assert.Equal(t, "GTC", form.Get("timeInForce"))
+	assert.Equal(t, "30500", form.Get("stopPrice"))
+	assert.Equal(t, "true", form.Get("priceProtect"))
+	assert.Equal(t, "true", form.Get("reduceOnly"))
+	assert.Empty(t, form.Get("closePosition"))
+}
+
+func TestMapConditionalOrderStatus(t *testing.T) {
+	assert.Equal(t, exchanges.NewOST, MapOrderStatusType("NEW", "STOP_MARKET", "STOP_MARKET"))
+	assert.Equal(t, exchanges.TriggeredOST, MapOrderStatusType("NEW", "MARKET", "STOP_MARKET"))
+	assert.Equal(t, exchanges.FilledOST, MapOrderStatusType("FILLED", "MARKET", "STOP_MARKET"))
+	assert.Equal(t, exchanges.NewOST, MapOrderStatusType("NEW_INSURANCE", "LIMIT", ""))
+	assert.Equal(t, exchanges.NewOST, MapOrderStatusType("NEW_ADL", "LIMIT", ""))
+
+	og := &OrderGetter{}
+	info, err := og.buildOrderDetailInfo(&api.Order{
+		Symbol: "BTCUSDT", Type: api.OrderTypeTrailingStopMarket, OrigType: "TRAILING_STOP_MARKET", Status: api.OrderStatusTypeNew,
+		Price: "0", OrigQuantity: "0.01", ExecutedQuantity: "0", StopPrice: "0", ActivatePrice: "31000", PriceRate: "1.5",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "31000", info.StopPrice.String())
+	assert.Equal(t, exchanges.TRAILING_STOP_MARKET, *info.OrderType)
+}
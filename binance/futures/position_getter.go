@@ -2,11 +2,11 @@ package futures
 
 import (
 	"context"
-	"fmt"
 
 	api "github.com/adshao/go-binance/v2/futures"
 	exchanges "github.com/aulaleslie/trade-exchanges"
 	"github.com/cockroachdb/apd"
+	"github.com/pkg/errors"
 )
 
 type PositionGetter struct {
@@ -19,45 +19,111 @@ func NewPositionGetter(client *api.Client) *PositionGetter {
 	}
 }
 
-func (pg *PositionGetter) getBinanceAccount(ctx context.Context) (*api.Account, error) {
-	res, err := pg.client.NewGetAccountService().Do(ctx)
+// GetAccountPosition returns wallet balances and open positions, symbols are prefixed with `prefix`
+func (pg *PositionGetter) GetAccountPosition(ctx context.Context, prefix string) (exchanges.Account, error) {
+	balances, err := pg.GetBalances(ctx)
 	if err != nil {
-		fmt.Println(err)
-		return nil, err
+		return exchanges.Account{}, err
 	}
-	return res, nil
+	positions, err := pg.GetPositions(ctx, prefix, "", false)
+	if err != nil {
+		return exchanges.Account{}, err
+	}
+	return exchanges.Account{AccountBalances: balances, AccountPositions: positions}, nil
 }
 
-func (pg *PositionGetter) GetAccountPosition(ctx context.Context) (res exchanges.Account, err error) {
-	account, err := pg.getBinanceAccount(ctx)
+// GetBalances returns non-zero balances of the futures wallet.
+// Locked is the initial margin of positions and open orders, Free is available for new ones:
+// Free + Locked differs from the wallet balance by unrealized PnL.
+func (pg *PositionGetter) GetBalances(ctx context.Context) ([]exchanges.AccountBalance, error) {
+	account, err := pg.client.NewGetAccountService().Do(ctx)
 	if err != nil {
-		return
+		return nil, errors.Wrap(err, "can't get account")
 	}
 
-	accountPositions := make([]exchanges.AccountPosition, 0)
-	for _, position := range account.Positions {
-		unrealizedProfit, _, err := apd.NewFromString(position.UnrealizedProfit)
+	res := []exchanges.AccountBalance{}
+	for _, asset := range account.Assets {
+		wallet, _, err := apd.NewFromString(asset.WalletBalance)
 		if err != nil {
-			return res, err
+			return nil, errors.Wrapf(err, "invalid wallet balance of %s", asset.Asset)
 		}
-
-		leverage, _, err := apd.NewFromString(position.Leverage)
+		if wallet.IsZero() {
+			continue
+		}
+		available, _, err := apd.NewFromString(asset.AvailableBalance)
 		if err != nil {
-			return res, err
+			return nil, errors.Wrapf(err, "invalid available balance of %s", asset.Asset)
 		}
-
-		accountPosition := exchanges.AccountPosition{
-			Symbol:           position.Symbol,
-			UnrealizedProfit: unrealizedProfit,
-			Leverage:         leverage,
-			EntryPrice:       nil,
-			Size:             nil,
+		crossUnPnl, _, err := apd.NewFromString(asset.CrossUnPnl)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid cross unrealized PnL of %s", asset.Asset)
+		}
+		locked, _, err := apd.NewFromString(asset.InitialMargin)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid initial margin of %s", asset.Asset)
 		}
 
-		accountPositions = append(accountPositions, accountPosition)
+		res = append(res, exchanges.AccountBalance{
+			Coin:               asset.Asset,
+			Free:               available,
+			Locked:             locked,
+			WalletBalance:      wallet,
+			CrossUnrealizedPnL: crossUnPnl,
+		})
+	}
+	return res, nil
+}
+
+// GetPositions returns positions from positionRisk, all symbols if `symbol` is empty.
+// Zero-size positions are skipped unless `includeEmpty` is set.
+func (pg *PositionGetter) GetPositions(
+	ctx context.Context, prefix, symbol string, includeEmpty bool,
+) ([]exchanges.AccountPosition, error) {
+	service := pg.client.NewGetPositionRiskService()
+	if symbol != "" {
+		service.Symbol(symbol)
+	}
+	risks, err := service.Do(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't get position risk")
 	}
 
-	res.AccountPositions = accountPositions
+	res := []exchanges.AccountPosition{}
+	for _, risk := range risks {
+		size, _, err := apd.NewFromString(risk.PositionAmt)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid position amount of %s", risk.Symbol)
+		}
+		if size.IsZero() && !includeEmpty {
+			continue
+		}
 
-	return
+		position := exchanges.AccountPosition{
+			Symbol:     exchanges.FullSymbol(prefix, risk.Symbol),
+			Size:       size,
+			Side:       risk.PositionSide,
+			MarginType: risk.MarginType,
+		}
+		for _, field := range []struct {
+			dst  **apd.Decimal
+			src  string
+			name string
+		}{
+			{&position.EntryPrice, risk.EntryPrice, "entry price"},
+			{&position.MarkPrice, risk.MarkPrice, "mark price"},
+			{&position.LiqPrice, risk.LiquidationPrice, "liquidation price"},
+			{&position.Leverage, risk.Leverage, "leverage"},
+			{&position.UnrealizedProfit, risk.UnRealizedProfit, "unrealized profit"},
+			{&position.IsolatedMargin, risk.IsolatedMargin, "isolated margin"},
+			{&position.PositionValue, risk.Notional, "notional"},
+		} {
+			value, _, err := apd.NewFromString(field.src)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid %s of %s", field.name, risk.Symbol)
+			}
+			*field.dst = value
+		}
+		res = append(res, position)
+	}
+	return res, nil
 }
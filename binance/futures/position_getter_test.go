@@ -0,0 +1,89 @@
+package futures
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	api "github.com/adshao/go-binance/v2/futures"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPositionRisk = `[
+	{"symbol":"BTCUSDT","positionAmt":"-0.010","entryPrice":"30000.0","markPrice":"29500.5","unRealizedProfit":"5.0",
+	 "liquidationPrice":"45000.1","leverage":"10","marginType":"isolated","isolatedMargin":"30.5","notional":"-295.005",
+	 "positionSide":"BOTH","maxNotionalValue":"250000","isAutoAddMargin":"false","isolatedWallet":"25.5"},
+	{"symbol":"ETHUSDT","positionAmt":"0.000","entryPrice":"0.0","markPrice":"2000","unRealizedProfit":"0",
+	 "liquidationPrice":"0","leverage":"20","marginType":"cross","isolatedMargin":"0","notional":"0",
+	 "positionSide":"BOTH","maxNotionalValue":"250000","isAutoAddMargin":"false","isolatedWallet":"0"}
+]`
+
+const testAccount = `{"assets":[
+	{"asset":"USDT","walletBalance":"100.5","availableBalance":"69.3","crossUnPnl":"-1.2","unrealizedProfit":"-1.2","initialMargin":"30.0"},
+	{"asset":"BUSD","walletBalance":"10","availableBalance":"12","crossUnPnl":"2","unrealizedProfit":"2","initialMargin":"0"},
+	{"asset":"BNB","walletBalance":"0","availableBalance":"0","crossUnPnl":"0","unrealizedProfit":"0"}
+],"positions":[]}`
+
+func newTestPositionGetter(t *testing.T) *PositionGetter {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fapi/v2/positionRisk":
+			w.Write([]byte(testPositionRisk))
+		case "/fapi/v2/account":
+			w.Write([]byte(testAccount))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := api.NewClient("key", "secret")
+	client.BaseURL = server.URL
+	return NewPositionGetter(client)
+}
+
+func TestGetPositionsSkipsEmpty(t *testing.T) {
+	pg := newTestPositionGetter(t)
+
+	positions, err := pg.GetPositions(context.Background(), "BINANCEFUTURES-", "", false)
+	require.NoError(t, err)
+	require.Len(t, positions, 1)
+	p := positions[0]
+	assert.Equal(t, "BINANCEFUTURES-BTCUSDT", p.Symbol)
+	assert.Equal(t, "-0.010", p.Size.String())
+	assert.Equal(t, "30000.0", p.EntryPrice.String())
+	assert.Equal(t, "29500.5", p.MarkPrice.String())
+	assert.Equal(t, "45000.1", p.LiqPrice.String())
+	assert.Equal(t, "10", p.Leverage.String())
+	assert.Equal(t, "isolated", p.MarginType)
+	assert.Equal(t, "30.5", p.IsolatedMargin.String())
+	assert.Equal(t, "-295.005", p.PositionValue.String())
+	assert.Equal(t, "BOTH", p.Side)
+
+	positions, err = pg.GetPositions(context.Background(), "BINANCEFUTURES-", "", true)
+	require.NoError(t, err)
+	assert.Len(t, positions, 2)
+}
+
+func TestGetAccountPositionBalances(t *testing.T) {
+	pg := newTestPositionGetter(t)
+
+	account, err := pg.GetAccountPosition(context.Background(), "BINANCEFUTURES-")
+	require.NoError(t, err)
+	require.Len(t, account.AccountBalances, 2)
+	b := account.AccountBalances[0]
+	assert.Equal(t, "USDT", b.Coin)
+	assert.Equal(t, "69.3", b.Free.String())
+	assert.Equal(t, "30.0", b.Locked.String())
+	assert.Equal(t, "100.5", b.WalletBalance.String())
+	assert.Equal(t, "-1.2", b.CrossUnrealizedPnL.String())
+	assert.Len(t, account.AccountPositions, 1)
+
+	// Positive PnL is available, but nothing is locked
+	b = account.AccountBalances[1]
+	assert.Equal(t, "BUSD", b.Coin)
+	assert.Equal(t, "12", b.Free.String())
+	assert.Equal(t, "0", b.Locked.String())
+}
@@ -0,0 +1,286 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	api "github.com/adshao/go-binance/v2"
+	"github.com/adshao/go-binance/v2/common"
+	"github.com/adshao/go-binance/v2/delivery"
+	"github.com/adshao/go-binance/v2/futures"
+	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const listenKeyExpiredEventType string = "listenKeyExpired"
+
+// ErrListenKeyExpired is sent as disconnection reason when Binance expires the listen key of the stream
+var ErrListenKeyExpired = errors.New("listen key expired")
+
+// ListenKeyService is userDataStream endpoints of one account
+type ListenKeyService interface {
+	Start(ctx context.Context) (string, error)
+	Keepalive(ctx context.Context, listenKey string) error
+	Close(ctx context.Context, listenKey string) error
+}
+
+type spotListenKeys struct{ client *api.Client }
+
+func (s spotListenKeys) Start(ctx context.Context) (string, error) {
+	return s.client.NewStartUserStreamService().Do(ctx)
+}
+
+func (s spotListenKeys) Keepalive(ctx context.Context, listenKey string) error {
+	return s.client.NewKeepaliveUserStreamService().ListenKey(listenKey).Do(ctx)
+}
+
+func (s spotListenKeys) Close(ctx context.Context, listenKey string) error {
+	return s.client.NewCloseUserStreamService().ListenKey(listenKey).Do(ctx)
+}
+
+type futuresListenKeys struct{ client *futures.Client }
+
+func (s futuresListenKeys) Start(ctx context.Context) (string, error) {
+	return s.client.NewStartUserStreamService().Do(ctx)
+}
+
+func (s futuresListenKeys) Keepalive(ctx context.Context, listenKey string) error {
+	return s.client.NewKeepaliveUserStreamService().ListenKey(listenKey).Do(ctx)
+}
+
+func (s futuresListenKeys) Close(ctx context.Context, listenKey string) error {
+	return s.client.NewCloseUserStreamService().ListenKey(listenKey).Do(ctx)
+}
+
+type deliveryListenKeys struct{ client *delivery.Client }
+
+func (s deliveryListenKeys) Start(ctx context.Context) (string, error) {
+	return s.client.NewStartUserStreamService().Do(ctx)
+}
+
+func (s deliveryListenKeys) Keepalive(ctx context.Context, listenKey string) error {
+	return s.client.NewKeepaliveUserStreamService().ListenKey(listenKey).Do(ctx)
+}
+
+func (s deliveryListenKeys) Close(ctx context.Context, listenKey string) error {
+	return s.client.NewCloseUserStreamService().ListenKey(listenKey).Do(ctx)
+}
+
+type marginListenKeys struct{ client *api.Client }
+
+func (s marginListenKeys) Start(ctx context.Context) (string, error) {
+	return s.client.NewStartMarginUserStreamService().Do(ctx)
+}
+
+func (s marginListenKeys) Keepalive(ctx context.Context, listenKey string) error {
+	return s.client.NewKeepaliveMarginUserStreamService().ListenKey(listenKey).Do(ctx)
+}
+
+func (s marginListenKeys) Close(ctx context.Context, listenKey string) error {
+	return s.client.NewCloseMarginUserStreamService().ListenKey(listenKey).Do(ctx)
+}
+
+type isolatedMarginListenKeys struct {
+	client *api.Client
+	symbol string
+}
+
+func (s isolatedMarginListenKeys) Start(ctx context.Context) (string, error) {
+	return s.client.NewStartIsolatedMarginUserStreamService().Symbol(s.symbol).Do(ctx)
+}
+
+func (s isolatedMarginListenKeys) Keepalive(ctx context.Context, listenKey string) error {
+	return s.client.NewKeepaliveIsolatedMarginUserStreamService().Symbol(s.symbol).ListenKey(listenKey).Do(ctx)
+}
+
+func (s isolatedMarginListenKeys) Close(ctx context.Context, listenKey string) error {
+	return s.client.NewCloseIsolatedMarginUserStreamService().Symbol(s.symbol).ListenKey(listenKey).Do(ctx)
+}
+
+// ListenKeyManager shares one listen key between all user data streams of the account.
+// The key is kept alive while any stream holds it and closed after the last one is done.
+type ListenKeyManager struct {
+	KeepaliveInterval time.Duration // optional, Binance expires keys after 60 minutes without keepalive
+	Timeout           time.Duration // optional, for keepalive and close requests
+
+	service ListenKeyService
+	lg      *zap.Logger
+
+	mu            sync.Mutex
+	key           string
+	holders       int
+	stopKeepalive context.CancelFunc
+}
+
+func NewListenKeyManager(service ListenKeyService, lg *zap.Logger) *ListenKeyManager {
+	return &ListenKeyManager{
+		KeepaliveInterval: 20 * time.Minute, // TODO: move to config
+		Timeout:           10 * time.Second,
+		service:           service,
+		lg:                lg.Named("ListenKey"),
+	}
+}
+
+// Acquire returns the listen key which is held until ctx is done
+func (m *ListenKeyManager) Acquire(ctx context.Context) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.key == "" {
+		key, err := m.service.Start(ctx)
+		if err != nil {
+			return "", errors.Wrap(err, "can't take listen key")
+		}
+		m.setKey(key)
+	}
+	m.holders++
+	key := m.key
+
+	go func() {
+		<-ctx.Done()
+		m.release()
+	}()
+	return key, nil
+}
+
+func (m *ListenKeyManager) release() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.holders--
+	if m.holders > 0 || m.key == "" {
+		return
+	}
+
+	key := m.key
+	m.resetKey()
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), m.Timeout)
+		defer cancel()
+		if err := m.service.Close(ctx, key); err != nil {
+			m.lg.Warn("Can't close listen key", zap.Error(err))
+		}
+	}()
+}
+
+// renew re-issues the key after Binance expired `expired` one. The holders must reconnect.
+func (m *ListenKeyManager) renew(expired string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.key != expired {
+		return // Already renewed or released
+	}
+	m.resetKey()
+	if m.holders == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.Timeout)
+	defer cancel()
+	key, err := m.service.Start(ctx)
+	if err != nil {
+		// The next Acquire takes a new key
+		m.lg.Warn("Can't re-issue expired listen key", zap.Error(err))
+		return
+	}
+	m.setKey(key)
+}
+
+// setKey must be called under the lock
+func (m *ListenKeyManager) setKey(key string) {
+	keepaliveCtx, cancel := context.WithCancel(context.Background())
+	m.key = key
+	m.stopKeepalive = cancel
+	go m.keepalive(keepaliveCtx, key)
+}
+
+// resetKey must be called under the lock
+func (m *ListenKeyManager) resetKey() {
+	m.stopKeepalive()
+	m.stopKeepalive = nil
+	m.key = ""
+}
+
+func (m *ListenKeyManager) keepalive(ctx context.Context, key string) {
+	ticker := time.NewTicker(m.KeepaliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		reqCtx, cancel := context.WithTimeout(ctx, m.Timeout)
+		err := m.service.Keepalive(reqCtx, key)
+		cancel()
+		if isListenKeyNotExistError(err) {
+			m.lg.Warn("Listen key doesn't exist anymore, re-issue it", zap.Error(err))
+			go m.renew(key) // renew stops this loop
+			return
+		}
+		if err != nil {
+			m.lg.Warn("Can't keep alive listen key, wait for the next tick", zap.Error(err))
+		}
+	}
+}
+
+// watchOrders holds the listen key while the stream made by `subscribe` is alive.
+// Expired key is re-issued before the disconnection is forwarded.
+func (m *ListenKeyManager) watchOrders(
+	ctx context.Context,
+	subscribe func(ctx context.Context, listenKey string) (<-chan exchanges.OrderEvent, error),
+) (<-chan exchanges.OrderEvent, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	key, err := m.Acquire(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	in, err := subscribe(ctx, key)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	out := make(chan exchanges.OrderEvent, 100) // TODO: move to config
+	go func() {
+		defer cancel()
+		defer close(out)
+		for event := range in {
+			if errors.Is(event.DisconnectedWithErr, ErrListenKeyExpired) {
+				m.renew(key)
+			}
+			select {
+			case out <- event:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return out, nil
+}
+
+func isListenKeyNotExistError(err error) bool {
+	apiErr, ok := errors.Cause(err).(*common.APIError)
+	if !ok {
+		return false
+	}
+	// https://binance-docs.github.io/apidocs/spot/en/#error-codes
+	return apiErr.Code == -1125
+}
+
+// listenKeyExpiredError returns ErrListenKeyExpired for listenKeyExpired event of user data stream
+func listenKeyExpiredError(message []byte) error {
+	data := userDataStreamCommonMessage{}
+	if err := json.Unmarshal(message, &data); err != nil {
+		return nil // Reported by event type check
+	}
+	if data.EventType != listenKeyExpiredEventType {
+		return nil
+	}
+	return errors.Wrap(ErrListenKeyExpired, "user data stream is closed")
+}
@@ -0,0 +1,141 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adshao/go-binance/v2/common"
+	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeListenKeys struct {
+	mu         sync.Mutex
+	started    int
+	keepalives map[string]int
+	closed     []string
+	expired    map[string]bool
+}
+
+func newFakeListenKeys() *fakeListenKeys {
+	return &fakeListenKeys{keepalives: map[string]int{}, expired: map[string]bool{}}
+}
+
+func (f *fakeListenKeys) Start(ctx context.Context) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.started++
+	return fmt.Sprintf("key%d", f.started), nil
+}
+
+func (f *fakeListenKeys) Keepalive(ctx context.Context, listenKey string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.expired[listenKey] {
+		return &common.APIError{Code: -1125, Message: "This listenKey does not exist."}
+	}
+	f.keepalives[listenKey]++
+	return nil
+}
+
+func (f *fakeListenKeys) Close(ctx context.Context, listenKey string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = append(f.closed, listenKey)
+	return nil
+}
+
+func (f *fakeListenKeys) state() (started int, keepalives map[string]int, closed []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	keepalives = map[string]int{}
+	for k, v := range f.keepalives {
+		keepalives[k] = v
+	}
+	return f.started, keepalives, append([]string{}, f.closed...)
+}
+
+func TestListenKeyManagerSharesKeyAndClosesIt(t *testing.T) {
+	service := newFakeListenKeys()
+	m := NewListenKeyManager(service, zap.NewNop())
+	m.KeepaliveInterval = 10 * time.Millisecond
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	key1, err := m.Acquire(ctx1)
+	require.NoError(t, err)
+	key2, err := m.Acquire(ctx2)
+	require.NoError(t, err)
+	require.Equal(t, key1, key2)
+
+	require.Eventually(t, func() bool {
+		_, keepalives, _ := service.state()
+		return keepalives[key1] > 0
+	}, time.Second, 5*time.Millisecond)
+
+	cancel1()
+	time.Sleep(30 * time.Millisecond)
+	_, _, closed := service.state()
+	require.Empty(t, closed)
+
+	cancel2()
+	require.Eventually(t, func() bool {
+		_, _, closed := service.state()
+		return len(closed) == 1 && closed[0] == key1
+	}, time.Second, 5*time.Millisecond)
+
+	// Keepalive is stopped after close
+	_, keepalives, _ := service.state()
+	time.Sleep(30 * time.Millisecond)
+	_, keepalivesAfter, _ := service.state()
+	require.Equal(t, keepalives[key1], keepalivesAfter[key1])
+}
+
+func TestListenKeyManagerRenewsExpiredKey(t *testing.T) {
+	service := newFakeListenKeys()
+	m := NewListenKeyManager(service, zap.NewNop())
+	m.KeepaliveInterval = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make(chan exchanges.OrderEvent, 1)
+	events <- exchanges.OrderEvent{DisconnectedWithErr: listenKeyExpiredError([]byte(`{"e":"listenKeyExpired","E":1576653824250}`))}
+	close(events)
+
+	out, err := m.watchOrders(ctx, func(ctx context.Context, listenKey string) (<-chan exchanges.OrderEvent, error) {
+		require.Equal(t, "key1", listenKey)
+		return events, nil
+	})
+	require.NoError(t, err)
+
+	event := <-out
+	require.ErrorIs(t, event.DisconnectedWithErr, ErrListenKeyExpired)
+
+	// Re-issued before the disconnection is forwarded
+	started, _, _ := service.state()
+	require.Equal(t, 2, started)
+}
+
+func TestListenKeyManagerRenewsKeyRejectedByKeepalive(t *testing.T) {
+	service := newFakeListenKeys()
+	service.expired["key1"] = true
+	m := NewListenKeyManager(service, zap.NewNop())
+	m.KeepaliveInterval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	key, err := m.Acquire(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "key1", key)
+
+	require.Eventually(t, func() bool {
+		_, keepalives, _ := service.state()
+		return keepalives["key2"] > 0
+	}, time.Second, 5*time.Millisecond)
+}
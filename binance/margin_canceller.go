@@ -0,0 +1,72 @@
+package binance
+
+import (
+	"context"
+
+	api "github.com/adshao/go-binance/v2"
+	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/aulaleslie/trade-exchanges/utils"
+	"github.com/pkg/errors"
+)
+
+type MarginOrderCanceller struct {
+	client      *api.Client
+	orderGetter *MarginOrderGetter
+	isolated    bool
+}
+
+func NewMarginOrderCanceller(client *api.Client, isolated bool) *MarginOrderCanceller {
+	return &MarginOrderCanceller{
+		client:      client,
+		orderGetter: NewMarginOrderGetter(client, isolated),
+		isolated:    isolated,
+	}
+}
+
+func (b *MarginOrderCanceller) CancelOrder(ctx context.Context, symbol, clientOrderID string) error {
+	err := b.tryToCancel(ctx, symbol, clientOrderID)
+	switch {
+	case err == nil:
+		return nil
+	case !errors.Is(err, exchanges.OrderNotFoundError):
+		return err
+	}
+
+	status, err := b.orderGetter.GetOrderBinanceStatus(ctx, symbol, clientOrderID)
+	if err != nil {
+		return err
+	}
+
+	switch status {
+	case api.OrderStatusTypeNew, api.OrderStatusTypePartiallyFilled:
+		return errors.Errorf("Can't cancel order + order nave status = %v on Binance", status)
+	case api.OrderStatusTypeFilled:
+		return exchanges.OrderExecutedError
+	case api.OrderStatusTypeCanceled, api.OrderStatusTypeRejected, api.OrderStatusTypeExpired:
+		return nil
+	case api.OrderStatusTypePendingCancel:
+		return errors.New("Order have PENDING_CANCEL status.")
+	default:
+		return errors.Errorf("Can't cancel order + order nave unknown status = %v on Binance", status)
+	}
+}
+
+func (b *MarginOrderCanceller) tryToCancel(ctx context.Context, symbol, clientOrderID string) error {
+	result, err := b.client.NewCancelMarginOrderService().
+		Symbol(symbol).
+		IsIsolated(b.isolated).
+		OrigClientOrderID(clientOrderID).
+		Do(ctx)
+	if (&BinanceOrderCanceller{}).isNotFoundDuringCancellation(err) {
+		return utils.ReplaceError(exchanges.OrderNotFoundError, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	if result.Status != api.OrderStatusTypeCanceled {
+		return errors.Errorf("Bad cancellation result status: %v", result.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,197 @@
+package binance
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	api "github.com/adshao/go-binance/v2"
+	"github.com/adshao/go-binance/v2/common"
+	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/aulaleslie/trade-exchanges/utils"
+	"github.com/cockroachdb/apd"
+	"github.com/pkg/errors"
+)
+
+// MarginInterest is accrued interest of margin loan
+type MarginInterest struct {
+	Asset          string
+	IsolatedSymbol string // Full symbol, isolated margin only
+	Principal      *apd.Decimal
+	Interest       *apd.Decimal
+	InterestRate   *apd.Decimal
+	Type           string // ON_BORROW, PERIODIC, PERIODIC_CONVERTED or ON_BORROW_CONVERTED
+	Time           time.Time
+}
+
+type marginInterestRow struct {
+	Asset               string `json:"asset"`
+	IsolatedSymbol      string `json:"isolatedSymbol"`
+	Principal           string `json:"principal"`
+	Interest            string `json:"interest"`
+	InterestRate        string `json:"interestRate"`
+	Type                string `json:"type"`
+	InterestAccuredTime int64  `json:"interestAccuredTime"`
+}
+
+type marginInterestHistory struct {
+	Rows  []marginInterestRow `json:"rows"`
+	Total int64               `json:"total"`
+}
+
+// MarginLoans borrows and repays assets of cross or isolated margin account.
+// `symbol` is the isolated pair and is ignored by cross margin.
+type MarginLoans struct {
+	client   *api.Client
+	isolated bool
+}
+
+func NewMarginLoans(client *api.Client, isolated bool) *MarginLoans {
+	return &MarginLoans{
+		client:   client,
+		isolated: isolated,
+	}
+}
+
+// Borrow returns transaction ID of the loan
+func (ml *MarginLoans) Borrow(ctx context.Context, symbol, asset string, amount *apd.Decimal) (int64, error) {
+	s := ml.client.NewMarginLoanService().Asset(asset).Amount(utils.ToFlatString(amount))
+	if ml.isolated {
+		s.IsIsolated(true).Symbol(symbol)
+	}
+	res, err := s.Do(ctx)
+	if err != nil {
+		return 0, errors.Wrapf(err, "can't borrow %s", asset)
+	}
+	return res.TranID, nil
+}
+
+// Repay returns transaction ID of the repayment
+func (ml *MarginLoans) Repay(ctx context.Context, symbol, asset string, amount *apd.Decimal) (int64, error) {
+	s := ml.client.NewMarginRepayService().Asset(asset).Amount(utils.ToFlatString(amount))
+	if ml.isolated {
+		s.IsIsolated(true).Symbol(symbol)
+	}
+	res, err := s.Do(ctx)
+	if err != nil {
+		return 0, errors.Wrapf(err, "can't repay %s", asset)
+	}
+	return res.TranID, nil
+}
+
+func (ml *MarginLoans) GetMaxBorrowable(ctx context.Context, symbol, asset string) (*apd.Decimal, error) {
+	s := ml.client.NewGetMaxBorrowableService().Asset(asset)
+	if ml.isolated {
+		s.IsolatedSymbol(symbol)
+	}
+	res, err := s.Do(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't get max borrowable %s", asset)
+	}
+	return utils.FromStringErr(res.Amount)
+}
+
+// GetInterestHistory returns interest accrued since `startTime`, newest first.
+// Empty `asset` means all assets.
+func (ml *MarginLoans) GetInterestHistory(
+	ctx context.Context, prefix, symbol, asset string, startTime time.Time,
+) ([]MarginInterest, error) {
+	params := url.Values{}
+	if asset != "" {
+		params.Set("asset", asset)
+	}
+	if ml.isolated && symbol != "" {
+		params.Set("isolatedSymbol", symbol)
+	}
+	params.Set("startTime", strconv.FormatInt(startTime.UnixMilli(), 10))
+	params.Set("size", "100") // Maximum of Binance
+
+	res := []MarginInterest{}
+	for current := 1; ; current++ {
+		params.Set("current", strconv.Itoa(current))
+		page := marginInterestHistory{}
+		if err := signedGet(ctx, ml.client, "/sapi/v1/margin/interestHistory", params, &page); err != nil {
+			return nil, errors.Wrap(err, "can't get interest history")
+		}
+		for _, row := range page.Rows {
+			interest, err := mapMarginInterest(prefix, row)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, interest)
+		}
+		if len(page.Rows) < 100 || int64(len(res)) >= page.Total {
+			return res, nil
+		}
+	}
+}
+
+func mapMarginInterest(prefix string, row marginInterestRow) (MarginInterest, error) {
+	res := MarginInterest{
+		Asset: row.Asset,
+		Type:  row.Type,
+		Time:  time.UnixMilli(row.InterestAccuredTime),
+	}
+	if row.IsolatedSymbol != "" {
+		res.IsolatedSymbol = exchanges.FullSymbol(prefix, row.IsolatedSymbol)
+	}
+	var err error
+	if res.Principal, err = utils.FromStringErr(row.Principal); err != nil {
+		return res, errors.Wrap(err, "invalid principal")
+	}
+	if res.Interest, err = utils.FromStringErr(row.Interest); err != nil {
+		return res, errors.Wrap(err, "invalid interest")
+	}
+	if res.InterestRate, err = utils.FromStringErr(row.InterestRate); err != nil {
+		return res, errors.Wrap(err, "invalid interest rate")
+	}
+	return res, nil
+}
+
+// signedGet calls signed endpoint which isn't supported by go-binance
+func signedGet(ctx context.Context, c *api.Client, endpoint string, params url.Values, out interface{}) error {
+	query := url.Values{}
+	for k, v := range params {
+		query[k] = v
+	}
+	query.Set("timestamp", strconv.FormatInt(time.Now().UnixMilli()-c.TimeOffset, 10))
+	mac := hmac.New(sha256.New, []byte(c.SecretKey))
+	mac.Write([]byte(query.Encode()))
+	fullURL := c.BaseURL + endpoint + "?" + query.Encode() + "&signature=" + hex.EncodeToString(mac.Sum(nil))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+	if err != nil {
+		return errors.Wrap(err, "can't create request")
+	}
+	req.Header.Set("X-MBX-APIKEY", c.APIKey)
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "can't do request")
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "can't read response")
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		apiErr := &common.APIError{}
+		if err := json.Unmarshal(data, apiErr); err != nil {
+			return errors.Errorf("unexpected status %d: %s", resp.StatusCode, data)
+		}
+		return apiErr
+	}
+	return errors.Wrap(json.Unmarshal(data, out), "can't unmarshal response")
+}
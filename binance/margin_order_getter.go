@@ -0,0 +1,157 @@
+package binance
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	api "github.com/adshao/go-binance/v2"
+	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/aulaleslie/trade-exchanges/utils"
+	"github.com/pkg/errors"
+)
+
+// MarginOrderGetter reads orders of cross or isolated margin account
+type MarginOrderGetter struct {
+	client   *api.Client
+	isolated bool
+}
+
+func NewMarginOrderGetter(client *api.Client, isolated bool) *MarginOrderGetter {
+	return &MarginOrderGetter{
+		client:   client,
+		isolated: isolated,
+	}
+}
+
+func (og *MarginOrderGetter) GetOrderInfoByClientOrderID(ctx context.Context, symbol, clientOrderID string) (exchanges.OrderInfo, error) {
+	orderInfo := exchanges.OrderInfo{}
+
+	data, err := og.GetBinanceOrder(ctx, symbol, clientOrderID)
+	if err != nil {
+		return orderInfo, errors.Wrap(err, "can't query order")
+	}
+
+	orderInfo.ClientOrderID = &data.ClientOrderID
+	orderInfo.ID = data.ClientOrderID
+	orderInfo.Status = mapOrderStatusType(string(data.Status))
+	return orderInfo, nil
+}
+
+func (og *MarginOrderGetter) GetOrderBinanceStatus(ctx context.Context, symbol, clientOrderID string) (api.OrderStatusType, error) {
+	order, err := og.GetBinanceOrder(ctx, symbol, clientOrderID)
+	if err != nil {
+		return "", err
+	}
+
+	return order.Status, nil
+}
+
+func (og *MarginOrderGetter) GetBinanceOrder(ctx context.Context, symbol, clientOrderID string) (*api.Order, error) {
+	order, err := og.client.NewGetMarginOrderService().
+		Symbol(symbol).
+		IsIsolated(og.isolated).
+		OrigClientOrderID(clientOrderID).
+		Do(ctx)
+	if err != nil {
+		if (&OrderGetter{}).isNotFoundDuringGetOrderStatus(err) {
+			return nil, utils.ReplaceError(exchanges.OrderNotFoundError, err)
+		}
+		return nil, err
+	}
+
+	return order, nil
+}
+
+func (og *MarginOrderGetter) buildOrderDetailInfo(prefix string, order *api.Order) (exchanges.OrderDetailInfo, error) {
+	res, err := (&OrderGetter{}).buildOrderDetailInfo(order)
+	if err != nil {
+		return res, err
+	}
+	res.Symbol = exchanges.FullSymbol(prefix, order.Symbol)
+	return res, nil
+}
+
+// GetOpenOrders returns open orders of the symbols, all symbols if it's empty.
+// Isolated margin requires the symbols.
+func (og *MarginOrderGetter) GetOpenOrders(ctx context.Context, prefix string, symbols ...string) ([]exchanges.OrderDetailInfo, error) {
+	if og.isolated && len(symbols) == 0 {
+		return nil, errors.New("symbols are required to list isolated margin orders")
+	}
+	if len(symbols) == 0 {
+		symbols = []string{""}
+	}
+
+	res := []exchanges.OrderDetailInfo{}
+	for _, symbol := range symbols {
+		service := og.client.NewListMarginOpenOrdersService().IsIsolated(og.isolated)
+		if symbol != "" {
+			service.Symbol(symbol)
+		}
+		orders, err := service.Do(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "error get binance margin open orders")
+		}
+		for _, order := range orders {
+			info, err := og.buildOrderDetailInfo(prefix, order)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, info)
+		}
+	}
+	return res, nil
+}
+
+func (og *MarginOrderGetter) GetHistoryOrders(
+	ctx context.Context,
+	prefix string,
+	symbol string,
+	orderID *string,
+	clientOrderID *string,
+	since *time.Time,
+) ([]exchanges.OrderDetailInfo, error) {
+	// Need to split because on listOrdersService doesn't support filter by clientOrderID
+	if orderID != nil || clientOrderID != nil {
+		orderService := og.client.NewGetMarginOrderService().Symbol(symbol).IsIsolated(og.isolated)
+		if orderID != nil {
+			id, err := strconv.ParseInt(*orderID, 10, 64)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid order ID %s", *orderID)
+			}
+			orderService.OrderID(id)
+		}
+		if clientOrderID != nil {
+			orderService.OrigClientOrderID(*clientOrderID)
+		}
+
+		order, err := orderService.Do(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "can't get margin order")
+		}
+		info, err := og.buildOrderDetailInfo(prefix, order)
+		if err != nil {
+			return nil, err
+		}
+		return []exchanges.OrderDetailInfo{info}, nil
+	}
+
+	listService := og.client.NewListMarginOrdersService().Symbol(symbol).IsIsolated(og.isolated)
+	if since != nil {
+		listService.StartTime(since.UnixMilli())
+	}
+	orders, err := listService.Do(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't list margin orders")
+	}
+
+	res := []exchanges.OrderDetailInfo{}
+	for _, order := range orders {
+		info, err := og.buildOrderDetailInfo(prefix, order)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, info)
+	}
+	return res, nil
+}
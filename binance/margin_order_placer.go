@@ -0,0 +1,127 @@
+package binance
+
+import (
+	"context"
+
+	api "github.com/adshao/go-binance/v2"
+	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/aulaleslie/trade-exchanges/utils"
+	"github.com/cockroachdb/apd"
+	"github.com/pkg/errors"
+)
+
+// SideEffectTypeAutoBorrowRepay borrows on open and repays on close of the order.
+// It isn't defined by go-binance yet.
+const SideEffectTypeAutoBorrowRepay api.SideEffectType = "AUTO_BORROW_REPAY"
+
+type marginOrderFields struct {
+	orderFields
+	IsIsolated     bool
+	SideEffectType api.SideEffectType
+}
+
+func (of *marginOrderFields) ToAPI(c *api.Client) *api.CreateMarginOrderService {
+	s := c.
+		NewCreateMarginOrderService().
+		Symbol(of.Symbol).
+		IsIsolated(of.IsIsolated).
+		Side(of.Side).
+		Type(of.Type).
+		Quantity(of.Quantity).
+		NewClientOrderID(of.NewClientOrderID).
+		NewOrderRespType(of.NewOrderRespType)
+	if of.SideEffectType != "" {
+		s.SideEffectType(of.SideEffectType)
+	}
+	if of.Type != api.OrderTypeMarket {
+		s.TimeInForce(of.TimeInForce).Price(of.Price)
+	}
+	return s
+}
+
+type MarginOrderPlacer struct {
+	orderGetter *MarginOrderGetter
+	client      *api.Client
+	isolated    bool
+}
+
+func NewMarginOrderPlacer(client *api.Client, isolated bool) *MarginOrderPlacer {
+	return &MarginOrderPlacer{
+		client:      client,
+		orderGetter: NewMarginOrderGetter(client, isolated),
+		isolated:    isolated,
+	}
+}
+
+// CreateOrderRequest Don't forget to floor `price` and `quantity`
+func (op *MarginOrderPlacer) CreateOrderRequest(
+	symbol string, price, quantity *apd.Decimal, preferredID string,
+	side api.SideType, orderType api.OrderType, sideEffect api.SideEffectType,
+) (*marginOrderFields, error) {
+	req := &marginOrderFields{
+		orderFields: orderFields{
+			Symbol:           symbol,
+			Side:             side,
+			Type:             orderType,
+			Quantity:         utils.ToFlatString(quantity),
+			NewClientOrderID: preferredID,
+			NewOrderRespType: api.NewOrderRespTypeACK,
+		},
+		IsIsolated:     op.isolated,
+		SideEffectType: sideEffect,
+	}
+	if orderType != api.OrderTypeMarket {
+		if price == nil {
+			return nil, errors.Errorf("price is required for %s order", orderType)
+		}
+		req.TimeInForce = api.TimeInForceTypeGTC
+		req.Price = utils.ToFlatString(price)
+	}
+	return req, nil
+}
+
+func (op *MarginOrderPlacer) PlaceOrder(ctx context.Context,
+	symbol string, price, quantity *apd.Decimal, preferredID string,
+	side api.SideType, orderType api.OrderType, sideEffect api.SideEffectType,
+) (id string, e error) {
+	orderReq, err := op.CreateOrderRequest(symbol, price, quantity, preferredID, side, orderType, sideEffect)
+	if err != nil {
+		return "", errors.Wrapf(err, "can't create order req")
+	}
+
+	id, placeErr := op.tryToPlaceOrder(ctx, orderReq)
+	if placeErr == nil {
+		return id, nil
+	}
+	if !errors.Is(placeErr, exchanges.NewOrderRejectedError) {
+		return "", placeErr
+	}
+
+	binanceOrder, getErr := op.orderGetter.GetBinanceOrder(ctx, symbol, preferredID)
+	if errors.Is(getErr, exchanges.OrderNotFoundError) {
+		return "", placeErr // Order rejected by another reason
+	}
+	if getErr != nil {
+		return "", errors.Wrapf(placeErr, "[Subreason: can't fetch order: %v]", getErr)
+	}
+
+	if orderReq.Equal(binanceOrder) {
+		return binanceOrder.ClientOrderID, nil
+	}
+	return "", errors.Errorf("different order with same ClientOrderID (%s) was placed", preferredID)
+}
+
+func (op *MarginOrderPlacer) tryToPlaceOrder(ctx context.Context, req *marginOrderFields) (id string, e error) {
+	order, err := req.ToAPI(op.client).Do(ctx)
+	if err != nil {
+		err = (&OrderPlacer{}).castToOrderRejecterErrorIfCan(err)
+		return "", errors.Wrapf(err, "can't place margin %s order", string(req.Side))
+	}
+
+	if order.Status == api.OrderStatusTypeRejected {
+		return "", errors.Wrap(
+			exchanges.NewOrderRejectedError, "order have status = REJECTED")
+	}
+
+	return order.ClientOrderID, nil
+}
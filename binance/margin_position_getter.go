@@ -0,0 +1,117 @@
+package binance
+
+import (
+	"context"
+
+	api "github.com/adshao/go-binance/v2"
+	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/aulaleslie/trade-exchanges/utils"
+	"github.com/cockroachdb/apd"
+	"github.com/pkg/errors"
+)
+
+// MarginPositionGetter reads balances and loans of cross or isolated margin account
+type MarginPositionGetter struct {
+	client   *api.Client
+	isolated bool
+}
+
+func NewMarginPositionGetter(client *api.Client, isolated bool) *MarginPositionGetter {
+	return &MarginPositionGetter{
+		client:   client,
+		isolated: isolated,
+	}
+}
+
+// GetAccountBalances returns free, locked, borrowed, interest and net asset per coin.
+// Isolated balances are returned per pair with filled IsolatedSymbol.
+func (pg *MarginPositionGetter) GetAccountBalances(ctx context.Context, prefix string) (exchanges.Account, error) {
+	res := exchanges.Account{AccountBalances: []exchanges.AccountBalance{}}
+
+	if !pg.isolated {
+		account, err := pg.client.NewGetMarginAccountService().Do(ctx)
+		if err != nil {
+			return res, errors.Wrap(err, "can't get margin account")
+		}
+		for _, asset := range account.UserAssets {
+			balance, err := marginBalance(asset.Asset, asset.Free, asset.Locked, asset.Borrowed, asset.Interest, asset.NetAsset)
+			if err != nil {
+				return res, err
+			}
+			res.AccountBalances = append(res.AccountBalances, balance)
+		}
+		return res, nil
+	}
+
+	account, err := pg.client.NewGetIsolatedMarginAccountService().Do(ctx)
+	if err != nil {
+		return res, errors.Wrap(err, "can't get isolated margin account")
+	}
+	for _, pair := range account.Assets {
+		for _, asset := range []api.IsolatedUserAsset{pair.BaseAsset, pair.QuoteAsset} {
+			balance, err := marginBalance(asset.Asset, asset.Free, asset.Locked, asset.Borrowed, asset.Interest, asset.NetAsset)
+			if err != nil {
+				return res, err
+			}
+			balance.IsolatedSymbol = exchanges.FullSymbol(prefix, pair.Symbol)
+			res.AccountBalances = append(res.AccountBalances, balance)
+		}
+	}
+	return res, nil
+}
+
+// GetMarginLevel returns margin level of cross account or of isolated pair
+func (pg *MarginPositionGetter) GetMarginLevel(ctx context.Context, symbol string) (*apd.Decimal, error) {
+	if !pg.isolated {
+		account, err := pg.client.NewGetMarginAccountService().Do(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "can't get margin account")
+		}
+		return utils.FromStringErr(account.MarginLevel)
+	}
+
+	account, err := pg.client.NewGetIsolatedMarginAccountService().Symbols(symbol).Do(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't get isolated margin account")
+	}
+	for _, pair := range account.Assets {
+		if pair.Symbol == symbol {
+			return utils.FromStringErr(pair.MarginLevel)
+		}
+	}
+	return nil, errors.Errorf("no isolated margin account for %s", symbol)
+}
+
+// GetIsolatedSymbols returns pairs with created isolated margin account
+func (pg *MarginPositionGetter) GetIsolatedSymbols(ctx context.Context) ([]string, error) {
+	account, err := pg.client.NewGetIsolatedMarginAccountService().Do(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't get isolated margin account")
+	}
+	symbols := []string{}
+	for _, pair := range account.Assets {
+		if pair.IsolatedCreated && pair.Enabled {
+			symbols = append(symbols, pair.Symbol)
+		}
+	}
+	return symbols, nil
+}
+
+func marginBalance(coin, free, locked, borrowed, interest, netAsset string) (exchanges.AccountBalance, error) {
+	values := []*apd.Decimal{}
+	for _, s := range []string{free, locked, borrowed, interest, netAsset} {
+		x, err := utils.FromStringErr(s)
+		if err != nil {
+			return exchanges.AccountBalance{}, errors.Wrapf(err, "invalid balance of %s", coin)
+		}
+		values = append(values, x)
+	}
+	return exchanges.AccountBalance{
+		Coin:     coin,
+		Free:     values[0],
+		Locked:   values[1],
+		Borrowed: values[2],
+		Interest: values[3],
+		NetAsset: values[4],
+	}, nil
+}
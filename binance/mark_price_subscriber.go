@@ -0,0 +1,94 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/aulaleslie/trade-exchanges/binance/adshao_binance"
+	"github.com/aulaleslie/trade-exchanges/utils"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const markPriceUpdateEventType = "markPriceUpdate"
+
+// WSMarkPriceEvent is pushed by `<symbol>@markPrice` stream of futures
+type WSMarkPriceEvent struct {
+	// ! DON'T REMOVE NullJSONValue fields. They are used to make JSON case-senitive
+	Event string `json:"e"`
+	Time  int64  `json:"E"`
+
+	Symbol string `json:"s"`
+
+	MarkPrice string              `json:"p"`
+	Ignore1   utils.NullJSONValue `json:"P"`
+
+	FundingRate string              `json:"r"`
+	Ignore2     utils.NullJSONValue `json:"R"`
+
+	NextFundingTime int64 `json:"T"`
+}
+
+// SubscribeToMarkPrice No reconnection in case of error
+// Returns control after connect
+func SubscribeToMarkPrice(ctx context.Context, wsEndpoint string, symbol string, lg *zap.Logger) (<-chan exchanges.PriceEvent, error) {
+	symbol = strings.ToUpper(symbol)
+
+	cfg := adshao_binance.WSConfig{
+		Endpoint:  wsEndpoint,
+		KeepAlive: true,
+		Timeout:   30 * time.Second,
+	}
+
+	wsServeCtx, cancel := context.WithCancel(ctx)
+	in, err := adshao_binance.WSServe(wsServeCtx, &cfg, lg.Named("MarkPrices"))
+	if err != nil {
+		cancel()
+		return nil, errors.Wrap(err, "can't start websocket")
+	}
+
+	out := make(chan exchanges.PriceEvent, 100) // TODO: move to config
+	go func() {
+		defer cancel()
+		defer close(out)
+
+		for msg := range in {
+			if msg.DisconnectedWithErr != nil {
+				out <- exchanges.PriceEvent{
+					DisconnectedWithErr: msg.DisconnectedWithErr,
+				}
+				return
+			}
+
+			event, err := mapWSMarkPriceEvent(msg.Payload)
+			if err != nil {
+				out <- exchanges.PriceEvent{DisconnectedWithErr: err}
+				return
+			}
+			if event.Event != markPriceUpdateEventType || strings.ToUpper(event.Symbol) != symbol {
+				continue
+			}
+
+			price, err := utils.FromStringErr(event.MarkPrice)
+			if err != nil {
+				out <- exchanges.PriceEvent{DisconnectedWithErr: err}
+				return
+			}
+			out <- exchanges.PriceEvent{Payload: price}
+		}
+	}()
+
+	return out, nil
+}
+
+func mapWSMarkPriceEvent(message []byte) (*WSMarkPriceEvent, error) {
+	event := &WSMarkPriceEvent{}
+	err := json.Unmarshal(message, event)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't unmarshal JSON")
+	}
+	return event, nil
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"time"
 
 	api "github.com/adshao/go-binance/v2"
 	"github.com/adshao/go-binance/v2/common"
 	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/aulaleslie/trade-exchanges/binance/wsapi"
 	"github.com/aulaleslie/trade-exchanges/utils"
 	"github.com/cockroachdb/apd"
 	"github.com/pkg/errors"
@@ -15,6 +17,7 @@ import (
 
 type OrderGetter struct {
 	client *api.Client
+	wsAPI  *wsapi.Client
 }
 
 func (og *OrderGetter) GetOrderInfoByClientOrderID(ctx context.Context, symbol, clientOrderID string) (exchanges.OrderInfo, error) {
@@ -41,6 +44,13 @@ func (og *OrderGetter) GetOrderBinanceStatus(ctx context.Context, symbol, client
 }
 
 func (og *OrderGetter) GetBinanceOrder(ctx context.Context, symbol, clientOrderID string) (*api.Order, error) {
+	if og.wsAPI != nil {
+		order, fallback, err := og.getOrderWS(ctx, symbol, clientOrderID)
+		if !fallback {
+			return order, err
+		}
+	}
+
 	order, err := og.client.NewGetOrderService().
 		Symbol(symbol).
 		OrigClientOrderID(clientOrderID).
@@ -150,6 +160,7 @@ func (og *OrderGetter) GetHistoryOrders(
 	symbol string,
 	orderID *string,
 	clientOrderID *string,
+	since *time.Time,
 ) (res []exchanges.OrderDetailInfo, err error) {
 	// Need to split because on listOrdersService doesn't support filter by clientOrderID
 	if orderID != nil || clientOrderID != nil {
@@ -182,7 +193,11 @@ func (og *OrderGetter) GetHistoryOrders(
 		return res, err
 	}
 
-	orders, err := og.client.NewListOrdersService().Symbol(symbol).Do(ctx)
+	listService := og.client.NewListOrdersService().Symbol(symbol)
+	if since != nil {
+		listService.StartTime(since.UnixMilli())
+	}
+	orders, err := listService.Do(ctx)
 	if err != nil {
 		fmt.Println(err)
 		return nil, err
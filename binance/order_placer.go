@@ -7,6 +7,7 @@ import (
 	api "github.com/adshao/go-binance/v2"
 	"github.com/adshao/go-binance/v2/common"
 	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/aulaleslie/trade-exchanges/binance/wsapi"
 	"github.com/aulaleslie/trade-exchanges/utils"
 	"github.com/cockroachdb/apd"
 	"github.com/pkg/errors"
@@ -77,6 +78,7 @@ func (of *orderFields) Equal(x *api.Order) bool {
 type OrderPlacer struct {
 	orderGetter *OrderGetter
 	client      *api.Client
+	wsAPI       *wsapi.Client
 }
 
 func NewOrderPlacer(client *api.Client) *OrderPlacer {
@@ -167,6 +169,13 @@ func (op *OrderPlacer) PlaceOrderV2(ctx context.Context, symbol string, price, q
 }
 
 func (op *OrderPlacer) tryToPlaceOrder(ctx context.Context, req *orderFields) (id string, e error) {
+	if op.wsAPI != nil {
+		id, fallback, err := op.placeOrderWS(ctx, req)
+		if !fallback {
+			return id, err
+		}
+	}
+
 	order, err := req.ToAPI(op.client).Do(ctx)
 	if err != nil {
 		err = op.castToOrderRejecterErrorIfCan(err)
@@ -0,0 +1,117 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func executionReport(t *testing.T, symbol, clientOrderID, status string) exchanges.OrderEvent {
+	msg := fmt.Sprintf(`{"e":"executionReport","s":"%s","c":"%s","C":"","X":"%s"}`, symbol, clientOrderID, status)
+	payload, err := mapToOrderEventPayload(BINANCE_PREFIX, []byte(msg))
+	require.NoError(t, err)
+	return exchanges.OrderEvent{Payload: payload}
+}
+
+func restOrder(symbol string, orderID int64, clientOrderID, status string, created time.Time) string {
+	return fmt.Sprintf(`{"symbol":"%s","orderId":%d,"clientOrderId":"%s","price":"1","origQty":"1",`+
+		`"executedQty":"0","status":"%s","type":"LIMIT","side":"BUY","timeInForce":"GTC","stopPrice":"0","time":%d}`,
+		symbol, orderID, clientOrderID, status, created.UnixMilli())
+}
+
+func TestBinanceLongOrderRecovery(t *testing.T) {
+	before := time.Now().Add(-time.Hour)
+	during := time.Now().Add(time.Minute)
+
+	history := map[string]int{}
+	lookups := map[string]int{}
+	round := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		switch r.URL.Path {
+		case "/api/v3/openOrders":
+			fmt.Fprintf(w, `[%s,%s,%s]`,
+				restOrder("BTCUSDT", 1, "c1", "NEW", before),
+				restOrder("BTCUSDT", 2, "c2", "PARTIALLY_FILLED", before),
+				restOrder("LTCUSDT", 7, "c7", "NEW", during))
+		case "/api/v3/allOrders":
+			history[r.Form.Get("symbol")]++
+			switch {
+			case round > 0:
+				fmt.Fprint(w, `[]`)
+			case r.Form.Get("symbol") == "BTCUSDT":
+				fmt.Fprintf(w, `[%s,%s]`,
+					restOrder("BTCUSDT", 1, "c1", "NEW", before),
+					restOrder("BTCUSDT", 5, "c5", "FILLED", during))
+			case r.Form.Get("symbol") == "LTCUSDT":
+				fmt.Fprintf(w, `[%s,%s]`,
+					restOrder("LTCUSDT", 7, "c7", "NEW", during),
+					restOrder("LTCUSDT", 8, "c8", "CANCELED", during.Add(time.Second)))
+			default:
+				fmt.Fprint(w, `[]`)
+			}
+		case "/api/v3/order":
+			id := r.Form.Get("origClientOrderId")
+			lookups[id]++
+			if id == "c3" {
+				fmt.Fprint(w, restOrder("BTCUSDT", 3, "c3", "CANCELED", before))
+				return
+			}
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"code":-2013,"msg":"Order does not exist."}`)
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+	b := NewBinanceLong(BinanceURLs{APIURL: srv.URL}, "key", "secret", zap.NewNop())
+
+	rec := exchanges.NewOrderEventRecoverer(b, zap.NewNop())
+	rec.Observe(executionReport(t, "BTCUSDT", "c1", "NEW"))
+	rec.Observe(executionReport(t, "BTCUSDT", "c2", "NEW"))
+	rec.Observe(executionReport(t, "BTCUSDT", "c3", "NEW"))
+	rec.Observe(executionReport(t, "ETHUSDT", "c6", "NEW"))
+	rec.Observe(exchanges.OrderEvent{DisconnectedWithErr: errors.New("disconnect")})
+
+	events, err := rec.Recover(context.Background())
+	require.NoError(t, err)
+
+	got := map[string]exchanges.OrderStatusType{}
+	for _, ev := range events {
+		assert.True(t, ev.Payload.Recovered)
+		got[ev.Payload.OrderID] = ev.Payload.OrderStatus
+		if ev.Payload.OrderID == "c7" || ev.Payload.OrderID == "c8" {
+			assert.Equal(t, "BINANCE-LTCUSDT", *ev.Payload.Symbol)
+		} else {
+			assert.Equal(t, "BINANCE-BTCUSDT", *ev.Payload.Symbol)
+		}
+	}
+	assert.Equal(t, map[string]exchanges.OrderStatusType{
+		"c2": exchanges.PartiallyFilledOST,
+		"c3": exchanges.CanceledOST,
+		"c5": exchanges.FilledOST,
+		"c7": exchanges.NewOST,
+		"c8": exchanges.CanceledOST,
+	}, got)
+	assert.Equal(t, "c8", events[len(events)-1].Payload.OrderID) // Sorted by creation time
+	// Every symbol is read once, only orders missing from the history are looked up
+	assert.Equal(t, map[string]int{"BTCUSDT": 1, "ETHUSDT": 1, "LTCUSDT": 1}, history)
+	assert.Equal(t, map[string]int{"c3": 1, "c6": 1}, lookups)
+
+	// The state is updated: nothing changed since the recovery and unseen c6 is forgotten
+	round++
+	events, err = rec.Recover(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, events)
+	assert.Equal(t, map[string]int{"BTCUSDT": 2, "ETHUSDT": 1, "LTCUSDT": 2}, history)
+	assert.Equal(t, map[string]int{"c3": 1, "c6": 1}, lookups)
+}
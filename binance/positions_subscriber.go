@@ -29,6 +29,7 @@ type BinanceBalancePositions struct {
 
 type FuturesAccountUpdateData struct {
 	EventReasonType string                    `json:"m"`
+	Balances        []FuturesBinanceBalance   `json:"B"`
 	Positions       []FuturesBinancePositions `json:"P"`
 }
 
@@ -38,6 +39,12 @@ type FuturesAccountUpdate struct {
 	Data      FuturesAccountUpdateData `json:"a"`
 }
 
+type FuturesBinanceBalance struct {
+	Asset              string `json:"a"`
+	WalletBalance      string `json:"wb"`
+	CrossWalletBalance string `json:"cw"`
+}
+
 type FuturesBinancePositions struct {
 	Symbol         string `json:"s"`
 	PositionAmount string `json:"pa"`
@@ -46,6 +53,7 @@ type FuturesBinancePositions struct {
 func SubscribeToPositionsFutures(
 	ctx context.Context,
 	wsEndpoint string,
+	prefix string,
 	lg *zap.Logger,
 ) (<-chan exchanges.PositionEvent, error) {
 	lg.Info("calling SubscribeToPositions Futures ...")
@@ -80,6 +88,11 @@ func SubscribeToPositionsFutures(
 				return
 			}
 
+			if err := listenKeyExpiredError(msg.Payload); err != nil {
+				out <- exchanges.PositionEvent{DisconnectedWithErr: err}
+				return
+			}
+
 			ok, err := isAccountUpdateFuturesEventPayload(msg.Payload)
 			if err != nil {
 				lg.Sugar().Errorf("error while checking account update event type: %v", err)
@@ -94,7 +107,7 @@ func SubscribeToPositionsFutures(
 				continue
 			}
 
-			result, err := mapToAccountUpdateFuturesEventPayload(msg.Payload)
+			result, err := mapToAccountUpdateFuturesEventPayload(prefix, msg.Payload)
 			if err != nil {
 				out <- exchanges.PositionEvent{
 					DisconnectedWithErr: errors.Wrap(err, "can't parse OrderEvent"),
@@ -147,6 +160,11 @@ func SubscribeToPositions(
 				return
 			}
 
+			if err := listenKeyExpiredError(msg.Payload); err != nil {
+				out <- exchanges.PositionEvent{DisconnectedWithErr: err}
+				return
+			}
+
 			ok, err := isAccountUpdateEventPayload(msg.Payload)
 			if err != nil {
 				lg.Sugar().Errorf("error while checking account update event type: %v", err)
@@ -201,6 +219,15 @@ func mapToAccountUpdateEventPayload(message []byte) (p []*exchanges.PositionPayl
 	return positionPayload, nil
 }
 
+// streamBalances returns free balances of `acc` as outboundAccountPosition events have them
+func streamBalances(acc exchanges.Account) []*exchanges.PositionPayload {
+	res := make([]*exchanges.PositionPayload, 0, len(acc.AccountBalances))
+	for _, b := range acc.AccountBalances {
+		res = append(res, &exchanges.PositionPayload{Symbol: b.Coin, Value: b.Free})
+	}
+	return res
+}
+
 func isAccountUpdateEventPayload(message []byte) (bool, error) {
 	data := userDataStreamCommonMessage{}
 	err := json.Unmarshal(message, &data)
@@ -219,7 +246,7 @@ func isAccountUpdateFuturesEventPayload(message []byte) (bool, error) {
 	return data.EventType == accountUpdateFuturesEventType, nil
 }
 
-func mapToAccountUpdateFuturesEventPayload(message []byte) (p []*exchanges.PositionPayload, e error) {
+func mapToAccountUpdateFuturesEventPayload(prefix string, message []byte) (p []*exchanges.PositionPayload, e error) {
 	accountUpdate := FuturesAccountUpdate{}
 	err := json.Unmarshal(message, &accountUpdate)
 	if err != nil {
@@ -234,7 +261,7 @@ func mapToAccountUpdateFuturesEventPayload(message []byte) (p []*exchanges.Posit
 		}
 
 		positionPayload = append(positionPayload, &exchanges.PositionPayload{
-			Symbol: balance.Symbol,
+			Symbol: exchanges.FullSymbol(prefix, balance.Symbol),
 			Value:  freeBalance,
 		})
 	}
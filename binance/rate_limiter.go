@@ -0,0 +1,451 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aulaleslie/trade-exchanges/utils"
+	"go.uber.org/zap"
+)
+
+// BinanceRateLimits are limits of one API (spot or futures). Zero means no limit.
+// https://binance-docs.github.io/apidocs/spot/en/#limits
+type BinanceRateLimits struct {
+	WeightPerMinute int
+	OrdersPer10Sec  int
+	OrdersPerDay    int // Spot only
+	OrdersPerMinute int // Futures only
+
+	// Weights of endpoints, key is "METHOD /path". Absent endpoints cost 1.
+	Weights map[string]int
+	// Weights used instead of Weights when request has no `symbol` parameter
+	WeightsWithoutSymbol map[string]int
+	// Requests which are counted by order limits
+	OrderEndpoints map[string]struct{}
+	// Requests with these path prefixes are not limited (e.g. /sapi has own limits)
+	Unlimited []string
+
+	// Fail immediately if limiter has to wait longer. Zero means wait as long as context allows.
+	MaxWait time.Duration
+}
+
+var SpotRateLimits = BinanceRateLimits{
+	WeightPerMinute: 6000,
+	OrdersPer10Sec:  100,
+	OrdersPerDay:    200000,
+	Weights: map[string]int{
+		"GET /api/v3/exchangeInfo":      20,
+		"GET /api/v3/ticker/24hr":       2,
+		"GET /api/v3/ticker/price":      2,
+		"GET /api/v3/depth":             5,
+		"GET /api/v3/order":             4,
+		"GET /api/v3/openOrders":        6,
+		"GET /api/v3/allOrders":         20,
+		"GET /api/v3/account":           20,
+		"GET /api/v3/myTrades":          20,
+		"POST /api/v3/userDataStream":   2,
+		"PUT /api/v3/userDataStream":    2,
+		"DELETE /api/v3/userDataStream": 2,
+	},
+	WeightsWithoutSymbol: map[string]int{
+		"GET /api/v3/ticker/24hr":  80,
+		"GET /api/v3/ticker/price": 4,
+		"GET /api/v3/openOrders":   80,
+	},
+	OrderEndpoints: map[string]struct{}{
+		"POST /api/v3/order": {},
+	},
+	Unlimited: []string{"/sapi/"},
+}
+
+var FuturesRateLimits = BinanceRateLimits{
+	WeightPerMinute: 2400,
+	OrdersPer10Sec:  300,
+	OrdersPerMinute: 1200,
+	Weights: map[string]int{
+		"GET /fapi/v1/allOrders":           5,
+		"GET /fapi/v2/account":             5,
+		"GET /fapi/v2/balance":             5,
+		"GET /fapi/v2/positionRisk":        5,
+		"GET /fapi/v1/userTrades":          5,
+		"POST /fapi/v1/batchOrders":        5,
+		"POST /fapi/v1/countdownCancelAll": 10,
+	},
+	WeightsWithoutSymbol: map[string]int{
+		"GET /fapi/v1/ticker/24hr":  40,
+		"GET /fapi/v1/ticker/price": 2,
+		"GET /fapi/v1/openOrders":   40,
+	},
+	OrderEndpoints: map[string]struct{}{
+		"POST /fapi/v1/order":       {},
+		"POST /fapi/v1/batchOrders": {},
+	},
+}
+
+// USRateLimits are limits of Binance.US, it has lower weight limit and older weights
+// https://docs.binance.us/#api-limit-introduction
+var USRateLimits = BinanceRateLimits{
+	WeightPerMinute: 1200,
+	OrdersPer10Sec:  100,
+	OrdersPerDay:    200000,
+	Weights: map[string]int{
+		"GET /api/v3/exchangeInfo":    10,
+		"GET /api/v3/order":           2,
+		"GET /api/v3/openOrders":      3,
+		"GET /api/v3/allOrders":       10,
+		"GET /api/v3/account":         10,
+		"GET /api/v3/myTrades":        10,
+		"GET /api/v3/ticker/24hr":     1,
+		"GET /api/v3/ticker/price":    1,
+		"POST /api/v3/userDataStream": 1,
+	},
+	WeightsWithoutSymbol: map[string]int{
+		"GET /api/v3/ticker/24hr":  40,
+		"GET /api/v3/ticker/price": 2,
+		"GET /api/v3/openOrders":   40,
+	},
+	OrderEndpoints: map[string]struct{}{
+		"POST /api/v3/order": {},
+	},
+	Unlimited: []string{"/sapi/"},
+}
+
+// MarginRateLimits count /api and /sapi weights in one window, it's stricter than Binance
+var MarginRateLimits = BinanceRateLimits{
+	WeightPerMinute: 6000,
+	OrdersPer10Sec:  100,
+	OrdersPerDay:    200000,
+	Weights: map[string]int{
+		"GET /api/v3/exchangeInfo":              20,
+		"GET /api/v3/ticker/24hr":               2,
+		"GET /sapi/v1/margin/account":           10,
+		"GET /sapi/v1/margin/isolated/account":  10,
+		"GET /sapi/v1/margin/order":             10,
+		"GET /sapi/v1/margin/allOrders":         200,
+		"GET /sapi/v1/margin/maxBorrowable":     50,
+		"POST /sapi/v1/margin/order":            6,
+		"POST /sapi/v1/userDataStream":          1,
+		"PUT /sapi/v1/userDataStream":           1,
+		"POST /sapi/v1/userDataStream/isolated": 1,
+		"PUT /sapi/v1/userDataStream/isolated":  1,
+	},
+	WeightsWithoutSymbol: map[string]int{
+		"GET /api/v3/ticker/24hr":        80,
+		"GET /sapi/v1/margin/openOrders": 10,
+	},
+	OrderEndpoints: map[string]struct{}{
+		"POST /sapi/v1/margin/order": {},
+	},
+}
+
+var DeliveryRateLimits = BinanceRateLimits{
+	WeightPerMinute: 2400,
+	OrdersPerMinute: 1200,
+	Weights: map[string]int{
+		"GET /dapi/v1/exchangeInfo":        1,
+		"GET /dapi/v1/allOrders":           20,
+		"GET /dapi/v1/account":             5,
+		"GET /dapi/v1/balance":             1,
+		"GET /dapi/v1/positionRisk":        1,
+		"GET /dapi/v1/userTrades":          20,
+		"POST /dapi/v1/batchOrders":        5,
+		"POST /dapi/v1/countdownCancelAll": 10,
+	},
+	WeightsWithoutSymbol: map[string]int{
+		"GET /dapi/v1/ticker/24hr":  40,
+		"GET /dapi/v1/ticker/price": 2,
+		"GET /dapi/v1/openOrders":   40,
+	},
+	OrderEndpoints: map[string]struct{}{
+		"POST /dapi/v1/order":       {},
+		"POST /dapi/v1/batchOrders": {},
+	},
+}
+
+// endpointCost returns weight of the request and whether it's counted by order limits.
+// ok is false if the request isn't limited at all.
+func (l *BinanceRateLimits) endpointCost(method, path string, query url.Values) (weight int, isOrder bool, ok bool) {
+	for _, prefix := range l.Unlimited {
+		if strings.HasPrefix(path, prefix) {
+			return 0, false, false
+		}
+	}
+
+	key := method + " " + path
+	weight = 1
+	if w, found := l.Weights[key]; found {
+		weight = w
+	}
+	if query.Get("symbol") == "" {
+		if w, found := l.WeightsWithoutSymbol[key]; found {
+			weight = w
+		}
+	}
+	_, isOrder = l.OrderEndpoints[key]
+	return weight, isOrder, true
+}
+
+// RateLimitError is returned instead of sending the request when it would break the limits
+type RateLimitError struct {
+	Reason     string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("binance rate limit: %s, retry after %v", e.Reason, e.RetryAfter)
+}
+
+// windowCounter counts usage in fixed windows aligned to the interval the same way Binance does
+type windowCounter struct {
+	name     string
+	interval time.Duration
+	limit    int
+	header   string // Response header with the server side value
+
+	windowStart time.Time
+	used        int
+}
+
+func (wc *windowCounter) roll(now time.Time) {
+	start := now.Truncate(wc.interval)
+	if !start.Equal(wc.windowStart) {
+		wc.windowStart = start
+		wc.used = 0
+	}
+}
+
+// wait returns how long to wait until `n` fits into the window
+func (wc *windowCounter) wait(now time.Time, n int) time.Duration {
+	if wc.limit <= 0 {
+		return 0
+	}
+	wc.roll(now)
+	if wc.used+n <= wc.limit {
+		return 0
+	}
+	return wc.windowStart.Add(wc.interval).Sub(now)
+}
+
+func (wc *windowCounter) add(now time.Time, n int) {
+	wc.roll(now)
+	wc.used += n
+}
+
+// observe takes server side value. Concurrent responses may come in any order, so the max is kept.
+func (wc *windowCounter) observe(now time.Time, h http.Header) {
+	value, err := strconv.Atoi(h.Get(wc.header))
+	if err != nil {
+		return
+	}
+	wc.roll(now)
+	if value > wc.used {
+		wc.used = value
+	}
+}
+
+// BinanceRateLimiter throttles requests of one Binance API by request weight and order count.
+// Local accounting is corrected by X-MBX-USED-WEIGHT-* and X-MBX-ORDER-COUNT-* headers,
+// 418 and 429 responses stop all requests until Retry-After.
+type BinanceRateLimiter struct {
+	limits BinanceRateLimits // Costs of requests and MaxWait
+	*rateWindows
+}
+
+// rateWindows are shared by limiters of one host because weight is counted by IP
+type rateWindows struct {
+	mu          sync.Mutex
+	weight      *windowCounter
+	orders      []*windowCounter
+	bannedUntil time.Time
+	sched       *utils.PriorityScheduler
+	lgs         *zap.SugaredLogger
+
+	now func() time.Time
+}
+
+var hostRateLimiters = struct {
+	sync.Mutex
+	byHost map[string]*BinanceRateLimiter
+}{byHost: map[string]*BinanceRateLimiter{}}
+
+// hostRateLimiter returns the limiter which counts requests with `limits` costs in the windows
+// of all adapters of `host`, e.g. spot and margin spend the same /api/v3 weight.
+// Sizes of the windows are taken from `limits` of the first adapter.
+func hostRateLimiter(host string, limits BinanceRateLimits, lg *zap.Logger) *BinanceRateLimiter {
+	hostRateLimiters.Lock()
+	defer hostRateLimiters.Unlock()
+	rl, ok := hostRateLimiters.byHost[host]
+	if !ok {
+		rl = NewBinanceRateLimiter(limits, lg)
+		hostRateLimiters.byHost[host] = rl
+	}
+	return &BinanceRateLimiter{limits: limits, rateWindows: rl.rateWindows}
+}
+
+func NewBinanceRateLimiter(limits BinanceRateLimits, lg *zap.Logger) *BinanceRateLimiter {
+	rl := &BinanceRateLimiter{limits: limits, rateWindows: &rateWindows{
+		weight: &windowCounter{
+			name: "weight 1m", interval: time.Minute, limit: limits.WeightPerMinute,
+			header: "X-MBX-USED-WEIGHT-1M",
+		},
+		sched: utils.NewPriorityScheduler(utils.DefaultPrioritySchedulerConfig(limits.WeightPerMinute)),
+		lgs:   lg.Named("RateLimiter").Sugar(),
+		now:   time.Now,
+	}}
+	if limits.OrdersPer10Sec > 0 {
+		rl.orders = append(rl.orders, &windowCounter{
+			name: "orders 10s", interval: 10 * time.Second, limit: limits.OrdersPer10Sec,
+			header: "X-MBX-ORDER-COUNT-10S",
+		})
+	}
+	if limits.OrdersPerMinute > 0 {
+		rl.orders = append(rl.orders, &windowCounter{
+			name: "orders 1m", interval: time.Minute, limit: limits.OrdersPerMinute,
+			header: "X-MBX-ORDER-COUNT-1M",
+		})
+	}
+	if limits.OrdersPerDay > 0 {
+		rl.orders = append(rl.orders, &windowCounter{
+			name: "orders 1d", interval: 24 * time.Hour, limit: limits.OrdersPerDay,
+			header: "X-MBX-ORDER-COUNT-1D",
+		})
+	}
+	return rl
+}
+
+// reserve returns zero and takes the capacity or returns how long to wait and why.
+// `floor` of the weight is left for requests with higher priority.
+func (rl *BinanceRateLimiter) reserve(weight int, isOrder bool, floor int) (time.Duration, string) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rl.now()
+	if now.Before(rl.bannedUntil) {
+		return rl.bannedUntil.Sub(now), "banned by exchange"
+	}
+	if wait := rl.weight.wait(now, weight+floor); wait > 0 {
+		return wait, rl.weight.name
+	}
+	if isOrder {
+		for _, wc := range rl.orders {
+			if wait := wc.wait(now, 1); wait > 0 {
+				return wait, wc.name
+			}
+		}
+	}
+
+	rl.weight.add(now, weight)
+	if isOrder {
+		for _, wc := range rl.orders {
+			wc.add(now, 1)
+		}
+	}
+	return 0, ""
+}
+
+// Wait blocks until the request fits the limits. Priority is taken from the context,
+// requests with higher priority go first. It fails fast with RateLimitError
+// when the wait is longer than MaxWait or than the context deadline.
+func (rl *BinanceRateLimiter) Wait(ctx context.Context, weight int, isOrder bool) error {
+	return rl.sched.Do(ctx, func(floor int) (time.Duration, error) {
+		wait, reason := rl.reserve(weight, isOrder, floor)
+		if wait == 0 {
+			return 0, nil
+		}
+		if rl.limits.MaxWait > 0 && wait > rl.limits.MaxWait {
+			return 0, &RateLimitError{Reason: reason, RetryAfter: wait}
+		}
+		if deadline, ok := ctx.Deadline(); ok && wait > time.Until(deadline) {
+			return 0, &RateLimitError{Reason: reason, RetryAfter: wait}
+		}
+		rl.lgs.Debugf("Waiting %v because of %s", wait, reason)
+		return wait, nil
+	})
+}
+
+// Stats returns queue depth and wait time per priority
+func (rl *BinanceRateLimiter) Stats() map[utils.Priority]utils.PriorityStats {
+	return rl.sched.Stats()
+}
+
+// Observe applies rate limit information of the response
+func (rl *BinanceRateLimiter) Observe(statusCode int, h http.Header) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rl.now()
+	rl.weight.observe(now, h)
+	for _, wc := range rl.orders {
+		wc.observe(now, h)
+	}
+
+	if statusCode != http.StatusTooManyRequests && statusCode != http.StatusTeapot {
+		return
+	}
+	// 429 is a warning, 418 is IP ban (from 2 minutes to 3 days)
+	retryAfter := time.Minute
+	if statusCode == http.StatusTeapot {
+		retryAfter = 2 * time.Minute
+	}
+	if seconds, err := strconv.Atoi(h.Get("Retry-After")); err == nil {
+		retryAfter = time.Duration(seconds) * time.Second
+	}
+	if until := now.Add(retryAfter); until.After(rl.bannedUntil) {
+		rl.bannedUntil = until
+	}
+	rl.lgs.Warnf("Got HTTP %d, requests are stopped for %v", statusCode, retryAfter)
+}
+
+// Transport wraps `next` (http.DefaultTransport if nil) with the limiter
+func (rl *BinanceRateLimiter) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &rateLimitedTransport{lim: rl, next: next}
+}
+
+type rateLimitedTransport struct {
+	lim  *BinanceRateLimiter
+	next http.RoundTripper
+}
+
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	weight, isOrder, ok := t.lim.limits.endpointCost(req.Method, req.URL.Path, req.URL.Query())
+	if !ok {
+		return t.next.RoundTrip(req)
+	}
+
+	ctx := req.Context()
+	if _, ok := utils.PriorityFromContext(ctx); !ok {
+		ctx = utils.WithPriority(ctx, requestPriority(req, isOrder))
+	}
+	if err := t.lim.Wait(ctx, weight, isOrder); err != nil {
+		return nil, err
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	t.lim.Observe(resp.StatusCode, resp.Header)
+	return resp, nil
+}
+
+// requestPriority is used for requests without priority in the context
+func requestPriority(req *http.Request, isOrder bool) utils.Priority {
+	switch {
+	case req.Method == http.MethodDelete && strings.Contains(strings.ToLower(req.URL.Path), "order"):
+		return utils.CancelPriority
+	case isOrder:
+		return utils.PlacePriority
+	case req.Header.Get("X-MBX-APIKEY") != "":
+		return utils.AccountReadPriority
+	default:
+		return utils.MarketDataPriority
+	}
+}
@@ -0,0 +1,152 @@
+package binance
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/adshao/go-binance/v2/common"
+	"github.com/aulaleslie/trade-exchanges/utils"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+)
+
+func newTestRateLimiter(limits BinanceRateLimits) *BinanceRateLimiter {
+	rl := NewBinanceRateLimiter(limits, zap.NewNop())
+	now := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+	rl.now = func() time.Time { return now }
+	return rl
+}
+
+func TestBinanceRateLimiterFailsFastOnUsedWeight(t *testing.T) {
+	requests := atomic.NewInt32(0)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Inc()
+		w.Header().Set("X-MBX-USED-WEIGHT-1M", "5390")
+		_, _ = w.Write([]byte(`{"serverTime": 1}`))
+	}))
+	defer srv.Close()
+
+	rl := newTestRateLimiter(SpotRateLimits)
+	client := NewBinanceClient(srv.URL, "key", "secret", rl, zap.NewNop())
+
+	_, err := client.NewServerTimeService().Do(context.Background())
+	assert.NoError(t, err)
+
+	// Account costs 20 and doesn't fit into 6000 without reserved 600 till the end of the minute
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = client.NewGetAccountService().Do(ctx)
+	var rlErr *RateLimitError
+	assert.True(t, errors.As(err, &rlErr))
+	assert.Equal(t, time.Minute, rlErr.RetryAfter)
+	assert.Equal(t, int32(1), requests.Load())
+
+	// Higher priorities still pass
+	_, err = client.NewServerTimeService().Do(utils.WithPriority(ctx, utils.PlacePriority))
+	assert.NoError(t, err)
+}
+
+func TestBinanceRateLimiterRetryAfter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTeapot)
+		_, _ = w.Write([]byte(`{"code": -1003, "msg": "Way too many requests"}`))
+	}))
+	defer srv.Close()
+
+	rl := newTestRateLimiter(SpotRateLimits)
+	client := NewBinanceClient(srv.URL, "key", "secret", rl, zap.NewNop())
+
+	_, err := client.NewServerTimeService().Do(context.Background())
+	assert.True(t, common.IsAPIError(err))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = client.NewServerTimeService().Do(ctx)
+	var rlErr *RateLimitError
+	assert.True(t, errors.As(err, &rlErr))
+	assert.Equal(t, 30*time.Second, rlErr.RetryAfter)
+}
+
+func TestBinanceRateLimiterOrderCount(t *testing.T) {
+	limits := FuturesRateLimits
+	limits.OrdersPer10Sec = 2
+	limits.MaxWait = time.Second
+	rl := newTestRateLimiter(limits)
+
+	weight, isOrder, ok := limits.endpointCost(http.MethodPost, "/fapi/v1/order", nil)
+	assert.True(t, ok)
+	assert.True(t, isOrder)
+
+	assert.NoError(t, rl.Wait(context.Background(), weight, isOrder))
+	assert.NoError(t, rl.Wait(context.Background(), weight, isOrder))
+	err := rl.Wait(context.Background(), weight, isOrder)
+	var rlErr *RateLimitError
+	assert.True(t, errors.As(err, &rlErr))
+	assert.Equal(t, "orders 10s", rlErr.Reason)
+
+	// Non order requests aren't affected
+	assert.NoError(t, rl.Wait(context.Background(), 1, false))
+}
+
+func TestBinanceRateLimiterReservesCapacityForCancels(t *testing.T) {
+	limits := SpotRateLimits
+	limits.MaxWait = time.Second
+	rl := newTestRateLimiter(limits)
+	rl.Observe(http.StatusOK, http.Header{"X-Mbx-Used-Weight-1m": []string{"5600"}})
+
+	read := utils.WithPriority(context.Background(), utils.AccountReadPriority)
+	var rlErr *RateLimitError
+	assert.True(t, errors.As(rl.Wait(read, 1, false), &rlErr))
+
+	place := utils.WithPriority(context.Background(), utils.PlacePriority)
+	assert.NoError(t, rl.Wait(place, 1, true))
+
+	cancel := utils.WithPriority(context.Background(), utils.CancelPriority)
+	assert.NoError(t, rl.Wait(cancel, 390, false))
+	assert.True(t, errors.As(rl.Wait(place, 1, true), &rlErr))
+	assert.NoError(t, rl.Wait(cancel, 9, false))
+
+	stats := rl.Stats()
+	assert.Equal(t, 2, stats[utils.CancelPriority].Served)
+	assert.Equal(t, 0, stats[utils.AccountReadPriority].Served)
+}
+
+func TestBinanceRateLimiterIsSharedByHost(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-MBX-USED-WEIGHT-1M", "5990")
+		_, _ = w.Write([]byte(`{"serverTime": 1}`))
+	}))
+	defer srv.Close()
+
+	urls := BinanceURLs{APIURL: srv.URL, USAPIURL: srv.URL + "/us"}
+	long := NewBinanceLong(urls, "key", "secret", zap.NewNop())
+	margin := NewBinanceMargin(urls, "key", "secret", false, zap.NewNop())
+	us := NewBinanceUS(urls, "key", "secret", zap.NewNop())
+	assert.Same(t, long.rateLimiter.rateWindows, margin.rateLimiter.rateWindows)
+	assert.NotSame(t, long.rateLimiter.rateWindows, us.rateLimiter.rateWindows)
+	assert.Equal(t, 1200, us.rateLimiter.weight.limit)
+
+	// Each adapter keeps own costs: /sapi is counted by margin only
+	_, _, ok := long.rateLimiter.limits.endpointCost(http.MethodGet, "/sapi/v1/margin/account", nil)
+	assert.False(t, ok)
+	weight, _, ok := margin.rateLimiter.limits.endpointCost(http.MethodGet, "/sapi/v1/margin/account", nil)
+	assert.True(t, ok)
+	assert.Equal(t, 10, weight)
+
+	// Weight used by margin isn't available to spot
+	now := time.Now()
+	long.rateLimiter.now = func() time.Time { return now }
+	_, err := margin.client.NewServerTimeService().Do(context.Background())
+	assert.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = long.client.NewGetAccountService().Do(ctx)
+	var rlErr *RateLimitError
+	assert.True(t, errors.As(err, &rlErr))
+}
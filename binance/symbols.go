@@ -0,0 +1,91 @@
+package binance
+
+import (
+	"time"
+
+	api "github.com/adshao/go-binance/v2"
+	deliveryApi "github.com/adshao/go-binance/v2/delivery"
+	futuresApi "github.com/adshao/go-binance/v2/futures"
+	exchanges "github.com/aulaleslie/trade-exchanges"
+)
+
+func ToBinanceSymbol(symbol string) string {
+	return exchanges.NativeSymbol(BINANCE_PREFIX, symbol)
+}
+
+func ToFullSymbol(binanceSymbol string) string {
+	return exchanges.FullSymbol(BINANCE_PREFIX, binanceSymbol)
+}
+
+func ToBinanceUSSymbol(symbol string) string {
+	return exchanges.NativeSymbol(BINANCE_US_PREFIX, symbol)
+}
+
+func ToFullUSSymbol(binanceSymbol string) string {
+	return exchanges.FullSymbol(BINANCE_US_PREFIX, binanceSymbol)
+}
+
+func ToBinanceFuturesSymbol(symbol string) string {
+	return exchanges.NativeSymbol(BINANCE_FUTURES_PREFIX, symbol)
+}
+
+func ToFullFuturesSymbol(binanceSymbol string) string {
+	return exchanges.FullSymbol(BINANCE_FUTURES_PREFIX, binanceSymbol)
+}
+
+func ToBinanceCoinFuturesSymbol(symbol string) string {
+	return exchanges.NativeSymbol(BINANCE_COIN_FUTURES_PREFIX, symbol)
+}
+
+func ToFullCoinFuturesSymbol(binanceSymbol string) string {
+	return exchanges.FullSymbol(BINANCE_COIN_FUTURES_PREFIX, binanceSymbol)
+}
+
+func spotInstrument(symbol api.Symbol) exchanges.Instrument {
+	return exchanges.Instrument{
+		Product: exchanges.SpotProduct,
+		Base:    symbol.BaseAsset,
+		Quote:   symbol.QuoteAsset,
+	}
+}
+
+func futuresInstrument(symbol futuresApi.Symbol) exchanges.Instrument {
+	instrument := exchanges.Instrument{
+		Product: exchanges.PerpetualProduct,
+		Base:    symbol.BaseAsset,
+		Quote:   symbol.QuoteAsset,
+		Settle:  symbol.MarginAsset,
+	}
+	if symbol.ContractType != futuresApi.ContractTypePerpetual && symbol.ContractType != "" {
+		instrument.Product = exchanges.FutureProduct
+		expiry := time.UnixMilli(symbol.DeliveryDate).UTC()
+		instrument.Expiry = &expiry
+	}
+	return instrument
+}
+
+// deliveryInstrument is inverse: settled in the base coin, e.g. BTC for BTCUSD_PERP
+func deliveryInstrument(symbol deliveryApi.Symbol) exchanges.Instrument {
+	instrument := exchanges.Instrument{
+		Product: exchanges.PerpetualProduct,
+		Base:    symbol.BaseAsset,
+		Quote:   symbol.QuoteAsset,
+		Settle:  symbol.MarginAsset,
+	}
+	if symbol.ContractType != "PERPETUAL" && symbol.ContractType != "" {
+		instrument.Product = exchanges.FutureProduct
+		expiry := time.UnixMilli(symbol.DeliveryDate).UTC()
+		instrument.Expiry = &expiry
+	}
+	return instrument
+}
+
+// streamOrderKey returns the client order ID and full symbol of `o`:
+// the order streams identify orders by them, the order getters return native ones.
+func streamOrderKey(prefix string, o exchanges.OrderDetailInfo) (string, string) {
+	id := o.ID
+	if o.ClientOrderID != nil && *o.ClientOrderID != "" {
+		id = *o.ClientOrderID
+	}
+	return id, exchanges.FullSymbol(prefix, exchanges.NativeSymbol(prefix, o.Symbol))
+}
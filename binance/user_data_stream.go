@@ -0,0 +1,679 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/aulaleslie/trade-exchanges/binance/adshao_binance"
+	"github.com/avast/retry-go/v3"
+	"github.com/cockroachdb/apd"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const marginCallFuturesEventType string = "MARGIN_CALL"
+const accountConfigUpdateFuturesEventType string = "ACCOUNT_CONFIG_UPDATE"
+
+// TODO: move to config
+var defaultUserDataReconnectOptions = []retry.Option{
+	retry.Attempts(3),
+	retry.Delay(time.Second * 2),
+	retry.DelayType(retry.BackOffDelay),
+	retry.MaxJitter(time.Second),
+	retry.LastErrorOnly(true),
+}
+
+// BalanceUpdate is a balance of the asset after the change
+type BalanceUpdate struct {
+	Asset              string
+	Free               *apd.Decimal // Wallet balance for futures
+	Locked             *apd.Decimal // optional, spot only
+	CrossWalletBalance *apd.Decimal // optional, futures only
+}
+
+// Should be one of DisconnectedWithErr, Reconnected or Payload
+type BalanceEvent struct {
+	DisconnectedWithErr error
+	Reconnected         *struct{}
+	Payload             []BalanceUpdate
+}
+
+type MarginCallPosition struct {
+	Symbol            string
+	PositionSide      string // BOTH, LONG or SHORT
+	MarginType        string // CROSSED or ISOLATED
+	PositionAmount    *apd.Decimal
+	MarkPrice         *apd.Decimal
+	UnrealizedPnL     *apd.Decimal
+	MaintenanceMargin *apd.Decimal
+}
+
+type MarginCall struct {
+	CrossWalletBalance *apd.Decimal // optional, only for cross positions
+	Positions          []MarginCallPosition
+}
+
+// Should be one of DisconnectedWithErr, Reconnected or Payload
+type MarginCallEvent struct {
+	DisconnectedWithErr error
+	Reconnected         *struct{}
+	Payload             *MarginCall
+}
+
+// AccountConfigUpdate is a change of leverage of the symbol or of multi-assets mode
+type AccountConfigUpdate struct {
+	Symbol          string // Empty if multi-assets mode is changed
+	Leverage        int64
+	MultiAssetsMode *bool // optional
+}
+
+// Should be one of DisconnectedWithErr, Reconnected or Payload
+type AccountConfigEvent struct {
+	DisconnectedWithErr error
+	Reconnected         *struct{}
+	Payload             *AccountConfigUpdate
+}
+
+type marginCallFutures struct {
+	EventType          string `json:"e"`
+	EventTime          int64  `json:"E"`
+	CrossWalletBalance string `json:"cw"`
+	Positions          []struct {
+		Symbol            string `json:"s"`
+		PositionSide      string `json:"ps"`
+		PositionAmount    string `json:"pa"`
+		MarginType        string `json:"mt"`
+		MarkPrice         string `json:"mp"`
+		UnrealizedPnL     string `json:"up"`
+		MaintenanceMargin string `json:"mm"`
+	} `json:"p"`
+}
+
+type accountConfigUpdateFutures struct {
+	EventType   string `json:"e"`
+	EventTime   int64  `json:"E"`
+	LeverageCfg *struct {
+		Symbol   string `json:"s"`
+		Leverage int64  `json:"l"`
+	} `json:"ac"`
+	AssetsCfg *struct {
+		MultiAssetsMode bool `json:"j"`
+	} `json:"ai"`
+}
+
+type userDataKind int
+
+const (
+	ordersKind userDataKind = iota
+	positionsKind
+	balancesKind
+	marginCallsKind
+	accountConfigKind
+)
+
+// userDataEvent should be one of DisconnectedWithErr, Reconnected or payload of the kind
+type userDataEvent struct {
+	DisconnectedWithErr error
+	Reconnected         *struct{}
+
+	Order         *exchanges.OrderEventPayload
+	Positions     []*exchanges.PositionPayload
+	Balances      []BalanceUpdate
+	MarginCall    *MarginCall
+	AccountConfig *AccountConfigUpdate
+}
+
+// ErrUserDataOverflow is sent to the subscriber which doesn't read events fast enough
+var ErrUserDataOverflow = errors.New("user data subscriber is too slow, events are dropped")
+
+// udWatcher guards the output channel from sends after closing
+type udWatcher struct {
+	ctx    context.Context
+	out    chan userDataEvent
+	mu     sync.Mutex
+	closed bool
+}
+
+// send never blocks the read loop: the watcher is finished by ErrUserDataOverflow
+// if the channel is full. It returns true in this case.
+func (w *udWatcher) send(ev userDataEvent) (overflow bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return false
+	}
+	// Only the watcher sends to the channel, so the last slot is always free for the disconnection
+	if len(w.out) < cap(w.out)-1 {
+		w.out <- ev
+		return false
+	}
+	w.closed = true
+	w.out <- userDataEvent{DisconnectedWithErr: ErrUserDataOverflow}
+	close(w.out)
+	return true
+}
+
+// finish sends `last` if it isn't nil and closes the channel
+func (w *udWatcher) finish(last *userDataEvent) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	w.closed = true
+	if last != nil {
+		select {
+		case w.out <- *last:
+		case <-w.ctx.Done():
+		}
+	}
+	close(w.out)
+}
+
+type userDataConn struct {
+	listenKey string
+	in        <-chan adshao_binance.WSMessage
+	cancel    context.CancelFunc // Closes the socket and releases the listen key
+}
+
+// UserDataStream shares one user data socket of the account between subscribers of all event types.
+// The socket is opened by the first subscriber and closed after the last one is done.
+// Disconnections are handled once: subscribers get Reconnected or, if all reconnects failed, DisconnectedWithErr.
+type UserDataStream struct {
+	ReconnectOptions []retry.Option // optional
+	// Socket dial failures are reported to it, `endpoint` is expected to pick from it
+	Endpoints *EndpointSelector // optional
+
+	listenKeys *ListenKeyManager
+	endpoint   func(listenKey string) string
+	prefix     string
+	lg         *zap.Logger
+
+	subMu    sync.Mutex // Serializes opening and closing of the socket
+	mu       sync.Mutex
+	watchers map[userDataKind]map[*udWatcher]struct{}
+	stop     context.CancelFunc // nil if the socket isn't opened
+}
+
+// NewUserDataStream `endpoint` returns socket URL of the listen key, symbols of events are prefixed with `prefix`
+func NewUserDataStream(
+	listenKeys *ListenKeyManager,
+	endpoint func(listenKey string) string,
+	prefix string,
+	lg *zap.Logger,
+) *UserDataStream {
+	return &UserDataStream{
+		listenKeys: listenKeys,
+		endpoint:   endpoint,
+		prefix:     prefix,
+		lg:         lg.Named("UserData"),
+		watchers:   map[userDataKind]map[*udWatcher]struct{}{},
+	}
+}
+
+// WatchOrders sends executionReport and ORDER_TRADE_UPDATE events until ctx is done
+func (s *UserDataStream) WatchOrders(ctx context.Context) (<-chan exchanges.OrderEvent, error) {
+	in, err := s.subscribe(ctx, ordersKind)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan exchanges.OrderEvent, 100) // TODO: move to config
+	go func() {
+		defer close(out)
+		for ev := range in {
+			select {
+			case out <- exchanges.OrderEvent{DisconnectedWithErr: ev.DisconnectedWithErr, Reconnected: ev.Reconnected, Payload: ev.Order}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return out, nil
+}
+
+// WatchPositions sends outboundAccountPosition and ACCOUNT_UPDATE positions until ctx is done
+func (s *UserDataStream) WatchPositions(ctx context.Context) (<-chan exchanges.PositionEvent, error) {
+	in, err := s.subscribe(ctx, positionsKind)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan exchanges.PositionEvent, 100) // TODO: move to config
+	go func() {
+		defer close(out)
+		for ev := range in {
+			select {
+			case out <- exchanges.PositionEvent{DisconnectedWithErr: ev.DisconnectedWithErr, Reconnected: ev.Reconnected, Payload: ev.Positions}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return out, nil
+}
+
+// WatchBalances sends outboundAccountPosition and ACCOUNT_UPDATE balances until ctx is done
+func (s *UserDataStream) WatchBalances(ctx context.Context) (<-chan BalanceEvent, error) {
+	in, err := s.subscribe(ctx, balancesKind)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan BalanceEvent, 100) // TODO: move to config
+	go func() {
+		defer close(out)
+		for ev := range in {
+			select {
+			case out <- BalanceEvent{DisconnectedWithErr: ev.DisconnectedWithErr, Reconnected: ev.Reconnected, Payload: ev.Balances}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return out, nil
+}
+
+// WatchMarginCalls sends MARGIN_CALL events of futures until ctx is done
+func (s *UserDataStream) WatchMarginCalls(ctx context.Context) (<-chan MarginCallEvent, error) {
+	in, err := s.subscribe(ctx, marginCallsKind)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan MarginCallEvent, 100) // TODO: move to config
+	go func() {
+		defer close(out)
+		for ev := range in {
+			select {
+			case out <- MarginCallEvent{DisconnectedWithErr: ev.DisconnectedWithErr, Reconnected: ev.Reconnected, Payload: ev.MarginCall}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return out, nil
+}
+
+// WatchAccountConfig sends ACCOUNT_CONFIG_UPDATE events of futures until ctx is done
+func (s *UserDataStream) WatchAccountConfig(ctx context.Context) (<-chan AccountConfigEvent, error) {
+	in, err := s.subscribe(ctx, accountConfigKind)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan AccountConfigEvent, 100) // TODO: move to config
+	go func() {
+		defer close(out)
+		for ev := range in {
+			select {
+			case out <- AccountConfigEvent{DisconnectedWithErr: ev.DisconnectedWithErr, Reconnected: ev.Reconnected, Payload: ev.AccountConfig}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (s *UserDataStream) subscribe(ctx context.Context, kind userDataKind) (<-chan userDataEvent, error) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+
+	w := &udWatcher{ctx: ctx, out: make(chan userDataEvent, 100)} // TODO: move to config
+
+	s.mu.Lock()
+	opened := s.stop != nil
+	if opened {
+		s.addWatcher(kind, w) // Under the same lock to not miss failAll
+	}
+	s.mu.Unlock()
+
+	if !opened {
+		runCtx, stop := context.WithCancel(context.Background())
+		conn, err := s.connect(runCtx)
+		if err != nil {
+			stop()
+			return nil, err
+		}
+		s.mu.Lock()
+		s.stop = stop
+		s.addWatcher(kind, w)
+		s.mu.Unlock()
+		go s.run(runCtx, conn)
+	}
+
+	go func() {
+		<-ctx.Done()
+		s.unsubscribe(kind, w)
+	}()
+	return w.out, nil
+}
+
+func (s *UserDataStream) unsubscribe(kind userDataKind, w *udWatcher) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+
+	s.mu.Lock()
+	delete(s.watchers[kind], w)
+	if s.countWatchers() == 0 && s.stop != nil {
+		s.stop()
+		s.stop = nil
+	}
+	s.mu.Unlock()
+	w.finish(nil)
+}
+
+// addWatcher must be called under the lock
+func (s *UserDataStream) addWatcher(kind userDataKind, w *udWatcher) {
+	if s.watchers[kind] == nil {
+		s.watchers[kind] = map[*udWatcher]struct{}{}
+	}
+	s.watchers[kind][w] = struct{}{}
+}
+
+// countWatchers must be called under the lock
+func (s *UserDataStream) countWatchers() int {
+	n := 0
+	for _, watchers := range s.watchers {
+		n += len(watchers)
+	}
+	return n
+}
+
+func (s *UserDataStream) connect(ctx context.Context) (*userDataConn, error) {
+	connCtx, cancel := context.WithCancel(ctx)
+	listenKey, err := s.listenKeys.Acquire(connCtx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	cfg := adshao_binance.WSConfig{
+		Endpoint:  s.endpoint(listenKey),
+		KeepAlive: true,
+		Timeout:   30 * time.Second,
+	}
+	in, err := adshao_binance.WSServe(connCtx, &cfg, s.lg)
+	if s.Endpoints != nil {
+		s.Endpoints.Report(cfg.Endpoint, err != nil && ctx.Err() == nil)
+	}
+	if err != nil {
+		cancel()
+		return nil, errors.Wrap(err, "can't start websocket")
+	}
+	return &userDataConn{listenKey: listenKey, in: in, cancel: cancel}, nil
+}
+
+func (s *UserDataStream) reconnect(ctx context.Context) (*userDataConn, error) {
+	opts := []retry.Option{retry.Context(ctx)}
+	if s.ReconnectOptions != nil {
+		opts = append(opts, s.ReconnectOptions...)
+	} else {
+		opts = append(opts, defaultUserDataReconnectOptions...)
+	}
+
+	var conn *userDataConn
+	err := retry.Do(func() error {
+		var err error
+		conn, err = s.connect(ctx)
+		if err != nil {
+			s.lg.Warn("Reconnect error", zap.Error(err))
+		}
+		return err
+	}, opts...)
+	return conn, err
+}
+
+// run reads the socket until ctx is done and reconnects it on failures
+func (s *UserDataStream) run(ctx context.Context, conn *userDataConn) {
+	for {
+		err := s.read(conn)
+		if ctx.Err() != nil {
+			conn.cancel()
+			return
+		}
+		if errors.Is(err, ErrListenKeyExpired) {
+			s.listenKeys.renew(conn.listenKey)
+		}
+		s.lg.Warn("Disconnected, reconnecting...", zap.Error(err))
+
+		// The old connection holds the listen key until the new one takes it
+		next, reconnectErr := s.reconnect(ctx)
+		conn.cancel()
+		if reconnectErr != nil {
+			s.failAll(ctx, errors.Wrap(err, "all reconnects failed"))
+			return
+		}
+		conn = next
+		s.lg.Info("Reconnected successfully")
+		s.broadcast(userDataEvent{Reconnected: &struct{}{}})
+	}
+}
+
+// read dispatches events of the connection and returns the reason of disconnection
+func (s *UserDataStream) read(conn *userDataConn) error {
+	for msg := range conn.in {
+		if msg.DisconnectedWithErr != nil {
+			return msg.DisconnectedWithErr
+		}
+		if err := listenKeyExpiredError(msg.Payload); err != nil {
+			return err
+		}
+		if err := s.dispatch(msg.Payload); err != nil {
+			return errors.Wrap(err, "can't parse user data event")
+		}
+	}
+	return errors.New("websocket is closed")
+}
+
+func (s *UserDataStream) dispatch(message []byte) error {
+	data := userDataStreamCommonMessage{}
+	if err := json.Unmarshal(message, &data); err != nil {
+		return errors.Wrap(err, string(message))
+	}
+
+	switch data.EventType {
+	case orderUpdateEventType:
+		order, err := mapToOrderEventPayload(s.prefix, message)
+		if err != nil {
+			return err
+		}
+		s.publish(ordersKind, userDataEvent{Order: order})
+	case orderUpdateFuturesEventType:
+		order, err := mapToOrderFuturesEventPayload(s.prefix, message)
+		if err != nil {
+			return err
+		}
+		s.publish(ordersKind, userDataEvent{Order: order})
+	case accountUpdateEventType:
+		positions, err := mapToAccountUpdateEventPayload(message)
+		if err != nil {
+			return err
+		}
+		balances, err := mapToBalanceUpdates(message)
+		if err != nil {
+			return err
+		}
+		s.publish(positionsKind, userDataEvent{Positions: positions})
+		s.publish(balancesKind, userDataEvent{Balances: balances})
+	case accountUpdateFuturesEventType:
+		positions, err := mapToAccountUpdateFuturesEventPayload(s.prefix, message)
+		if err != nil {
+			return err
+		}
+		balances, err := mapToFuturesBalanceUpdates(message)
+		if err != nil {
+			return err
+		}
+		s.publish(positionsKind, userDataEvent{Positions: positions})
+		s.publish(balancesKind, userDataEvent{Balances: balances})
+	case marginCallFuturesEventType:
+		marginCall, err := mapToMarginCall(s.prefix, message)
+		if err != nil {
+			return err
+		}
+		s.publish(marginCallsKind, userDataEvent{MarginCall: marginCall})
+	case accountConfigUpdateFuturesEventType:
+		update, err := mapToAccountConfigUpdate(s.prefix, message)
+		if err != nil {
+			return err
+		}
+		s.publish(accountConfigKind, userDataEvent{AccountConfig: update})
+	}
+	return nil
+}
+
+func (s *UserDataStream) kindWatchers(kind userDataKind) []*udWatcher {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := make([]*udWatcher, 0, len(s.watchers[kind]))
+	for w := range s.watchers[kind] {
+		res = append(res, w)
+	}
+	return res
+}
+
+func (s *UserDataStream) publish(kind userDataKind, ev userDataEvent) {
+	for _, w := range s.kindWatchers(kind) {
+		if w.send(ev) {
+			s.lg.Warn("Subscriber is too slow, dropping it")
+			go s.unsubscribe(kind, w) // Takes subMu, which can be held by slow subscribe
+		}
+	}
+}
+
+func (s *UserDataStream) broadcast(ev userDataEvent) {
+	for kind := range s.allKinds() {
+		s.publish(kind, ev)
+	}
+}
+
+func (s *UserDataStream) allKinds() map[userDataKind]struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := map[userDataKind]struct{}{}
+	for kind := range s.watchers {
+		res[kind] = struct{}{}
+	}
+	return res
+}
+
+// failAll closes all watchers of the run with ctx, the next subscriber opens the socket again
+func (s *UserDataStream) failAll(ctx context.Context, err error) {
+	s.mu.Lock()
+	if ctx.Err() != nil {
+		s.mu.Unlock()
+		return // Closed by the last subscriber
+	}
+	watchers := s.watchers
+	s.watchers = map[userDataKind]map[*udWatcher]struct{}{}
+	s.stop()
+	s.stop = nil
+	s.mu.Unlock()
+
+	for _, kindWatchers := range watchers {
+		for w := range kindWatchers {
+			w.finish(&userDataEvent{DisconnectedWithErr: err})
+		}
+	}
+}
+
+func mapToBalanceUpdates(message []byte) ([]BalanceUpdate, error) {
+	accountUpdate := AccountUpdate{}
+	if err := json.Unmarshal(message, &accountUpdate); err != nil {
+		return nil, errors.Wrap(err, "can't unmarshal JSON")
+	}
+
+	res := []BalanceUpdate{}
+	for _, balance := range accountUpdate.BalancesArray {
+		free, _, err := apd.NewFromString(balance.Free)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid free balance %s", balance.Free)
+		}
+		locked, _, err := apd.NewFromString(balance.Locked)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid locked balance %s", balance.Locked)
+		}
+		res = append(res, BalanceUpdate{Asset: balance.Asset, Free: free, Locked: locked})
+	}
+	return res, nil
+}
+
+func mapToFuturesBalanceUpdates(message []byte) ([]BalanceUpdate, error) {
+	accountUpdate := FuturesAccountUpdate{}
+	if err := json.Unmarshal(message, &accountUpdate); err != nil {
+		return nil, errors.Wrap(err, "can't unmarshal JSON")
+	}
+
+	res := []BalanceUpdate{}
+	for _, balance := range accountUpdate.Data.Balances {
+		wallet, _, err := apd.NewFromString(balance.WalletBalance)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid wallet balance %s", balance.WalletBalance)
+		}
+		crossWallet, _, err := apd.NewFromString(balance.CrossWalletBalance)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid cross wallet balance %s", balance.CrossWalletBalance)
+		}
+		res = append(res, BalanceUpdate{Asset: balance.Asset, Free: wallet, CrossWalletBalance: crossWallet})
+	}
+	return res, nil
+}
+
+func mapToMarginCall(prefix string, message []byte) (*MarginCall, error) {
+	event := marginCallFutures{}
+	if err := json.Unmarshal(message, &event); err != nil {
+		return nil, errors.Wrap(err, "can't unmarshal JSON")
+	}
+
+	res := &MarginCall{}
+	if event.CrossWalletBalance != "" {
+		cw, _, err := apd.NewFromString(event.CrossWalletBalance)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid cross wallet balance %s", event.CrossWalletBalance)
+		}
+		res.CrossWalletBalance = cw
+	}
+
+	for _, p := range event.Positions {
+		position := MarginCallPosition{
+			Symbol:       exchanges.FullSymbol(prefix, p.Symbol),
+			PositionSide: p.PositionSide,
+			MarginType:   p.MarginType,
+		}
+		for _, field := range []struct {
+			dst **apd.Decimal
+			src string
+		}{
+			{&position.PositionAmount, p.PositionAmount},
+			{&position.MarkPrice, p.MarkPrice},
+			{&position.UnrealizedPnL, p.UnrealizedPnL},
+			{&position.MaintenanceMargin, p.MaintenanceMargin},
+		} {
+			value, _, err := apd.NewFromString(field.src)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid number %s of %s", field.src, p.Symbol)
+			}
+			*field.dst = value
+		}
+		res.Positions = append(res.Positions, position)
+	}
+	return res, nil
+}
+
+func mapToAccountConfigUpdate(prefix string, message []byte) (*AccountConfigUpdate, error) {
+	event := accountConfigUpdateFutures{}
+	if err := json.Unmarshal(message, &event); err != nil {
+		return nil, errors.Wrap(err, "can't unmarshal JSON")
+	}
+
+	res := &AccountConfigUpdate{}
+	if event.LeverageCfg != nil {
+		res.Symbol = exchanges.FullSymbol(prefix, event.LeverageCfg.Symbol)
+		res.Leverage = event.LeverageCfg.Leverage
+	}
+	if event.AssetsCfg != nil {
+		multiAssets := event.AssetsCfg.MultiAssetsMode
+		res.MultiAssetsMode = &multiAssets
+	}
+	return res, nil
+}
@@ -0,0 +1,186 @@
+package binance
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/avast/retry-go/v3"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type userDataServer struct {
+	*httptest.Server
+	mu    sync.Mutex
+	conns []*websocket.Conn
+	keys  []string
+}
+
+func newUserDataServer(t *testing.T) *userDataServer {
+	s := &userDataServer{}
+	upgrader := websocket.Upgrader{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		s.mu.Lock()
+		s.conns = append(s.conns, c)
+		s.keys = append(s.keys, strings.TrimPrefix(r.URL.Path, "/ws/"))
+		s.mu.Unlock()
+		for {
+			if _, _, err := c.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *userDataServer) endpoint(listenKey string) string {
+	return "ws" + strings.TrimPrefix(s.URL, "http") + "/ws/" + listenKey
+}
+
+func (s *userDataServer) connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.conns)
+}
+
+func (s *userDataServer) last() *websocket.Conn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conns[len(s.conns)-1]
+}
+
+func (s *userDataServer) send(t *testing.T, msg string) {
+	require.NoError(t, s.last().WriteMessage(websocket.TextMessage, []byte(msg)))
+}
+
+const (
+	testOrderTradeUpdate = `{"e":"ORDER_TRADE_UPDATE","E":1,"o":{"s":"BTCUSDT","c":"id1","S":"BUY","x":"NEW","X":"NEW"}}`
+	testAccountUpdate    = `{"e":"ACCOUNT_UPDATE","E":2,"a":{"m":"ORDER","B":[{"a":"USDT","wb":"100.5","cw":"90"}],"P":[{"s":"BTCUSDT","pa":"0.01"}]}}`
+	testMarginCall       = `{"e":"MARGIN_CALL","E":3,"cw":"3.1","p":[{"s":"BTCUSDT","ps":"LONG","pa":"1","mt":"CROSSED","iw":"0","mp":"100","up":"-1","mm":"1.6"}]}`
+)
+
+func TestUserDataStreamRoutesEventsOfOneSocket(t *testing.T) {
+	server := newUserDataServer(t)
+	service := newFakeListenKeys()
+	s := NewUserDataStream(NewListenKeyManager(service, zap.NewNop()), server.endpoint, BINANCE_FUTURES_PREFIX, zap.NewNop())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	orders, err := s.WatchOrders(ctx)
+	require.NoError(t, err)
+	positionsCtx, cancelPositions := context.WithCancel(ctx)
+	positions, err := s.WatchPositions(positionsCtx)
+	require.NoError(t, err)
+	balances, err := s.WatchBalances(ctx)
+	require.NoError(t, err)
+	marginCalls, err := s.WatchMarginCalls(ctx)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return server.connections() == 1 }, time.Second, 5*time.Millisecond)
+
+	server.send(t, testOrderTradeUpdate)
+	server.send(t, testAccountUpdate)
+	server.send(t, testMarginCall)
+
+	order := <-orders
+	require.Equal(t, "id1", order.Payload.OrderID)
+	require.Equal(t, "BINANCEFUTURES-BTCUSDT", *order.Payload.Symbol)
+
+	position := <-positions
+	require.Len(t, position.Payload, 1)
+	require.Equal(t, "0.01", position.Payload[0].Value.String())
+
+	balance := <-balances
+	require.Equal(t, "USDT", balance.Payload[0].Asset)
+	require.Equal(t, "100.5", balance.Payload[0].Free.String())
+	require.Equal(t, "90", balance.Payload[0].CrossWalletBalance.String())
+
+	marginCall := <-marginCalls
+	require.Equal(t, "3.1", marginCall.Payload.CrossWalletBalance.String())
+	require.Equal(t, "1.6", marginCall.Payload.Positions[0].MaintenanceMargin.String())
+
+	// Detached subscriber doesn't affect others
+	cancelPositions()
+	_, ok := <-positions
+	require.False(t, ok)
+	server.send(t, testOrderTradeUpdate)
+	require.NotNil(t, (<-orders).Payload)
+	require.Equal(t, 1, server.connections())
+	started, _, _ := service.state()
+	require.Equal(t, 1, started)
+}
+
+func TestUserDataStreamReconnectsOnceForAllSubscribers(t *testing.T) {
+	server := newUserDataServer(t)
+	service := newFakeListenKeys()
+	s := NewUserDataStream(NewListenKeyManager(service, zap.NewNop()), server.endpoint, BINANCE_FUTURES_PREFIX, zap.NewNop())
+	s.ReconnectOptions = []retry.Option{retry.Attempts(3), retry.Delay(10 * time.Millisecond)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	orders, err := s.WatchOrders(ctx)
+	require.NoError(t, err)
+	positions, err := s.WatchPositions(ctx)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return server.connections() == 1 }, time.Second, 5*time.Millisecond)
+
+	// Binance expires the key, the stream takes a new one
+	server.send(t, `{"e":"listenKeyExpired","E":1}`)
+	require.NotNil(t, (<-orders).Reconnected)
+	require.NotNil(t, (<-positions).Reconnected)
+	require.Equal(t, 2, server.connections())
+	server.mu.Lock()
+	require.Equal(t, []string{"key1", "key2"}, server.keys)
+	server.mu.Unlock()
+
+	server.send(t, testOrderTradeUpdate)
+	require.NotNil(t, (<-orders).Payload)
+
+	// The key is closed after the last subscriber is done
+	cancel()
+	require.Eventually(t, func() bool {
+		_, _, closed := service.state()
+		return len(closed) == 1 && closed[0] == "key2"
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestUserDataStreamDropsSlowSubscriber(t *testing.T) {
+	server := newUserDataServer(t)
+	service := newFakeListenKeys()
+	s := NewUserDataStream(NewListenKeyManager(service, zap.NewNop()), server.endpoint, BINANCE_FUTURES_PREFIX, zap.NewNop())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	orders, err := s.WatchOrders(ctx)
+	require.NoError(t, err)
+	positions, err := s.WatchPositions(ctx)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return server.connections() == 1 }, time.Second, 5*time.Millisecond)
+
+	// Orders aren't read, but positions are still dispatched
+	for i := 0; i < 300; i++ {
+		server.send(t, testOrderTradeUpdate)
+	}
+	server.send(t, testAccountUpdate)
+	select {
+	case position := <-positions:
+		require.Len(t, position.Payload, 1)
+	case <-time.After(5 * time.Second):
+		t.Fatal("dispatch is blocked by slow subscriber")
+	}
+
+	var last error
+	for ev := range orders {
+		if ev.DisconnectedWithErr != nil {
+			last = ev.DisconnectedWithErr
+		}
+	}
+	require.ErrorIs(t, last, ErrUserDataOverflow)
+}
@@ -0,0 +1,114 @@
+package binance
+
+import (
+	"context"
+
+	api "github.com/adshao/go-binance/v2"
+	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/aulaleslie/trade-exchanges/binance/wsapi"
+	"github.com/aulaleslie/trade-exchanges/utils"
+	"github.com/pkg/errors"
+)
+
+func (of *orderFields) wsParams() map[string]string {
+	params := map[string]string{
+		"symbol":           of.Symbol,
+		"side":             string(of.Side),
+		"type":             string(of.Type),
+		"quantity":         of.Quantity,
+		"newClientOrderId": of.NewClientOrderID,
+		"newOrderRespType": string(of.NewOrderRespType),
+	}
+	if of.Type != api.OrderTypeMarket {
+		params["timeInForce"] = string(of.TimeInForce)
+		params["price"] = of.Price
+	}
+	return params
+}
+
+// UseWSAPI sends orders by WebSocket API, REST is used while the socket is down
+func (op *OrderPlacer) UseWSAPI(ws *wsapi.Client) {
+	op.wsAPI = ws
+	op.orderGetter.wsAPI = ws
+}
+
+// placeOrderWS returns `fallback` if the order isn't placed and REST should be used
+func (op *OrderPlacer) placeOrderWS(ctx context.Context, req *orderFields) (id string, fallback bool, e error) {
+	order := api.CreateOrderResponse{}
+	err := op.wsAPI.Do(ctx, "order.place", req.wsParams(), &order)
+	switch {
+	case err == nil:
+		if order.Status == api.OrderStatusTypeRejected {
+			return "", false, errors.Wrap(
+				exchanges.NewOrderRejectedError, "order have status = REJECTED")
+		}
+		return order.ClientOrderID, false, nil
+	case errors.Is(err, wsapi.ErrNotSent):
+		return "", true, nil
+	case errors.Is(err, wsapi.ErrNoResponse):
+		// The order may be placed, so it's placed by REST only if it isn't found
+		placed, getErr := op.orderGetter.GetBinanceOrder(ctx, req.Symbol, req.NewClientOrderID)
+		if errors.Is(getErr, exchanges.OrderNotFoundError) {
+			return "", true, nil
+		}
+		if getErr != nil {
+			return "", false, errors.Wrapf(err, "[Subreason: can't fetch order: %v]", getErr)
+		}
+		if req.Equal(placed) {
+			return placed.ClientOrderID, false, nil
+		}
+		return "", false, errors.Errorf("different order with same ClientOrderID (%s) was placed", req.NewClientOrderID)
+	default:
+		err = op.castToOrderRejecterErrorIfCan(err)
+		return "", false, errors.Wrapf(err, "can't place %s order", string(req.Side))
+	}
+}
+
+// UseWSAPI cancels orders by WebSocket API, REST is used while the socket is down
+func (b *BinanceOrderCanceller) UseWSAPI(ws *wsapi.Client) {
+	b.wsAPI = ws
+	b.orderGetter.wsAPI = ws
+}
+
+// cancelWS returns `fallback` if cancellation should be repeated by REST
+func (b *BinanceOrderCanceller) cancelWS(ctx context.Context, symbol, clientOrderID string) (fallback bool, e error) {
+	result := api.CancelOrderResponse{}
+	err := b.wsAPI.Do(ctx, "order.cancel", map[string]string{
+		"symbol":            symbol,
+		"origClientOrderId": clientOrderID,
+	}, &result)
+	switch {
+	case errors.Is(err, wsapi.ErrNotSent), errors.Is(err, wsapi.ErrNoResponse):
+		return true, nil
+	case b.isNotFoundDuringCancellation(err):
+		return false, utils.ReplaceError(exchanges.OrderNotFoundError, err)
+	case err != nil:
+		return false, err
+	case result.Status != api.OrderStatusTypeCanceled:
+		return false, errors.Errorf("Bad cancellation result status: %v", result.Status)
+	}
+	return false, nil
+}
+
+// UseWSAPI queries orders by WebSocket API, REST is used while the socket is down
+func (og *OrderGetter) UseWSAPI(ws *wsapi.Client) {
+	og.wsAPI = ws
+}
+
+// getOrderWS returns `fallback` if the order should be requested by REST
+func (og *OrderGetter) getOrderWS(ctx context.Context, symbol, clientOrderID string) (order *api.Order, fallback bool, e error) {
+	order = &api.Order{}
+	err := og.wsAPI.Do(ctx, "order.status", map[string]string{
+		"symbol":            symbol,
+		"origClientOrderId": clientOrderID,
+	}, order)
+	switch {
+	case errors.Is(err, wsapi.ErrNotSent), errors.Is(err, wsapi.ErrNoResponse):
+		return nil, true, nil
+	case og.isNotFoundDuringGetOrderStatus(err):
+		return nil, false, utils.ReplaceError(exchanges.OrderNotFoundError, err)
+	case err != nil:
+		return nil, false, err
+	}
+	return order, false, nil
+}
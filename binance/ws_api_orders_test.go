@@ -0,0 +1,83 @@
+package binance
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	api "github.com/adshao/go-binance/v2"
+	"github.com/aulaleslie/trade-exchanges/binance/wsapi"
+	"github.com/aulaleslie/trade-exchanges/utils"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newRESTPlacer returns placer with REST server which records requests and places every order
+func newRESTPlacer(t *testing.T) (*OrderPlacer, func() []string) {
+	var mu sync.Mutex
+	calls := []string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		if r.Method == http.MethodGet {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":-2013,"msg":"Order does not exist."}`))
+			return
+		}
+		w.Write([]byte(`{"symbol":"BTCUSDT","clientOrderId":"id1"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	placer := NewOrderPlacer(&api.Client{BaseURL: srv.URL, HTTPClient: http.DefaultClient})
+	return placer, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, calls...)
+	}
+}
+
+func TestOrderPlacerFallsBackToREST(t *testing.T) {
+	placer, calls := newRESTPlacer(t)
+	placer.UseWSAPI(wsapi.NewClient("ws://127.0.0.1:1", "key", "secret", zap.NewNop()))
+
+	id, err := placer.PlaceOrder(context.Background(), "BTCUSDT",
+		utils.FromString("30000"), utils.FromString("0.01"), "id1", api.SideTypeBuy)
+	require.NoError(t, err)
+	assert.Equal(t, "id1", id)
+	assert.Equal(t, []string{"POST /api/v3/order"}, calls())
+}
+
+func TestOrderPlacerChecksOrderAfterNoResponse(t *testing.T) {
+	// Socket receives requests but never answers
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	placer, calls := newRESTPlacer(t)
+	ws := wsapi.NewClient("ws"+strings.TrimPrefix(srv.URL, "http"), "key", "secret", zap.NewNop())
+	ws.Timeout = 100 * time.Millisecond
+	defer ws.Close()
+	placer.UseWSAPI(ws)
+
+	id, err := placer.PlaceOrder(context.Background(), "BTCUSDT",
+		utils.FromString("30000"), utils.FromString("0.01"), "id1", api.SideTypeBuy)
+	require.NoError(t, err)
+	assert.Equal(t, "id1", id)
+	// The order isn't found, so it's placed by REST only once
+	assert.Equal(t, []string{"GET /api/v3/order", "POST /api/v3/order"}, calls())
+}
@@ -0,0 +1,295 @@
+// Package wsapi sends requests over Binance WebSocket API.
+// https://binance-docs.github.io/apidocs/websocket_api/en/
+package wsapi
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/common"
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+)
+
+// ErrNotSent means the socket is down and the request wasn't sent, it's safe to retry it by REST
+var ErrNotSent = errors.New("request isn't sent by WebSocket API")
+
+// ErrNoResponse means the request was sent but response wasn't received, it may be processed
+var ErrNoResponse = errors.New("no response from WebSocket API")
+
+// Limiter is shared with REST because WebSocket API requests count to the same limits
+type Limiter interface {
+	Wait(ctx context.Context, weight int, isOrder bool) error
+}
+
+// Request weights of WebSocket API, absent methods cost 1
+var methodWeights = map[string]int{
+	"session.logon": 2,
+	"order.status":  4,
+}
+
+// Client keeps one session-authenticated connection and matches responses by request ID.
+// It doesn't reconnect in the background, the next request dials again after RetryInterval.
+type Client struct {
+	// Ed25519 key logs in the session by `session.logon`.
+	// Without it every request is signed by HMAC secret key.
+	PrivateKey    ed25519.PrivateKey // optional
+	Dialer        *websocket.Dialer  // optional
+	Timeout       time.Duration      // optional, response timeout
+	RetryInterval time.Duration      // optional, requests fail with ErrNotSent during it after disconnection
+	TimeOffset    int64              // optional, milliseconds subtracted from timestamps
+	Limiter       Limiter            // optional
+
+	endpoint  string
+	apiKey    string
+	secretKey string
+	lg        *zap.Logger
+	lastID    atomic.Int64
+
+	dialMu   sync.Mutex // Serializes connecting
+	writeMu  sync.Mutex // Serializes writing
+	mu       sync.Mutex
+	conn     *websocket.Conn
+	loggedIn bool
+	pending  map[string]chan response
+	retryAt  time.Time
+}
+
+type request struct {
+	ID     string            `json:"id"`
+	Method string            `json:"method"`
+	Params map[string]string `json:"params,omitempty"`
+}
+
+type response struct {
+	ID     string           `json:"id"`
+	Status int              `json:"status"`
+	Result json.RawMessage  `json:"result"`
+	Error  *common.APIError `json:"error"`
+}
+
+func NewClient(endpoint, apiKey, secretKey string, lg *zap.Logger) *Client {
+	return &Client{
+		Dialer:        websocket.DefaultDialer,
+		Timeout:       10 * time.Second,
+		RetryInterval: 5 * time.Second,
+		endpoint:      endpoint,
+		apiKey:        apiKey,
+		secretKey:     secretKey,
+		lg:            lg.Named("WSAPI"),
+		pending:       map[string]chan response{},
+	}
+}
+
+// Do sends signed request and unmarshals its result into `out`.
+// Errors of Binance are returned as *common.APIError.
+func (c *Client) Do(ctx context.Context, method string, params map[string]string, out interface{}) error {
+	if c.Limiter != nil {
+		weight, ok := methodWeights[method]
+		if !ok {
+			weight = 1
+		}
+		if err := c.Limiter.Wait(ctx, weight, method == "order.place"); err != nil {
+			return err
+		}
+	}
+	conn, loggedIn, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, conn, method, c.sign(params, loggedIn), out)
+}
+
+// Close closes current connection, next request dials again
+func (c *Client) Close() error {
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+	if conn == nil {
+		return nil
+	}
+	return conn.Close()
+}
+
+func (c *Client) connect(ctx context.Context) (*websocket.Conn, bool, error) {
+	c.dialMu.Lock()
+	defer c.dialMu.Unlock()
+
+	c.mu.Lock()
+	conn, loggedIn, retryAt := c.conn, c.loggedIn, c.retryAt
+	c.mu.Unlock()
+	if conn != nil {
+		return conn, loggedIn, nil
+	}
+	if time.Now().Before(retryAt) {
+		return nil, false, errors.Wrap(ErrNotSent, "socket is down")
+	}
+
+	conn, _, err := c.Dialer.DialContext(ctx, c.endpoint, nil)
+	if err != nil {
+		c.disconnected(nil, err)
+		return nil, false, errors.Wrapf(ErrNotSent, "can't connect: %v", err)
+	}
+	c.mu.Lock()
+	c.conn = conn
+	c.loggedIn = false
+	c.mu.Unlock()
+	go c.reader(conn)
+
+	if c.PrivateKey == nil {
+		return conn, false, nil
+	}
+	params := map[string]string{
+		"apiKey":    c.apiKey,
+		"timestamp": c.timestamp(),
+	}
+	params["signature"] = base64.StdEncoding.EncodeToString(ed25519.Sign(c.PrivateKey, []byte(payload(params))))
+	if err := c.do(ctx, conn, "session.logon", params, nil); err != nil {
+		conn.Close()
+		return nil, false, errors.Wrapf(ErrNotSent, "can't log in: %v", err)
+	}
+	c.mu.Lock()
+	c.loggedIn = true
+	c.mu.Unlock()
+	return conn, true, nil
+}
+
+func (c *Client) do(ctx context.Context, conn *websocket.Conn, method string, params map[string]string, out interface{}) error {
+	req := request{
+		ID:     strconv.FormatInt(c.lastID.Inc(), 10),
+		Method: method,
+		Params: params,
+	}
+	ch := make(chan response, 1)
+	c.mu.Lock()
+	c.pending[req.ID] = ch
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, req.ID)
+		c.mu.Unlock()
+	}()
+
+	c.writeMu.Lock()
+	conn.SetWriteDeadline(time.Now().Add(c.Timeout))
+	err := conn.WriteJSON(req)
+	c.writeMu.Unlock()
+	if err != nil {
+		conn.Close()
+		// Part of the frame may be sent
+		return errors.Wrapf(ErrNoResponse, "can't write %s: %v", method, err)
+	}
+
+	timer := time.NewTimer(c.Timeout)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return errors.Wrapf(ErrNoResponse, "%s timed out", method)
+	case resp := <-ch:
+		if resp.ID == "" {
+			return errors.Wrapf(ErrNoResponse, "socket closed during %s", method)
+		}
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if out == nil {
+			return nil
+		}
+		return errors.Wrapf(json.Unmarshal(resp.Result, out), "can't unmarshal result of %s", method)
+	}
+}
+
+// reader delivers responses until the connection fails
+func (c *Client) reader(conn *websocket.Conn) {
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			c.disconnected(conn, err)
+			return
+		}
+		resp := response{}
+		if err := json.Unmarshal(msg, &resp); err != nil {
+			c.lg.Warn("Can't unmarshal response", zap.ByteString("msg", msg), zap.Error(err))
+			continue
+		}
+		c.mu.Lock()
+		ch, ok := c.pending[resp.ID]
+		c.mu.Unlock()
+		if ok {
+			ch <- resp
+		}
+	}
+}
+
+// disconnected fails pending requests, `conn` is nil if dialing failed
+func (c *Client) disconnected(conn *websocket.Conn, err error) {
+	c.lg.Warn("WebSocket API is down, REST is used", zap.Error(err))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if conn != nil && c.conn != conn {
+		return
+	}
+	if conn != nil {
+		conn.Close()
+	}
+	c.conn = nil
+	c.loggedIn = false
+	c.retryAt = time.Now().Add(c.RetryInterval)
+	for id, ch := range c.pending {
+		select {
+		case ch <- response{}:
+		default:
+		}
+		delete(c.pending, id)
+	}
+}
+
+// sign adds key, timestamp and HMAC signature, logged in session needs timestamp only
+func (c *Client) sign(params map[string]string, loggedIn bool) map[string]string {
+	signed := map[string]string{}
+	for k, v := range params {
+		signed[k] = v
+	}
+	signed["timestamp"] = c.timestamp()
+	if loggedIn {
+		return signed
+	}
+	signed["apiKey"] = c.apiKey
+	mac := hmac.New(sha256.New, []byte(c.secretKey))
+	mac.Write([]byte(payload(signed)))
+	signed["signature"] = hex.EncodeToString(mac.Sum(nil))
+	return signed
+}
+
+func (c *Client) timestamp() string {
+	return strconv.FormatInt(time.Now().UnixMilli()-c.TimeOffset, 10)
+}
+
+// payload is signed text: parameters sorted by name
+func payload(params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+params[k])
+	}
+	return strings.Join(parts, "&")
+}
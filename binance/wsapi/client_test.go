@@ -0,0 +1,130 @@
+package wsapi
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adshao/go-binance/v2/common"
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeServer answers requests in reverse order to check correlation by ID
+type fakeServer struct {
+	t      *testing.T
+	handle func(req request) *response // nil means no response
+}
+
+func (f *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+	require.NoError(f.t, err)
+	defer conn.Close()
+
+	var mu sync.Mutex
+	for {
+		req := request{}
+		if err := conn.ReadJSON(&req); err != nil {
+			return
+		}
+		go func() {
+			resp := f.handle(req)
+			if resp == nil {
+				return
+			}
+			resp.ID = req.ID
+			mu.Lock()
+			defer mu.Unlock()
+			conn.WriteJSON(resp)
+		}()
+	}
+}
+
+func newTestClient(t *testing.T, handle func(req request) *response) *Client {
+	srv := httptest.NewServer(&fakeServer{t: t, handle: handle})
+	t.Cleanup(srv.Close)
+	c := NewClient("ws"+strings.TrimPrefix(srv.URL, "http"), "key", "secret", zap.NewNop())
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestClientCorrelatesResponses(t *testing.T) {
+	c := newTestClient(t, func(req request) *response {
+		assert.Equal(t, "key", req.Params["apiKey"])
+		assert.NotEmpty(t, req.Params["signature"])
+		if req.Params["symbol"] == "BAD" {
+			return &response{Status: 400, Error: &common.APIError{Code: -1121, Message: "Invalid symbol."}}
+		}
+		// Later requests are answered first
+		time.Sleep(time.Duration(30-len(req.Params["newClientOrderId"])) * 5 * time.Millisecond)
+		return &response{Status: 200, Result: []byte(`{"clientOrderId":"` + req.Params["newClientOrderId"] + `"}`)}
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			out := struct {
+				ClientOrderID string `json:"clientOrderId"`
+			}{}
+			err := c.Do(context.Background(), "order.place", map[string]string{"newClientOrderId": id}, &out)
+			assert.NoError(t, err)
+			assert.Equal(t, id, out.ClientOrderID)
+		}(strings.Repeat("x", i+1))
+	}
+	wg.Wait()
+
+	err := c.Do(context.Background(), "order.place", map[string]string{"symbol": "BAD"}, nil)
+	apiErr, ok := err.(*common.APIError)
+	require.True(t, ok, "%v", err)
+	assert.Equal(t, int64(-1121), apiErr.Code)
+}
+
+func TestClientFailures(t *testing.T) {
+	c := newTestClient(t, func(req request) *response { return nil })
+	c.Timeout = 100 * time.Millisecond
+
+	err := c.Do(context.Background(), "order.place", nil, nil)
+	assert.True(t, errors.Is(err, ErrNoResponse), "%v", err)
+
+	down := NewClient("ws://127.0.0.1:1", "key", "secret", zap.NewNop())
+	err = down.Do(context.Background(), "order.place", nil, nil)
+	assert.True(t, errors.Is(err, ErrNotSent), "%v", err)
+
+	// REST is used during RetryInterval without dialing again
+	down.Dialer = nil
+	err = down.Do(context.Background(), "order.place", nil, nil)
+	assert.True(t, errors.Is(err, ErrNotSent), "%v", err)
+}
+
+func TestClientSessionLogon(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	c := newTestClient(t, func(req request) *response {
+		if req.Method == "session.logon" {
+			signature, err := base64.StdEncoding.DecodeString(req.Params["signature"])
+			require.NoError(t, err)
+			delete(req.Params, "signature")
+			assert.True(t, ed25519.Verify(public, []byte(payload(req.Params)), signature))
+			return &response{Status: 200, Result: []byte(`{}`)}
+		}
+		assert.NotEmpty(t, req.Params["timestamp"])
+		assert.Empty(t, req.Params["signature"], "logged in session isn't signed")
+		return &response{Status: 200, Result: []byte(`{}`)}
+	})
+	c.PrivateKey = private
+
+	require.NoError(t, c.Do(context.Background(), "order.status", map[string]string{"symbol": "BTCUSDT"}, nil))
+	require.NoError(t, c.Do(context.Background(), "order.status", map[string]string{"symbol": "BTCUSDT"}, nil))
+}
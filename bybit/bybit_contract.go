@@ -9,7 +9,6 @@ import (
 	"net/url"
 	"regexp"
 	"strconv"
-	"strings"
 	"time"
 
 	exchanges "github.com/aulaleslie/trade-exchanges"
@@ -21,28 +20,32 @@ import (
 	"go.uber.org/zap"
 )
 
-const BYBIT_PREFIX = "BYBIT-"
-
 type BybitContract struct {
-	client     *bybit.Client
-	wsClient   *bybit.WebSocketClient
-	httpClient *http.Client
-	lg         *zap.Logger
-	key        string
-	secret     string
+	client      *bybit.Client
+	wsClient    *bybit.WebSocketClient
+	httpClient  *http.Client
+	rateLimiter *BybitRateLimiter
+	lg          *zap.Logger
+	key         string
+	secret      string
+
+	// Resubscribe streams with backoff after the socket failure instead of closing them
+	Resubscribe bool
 }
 
 // TODO: don't forget to check time
 var _ exchanges.Exchange = (*BybitContract)(nil) // Type check
+var _ exchanges.DeadMansSwitchExchange = (*BybitContract)(nil)
 
 func NewBybitContract(apiKey, secretKey, host string, lg *zap.Logger) *BybitContract {
 	lg = lg.Named("Bybit")
 
 	b := &BybitContract{}
 
-	b.client = NewBybitRestClient(apiKey, secretKey, lg)
+	b.rateLimiter = SharedBybitRateLimiter(apiKey, lg)
+	b.client = NewBybitRestClient(apiKey, secretKey, b.rateLimiter, lg)
 	b.wsClient = NewBybitWSClient(apiKey, secretKey, lg)
-	b.httpClient = NewHTTPClient(time.Second * 10)
+	b.httpClient = NewHTTPClient(time.Second*10, b.rateLimiter)
 	b.key = apiKey
 	b.secret = secretKey
 	b.lg = lg
@@ -50,14 +53,6 @@ func NewBybitContract(apiKey, secretKey, host string, lg *zap.Logger) *BybitCont
 	return b
 }
 
-func ToBybitFullSymbol(symbol string) string {
-	return BYBIT_PREFIX + symbol
-}
-
-func ToBybitSymbol(symbol string) string {
-	return strings.TrimPrefix(symbol, BYBIT_PREFIX)
-}
-
 func (b *BybitContract) GetOrders(ctx context.Context, filter exchanges.OrderFilter) (res []exchanges.OrderDetailInfo, err error) {
 	var symbol *bybit.SymbolV5
 
@@ -256,6 +251,12 @@ func (b *BybitContract) CancelOrder(ctx context.Context, symbol, id string) erro
 	return nil
 }
 
+// CancelAllAfter sets disconnect-cancel (DCP) time window, `symbol` is ignored: DCP covers the whole product.
+// Orders are canceled when all private WebSockets (see WatchOrdersStatuses) are disconnected, not by countdown.
+func (b *BybitContract) CancelAllAfter(ctx context.Context, _ string, timeout time.Duration) error {
+	return setDisconnectCancel(ctx, b.httpClient, BybitBaseURL, b.key, b.secret, dcpProductSpot, timeout)
+}
+
 func (b *BybitContract) ReleaseOrder(_ context.Context, symbol, id string) error {
 	// _, err := b.client.V5().Order().
 	return nil
@@ -271,7 +272,7 @@ func (b *BybitContract) GetOrderInfoByClientOrderID(ctx context.Context, symbol,
 	baseURL := BybitBaseURL + GetOrderHistoryPath
 	params := url.Values{}
 	params.Set("category", string(bybit.CategoryV5Spot))
-	params.Set("symbol", symbol)
+	params.Set("symbol", ToBybitSymbol(symbol))
 	params.Set("orderLinkId", clientOrderID)
 
 	reqURL, err := url.Parse(baseURL)
@@ -282,7 +283,7 @@ func (b *BybitContract) GetOrderInfoByClientOrderID(ctx context.Context, symbol,
 
 	signature, timestamp := bybitSignatureGenerator(b.key, b.secret, reqURL.RawQuery)
 
-	req, err := http.NewRequest("GET", reqURL.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL.String(), nil)
 	if err != nil {
 		return orderInfo, errors.Wrap(err, "unable to create http request")
 	}
@@ -355,7 +356,12 @@ func (b *BybitContract) GetTradableSymbols(ctx context.Context) ([]exchanges.Sym
 
 	result := []exchanges.SymbolInfo{}
 	for _, symbol := range res.Result.Spot.List {
-		fullSymbol := ToBybitFullSymbol(string(symbol.Symbol))
+		instrument := exchanges.Instrument{
+			Product: exchanges.SpotProduct,
+			Base:    string(symbol.BaseCoin),
+			Quote:   string(symbol.QuoteCoin),
+		}
+		fullSymbol := exchanges.Instruments.Register(BYBIT_PREFIX, string(symbol.Symbol), instrument)
 		f := &SymbolFilter{
 			LotSizeFilter: symbol.LotSizeFilter,
 			PriceFilter:   symbol.PriceFilter,
@@ -366,6 +372,7 @@ func (b *BybitContract) GetTradableSymbols(ctx context.Context) ([]exchanges.Sym
 			Symbol:         fullSymbol,
 			OriginalSymbol: string(symbol.Symbol),
 			Filters:        []map[string]interface{}{fltrMap},
+			Instrument:     &instrument,
 		}
 		result = append(result, sInfo)
 	}
@@ -374,7 +381,9 @@ func (b *BybitContract) GetTradableSymbols(ctx context.Context) ([]exchanges.Sym
 
 // WatchOrdersStatuses Returns control immediately
 func (b *BybitContract) WatchOrdersStatuses(ctx context.Context) (<-chan exchanges.OrderEvent, error) {
-	return SubscribeToOrders(ctx, b.wsClient, b.lg, bybit.CategoryV5Spot)
+	return watchOrders(ctx, b.Resubscribe, func(ctx context.Context) (<-chan exchanges.OrderEvent, error) {
+		return SubscribeToOrders(ctx, b.wsClient, b.lg, bybit.CategoryV5Spot)
+	}, b.lg)
 }
 
 // // WatchOrdersStatuses Returns control immediately
@@ -392,12 +401,9 @@ func (b *BybitContract) WatchOrdersStatuses(ctx context.Context) (<-chan exchang
 // WatchSymbolPrice
 func (b *BybitContract) WatchSymbolPrice(ctx context.Context, symbol string) (<-chan exchanges.PriceEvent, error) {
 	bybitSymbol := ToBybitSymbol(symbol)
-	in, err := SubscribeToPrices(ctx, b.wsClient, b.lg, bybitSymbol)
-	if err != nil {
-		return nil, err
-	}
-
-	return in, nil
+	return watchPrices(ctx, b.Resubscribe, func(ctx context.Context) (<-chan exchanges.PriceEvent, error) {
+		return SubscribeToPrices(ctx, b.wsClient, b.lg, bybitSymbol)
+	}, b.lg)
 }
 
 // PlaceBuyOrderV2 Place Buy Order with OrderType param
@@ -445,19 +451,9 @@ func (b *BybitContract) PlaceSellOrderV2(ctx context.Context, _ bool, symbol str
 }
 
 func (b *BybitContract) WatchAccountPositions(ctx context.Context) (<-chan exchanges.PositionEvent, error) {
-	in, err := SubscribeToPositions(ctx, b.wsClient, b.lg, bybit.CategoryV5Spot)
-	if err != nil {
-		return nil, err
-	}
-
-	out := make(chan exchanges.PositionEvent, 100)
-	go func() {
-		defer close(out)
-		for ev := range in {
-			out <- ev
-		}
-	}()
-	return out, nil
+	return watchPositions(ctx, b.Resubscribe, func(ctx context.Context) (<-chan exchanges.PositionEvent, error) {
+		return SubscribeToPositions(ctx, b.wsClient, b.lg, bybit.CategoryV5Spot)
+	}, b.lg)
 }
 
 func (b *BybitContract) GenerateClientOrderID(ctx context.Context, identifierID string) (string, error) {
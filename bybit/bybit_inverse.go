@@ -21,24 +21,30 @@ import (
 )
 
 type BybitInverse struct {
-	client     *bybit.Client
-	wsClient   *bybit.WebSocketClient
-	httpClient *http.Client
-	lg         *zap.Logger
-	key        string
-	secret     string
+	client      *bybit.Client
+	wsClient    *bybit.WebSocketClient
+	httpClient  *http.Client
+	rateLimiter *BybitRateLimiter
+	lg          *zap.Logger
+	key         string
+	secret      string
+
+	// Resubscribe streams with backoff after the socket failure instead of closing them
+	Resubscribe bool
 }
 
 var _ exchanges.Exchange = (*BybitInverse)(nil)
+var _ exchanges.DeadMansSwitchExchange = (*BybitInverse)(nil)
 
 func NewBybitInverse(apiKey, secretKey, host string, lg *zap.Logger) *BybitInverse {
 	lg = lg.Named("Bybit")
 
 	b := &BybitInverse{}
 
-	b.client = NewBybitRestClient(apiKey, secretKey, lg)
+	b.rateLimiter = SharedBybitRateLimiter(apiKey, lg)
+	b.client = NewBybitRestClient(apiKey, secretKey, b.rateLimiter, lg)
 	b.wsClient = NewBybitWSClient(apiKey, secretKey, lg)
-	b.httpClient = NewHTTPClient(time.Second * 10)
+	b.httpClient = NewHTTPClient(time.Second*10, b.rateLimiter)
 	b.key = apiKey
 	b.secret = secretKey
 	b.lg = lg
@@ -50,7 +56,7 @@ func (b *BybitInverse) GetOrders(ctx context.Context, filter exchanges.OrderFilt
 	var symbol *bybit.SymbolV5
 
 	if filter.Symbol != nil {
-		bybitSymbol := ToBybitSymbol(*filter.Symbol)
+		bybitSymbol := ToBybitInverseSymbol(*filter.Symbol)
 		symbol = (*bybit.SymbolV5)(&bybitSymbol)
 	}
 
@@ -212,7 +218,7 @@ func (b *BybitInverse) GetAccount(ctx context.Context) (res exchanges.Account, e
 			// liqPrice := utils.FromString(position.LiqPrice)
 
 			accountPositions = append(accountPositions, exchanges.AccountPosition{
-				Symbol:           ToBybitFullSymbol(string(position.Symbol)),
+				Symbol:           ToBybitInverseFullSymbol(string(position.Symbol)),
 				UnrealizedProfit: unrealizedProfit,
 				Leverage:         leverage,
 				EntryPrice:       entryPrice,
@@ -259,7 +265,7 @@ func (b *BybitInverse) RoundQuantity(_ context.Context, symbol string, qty *apd.
 }
 
 func (b *BybitInverse) GetPrefix() string {
-	return BYBIT_PREFIX
+	return BYBIT_INVERSE_PREFIX
 }
 
 func (b *BybitInverse) GetName() string {
@@ -280,7 +286,7 @@ func (b *BybitInverse) PlaceSellOrder(ctx context.Context,
 
 func (b *BybitInverse) CancelOrder(ctx context.Context, symbol, id string) error {
 	input := bybit.V5CancelOrderParam{
-		Symbol:      bybit.SymbolV5(ToBybitSymbol(symbol)),
+		Symbol:      bybit.SymbolV5(ToBybitInverseSymbol(symbol)),
 		OrderLinkID: &id,
 		Category:    bybit.CategoryV5Inverse,
 	}
@@ -290,7 +296,7 @@ func (b *BybitInverse) CancelOrder(ctx context.Context, symbol, id string) error
 		err = nil
 		// try to cancel with order id
 		input = bybit.V5CancelOrderParam{
-			Symbol:   bybit.SymbolV5(ToBybitSymbol(symbol)),
+			Symbol:   bybit.SymbolV5(ToBybitInverseSymbol(symbol)),
 			OrderID:  &id,
 			Category: bybit.CategoryV5Inverse,
 		}
@@ -304,6 +310,12 @@ func (b *BybitInverse) CancelOrder(ctx context.Context, symbol, id string) error
 	return nil
 }
 
+// CancelAllAfter sets disconnect-cancel (DCP) time window, `symbol` is ignored: DCP covers the whole product.
+// Orders are canceled when all private WebSockets (see WatchOrdersStatuses) are disconnected, not by countdown.
+func (b *BybitInverse) CancelAllAfter(ctx context.Context, _ string, timeout time.Duration) error {
+	return setDisconnectCancel(ctx, b.httpClient, BybitBaseURL, b.key, b.secret, dcpProductDerivatives, timeout)
+}
+
 func (b *BybitInverse) ReleaseOrder(_ context.Context, symbol, id string) error {
 	// _, err := b.client.V5().Order().
 	return nil
@@ -321,7 +333,7 @@ func (b *BybitInverse) GetOrderInfoByClientOrderID(ctx context.Context, symbol,
 	baseURL := BybitBaseURL + GetOrderHistoryPath
 	params := url.Values{}
 	params.Set("category", string(bybit.CategoryV5Inverse))
-	params.Set("symbol", symbol)
+	params.Set("symbol", ToBybitInverseSymbol(symbol))
 	params.Set("orderLinkId", clientOrderID)
 
 	reqURL, err := url.Parse(baseURL)
@@ -332,7 +344,7 @@ func (b *BybitInverse) GetOrderInfoByClientOrderID(ctx context.Context, symbol,
 
 	signature, timestamp := bybitSignatureGenerator(b.key, b.secret, reqURL.RawQuery)
 
-	req, err := http.NewRequest("GET", reqURL.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL.String(), nil)
 	if err != nil {
 		return orderInfo, errors.Wrap(err, "unable to create http request")
 	}
@@ -372,7 +384,7 @@ func (b *BybitInverse) GetOrderInfoByClientOrderID(ctx context.Context, symbol,
 }
 
 func (b *BybitInverse) GetPrice(ctx context.Context, symbol string) (*apd.Decimal, error) {
-	bybitSmbl := ToBybitSymbol(symbol)
+	bybitSmbl := ToBybitInverseSymbol(symbol)
 
 	symbl := bybit.SymbolV5(bybitSmbl)
 	input := bybit.V5GetTickersParam{
@@ -398,7 +410,8 @@ func (b *BybitInverse) GetTradableSymbols(ctx context.Context) ([]exchanges.Symb
 
 	result := []exchanges.SymbolInfo{}
 	for _, symbol := range res.Result.LinearInverse.List {
-		fullSymbol := ToBybitFullSymbol(string(symbol.Symbol))
+		instrument := linearInverseInstrument(symbol.ContractType, string(symbol.BaseCoin), string(symbol.QuoteCoin), string(symbol.SettleCoin), symbol.DeliveryTime)
+		fullSymbol := exchanges.Instruments.Register(BYBIT_INVERSE_PREFIX, string(symbol.Symbol), instrument)
 		f := &SymbolInverseFilter{
 			LotSizeFilter: symbol.LotSizeFilter,
 			PriceFilter:   symbol.PriceFilter,
@@ -409,6 +422,7 @@ func (b *BybitInverse) GetTradableSymbols(ctx context.Context) ([]exchanges.Symb
 			Symbol:         fullSymbol,
 			OriginalSymbol: string(symbol.Symbol),
 			Filters:        []map[string]interface{}{fltrMap},
+			Instrument:     &instrument,
 		}
 		result = append(result, sInfo)
 	}
@@ -417,7 +431,9 @@ func (b *BybitInverse) GetTradableSymbols(ctx context.Context) ([]exchanges.Symb
 
 // WatchOrdersStatuses Returns control immediately
 func (b *BybitInverse) WatchOrdersStatuses(ctx context.Context) (<-chan exchanges.OrderEvent, error) {
-	return SubscribeToOrders(ctx, b.wsClient, b.lg, bybit.CategoryV5Inverse)
+	return watchOrders(ctx, b.Resubscribe, func(ctx context.Context) (<-chan exchanges.OrderEvent, error) {
+		return SubscribeToOrders(ctx, b.wsClient, b.lg, bybit.CategoryV5Inverse)
+	}, b.lg)
 }
 
 // // WatchOrdersStatuses Returns control immediately
@@ -434,20 +450,17 @@ func (b *BybitInverse) WatchOrdersStatuses(ctx context.Context) (<-chan exchange
 
 // WatchSymbolPrice
 func (b *BybitInverse) WatchSymbolPrice(ctx context.Context, symbol string) (<-chan exchanges.PriceEvent, error) {
-	bybitSymbol := ToBybitSymbol(symbol)
-	in, err := SubscribeToPricesInverse(ctx, b.wsClient, b.lg, bybitSymbol)
-	if err != nil {
-		return nil, err
-	}
-
-	return in, nil
+	bybitSymbol := ToBybitInverseSymbol(symbol)
+	return watchPrices(ctx, b.Resubscribe, func(ctx context.Context) (<-chan exchanges.PriceEvent, error) {
+		return SubscribeToPricesInverse(ctx, b.wsClient, b.lg, bybitSymbol)
+	}, b.lg)
 }
 
 // PlaceBuyOrderV2 Place Buy Order with OrderType param
 func (b *BybitInverse) PlaceBuyOrderV2(ctx context.Context, _ bool, symbol string, price, qty *apd.Decimal, preferredID string, orderType string) (id string, e error) {
 	qttString := utils.ToFlatString(qty)
 	priceString := utils.ToFlatString(price)
-	symbol = ToBybitSymbol(symbol)
+	symbol = ToBybitInverseSymbol(symbol)
 	input := bybit.V5CreateOrderParam{
 		Category:    bybit.CategoryV5Inverse,
 		Symbol:      bybit.SymbolV5(symbol),
@@ -469,7 +482,7 @@ func (b *BybitInverse) PlaceBuyOrderV2(ctx context.Context, _ bool, symbol strin
 func (b *BybitInverse) PlaceSellOrderV2(ctx context.Context, _ bool, symbol string, price, qty *apd.Decimal, preferredID string, orderType string) (id string, e error) {
 	qttString := utils.ToFlatString(qty)
 	priceString := utils.ToFlatString(price)
-	symbol = ToBybitSymbol(symbol)
+	symbol = ToBybitInverseSymbol(symbol)
 	input := bybit.V5CreateOrderParam{
 		Category:    bybit.CategoryV5Inverse,
 		Symbol:      bybit.SymbolV5(symbol),
@@ -488,19 +501,9 @@ func (b *BybitInverse) PlaceSellOrderV2(ctx context.Context, _ bool, symbol stri
 }
 
 func (b *BybitInverse) WatchAccountPositions(ctx context.Context) (<-chan exchanges.PositionEvent, error) {
-	in, err := SubscribeToPositions(ctx, b.wsClient, b.lg, bybit.CategoryV5Inverse)
-	if err != nil {
-		return nil, err
-	}
-
-	out := make(chan exchanges.PositionEvent, 100)
-	go func() {
-		defer close(out)
-		for ev := range in {
-			out <- ev
-		}
-	}()
-	return out, nil
+	return watchPositions(ctx, b.Resubscribe, func(ctx context.Context) (<-chan exchanges.PositionEvent, error) {
+		return SubscribeToPositions(ctx, b.wsClient, b.lg, bybit.CategoryV5Inverse)
+	}, b.lg)
 }
 
 func (b *BybitInverse) GenerateClientOrderID(ctx context.Context, identifierID string) (string, error) {
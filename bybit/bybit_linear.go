@@ -21,24 +21,30 @@ import (
 )
 
 type BybitLinear struct {
-	client     *bybit.Client
-	wsClient   *bybit.WebSocketClient
-	httpClient *http.Client
-	lg         *zap.Logger
-	key        string
-	secret     string
+	client      *bybit.Client
+	wsClient    *bybit.WebSocketClient
+	httpClient  *http.Client
+	rateLimiter *BybitRateLimiter
+	lg          *zap.Logger
+	key         string
+	secret      string
+
+	// Resubscribe streams with backoff after the socket failure instead of closing them
+	Resubscribe bool
 }
 
 var _ exchanges.Exchange = (*BybitLinear)(nil)
+var _ exchanges.DeadMansSwitchExchange = (*BybitLinear)(nil)
 
 func NewBybitLinear(apiKey, secretKey, host string, lg *zap.Logger) *BybitLinear {
 	lg = lg.Named("Bybit")
 
 	b := &BybitLinear{}
 
-	b.client = NewBybitRestClient(apiKey, secretKey, lg)
+	b.rateLimiter = SharedBybitRateLimiter(apiKey, lg)
+	b.client = NewBybitRestClient(apiKey, secretKey, b.rateLimiter, lg)
 	b.wsClient = NewBybitWSClient(apiKey, secretKey, lg)
-	b.httpClient = NewHTTPClient(time.Second * 10)
+	b.httpClient = NewHTTPClient(time.Second*10, b.rateLimiter)
 	b.key = apiKey
 	b.secret = secretKey
 	b.lg = lg
@@ -50,7 +56,7 @@ func (b *BybitLinear) GetOrders(ctx context.Context, filter exchanges.OrderFilte
 	var symbol *bybit.SymbolV5
 
 	if filter.Symbol != nil {
-		bybitSymbol := ToBybitSymbol(*filter.Symbol)
+		bybitSymbol := ToBybitLinearSymbol(*filter.Symbol)
 		symbol = (*bybit.SymbolV5)(&bybitSymbol)
 	}
 
@@ -192,7 +198,7 @@ func (b *BybitLinear) GetAccount(ctx context.Context) (res exchanges.Account, er
 		liqPrice := utils.FromString(position.LiqPrice)
 
 		accountPositions = append(accountPositions, exchanges.AccountPosition{
-			Symbol:           ToBybitFullSymbol(string(position.Symbol)),
+			Symbol:           ToBybitLinearFullSymbol(string(position.Symbol)),
 			UnrealizedProfit: unrealizedProfit,
 			Leverage:         leverage,
 			EntryPrice:       entryPrice,
@@ -239,7 +245,7 @@ func (b *BybitLinear) RoundQuantity(_ context.Context, symbol string, qty *apd.D
 }
 
 func (b *BybitLinear) GetPrefix() string {
-	return BYBIT_PREFIX
+	return BYBIT_LINEAR_PREFIX
 }
 
 func (b *BybitLinear) GetName() string {
@@ -260,7 +266,7 @@ func (b *BybitLinear) PlaceSellOrder(ctx context.Context,
 
 func (b *BybitLinear) CancelOrder(ctx context.Context, symbol, id string) error {
 	input := bybit.V5CancelOrderParam{
-		Symbol:      bybit.SymbolV5(ToBybitSymbol(symbol)),
+		Symbol:      bybit.SymbolV5(ToBybitLinearSymbol(symbol)),
 		OrderLinkID: &id,
 		Category:    bybit.CategoryV5Linear,
 	}
@@ -270,7 +276,7 @@ func (b *BybitLinear) CancelOrder(ctx context.Context, symbol, id string) error
 		err = nil
 		// try to cancel with order id
 		input = bybit.V5CancelOrderParam{
-			Symbol:   bybit.SymbolV5(ToBybitSymbol(symbol)),
+			Symbol:   bybit.SymbolV5(ToBybitLinearSymbol(symbol)),
 			OrderID:  &id,
 			Category: bybit.CategoryV5Linear,
 		}
@@ -284,6 +290,12 @@ func (b *BybitLinear) CancelOrder(ctx context.Context, symbol, id string) error
 	return nil
 }
 
+// CancelAllAfter sets disconnect-cancel (DCP) time window, `symbol` is ignored: DCP covers the whole product.
+// Orders are canceled when all private WebSockets (see WatchOrdersStatuses) are disconnected, not by countdown.
+func (b *BybitLinear) CancelAllAfter(ctx context.Context, _ string, timeout time.Duration) error {
+	return setDisconnectCancel(ctx, b.httpClient, BybitBaseURL, b.key, b.secret, dcpProductDerivatives, timeout)
+}
+
 func (b *BybitLinear) ReleaseOrder(_ context.Context, symbol, id string) error {
 	// _, err := b.client.V5().Order().
 	return nil
@@ -301,7 +313,7 @@ func (b *BybitLinear) GetOrderInfoByClientOrderID(ctx context.Context, symbol, c
 	baseURL := BybitBaseURL + GetOrderHistoryPath
 	params := url.Values{}
 	params.Set("category", string(bybit.CategoryV5Linear))
-	params.Set("symbol", symbol)
+	params.Set("symbol", ToBybitLinearSymbol(symbol))
 	params.Set("orderLinkId", clientOrderID)
 
 	reqURL, err := url.Parse(baseURL)
@@ -312,7 +324,7 @@ func (b *BybitLinear) GetOrderInfoByClientOrderID(ctx context.Context, symbol, c
 
 	signature, timestamp := bybitSignatureGenerator(b.key, b.secret, reqURL.RawQuery)
 
-	req, err := http.NewRequest("GET", reqURL.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL.String(), nil)
 	if err != nil {
 		return orderInfo, errors.Wrap(err, "unable to create http request")
 	}
@@ -352,7 +364,7 @@ func (b *BybitLinear) GetOrderInfoByClientOrderID(ctx context.Context, symbol, c
 }
 
 func (b *BybitLinear) GetPrice(ctx context.Context, symbol string) (*apd.Decimal, error) {
-	bybitSmbl := ToBybitSymbol(symbol)
+	bybitSmbl := ToBybitLinearSymbol(symbol)
 
 	symbl := bybit.SymbolV5(bybitSmbl)
 	input := bybit.V5GetTickersParam{
@@ -378,7 +390,8 @@ func (b *BybitLinear) GetTradableSymbols(ctx context.Context) ([]exchanges.Symbo
 
 	result := []exchanges.SymbolInfo{}
 	for _, symbol := range res.Result.LinearInverse.List {
-		fullSymbol := ToBybitFullSymbol(string(symbol.Symbol))
+		instrument := linearInverseInstrument(symbol.ContractType, string(symbol.BaseCoin), string(symbol.QuoteCoin), string(symbol.SettleCoin), symbol.DeliveryTime)
+		fullSymbol := exchanges.Instruments.Register(BYBIT_LINEAR_PREFIX, string(symbol.Symbol), instrument)
 		f := &SymbolInverseFilter{
 			LotSizeFilter: symbol.LotSizeFilter,
 			PriceFilter:   symbol.PriceFilter,
@@ -389,6 +402,7 @@ func (b *BybitLinear) GetTradableSymbols(ctx context.Context) ([]exchanges.Symbo
 			Symbol:         fullSymbol,
 			OriginalSymbol: string(symbol.Symbol),
 			Filters:        []map[string]interface{}{fltrMap},
+			Instrument:     &instrument,
 		}
 		result = append(result, sInfo)
 	}
@@ -397,7 +411,9 @@ func (b *BybitLinear) GetTradableSymbols(ctx context.Context) ([]exchanges.Symbo
 
 // WatchOrdersStatuses Returns control immediately
 func (b *BybitLinear) WatchOrdersStatuses(ctx context.Context) (<-chan exchanges.OrderEvent, error) {
-	return SubscribeToOrders(ctx, b.wsClient, b.lg, bybit.CategoryV5Linear)
+	return watchOrders(ctx, b.Resubscribe, func(ctx context.Context) (<-chan exchanges.OrderEvent, error) {
+		return SubscribeToOrders(ctx, b.wsClient, b.lg, bybit.CategoryV5Linear)
+	}, b.lg)
 }
 
 // // WatchOrdersStatuses Returns control immediately
@@ -414,20 +430,17 @@ func (b *BybitLinear) WatchOrdersStatuses(ctx context.Context) (<-chan exchanges
 
 // WatchSymbolPrice
 func (b *BybitLinear) WatchSymbolPrice(ctx context.Context, symbol string) (<-chan exchanges.PriceEvent, error) {
-	bybitSymbol := ToBybitSymbol(symbol)
-	in, err := SubscribeToPricesLinear(ctx, b.wsClient, b.lg, bybitSymbol)
-	if err != nil {
-		return nil, err
-	}
-
-	return in, nil
+	bybitSymbol := ToBybitLinearSymbol(symbol)
+	return watchPrices(ctx, b.Resubscribe, func(ctx context.Context) (<-chan exchanges.PriceEvent, error) {
+		return SubscribeToPricesLinear(ctx, b.wsClient, b.lg, bybitSymbol)
+	}, b.lg)
 }
 
 // PlaceBuyOrderV2 Place Buy Order with OrderType param
 func (b *BybitLinear) PlaceBuyOrderV2(ctx context.Context, _ bool, symbol string, price, qty *apd.Decimal, preferredID string, orderType string) (id string, e error) {
 	qttString := utils.ToFlatString(qty)
 	priceString := utils.ToFlatString(price)
-	symbol = ToBybitSymbol(symbol)
+	symbol = ToBybitLinearSymbol(symbol)
 	input := bybit.V5CreateOrderParam{
 		Category:    bybit.CategoryV5Linear,
 		Symbol:      bybit.SymbolV5(symbol),
@@ -449,7 +462,7 @@ func (b *BybitLinear) PlaceBuyOrderV2(ctx context.Context, _ bool, symbol string
 func (b *BybitLinear) PlaceSellOrderV2(ctx context.Context, _ bool, symbol string, price, qty *apd.Decimal, preferredID string, orderType string) (id string, e error) {
 	qttString := utils.ToFlatString(qty)
 	priceString := utils.ToFlatString(price)
-	symbol = ToBybitSymbol(symbol)
+	symbol = ToBybitLinearSymbol(symbol)
 	input := bybit.V5CreateOrderParam{
 		Category:    bybit.CategoryV5Linear,
 		Symbol:      bybit.SymbolV5(symbol),
@@ -468,19 +481,9 @@ func (b *BybitLinear) PlaceSellOrderV2(ctx context.Context, _ bool, symbol strin
 }
 
 func (b *BybitLinear) WatchAccountPositions(ctx context.Context) (<-chan exchanges.PositionEvent, error) {
-	in, err := SubscribeToPositions(ctx, b.wsClient, b.lg, bybit.CategoryV5Linear)
-	if err != nil {
-		return nil, err
-	}
-
-	out := make(chan exchanges.PositionEvent, 100)
-	go func() {
-		defer close(out)
-		for ev := range in {
-			out <- ev
-		}
-	}()
-	return out, nil
+	return watchPositions(ctx, b.Resubscribe, func(ctx context.Context) (<-chan exchanges.PositionEvent, error) {
+		return SubscribeToPositions(ctx, b.wsClient, b.lg, bybit.CategoryV5Linear)
+	}, b.lg)
 }
 
 func (b *BybitLinear) GenerateClientOrderID(ctx context.Context, identifierID string) (string, error) {
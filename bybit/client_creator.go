@@ -8,8 +8,11 @@ import (
 	"go.uber.org/zap"
 )
 
-func NewBybitRestClient(apiKey, secretKey string, l *zap.Logger) *bybit.Client {
+func NewBybitRestClient(apiKey, secretKey string, lim *BybitRateLimiter, l *zap.Logger) *bybit.Client {
 	client := bybit.NewClient().WithAuth(apiKey, secretKey)
+	if lim != nil {
+		client = client.WithHTTPClient(&http.Client{Transport: lim.Transport(nil)})
+	}
 
 	return client
 }
@@ -20,9 +23,12 @@ func NewBybitWSClient(apiKey, secretKey string, l *zap.Logger) *bybit.WebSocketC
 	return wsClient
 }
 
-func NewHTTPClient(timeout time.Duration) *http.Client {
+func NewHTTPClient(timeout time.Duration, lim *BybitRateLimiter) *http.Client {
 	client := &http.Client{
 		Timeout: timeout, // Example: set a timeout of 10 seconds
 	}
+	if lim != nil {
+		client.Transport = lim.Transport(nil)
+	}
 	return client
 }
@@ -12,8 +12,9 @@ import (
 )
 
 const (
-	GetOrderHistoryPath = "/v5/order/history"
-	BybitBaseURL        = "https://api.bybit.com"
+	GetOrderHistoryPath       = "/v5/order/history"
+	DisconnectedCancelAllPath = "/v5/order/disconnected-cancel-all"
+	BybitBaseURL              = "https://api.bybit.com"
 )
 
 var recWindow = "5000"
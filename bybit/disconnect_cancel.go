@@ -0,0 +1,78 @@
+package bybit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/pkg/errors"
+)
+
+// Products of disconnect-cancel (DCP)
+const (
+	dcpProductSpot        = "SPOT"
+	dcpProductDerivatives = "DERIVATIVES"
+)
+
+const (
+	dcpMinTimeWindow = 3 * time.Second
+	dcpMaxTimeWindow = 300 * time.Second
+)
+
+// setDisconnectCancel sets DCP time window of the product: Bybit cancels all orders of
+// the product when all private WebSockets are disconnected for `timeout`.
+// DCP can't be disabled by API, so zero `timeout` returns ErrCannotDisarm.
+func setDisconnectCancel(
+	ctx context.Context, httpClient *http.Client, baseURL, key, secret, product string, timeout time.Duration,
+) error {
+	if timeout == 0 {
+		return exchanges.ErrCannotDisarm
+	}
+	if timeout < dcpMinTimeWindow || timeout > dcpMaxTimeWindow {
+		return errors.Errorf("DCP time window should be in [%v, %v], got %v", dcpMinTimeWindow, dcpMaxTimeWindow, timeout)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"product":    product,
+		"timeWindow": int(timeout / time.Second),
+	})
+	if err != nil {
+		return errors.Wrap(err, "can't marshal request")
+	}
+	signature, timestamp := bybitSignatureGenerator(key, secret, string(body))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+DisconnectedCancelAllPath, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "unable to create http request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-BAPI-SIGN-TYPE", "2")
+	req.Header.Set("X-BAPI-SIGN", signature)
+	req.Header.Set("X-BAPI-API-KEY", key)
+	req.Header.Set("X-BAPI-TIMESTAMP", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-BAPI-RECV-WINDOW", recWindow)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "unable to set disconnect-cancel")
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "unable to read response of disconnect-cancel")
+	}
+	var res BybitResponse
+	if err := json.Unmarshal(data, &res); err != nil {
+		return errors.Wrapf(err, "unable to unmarshall response of disconnect-cancel (status %d)", resp.StatusCode)
+	}
+	if res.RetCode != 0 {
+		return errors.Errorf("can't set disconnect-cancel: %s (code %d)", res.RetMsg, res.RetCode)
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+package bybit
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetDisconnectCancelCantDisarm(t *testing.T) {
+	// DCP can't be turned off, no request is sent
+	err := setDisconnectCancel(context.Background(), http.DefaultClient, "http://127.0.0.1:0", "key", "secret", dcpProductDerivatives, 0)
+	assert.ErrorIs(t, err, exchanges.ErrCannotDisarm)
+}
@@ -10,15 +10,19 @@ import (
 	"go.uber.org/zap"
 )
 
+// SubscribeToOrders returns control immediately. The socket is closed when `ctx` is done,
+// on the socket failure DisconnectedWithErr is sent and the channel is closed.
 func SubscribeToOrders(ctx context.Context, wsClient *bybit.WebSocketClient, lg *zap.Logger, category bybit.CategoryV5) (<-chan exchanges.OrderEvent, error) {
 	svc, err := wsClient.V5().Private()
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to create V5 service")
 	}
-	out := make(chan exchanges.OrderEvent, 100)
+	out := make(chan exchanges.OrderEvent, 100) // TODO: move to config
+	stream := &wsStream{ctx: ctx}
 
 	err = svc.Subscribe()
 	if err != nil {
+		_ = svc.Close()
 		return nil, errors.Wrap(err, "unable to subscribe V5 service")
 	}
 
@@ -27,7 +31,7 @@ func SubscribeToOrders(ctx context.Context, wsClient *bybit.WebSocketClient, lg
 
 			if orderData.Category == string(category) {
 				orderID := fmt.Sprintf("%v", orderData.OrderID)
-				symbol := ToBybitFullSymbol(string(orderData.Symbol))
+				symbol := toFullSymbolByCategory(category, string(orderData.Symbol))
 
 				if orderData.OrderLinkID != "" {
 					orderID = orderData.OrderLinkID
@@ -41,32 +45,32 @@ func SubscribeToOrders(ctx context.Context, wsClient *bybit.WebSocketClient, lg
 					string(orderData.OrderStatus),
 					string(symbol),
 				)
-				out <- exchanges.OrderEvent{Payload: &exchanges.OrderEventPayload{
+				stream.sendOrder(out, exchanges.OrderEvent{Payload: &exchanges.OrderEventPayload{
 					OrderID:     orderID,
 					OrderStatus: orderStatus,
 					Symbol:      &symbol,
-				}}
+				}})
 			}
 		}
-		return err
+		return nil
 	})
-
 	if err != nil {
-		out <- exchanges.OrderEvent{DisconnectedWithErr: fmt.Errorf("connection error %v", err)}
-		defer close(out)
-
-		// handle registration error
-		lg.Sugar().Info("registration connection", err.Error())
-	}
-
-	errHandler := func(isWebsocketClosed bool, err error) {
-		// Connection issue (timeout, etc.).
-		// TODO: At this point, the connection is dead and you must handle the reconnection yourself
-		lg.Sugar().Info("err handler called", err.Error())
+		_ = svc.Close()
+		return nil, errors.Wrap(err, "unable to subscribe to orders")
 	}
 
-	go svc.Start(context.Background(), errHandler)
+	go func() {
+		err := runWS(ctx, svc, lg)
+		stream.finish(func() {
+			if err != nil {
+				select {
+				case out <- exchanges.OrderEvent{DisconnectedWithErr: err}:
+				case <-ctx.Done():
+				}
+			}
+			close(out)
+		})
+	}()
 
-	lg.Sugar().Info("leaving the Subscribe to Order")
 	return out, nil
 }
@@ -2,7 +2,6 @@ package bybit
 
 import (
 	"context"
-	"fmt"
 
 	exchanges "github.com/aulaleslie/trade-exchanges"
 	"github.com/aulaleslie/trade-exchanges/utils"
@@ -11,15 +10,19 @@ import (
 	"go.uber.org/zap"
 )
 
+// SubscribeToPositions returns control immediately. The socket is closed when `ctx` is done,
+// on the socket failure DisconnectedWithErr is sent and the channel is closed.
 func SubscribeToPositions(ctx context.Context, wsClient *bybit.WebSocketClient, lg *zap.Logger, category bybit.CategoryV5) (<-chan exchanges.PositionEvent, error) {
 	svc, err := wsClient.V5().Private()
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to create V5 service")
 	}
-	out := make(chan exchanges.PositionEvent, 100)
+	out := make(chan exchanges.PositionEvent, 100) // TODO: move to config
+	stream := &wsStream{ctx: ctx}
 
 	err = svc.Subscribe()
 	if err != nil {
+		_ = svc.Close()
 		return nil, errors.Wrap(err, "unable to subscribe V5 service")
 	}
 
@@ -28,7 +31,7 @@ func SubscribeToPositions(ctx context.Context, wsClient *bybit.WebSocketClient,
 		for _, positionData := range response.Data {
 
 			if string(positionData.Category) == string(category) {
-				symbol := fmt.Sprintf("%v", positionData.Symbol)
+				symbol := toFullSymbolByCategory(category, string(positionData.Symbol))
 
 				lg.Sugar().Infof("Get bybit position from websocket with symbol: %v",
 					string(symbol),
@@ -39,28 +42,29 @@ func SubscribeToPositions(ctx context.Context, wsClient *bybit.WebSocketClient,
 				}
 
 				payloads = append(payloads, positionPayload)
-				out <- exchanges.PositionEvent{
-					Payload: payloads}
+				stream.sendPosition(out, exchanges.PositionEvent{
+					Payload: payloads})
 			}
 		}
-		return err
+		return nil
 	})
-
 	if err != nil {
-		out <- exchanges.PositionEvent{DisconnectedWithErr: fmt.Errorf("connection error %v", err)}
-		defer close(out)
-
-		// handle registration error
-		lg.Sugar().Info("registration connection", err.Error())
-	}
-
-	errHandler := func(isWebsocketClosed bool, err error) {
-		// Connection issue (timeout, etc.).
-		// TODO: At this point, the connection is dead and you must handle the reconnection yourself
-		lg.Sugar().Info("err handler called", err.Error())
+		_ = svc.Close()
+		return nil, errors.Wrap(err, "unable to subscribe to positions")
 	}
 
-	go svc.Start(context.Background(), errHandler)
+	go func() {
+		err := runWS(ctx, svc, lg)
+		stream.finish(func() {
+			if err != nil {
+				select {
+				case out <- exchanges.PositionEvent{DisconnectedWithErr: err}:
+				case <-ctx.Done():
+				}
+			}
+			close(out)
+		})
+	}()
 
 	return out, nil
 }
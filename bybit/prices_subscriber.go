@@ -2,7 +2,6 @@ package bybit
 
 import (
 	"context"
-	"fmt"
 
 	exchanges "github.com/aulaleslie/trade-exchanges"
 	"github.com/aulaleslie/trade-exchanges/utils"
@@ -12,127 +11,65 @@ import (
 )
 
 func SubscribeToPrices(ctx context.Context, wsClient *bybit.WebSocketClient, lg *zap.Logger, symbol string) (<-chan exchanges.PriceEvent, error) {
-	svc, err := wsClient.V5().Public(bybit.CategoryV5Spot)
-	if err != nil {
-		return nil, errors.Wrap(err, "unable to create V5 service")
-	}
-	out := make(chan exchanges.PriceEvent, 100)
-
-	_, err = svc.SubscribeTicker(
-		bybit.V5WebsocketPublicTickerParamKey{
-			Symbol: bybit.SymbolV5(symbol),
-		},
-		func(response bybit.V5WebsocketPublicTickerResponse) error {
-			priceData := response.Data.Spot
-
-			if priceData.LastPrice != "" {
-				price := utils.FromString(priceData.LastPrice)
-				out <- exchanges.PriceEvent{
-					Payload: price}
-			}
-
-			return err
-		})
-
-	if err != nil {
-		out <- exchanges.PriceEvent{DisconnectedWithErr: fmt.Errorf("connection error %v", err)}
-		defer close(out)
-
-		// handle registration error
-		lg.Sugar().Info("registration connection", err.Error())
-	}
-
-	errHandler := func(isWebsocketClosed bool, err error) {
-		// Connection issue (timeout, etc.).
-		// TODO: At this point, the connection is dead and you must handle the reconnection yourself
-		lg.Sugar().Info("err handler called", err.Error())
-	}
-
-	go svc.Start(context.Background(), errHandler)
-
-	return out, nil
+	return subscribeToPrices(ctx, wsClient, lg, bybit.CategoryV5Spot, symbol)
 }
 
 func SubscribeToPricesInverse(ctx context.Context, wsClient *bybit.WebSocketClient, lg *zap.Logger, symbol string) (<-chan exchanges.PriceEvent, error) {
-	svc, err := wsClient.V5().Public(bybit.CategoryV5Inverse)
-	if err != nil {
-		return nil, errors.Wrap(err, "unable to create V5 service")
-	}
-	out := make(chan exchanges.PriceEvent, 100)
-
-	_, err = svc.SubscribeTicker(
-		bybit.V5WebsocketPublicTickerParamKey{
-			Symbol: bybit.SymbolV5(symbol),
-		},
-		func(response bybit.V5WebsocketPublicTickerResponse) error {
-			priceData := response.Data.LinearInverse
-
-			if priceData.LastPrice != "" {
-				price := utils.FromString(priceData.LastPrice)
-				out <- exchanges.PriceEvent{
-					Payload: price}
-			}
-
-			return err
-		})
-
-	if err != nil {
-		out <- exchanges.PriceEvent{DisconnectedWithErr: fmt.Errorf("connection error %v", err)}
-		defer close(out)
-
-		// handle registration error
-		lg.Sugar().Info("registration connection", err.Error())
-	}
-
-	errHandler := func(isWebsocketClosed bool, err error) {
-		// Connection issue (timeout, etc.).
-		// TODO: At this point, the connection is dead and you must handle the reconnection yourself
-		lg.Sugar().Info("err handler called", err.Error())
-	}
-
-	go svc.Start(context.Background(), errHandler)
-
-	return out, nil
+	return subscribeToPrices(ctx, wsClient, lg, bybit.CategoryV5Inverse, symbol)
 }
 
 func SubscribeToPricesLinear(ctx context.Context, wsClient *bybit.WebSocketClient, lg *zap.Logger, symbol string) (<-chan exchanges.PriceEvent, error) {
-	svc, err := wsClient.V5().Public(bybit.CategoryV5Linear)
+	return subscribeToPrices(ctx, wsClient, lg, bybit.CategoryV5Linear, symbol)
+}
+
+// subscribeToPrices returns control immediately. The socket is closed when `ctx` is done,
+// on the socket failure DisconnectedWithErr is sent and the channel is closed.
+func subscribeToPrices(
+	ctx context.Context, wsClient *bybit.WebSocketClient, lg *zap.Logger, category bybit.CategoryV5, symbol string,
+) (<-chan exchanges.PriceEvent, error) {
+	svc, err := wsClient.V5().Public(category)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to create V5 service")
 	}
-	out := make(chan exchanges.PriceEvent, 100)
+	out := make(chan exchanges.PriceEvent, 100) // TODO: move to config
+	stream := &wsStream{ctx: ctx}
 
 	_, err = svc.SubscribeTicker(
 		bybit.V5WebsocketPublicTickerParamKey{
 			Symbol: bybit.SymbolV5(symbol),
 		},
 		func(response bybit.V5WebsocketPublicTickerResponse) error {
-			priceData := response.Data.LinearInverse
-
-			if priceData.LastPrice != "" {
-				price := utils.FromString(priceData.LastPrice)
-				out <- exchanges.PriceEvent{
-					Payload: price}
+			lastPrice := ""
+			switch {
+			case response.Data.Spot != nil:
+				lastPrice = response.Data.Spot.LastPrice
+			case response.Data.LinearInverse != nil:
+				lastPrice = response.Data.LinearInverse.LastPrice
 			}
 
-			return err
+			if lastPrice != "" {
+				stream.sendPrice(out, exchanges.PriceEvent{
+					Payload: utils.FromString(lastPrice)})
+			}
+			return nil
 		})
-
 	if err != nil {
-		out <- exchanges.PriceEvent{DisconnectedWithErr: fmt.Errorf("connection error %v", err)}
-		defer close(out)
-
-		// handle registration error
-		lg.Sugar().Info("registration connection", err.Error())
-	}
-
-	errHandler := func(isWebsocketClosed bool, err error) {
-		// Connection issue (timeout, etc.).
-		// TODO: At this point, the connection is dead and you must handle the reconnection yourself
-		lg.Sugar().Info("err handler called", err.Error())
+		_ = svc.Close()
+		return nil, errors.Wrap(err, "unable to subscribe to ticker")
 	}
 
-	go svc.Start(context.Background(), errHandler)
+	go func() {
+		err := runWS(ctx, svc, lg)
+		stream.finish(func() {
+			if err != nil {
+				select {
+				case out <- exchanges.PriceEvent{DisconnectedWithErr: err}:
+				case <-ctx.Done():
+				}
+			}
+			close(out)
+		})
+	}()
 
 	return out, nil
 }
@@ -0,0 +1,207 @@
+package bybit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const rateLimitRetCode = 10006
+
+// RateLimitError is returned when Bybit answered with retCode 10006 or
+// when the limiter knows the request would get it.
+type RateLimitError struct {
+	Group   string
+	ResetAt time.Time
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("bybit rate limit of %s, reset at %v", e.Group, e.ResetAt.Format(time.RFC3339Nano))
+}
+
+// groupState is the last known state of the endpoint group
+type groupState struct {
+	limit     int
+	remaining int
+	resetAt   time.Time
+}
+
+// BybitRateLimiter delays requests of endpoint groups which have no remaining capacity.
+// Bybit limits every endpoint separately per UID and reports the state in
+// X-Bapi-Limit, X-Bapi-Limit-Status and X-Bapi-Limit-Reset-Timestamp headers.
+// Between responses the remaining capacity is decremented locally.
+// Cancels don't share groups with queries, so there is no priority scheduling.
+type BybitRateLimiter struct {
+	mu     sync.Mutex
+	groups map[string]*groupState
+	lgs    *zap.SugaredLogger
+
+	// Requests without context deadline fail when they would wait longer.
+	// hirokisan/bybit doesn't pass contexts so it's the only bound for them.
+	MaxWait time.Duration
+
+	now func() time.Time
+}
+
+func NewBybitRateLimiter(lg *zap.Logger) *BybitRateLimiter {
+	return &BybitRateLimiter{
+		groups:  map[string]*groupState{},
+		lgs:     lg.Named("RateLimiter").Sugar(),
+		MaxWait: 10 * time.Second,
+		now:     time.Now,
+	}
+}
+
+var sharedLimitersMu sync.Mutex
+var sharedLimiters = map[string]*BybitRateLimiter{}
+
+// SharedBybitRateLimiter returns the same limiter for the same API key
+// because Bybit limits are per account, not per product.
+func SharedBybitRateLimiter(apiKey string, lg *zap.Logger) *BybitRateLimiter {
+	sharedLimitersMu.Lock()
+	defer sharedLimitersMu.Unlock()
+
+	lim, ok := sharedLimiters[apiKey]
+	if !ok {
+		lim = NewBybitRateLimiter(lg)
+		sharedLimiters[apiKey] = lim
+	}
+	return lim
+}
+
+// endpointGroup is the path: every V5 endpoint has own limit
+func endpointGroup(req *http.Request) string {
+	return req.URL.Path
+}
+
+// reserve takes one request from the group or returns the time of the group reset
+func (rl *BybitRateLimiter) reserve(group string) (time.Time, bool) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	st, ok := rl.groups[group]
+	if !ok {
+		return time.Time{}, true
+	}
+	if !rl.now().Before(st.resetAt) {
+		// New window, the state is unknown till the next response
+		delete(rl.groups, group)
+		return time.Time{}, true
+	}
+	if st.remaining <= 0 {
+		return st.resetAt, false
+	}
+	st.remaining--
+	return time.Time{}, true
+}
+
+// Wait blocks until the group has capacity. It fails fast with RateLimitError
+// if the wait is longer than context deadline or MaxWait.
+func (rl *BybitRateLimiter) Wait(ctx context.Context, group string) error {
+	for {
+		resetAt, ok := rl.reserve(group)
+		if ok {
+			return nil
+		}
+		wait := resetAt.Sub(rl.now())
+		if deadline, ok := ctx.Deadline(); ok && wait > time.Until(deadline) {
+			return &RateLimitError{Group: group, ResetAt: resetAt}
+		}
+		if rl.MaxWait > 0 && wait > rl.MaxWait {
+			return &RateLimitError{Group: group, ResetAt: resetAt}
+		}
+
+		rl.lgs.Debugf("Waiting %v for %s", wait, group)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Observe applies rate limit headers of the response
+func (rl *BybitRateLimiter) Observe(group string, h http.Header) {
+	limit, errL := strconv.Atoi(h.Get("X-Bapi-Limit"))
+	remaining, errR := strconv.Atoi(h.Get("X-Bapi-Limit-Status"))
+	resetMs, errT := strconv.ParseInt(h.Get("X-Bapi-Limit-Reset-Timestamp"), 10, 64)
+	if errL != nil || errR != nil || errT != nil {
+		return
+	}
+	rl.set(group, &groupState{limit: limit, remaining: remaining, resetAt: time.UnixMilli(resetMs)})
+}
+
+// exhaust marks the group as having no capacity till `resetAt`
+func (rl *BybitRateLimiter) exhaust(group string, resetAt time.Time) {
+	rl.set(group, &groupState{remaining: 0, resetAt: resetAt})
+}
+
+func (rl *BybitRateLimiter) set(group string, st *groupState) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if old, ok := rl.groups[group]; ok && old.resetAt.Equal(st.resetAt) && old.remaining < st.remaining {
+		// Responses of concurrent requests may come out of order
+		return
+	}
+	rl.groups[group] = st
+}
+
+// Transport wraps `next` (http.DefaultTransport if nil) with the limiter
+func (rl *BybitRateLimiter) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &rateLimitedTransport{lim: rl, next: next}
+}
+
+type rateLimitedTransport struct {
+	lim  *BybitRateLimiter
+	next http.RoundTripper
+}
+
+type retCodeResponse struct {
+	RetCode int `json:"retCode"`
+}
+
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	group := endpointGroup(req)
+	if err := t.lim.Wait(req.Context(), group); err != nil {
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	t.lim.Observe(group, resp.Header)
+
+	// retCode is in the body, so it has to be read and put back
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	rc := retCodeResponse{}
+	if json.Unmarshal(body, &rc) == nil && rc.RetCode == rateLimitRetCode {
+		resetAt := t.lim.now().Add(time.Second)
+		if ms, err := strconv.ParseInt(resp.Header.Get("X-Bapi-Limit-Reset-Timestamp"), 10, 64); err == nil {
+			resetAt = time.UnixMilli(ms)
+		}
+		t.lim.exhaust(group, resetAt)
+		return nil, &RateLimitError{Group: group, ResetAt: resetAt}
+	}
+	return resp, nil
+}
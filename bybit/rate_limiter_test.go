@@ -0,0 +1,78 @@
+package bybit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hirokisan/bybit/v2"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+)
+
+func TestBybitRateLimiterDelaysExhaustedGroup(t *testing.T) {
+	requests := atomic.NewInt32(0)
+	resetAt := time.Now().Add(300 * time.Millisecond).Truncate(time.Millisecond)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Inc()
+		w.Header().Set("X-Bapi-Limit", "10")
+		w.Header().Set("X-Bapi-Limit-Status", "0")
+		w.Header().Set("X-Bapi-Limit-Reset-Timestamp", strconv.FormatInt(resetAt.UnixMilli(), 10))
+		_, _ = w.Write([]byte(`{"retCode": 0}`))
+	}))
+	defer srv.Close()
+
+	client := NewHTTPClient(time.Second, NewBybitRateLimiter(zap.NewNop()))
+
+	resp, err := client.Get(srv.URL + "/v5/order/realtime")
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	// Another group isn't affected
+	resp, err = client.Get(srv.URL + "/v5/position/list")
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	// Too short deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/v5/order/realtime", nil)
+	_, err = client.Do(req)
+	var rlErr *RateLimitError
+	assert.True(t, errors.As(err, &rlErr))
+	assert.Equal(t, "/v5/order/realtime", rlErr.Group)
+	assert.Equal(t, int32(2), requests.Load())
+
+	// Waits for the reset
+	resp, err = client.Get(srv.URL + "/v5/order/realtime")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.False(t, time.Now().Before(resetAt))
+}
+
+func TestBybitRateLimiterRetCode(t *testing.T) {
+	resetAt := time.Now().Add(time.Minute).Truncate(time.Millisecond)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Bapi-Limit-Reset-Timestamp", strconv.FormatInt(resetAt.UnixMilli(), 10))
+		_, _ = w.Write([]byte(`{"retCode": 10006, "retMsg": "Too many visits!"}`))
+	}))
+	defer srv.Close()
+
+	client := NewBybitRestClient("key", "secret", NewBybitRateLimiter(zap.NewNop()), zap.NewNop()).
+		WithBaseURL(srv.URL)
+	_, err := client.V5().Market().GetInstrumentsInfo(bybit.V5GetInstrumentsInfoParam{Category: bybit.CategoryV5Spot})
+
+	var rlErr *RateLimitError
+	assert.True(t, errors.As(err, &rlErr))
+	assert.True(t, resetAt.Equal(rlErr.ResetAt))
+}
+
+func TestSharedBybitRateLimiter(t *testing.T) {
+	assert.Same(t, SharedBybitRateLimiter("k1", zap.NewNop()), SharedBybitRateLimiter("k1", zap.NewNop()))
+	assert.NotSame(t, SharedBybitRateLimiter("k1", zap.NewNop()), SharedBybitRateLimiter("k2", zap.NewNop()))
+}
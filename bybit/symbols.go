@@ -0,0 +1,73 @@
+package bybit
+
+import (
+	"strconv"
+	"time"
+
+	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/hirokisan/bybit/v2"
+)
+
+// Every product has own prefix so the same native symbol (e.g. BTCUSDT)
+// on spot and derivatives doesn't collide.
+const (
+	BYBIT_PREFIX         = "BYBIT-"
+	BYBIT_LINEAR_PREFIX  = "BYBITLINEAR-"
+	BYBIT_INVERSE_PREFIX = "BYBITINVERSE-"
+)
+
+func ToBybitFullSymbol(symbol string) string {
+	return exchanges.FullSymbol(BYBIT_PREFIX, symbol)
+}
+
+func ToBybitSymbol(symbol string) string {
+	return exchanges.NativeSymbol(BYBIT_PREFIX, symbol)
+}
+
+func ToBybitLinearFullSymbol(symbol string) string {
+	return exchanges.FullSymbol(BYBIT_LINEAR_PREFIX, symbol)
+}
+
+func ToBybitLinearSymbol(symbol string) string {
+	return exchanges.NativeSymbol(BYBIT_LINEAR_PREFIX, symbol)
+}
+
+func ToBybitInverseFullSymbol(symbol string) string {
+	return exchanges.FullSymbol(BYBIT_INVERSE_PREFIX, symbol)
+}
+
+func ToBybitInverseSymbol(symbol string) string {
+	return exchanges.NativeSymbol(BYBIT_INVERSE_PREFIX, symbol)
+}
+
+func prefixByCategory(category bybit.CategoryV5) string {
+	switch category {
+	case bybit.CategoryV5Linear:
+		return BYBIT_LINEAR_PREFIX
+	case bybit.CategoryV5Inverse:
+		return BYBIT_INVERSE_PREFIX
+	default:
+		return BYBIT_PREFIX
+	}
+}
+
+func toFullSymbolByCategory(category bybit.CategoryV5, symbol string) string {
+	return exchanges.FullSymbol(prefixByCategory(category), symbol)
+}
+
+func linearInverseInstrument(contractType bybit.ContractType, base, quote, settle, deliveryTime string) exchanges.Instrument {
+	instrument := exchanges.Instrument{
+		Product: exchanges.PerpetualProduct,
+		Base:    base,
+		Quote:   quote,
+		Settle:  settle,
+	}
+	if contractType != bybit.ContractTypeLinearPerpetual && contractType != bybit.ContractTypeInversePerpetual {
+		instrument.Product = exchanges.FutureProduct
+		if ms, err := strconv.ParseInt(deliveryTime, 10, 64); err == nil && ms > 0 {
+			expiry := time.UnixMilli(ms).UTC()
+			instrument.Expiry = &expiry
+		}
+	}
+	return instrument
+}
@@ -0,0 +1,135 @@
+package bybit
+
+import (
+	"context"
+	"sync"
+
+	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/hirokisan/bybit/v2"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+var errWSClosed = errors.New("bybit websocket closed")
+
+type wsService interface {
+	Start(context.Context, bybit.ErrHandler) error
+	Close() error
+}
+
+// runWS blocks until the socket fails or `ctx` is done.
+// It returns nil only in the last case.
+func runWS(ctx context.Context, svc wsService, lg *zap.Logger) error {
+	errCh := make(chan error, 1)
+	errHandler := func(isWebsocketClosed bool, err error) {
+		lg.Warn("Websocket error", zap.Bool("closed", isWebsocketClosed), zap.Error(err))
+		select {
+		case errCh <- err:
+		default:
+		}
+	}
+
+	startErr := svc.Start(ctx, errHandler)
+	if startErr != nil {
+		// Ping failed, reading goroutine can still be alive
+		_ = svc.Close()
+	}
+
+	// Errors of the socket closed by cancellation aren't failures
+	if ctx.Err() != nil {
+		return nil
+	}
+	select {
+	case err := <-errCh:
+		return errors.Wrap(err, "websocket error")
+	default:
+	}
+	if startErr != nil {
+		return errors.Wrap(startErr, "websocket error")
+	}
+	return errWSClosed
+}
+
+// wsStream guards the output channel: handlers of the socket can be called
+// after the stream is finished.
+type wsStream struct {
+	ctx    context.Context
+	mu     sync.Mutex
+	closed bool
+}
+
+// send calls `fn` if the stream isn't finished
+func (s *wsStream) send(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		fn()
+	}
+}
+
+// finish calls `fn` once, later sends are ignored
+func (s *wsStream) finish(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		fn()
+	}
+}
+
+func (s *wsStream) sendOrder(out chan<- exchanges.OrderEvent, ev exchanges.OrderEvent) {
+	s.send(func() {
+		select {
+		case out <- ev:
+		case <-s.ctx.Done():
+		}
+	})
+}
+
+func (s *wsStream) sendPosition(out chan<- exchanges.PositionEvent, ev exchanges.PositionEvent) {
+	s.send(func() {
+		select {
+		case out <- ev:
+		case <-s.ctx.Done():
+		}
+	})
+}
+
+func (s *wsStream) sendPrice(out chan<- exchanges.PriceEvent, ev exchanges.PriceEvent) {
+	s.send(func() {
+		select {
+		case out <- ev:
+		case <-s.ctx.Done():
+		}
+	})
+}
+
+// The functions below wrap subscriptions by reconnectors if `resubscribe` is set.
+// Otherwise the stream sends DisconnectedWithErr and is closed on the socket failure.
+
+func watchOrders(
+	ctx context.Context, resubscribe bool, connect exchanges.OrderEventReconnectorFn, lg *zap.Logger,
+) (<-chan exchanges.OrderEvent, error) {
+	if !resubscribe {
+		return connect(ctx)
+	}
+	return exchanges.NewOrderEventReconnector(connect, nil, lg).Watch(ctx)
+}
+
+func watchPositions(
+	ctx context.Context, resubscribe bool, connect exchanges.PositionEventReconnectorFn, lg *zap.Logger,
+) (<-chan exchanges.PositionEvent, error) {
+	if !resubscribe {
+		return connect(ctx)
+	}
+	return exchanges.NewPositionEventReconnector(connect, nil, lg).Watch(ctx)
+}
+
+func watchPrices(
+	ctx context.Context, resubscribe bool, connect exchanges.PriceEventReconnectorFn, lg *zap.Logger,
+) (<-chan exchanges.PriceEvent, error) {
+	if !resubscribe {
+		return connect(ctx)
+	}
+	return exchanges.NewPriceEventReconnector(connect, nil, lg).Watch(ctx)
+}
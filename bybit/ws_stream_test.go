@@ -0,0 +1,63 @@
+package bybit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/hirokisan/bybit/v2"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type fakeWSService struct {
+	fail     error
+	closeErr error // Reported after cancellation
+}
+
+func (s *fakeWSService) Start(ctx context.Context, errHandler bybit.ErrHandler) error {
+	if s.fail != nil {
+		errHandler(true, s.fail)
+		return nil
+	}
+	<-ctx.Done()
+	if s.closeErr != nil {
+		errHandler(true, s.closeErr)
+	}
+	return nil
+}
+
+func (s *fakeWSService) Close() error {
+	return nil
+}
+
+func TestRunWS(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.NoError(t, runWS(ctx, &fakeWSService{}, zap.NewNop()))
+	closeErr := errors.New("use of closed network connection")
+	assert.NoError(t, runWS(ctx, &fakeWSService{closeErr: closeErr}, zap.NewNop()))
+
+	err := runWS(context.Background(), &fakeWSService{fail: errors.New("broken pipe")}, zap.NewNop())
+	assert.ErrorContains(t, err, "broken pipe")
+}
+
+func TestWSStreamIgnoresSendsAfterFinish(t *testing.T) {
+	out := make(chan exchanges.PriceEvent, 1)
+	stream := &wsStream{ctx: context.Background()}
+
+	stream.finish(func() {
+		out <- exchanges.PriceEvent{DisconnectedWithErr: errWSClosed}
+		close(out)
+	})
+	stream.sendPrice(out, exchanges.PriceEvent{})
+	stream.finish(func() { close(out) })
+
+	events := []exchanges.PriceEvent{}
+	for ev := range out {
+		events = append(events, ev)
+	}
+	assert.Equal(t, []exchanges.PriceEvent{{DisconnectedWithErr: errWSClosed}}, events)
+}
@@ -0,0 +1,265 @@
+package exchanges
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// TODO: move to config
+const DefaultDeadMansSwitchTimeout = time.Minute
+
+type DeadMansSwitchState struct {
+	Armed     bool
+	Native    bool      // The venue cancels orders itself, see DeadMansSwitchExchange
+	ArmedAt   time.Time // Last successful arming
+	Triggered bool      // Orders were canceled by the local watchdog
+	Err       error     // Last arming or cancellation error
+}
+
+// DeadMansSwitch cancels orders if the process isn't healthy anymore.
+//
+// Venues implementing DeadMansSwitchExchange are armed every `Interval` while the
+// process is healthy, so orders are canceled by the venue even if the process dies.
+// For other venues (including Phemex, which has no cancel-all-after endpoint) the switch is
+// emulated by a local watchdog: it calls cancel-all when the orders stream is lost for `Timeout`.
+// In both modes orders are canceled locally when heartbeats are missed for `HeartbeatTimeout`.
+//
+// Pass the venue itself, not a wrapper like RetryeableExchange, to use the native switch.
+type DeadMansSwitch struct {
+	Timeout          time.Duration // optional, DefaultDeadMansSwitchTimeout by default
+	Interval         time.Duration // optional, Timeout / 4 by default
+	HeartbeatTimeout time.Duration // optional, heartbeats aren't required if zero
+	// Symbols armed separately, the whole account (or all symbols with open orders) if empty
+	Symbols []string // optional
+	// Called after every change of Armed or Triggered
+	OnStateChange func(DeadMansSwitchState) // optional
+
+	exchange Exchange
+	native   DeadMansSwitchExchange
+	lg       *zap.Logger
+
+	mu            sync.Mutex
+	state         DeadMansSwitchState
+	lastHeartbeat time.Time
+	streamLostAt  time.Time
+
+	now func() time.Time
+}
+
+func NewDeadMansSwitch(ex Exchange, lg *zap.Logger) *DeadMansSwitch {
+	d := &DeadMansSwitch{
+		exchange: ex,
+		lg:       lg.Named("DeadMansSwitch"),
+		now:      time.Now,
+	}
+	d.native, _ = ex.(DeadMansSwitchExchange)
+	d.state.Native = d.native != nil
+	return d
+}
+
+func (d *DeadMansSwitch) State() DeadMansSwitchState {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.state
+}
+
+// Heartbeat should be called by the process at least every `HeartbeatTimeout`
+func (d *DeadMansSwitch) Heartbeat() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.lastHeartbeat = d.now()
+}
+
+func (d *DeadMansSwitch) timeouts() (timeout, interval time.Duration) {
+	timeout = d.Timeout
+	if timeout <= 0 {
+		timeout = DefaultDeadMansSwitchTimeout
+	}
+	interval = d.Interval
+	if interval <= 0 {
+		interval = timeout / 4
+	}
+	return timeout, interval
+}
+
+// Run keeps the switch armed until `ctx` is done, then disarms it.
+// Orders aren't canceled by clean shutdown, except of venues which can't be disarmed:
+// the switch stays armed for them, see ErrCannotDisarm.
+func (d *DeadMansSwitch) Run(ctx context.Context) {
+	timeout, interval := d.timeouts()
+	d.Heartbeat()
+	if d.native == nil {
+		go d.watchStream(ctx, interval)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		d.check(ctx, timeout)
+		select {
+		case <-ctx.Done():
+			d.disarm()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *DeadMansSwitch) check(ctx context.Context, timeout time.Duration) {
+	if reason := d.unhealthy(timeout); reason != "" {
+		if d.State().Triggered {
+			return
+		}
+		d.lg.Warn("cancelling all orders", zap.String("reason", reason))
+		err := d.cancelAll(ctx)
+		d.setState(func(s *DeadMansSwitchState) {
+			s.Err = err
+			if err == nil {
+				s.Armed = false
+				s.Triggered = true
+			}
+		})
+		if err != nil {
+			d.lg.Error("can't cancel all orders", zap.Error(err))
+		}
+		return
+	}
+
+	var err error
+	if d.native != nil {
+		err = d.arm(ctx, timeout)
+	}
+	if err != nil {
+		d.lg.Warn("can't arm", zap.Error(err))
+	}
+	d.setState(func(s *DeadMansSwitchState) {
+		s.Err = err
+		if err == nil {
+			s.Armed = true
+			s.Triggered = false
+			s.ArmedAt = d.now()
+		}
+	})
+}
+
+func (d *DeadMansSwitch) unhealthy(timeout time.Duration) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := d.now()
+	if d.HeartbeatTimeout > 0 && now.Sub(d.lastHeartbeat) > d.HeartbeatTimeout {
+		return "heartbeat is lost"
+	}
+	if !d.streamLostAt.IsZero() && now.Sub(d.streamLostAt) > timeout {
+		return "orders stream is lost"
+	}
+	return ""
+}
+
+func (d *DeadMansSwitch) arm(ctx context.Context, timeout time.Duration) error {
+	if len(d.Symbols) == 0 {
+		return d.native.CancelAllAfter(ctx, "", timeout)
+	}
+	for _, symbol := range d.Symbols {
+		if err := d.native.CancelAllAfter(ctx, symbol, timeout); err != nil {
+			return errors.Wrapf(err, "can't arm %s", symbol)
+		}
+	}
+	return nil
+}
+
+func (d *DeadMansSwitch) disarm() {
+	if d.native != nil && d.State().Armed {
+		// The main context is done already
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second) // TODO: move to config
+		defer cancel()
+		if err := d.arm(ctx, 0); err != nil {
+			if errors.Is(err, ErrCannotDisarm) {
+				d.lg.Warn("the venue can't disarm, orders will be canceled by it", zap.Error(err))
+			} else {
+				d.lg.Error("can't disarm", zap.Error(err))
+			}
+			d.setState(func(s *DeadMansSwitchState) { s.Err = err })
+			return
+		}
+	}
+	d.setState(func(s *DeadMansSwitchState) { s.Armed = false })
+}
+
+func (d *DeadMansSwitch) cancelAll(ctx context.Context) error {
+	orders, err := d.exchange.GetOpenOrders(ctx)
+	if err != nil {
+		return errors.Wrap(err, "can't get open orders")
+	}
+	var firstErr error
+	for _, o := range orders {
+		if o.Status.IsFinalStatus() {
+			continue
+		}
+		err := d.exchange.CancelOrder(ctx, o.Symbol, o.ID)
+		if err != nil && !errors.Is(err, OrderExecutedError) {
+			d.lg.Warn("can't cancel order", zap.String("symbol", o.Symbol), zap.String("id", o.ID), zap.Error(err))
+			if firstErr == nil {
+				firstErr = errors.Wrapf(err, "can't cancel order (OrderID=%s)", o.ID)
+			}
+		}
+	}
+	return firstErr
+}
+
+// watchStream marks the orders stream as lost until it's connected again
+func (d *DeadMansSwitch) watchStream(ctx context.Context, interval time.Duration) {
+	setLost := func(lost bool) {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		switch {
+		case !lost:
+			d.streamLostAt = time.Time{}
+		case d.streamLostAt.IsZero():
+			d.streamLostAt = d.now()
+		}
+	}
+
+	for {
+		events, err := d.exchange.WatchOrdersStatuses(ctx)
+		if err != nil {
+			d.lg.Warn("can't watch orders", zap.Error(err))
+			setLost(true)
+		} else {
+			setLost(false)
+			for ev := range events {
+				switch {
+				case ev.DisconnectedWithErr != nil:
+					setLost(true)
+				case ev.Reconnected != nil:
+					setLost(false)
+				}
+			}
+			setLost(true)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (d *DeadMansSwitch) setState(update func(*DeadMansSwitchState)) {
+	d.mu.Lock()
+	prev := d.state
+	update(&d.state)
+	state := d.state
+	d.mu.Unlock()
+
+	if prev.Armed != state.Armed || prev.Triggered != state.Triggered {
+		d.lg.Info("state is changed", zap.Bool("armed", state.Armed), zap.Bool("triggered", state.Triggered))
+		if d.OnStateChange != nil {
+			d.OnStateChange(state)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package exchanges
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type nativeSwitchExchange struct {
+	*MockExchange
+	*MockDeadMansSwitchExchange
+}
+
+func TestDeadMansSwitchArmsNativeCountdown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ex := NewMockExchange(ctrl)
+	native := NewMockDeadMansSwitchExchange(ctrl)
+
+	d := NewDeadMansSwitch(&nativeSwitchExchange{ex, native}, zap.NewNop())
+	d.Timeout = time.Second
+	d.Interval = 10 * time.Millisecond
+	d.Symbols = []string{"BINANCEFUTURES-BTCUSDT"}
+	states := make(chan DeadMansSwitchState, 10)
+	d.OnStateChange = func(s DeadMansSwitchState) { states <- s }
+
+	armed := make(chan struct{}, 100)
+	native.EXPECT().CancelAllAfter(gomock.Any(), "BINANCEFUTURES-BTCUSDT", time.Second).
+		DoAndReturn(func(context.Context, string, time.Duration) error {
+			armed <- struct{}{}
+			return nil
+		}).MinTimes(2)
+	native.EXPECT().CancelAllAfter(gomock.Any(), "BINANCEFUTURES-BTCUSDT", time.Duration(0)).Return(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		d.Run(ctx)
+		close(done)
+	}()
+
+	<-armed
+	<-armed
+	s := <-states
+	assert.True(t, s.Armed)
+	assert.True(t, s.Native)
+	assert.False(t, s.ArmedAt.IsZero())
+
+	// Clean shutdown disarms the countdown
+	cancel()
+	<-done
+	assert.False(t, (<-states).Armed)
+	assert.False(t, d.State().Armed)
+}
+
+func TestDeadMansSwitchWatchdogCancelsAll(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ex := NewMockExchange(ctrl)
+
+	now := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+	d := NewDeadMansSwitch(ex, zap.NewNop())
+	d.now = func() time.Time { return now }
+	d.HeartbeatTimeout = 30 * time.Second
+	d.Heartbeat()
+
+	ctx := context.Background()
+	d.check(ctx, time.Minute)
+	assert.Equal(t, DeadMansSwitchState{Armed: true, ArmedAt: now}, d.State())
+
+	// The stream is lost but it isn't late yet
+	d.streamLostAt = now
+	now = now.Add(20 * time.Second)
+	d.check(ctx, time.Minute)
+	assert.True(t, d.State().Armed)
+
+	// Heartbeat is missed
+	now = now.Add(20 * time.Second)
+	ex.EXPECT().GetOpenOrders(gomock.Any()).Return([]OrderDetailInfo{
+		{ID: "1", Symbol: "BINANCE-BTCUSDT", Status: NewOST},
+		{ID: "2", Symbol: "BINANCE-ETHUSDT", Status: PartiallyFilledOST},
+		{ID: "3", Symbol: "BINANCE-ETHUSDT", Status: FilledOST},
+	}, nil)
+	ex.EXPECT().CancelOrder(gomock.Any(), "BINANCE-BTCUSDT", "1").Return(nil)
+	ex.EXPECT().CancelOrder(gomock.Any(), "BINANCE-ETHUSDT", "2").Return(OrderExecutedError)
+	d.check(ctx, time.Minute)
+	s := d.State()
+	assert.False(t, s.Armed)
+	assert.True(t, s.Triggered)
+	assert.NoError(t, s.Err)
+
+	// Cancelled once
+	d.check(ctx, time.Minute)
+
+	// The process is alive again but the stream is lost for too long
+	now = now.Add(30 * time.Second)
+	d.Heartbeat()
+	d.check(ctx, time.Minute)
+	assert.True(t, d.State().Triggered)
+
+	d.streamLostAt = time.Time{}
+	d.check(ctx, time.Minute)
+	assert.Equal(t, DeadMansSwitchState{Armed: true, ArmedAt: now}, d.State())
+}
+
+func TestDeadMansSwitchStaysArmedIfCantDisarm(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ex := NewMockExchange(ctrl)
+	native := NewMockDeadMansSwitchExchange(ctrl)
+
+	d := NewDeadMansSwitch(&nativeSwitchExchange{ex, native}, zap.NewNop())
+	native.EXPECT().CancelAllAfter(gomock.Any(), "", DefaultDeadMansSwitchTimeout).Return(nil)
+	native.EXPECT().CancelAllAfter(gomock.Any(), "", time.Duration(0)).Return(ErrCannotDisarm)
+
+	d.check(context.Background(), DefaultDeadMansSwitchTimeout)
+	assert.True(t, d.State().Armed)
+
+	// The venue still cancels orders after the shutdown
+	d.disarm()
+	s := d.State()
+	assert.True(t, s.Armed)
+	assert.ErrorIs(t, s.Err, ErrCannotDisarm)
+}
@@ -37,6 +37,8 @@ const (
 	ExpiredOST  OrderStatusType = 6 // EXPIRED - The order was canceled according to the order type's rules (e.g. LIMIT FOK orders with no fill, LIMIT IOC or MARKET orders that partially fill) or by the exchange, (e.g. orders canceled during liquidation, orders canceled during maintenance)
 	OpenOST     OrderStatusType = 7 // OPEN status for FTX exchange
 	ClosedOST   OrderStatusType = 8 // Closed status for FTX exchange
+	// TRIGGERED - Conditional order reached its stop price and is working as market or limit order
+	TriggeredOST OrderStatusType = 9
 )
 
 func (ost OrderStatusType) String() string {
@@ -59,6 +61,8 @@ func (ost OrderStatusType) String() string {
 		return "Open"
 	case ClosedOST:
 		return "Closed"
+	case TriggeredOST:
+		return "Triggered"
 	default:
 		return "(ERROR: unexpected status)"
 	}
@@ -68,7 +72,7 @@ func (ost OrderStatusType) IsFinalStatus() bool {
 	switch ost {
 	case UnknownOST:
 		return false
-	case NewOST, PartiallyFilledOST:
+	case NewOST, PartiallyFilledOST, TriggeredOST:
 		return false
 	case FilledOST, CanceledOST, RejectedOST, ExpiredOST:
 		return true
@@ -82,10 +86,14 @@ var OrderExecutedError = errors.New("order was executed")
 var OrderNotFoundError = errors.New("order not found")
 var NewOrderRejectedError = errors.New("new order was rejected")
 
+// ErrCannotDisarm is returned by DeadMansSwitchExchange venues which can't turn the switch off
+var ErrCannotDisarm = errors.New("dead man's switch can't be disarmed")
+
 type OrderEventPayload struct {
 	OrderID     string
 	OrderStatus OrderStatusType
 	Symbol      *string // Now it is optional. But it's better to fill it if possible
+	Recovered   bool    // Restored by OrderEventRecoverer after reconnection
 }
 
 func (oep *OrderEventPayload) String() string {
@@ -93,8 +101,12 @@ func (oep *OrderEventPayload) String() string {
 	if oep.Symbol != nil {
 		symbol = ", Symbol: " + *oep.Symbol
 	}
-	return fmt.Sprintf("{OrderID: %s, OrderStatus: %v%s",
-		oep.OrderID, oep.OrderStatus, symbol)
+	recovered := ""
+	if oep.Recovered {
+		recovered = ", Recovered"
+	}
+	return fmt.Sprintf("{OrderID: %s, OrderStatus: %v%s%s",
+		oep.OrderID, oep.OrderStatus, symbol, recovered)
 }
 
 // Should be one of three
@@ -103,6 +115,8 @@ type OrderEvent struct {
 	DisconnectedWithErr error
 	Reconnected         *struct{}
 	Payload             *OrderEventPayload
+
+	Venue string // Filled by Router only
 }
 
 func (ev OrderEvent) String() string {
@@ -158,6 +172,7 @@ type SymbolInfo struct {
 	OriginalSymbol string // "LTC_BTC"
 	Symbol         string // "BN-LTCBTC"
 	Filters        []map[string]interface{}
+	Instrument     *Instrument // Canonical description, nil if venue doesn't provide it
 }
 
 type OrderType string
@@ -173,6 +188,12 @@ const (
 	MARKET_IF_TOUCHED OrderType = "MARKET_IF_TOUCHED"
 	LIMIT_IF_TOUCHED  OrderType = "LIMIT_IF_TOUCHED"
 	PEGGED            OrderType = "PEGGED"
+
+	// Binance futures conditional orders
+	STOP                 OrderType = "STOP"
+	STOP_MARKET          OrderType = "STOP_MARKET"
+	TAKE_PROFIT_MARKET   OrderType = "TAKE_PROFIT_MARKET"
+	TRAILING_STOP_MARKET OrderType = "TRAILING_STOP_MARKET"
 )
 
 type OrderSide string
@@ -223,12 +244,28 @@ type AccountPosition struct {
 	CumRealisedPnl   *apd.Decimal
 	LiqPrice         *apd.Decimal
 	Category         string
+
+	// Futures only
+	MarginType     string       // optional, e.g. cross or isolated
+	IsolatedMargin *apd.Decimal // optional
 }
 
 type AccountBalance struct {
 	Coin   string
 	Free   *apd.Decimal
 	Locked *apd.Decimal
+
+	// Margin accounts only
+	Borrowed       *apd.Decimal // optional
+	Interest       *apd.Decimal // optional
+	NetAsset       *apd.Decimal // optional, Free + Locked - Borrowed - Interest
+	IsolatedSymbol string       // optional, full symbol of isolated margin pair
+
+	// Futures accounts only
+	WalletBalance      *apd.Decimal // optional, Free + Locked
+	CrossUnrealizedPnL *apd.Decimal // optional
+
+	Venue string // Filled by Router only
 }
 
 type Account struct {
@@ -240,6 +277,8 @@ type OrderFilter struct {
 	Symbol        *string
 	OrderID       *string
 	ClientOrderID *string
+	// Orders created since the time, venues which can't filter by time return older orders too
+	Since *time.Time // optional
 }
 
 // `id` of orders placing result should be consistent (accept/return) with other methods.
@@ -322,10 +361,38 @@ type BulkCancelExchange interface {
 	BulkCancelOrder(symbol string, ids []string) ([]BulkCancelResult, error)
 }
 
+// DeadMansSwitchExchange is implemented by venues which cancel orders on their side
+// when the countdown isn't renewed in time (cancel-on-disconnect).
+type DeadMansSwitchExchange interface {
+	// Arms the countdown: orders of `symbol` are canceled if it isn't renewed within `timeout`.
+	// Empty `symbol` arms the whole account or all symbols with open orders.
+	// Zero `timeout` disarms the countdown or returns ErrCannotDisarm.
+	CancelAllAfter(_ context.Context, symbol string, timeout time.Duration) error
+}
+
+// PositionStreamSnapshotter is implemented by venues whose position streams send
+// other values than sizes of AccountPositions, e.g. free balances of spot accounts.
+type PositionStreamSnapshotter interface {
+	// Returns values of `acc` as WatchAccountPositions sends them
+	StreamPositions(acc Account) []*PositionPayload
+}
+
+// OrderStreamKeyer is implemented by venues whose order streams identify orders
+// differently from OrderDetailInfo.ID and Symbol, e.g. by client order ID.
+type OrderStreamKeyer interface {
+	// Returns the order ID and symbol of `o` as WatchOrdersStatuses sends them
+	StreamOrderKey(o OrderDetailInfo) (id, symbol string)
+}
+
 type PositionEvent struct {
 	DisconnectedWithErr error
 	Reconnected         *struct{}
 	Payload             []*PositionPayload
+	// Payload has all positions, symbols absent there are closed.
+	// Sent by RetryeableExchange after Reconnected.
+	Snapshot bool
+
+	Venue string // Filled by Router only
 }
 
 type PositionPayload struct {
@@ -384,3 +384,115 @@ func (mr *MockBulkCancelExchangeMockRecorder) BulkCancelOrder(symbol, ids interf
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkCancelOrder", reflect.TypeOf((*MockBulkCancelExchange)(nil).BulkCancelOrder), symbol, ids)
 }
+
+// MockDeadMansSwitchExchange is a mock of DeadMansSwitchExchange interface.
+type MockDeadMansSwitchExchange struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeadMansSwitchExchangeMockRecorder
+}
+
+// MockDeadMansSwitchExchangeMockRecorder is the mock recorder for MockDeadMansSwitchExchange.
+type MockDeadMansSwitchExchangeMockRecorder struct {
+	mock *MockDeadMansSwitchExchange
+}
+
+// NewMockDeadMansSwitchExchange creates a new mock instance.
+func NewMockDeadMansSwitchExchange(ctrl *gomock.Controller) *MockDeadMansSwitchExchange {
+	mock := &MockDeadMansSwitchExchange{ctrl: ctrl}
+	mock.recorder = &MockDeadMansSwitchExchangeMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDeadMansSwitchExchange) EXPECT() *MockDeadMansSwitchExchangeMockRecorder {
+	return m.recorder
+}
+
+// CancelAllAfter mocks base method.
+func (m *MockDeadMansSwitchExchange) CancelAllAfter(arg0 context.Context, symbol string, timeout time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelAllAfter", arg0, symbol, timeout)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelAllAfter indicates an expected call of CancelAllAfter.
+func (mr *MockDeadMansSwitchExchangeMockRecorder) CancelAllAfter(arg0, symbol, timeout interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelAllAfter", reflect.TypeOf((*MockDeadMansSwitchExchange)(nil).CancelAllAfter), arg0, symbol, timeout)
+}
+
+// MockOrderStreamKeyer is a mock of OrderStreamKeyer interface.
+type MockOrderStreamKeyer struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrderStreamKeyerMockRecorder
+}
+
+// MockOrderStreamKeyerMockRecorder is the mock recorder for MockOrderStreamKeyer.
+type MockOrderStreamKeyerMockRecorder struct {
+	mock *MockOrderStreamKeyer
+}
+
+// NewMockOrderStreamKeyer creates a new mock instance.
+func NewMockOrderStreamKeyer(ctrl *gomock.Controller) *MockOrderStreamKeyer {
+	mock := &MockOrderStreamKeyer{ctrl: ctrl}
+	mock.recorder = &MockOrderStreamKeyerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOrderStreamKeyer) EXPECT() *MockOrderStreamKeyerMockRecorder {
+	return m.recorder
+}
+
+// StreamOrderKey mocks base method.
+func (m *MockOrderStreamKeyer) StreamOrderKey(o OrderDetailInfo) (string, string) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamOrderKey", o)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	return ret0, ret1
+}
+
+// StreamOrderKey indicates an expected call of StreamOrderKey.
+func (mr *MockOrderStreamKeyerMockRecorder) StreamOrderKey(o interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamOrderKey", reflect.TypeOf((*MockOrderStreamKeyer)(nil).StreamOrderKey), o)
+}
+
+// MockPositionStreamSnapshotter is a mock of PositionStreamSnapshotter interface.
+type MockPositionStreamSnapshotter struct {
+	ctrl     *gomock.Controller
+	recorder *MockPositionStreamSnapshotterMockRecorder
+}
+
+// MockPositionStreamSnapshotterMockRecorder is the mock recorder for MockPositionStreamSnapshotter.
+type MockPositionStreamSnapshotterMockRecorder struct {
+	mock *MockPositionStreamSnapshotter
+}
+
+// NewMockPositionStreamSnapshotter creates a new mock instance.
+func NewMockPositionStreamSnapshotter(ctrl *gomock.Controller) *MockPositionStreamSnapshotter {
+	mock := &MockPositionStreamSnapshotter{ctrl: ctrl}
+	mock.recorder = &MockPositionStreamSnapshotterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPositionStreamSnapshotter) EXPECT() *MockPositionStreamSnapshotterMockRecorder {
+	return m.recorder
+}
+
+// StreamPositions mocks base method.
+func (m *MockPositionStreamSnapshotter) StreamPositions(acc Account) []*PositionPayload {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamPositions", acc)
+	ret0, _ := ret[0].([]*PositionPayload)
+	return ret0
+}
+
+// StreamPositions indicates an expected call of StreamPositions.
+func (mr *MockPositionStreamSnapshotterMockRecorder) StreamPositions(acc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamPositions", reflect.TypeOf((*MockPositionStreamSnapshotter)(nil).StreamPositions), acc)
+}
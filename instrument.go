@@ -0,0 +1,117 @@
+package exchanges
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type ProductType string
+
+const (
+	SpotProduct      ProductType = "SPOT"
+	PerpetualProduct ProductType = "PERPETUAL"
+	FutureProduct    ProductType = "FUTURE" // Dated (delivery) contract
+)
+
+// Instrument is venue independent description of the tradable thing.
+// Two instruments with the same Base, Quote, Settle and Product but
+// different Venue are the same market on different exchanges.
+type Instrument struct {
+	Venue   string // "BINANCEFUTURES", prefix of the full symbol without the dash
+	Product ProductType
+	Base    string // "BTC"
+	Quote   string // "USDT"
+	Settle  string // "USDT" for linear, "BTC" for inverse, empty for spot
+
+	Expiry *time.Time // Only for FutureProduct
+}
+
+func (i Instrument) String() string {
+	s := i.Venue + ":" + i.Base + "/" + i.Quote
+	if i.Settle != "" {
+		s += ":" + i.Settle
+	}
+	return s + " " + string(i.Product)
+}
+
+// VenueFromPrefix converts "BINANCEFUTURES-" into "BINANCEFUTURES"
+func VenueFromPrefix(prefix string) string {
+	return strings.TrimSuffix(prefix, "-")
+}
+
+// InstrumentQuery is a filter for InstrumentRegistry.Find. Empty fields match anything.
+type InstrumentQuery struct {
+	Venue   string
+	Product ProductType
+	Base    string
+	Quote   string
+	Settle  string
+}
+
+func (q InstrumentQuery) match(i Instrument) bool {
+	return (q.Venue == "" || q.Venue == i.Venue) &&
+		(q.Product == "" || q.Product == i.Product) &&
+		(q.Base == "" || strings.EqualFold(q.Base, i.Base)) &&
+		(q.Quote == "" || strings.EqualFold(q.Quote, i.Quote)) &&
+		(q.Settle == "" || strings.EqualFold(q.Settle, i.Settle))
+}
+
+// NativeSymbol converts the full symbol into the one the exchange API accepts.
+// Full symbols are always `prefix + native`, no lookup is needed.
+func NativeSymbol(prefix, symbol string) string {
+	return strings.TrimPrefix(symbol, prefix)
+}
+
+// FullSymbol is the reverse of NativeSymbol
+func FullSymbol(prefix, native string) string {
+	return prefix + native
+}
+
+// InstrumentRegistry describes full symbols ("BINANCEFUTURES-BTCUSDT") by canonical
+// instruments to find the same market across venues. Adapters fill it from GetTradableSymbols.
+// It doesn't translate symbols, see NativeSymbol and FullSymbol.
+type InstrumentRegistry struct {
+	mu       sync.RWMutex
+	bySymbol map[string]Instrument
+}
+
+func NewInstrumentRegistry() *InstrumentRegistry {
+	return &InstrumentRegistry{bySymbol: map[string]Instrument{}}
+}
+
+// Instruments is the registry shared by all adapters of the process.
+var Instruments = NewInstrumentRegistry()
+
+// Register stores the instrument under `prefix + native` and returns the full symbol.
+// Venue is taken from the prefix when it is empty.
+func (r *InstrumentRegistry) Register(prefix, native string, inst Instrument) string {
+	if inst.Venue == "" {
+		inst.Venue = VenueFromPrefix(prefix)
+	}
+	full := FullSymbol(prefix, native)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bySymbol[full] = inst
+	return full
+}
+
+// Find returns sorted full symbols of all registered instruments matching the query.
+// E.g. all BTC/USDT perpetuals across venues:
+//
+//	Instruments.Find(InstrumentQuery{Base: "BTC", Quote: "USDT", Product: PerpetualProduct})
+func (r *InstrumentRegistry) Find(q InstrumentQuery) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := []string{}
+	for symbol, inst := range r.bySymbol {
+		if q.match(inst) {
+			result = append(result, symbol)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
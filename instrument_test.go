@@ -0,0 +1,35 @@
+package exchanges
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstrumentRegistry(t *testing.T) {
+	r := NewInstrumentRegistry()
+
+	spot := Instrument{Product: SpotProduct, Base: "BTC", Quote: "USDT"}
+	perp := Instrument{Product: PerpetualProduct, Base: "BTC", Quote: "USDT", Settle: "USDT"}
+	inverse := Instrument{Product: PerpetualProduct, Base: "BTC", Quote: "USD", Settle: "BTC"}
+
+	assert.Equal(t, "BINANCE-BTCUSDT", r.Register("BINANCE-", "BTCUSDT", spot))
+	assert.Equal(t, "BINANCEFUTURES-BTCUSDT", r.Register("BINANCEFUTURES-", "BTCUSDT", perp))
+	assert.Equal(t, "BYBITLINEAR-BTCUSDT", r.Register("BYBITLINEAR-", "BTCUSDT", perp))
+	assert.Equal(t, "BYBITINVERSE-BTCUSD", r.Register("BYBITINVERSE-", "BTCUSD", inverse))
+
+	// Venue is taken from the prefix
+	assert.Equal(t, []string{"BINANCEFUTURES-BTCUSDT"}, r.Find(InstrumentQuery{Venue: "BINANCEFUTURES"}))
+
+	assert.Equal(t,
+		[]string{"BINANCEFUTURES-BTCUSDT", "BYBITLINEAR-BTCUSDT"},
+		r.Find(InstrumentQuery{Base: "btc", Quote: "USDT", Product: PerpetualProduct}))
+	assert.Equal(t,
+		[]string{"BYBITINVERSE-BTCUSD"},
+		r.Find(InstrumentQuery{Settle: "BTC"}))
+}
+
+func TestNativeSymbol(t *testing.T) {
+	assert.Equal(t, "BTCUSD", NativeSymbol("PHEMEX-", "PHEMEX-BTCUSD"))
+	assert.Equal(t, "PHEMEX-ETHUSD", FullSymbol("PHEMEX-", "ETHUSD"))
+}
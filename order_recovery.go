@@ -0,0 +1,216 @@
+package exchanges
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+type knownOrder struct {
+	symbol string // Can be empty
+	status OrderStatusType
+}
+
+// OrderEventRecoverer restores order events lost while the stream was disconnected.
+// It remembers the last status of every not final order from the stream and after
+// reconnection compares them with GetOpenOrders and GetOrders results.
+// Order times of GetOrders are expected in milliseconds.
+// Orders are matched by the stream's ID and symbol, see OrderStreamKeyer.
+type OrderEventRecoverer struct {
+	exchange Exchange
+	lg       *zap.Logger
+
+	mu             sync.Mutex
+	orders         map[string]knownOrder // By order ID
+	disconnectedAt time.Time
+
+	now func() time.Time
+}
+
+func NewOrderEventRecoverer(ex Exchange, lg *zap.Logger) *OrderEventRecoverer {
+	return &OrderEventRecoverer{
+		exchange: ex,
+		lg:       lg.Named("OrderEventRecoverer"),
+		orders:   map[string]knownOrder{},
+		now:      time.Now,
+	}
+}
+
+// Observe should be called for every event of the target stream, before reconnector
+func (r *OrderEventRecoverer) Observe(ev OrderEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch {
+	case ev.DisconnectedWithErr != nil:
+		if r.disconnectedAt.IsZero() {
+			r.disconnectedAt = r.now()
+		}
+	case ev.Payload != nil:
+		symbol := ""
+		if ev.Payload.Symbol != nil {
+			symbol = *ev.Payload.Symbol
+		}
+		r.setStatus(ev.Payload.OrderID, symbol, ev.Payload.OrderStatus)
+	}
+}
+
+func (r *OrderEventRecoverer) setStatus(id, symbol string, status OrderStatusType) {
+	if status.IsFinalStatus() {
+		delete(r.orders, id)
+		return
+	}
+	if symbol == "" {
+		symbol = r.orders[id].symbol
+	}
+	r.orders[id] = knownOrder{symbol: symbol, status: status}
+}
+
+// WrapConnect makes `connect` observed by the recoverer
+func (r *OrderEventRecoverer) WrapConnect(connect OrderEventReconnectorFn) OrderEventReconnectorFn {
+	return func(ctx context.Context) (<-chan OrderEvent, error) {
+		in, err := connect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		out := make(chan OrderEvent, 100) // TODO: move to config
+		go func() {
+			defer close(out)
+			for ev := range in {
+				r.Observe(ev)
+				select {
+				case out <- ev:
+				case <-ctx.Done():
+				}
+			}
+		}()
+		return out, nil
+	}
+}
+
+func (r *OrderEventRecoverer) streamKeyed(o OrderDetailInfo) OrderDetailInfo {
+	if keyer, ok := r.exchange.(OrderStreamKeyer); ok {
+		o.ID, o.Symbol = keyer.StreamOrderKey(o)
+	}
+	return o
+}
+
+// Recover returns events of orders which changed status since the disconnection.
+// Orders created during the disconnection are included too: the history since the
+// disconnection is read for every symbol with open or known orders.
+// Known orders which can't be found anymore are forgotten.
+func (r *OrderEventRecoverer) Recover(ctx context.Context) ([]OrderEvent, error) {
+	r.mu.Lock()
+	since := r.disconnectedAt
+	known := make(map[string]knownOrder, len(r.orders))
+	for id, o := range r.orders {
+		known[id] = o
+	}
+	r.mu.Unlock()
+
+	openOrders, err := r.exchange.GetOpenOrders(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't get open orders")
+	}
+
+	actual := map[string]OrderDetailInfo{}
+	symbols := map[string]struct{}{}
+	for _, o := range openOrders {
+		o = r.streamKeyed(o)
+		actual[o.ID] = o
+		symbols[o.Symbol] = struct{}{}
+	}
+	for _, o := range known {
+		if o.symbol != "" {
+			symbols[o.symbol] = struct{}{}
+		}
+	}
+
+	filter := OrderFilter{}
+	if !since.IsZero() {
+		filter.Since = &since
+	}
+	for symbol := range symbols {
+		s := symbol
+		filter.Symbol = &s
+		history, err := r.exchange.GetOrders(ctx, filter)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't get orders of %s", symbol)
+		}
+		for _, o := range history {
+			o = r.streamKeyed(o)
+			if _, ok := actual[o.ID]; !ok {
+				actual[o.ID] = o
+			}
+		}
+	}
+
+	// Known orders created before the disconnection may be out of the history
+	unseen := []string{}
+	for id, k := range known {
+		if _, ok := actual[id]; ok {
+			continue
+		}
+		if k.symbol == "" {
+			unseen = append(unseen, id)
+			continue
+		}
+		info, err := r.exchange.GetOrderInfo(ctx, k.symbol, id, nil)
+		if errors.Is(err, OrderNotFoundError) {
+			unseen = append(unseen, id)
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't get order (OrderID=%s)", id)
+		}
+		actual[id] = OrderDetailInfo{ID: id, Symbol: k.symbol, Status: info.Status}
+	}
+
+	changed := []OrderDetailInfo{}
+	for id, o := range actual {
+		k, isKnown := known[id]
+		switch {
+		case isKnown && k.status != o.Status:
+			changed = append(changed, o)
+		case !isKnown && !since.IsZero() && o.Time >= since.UnixMilli():
+			changed = append(changed, o)
+		}
+	}
+	sort.Slice(changed, func(i, j int) bool {
+		if changed[i].Time != changed[j].Time {
+			return changed[i].Time < changed[j].Time
+		}
+		return changed[i].ID < changed[j].ID
+	})
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.disconnectedAt = time.Time{}
+	for _, id := range unseen {
+		if r.orders[id] != known[id] {
+			continue // The stream has sent newer status
+		}
+		r.lg.Warn("Order wasn't found after reconnect, forgetting it",
+			zap.String("orderID", id), zap.String("symbol", known[id].symbol))
+		delete(r.orders, id)
+	}
+	events := make([]OrderEvent, 0, len(changed))
+	for _, o := range changed {
+		if r.orders[o.ID] != known[o.ID] {
+			continue // The stream has sent newer status
+		}
+		symbol := o.Symbol
+		r.setStatus(o.ID, symbol, o.Status)
+		events = append(events, OrderEvent{Payload: &OrderEventPayload{
+			OrderID:     o.ID,
+			OrderStatus: o.Status,
+			Symbol:      &symbol,
+			Recovered:   true,
+		}})
+	}
+	return events, nil
+}
@@ -0,0 +1,32 @@
+package exchanges
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestOrderEventRecovererWrapConnectDoesNotBlockAfterCancel(t *testing.T) {
+	in := make(chan OrderEvent, 200)
+	for i := 0; i < cap(in); i++ {
+		in <- OrderEvent{Payload: &OrderEventPayload{OrderID: "1", OrderStatus: NewOST}}
+	}
+	close(in)
+
+	rec := NewOrderEventRecoverer(nil, zap.NewNop())
+	connect := rec.WrapConnect(func(ctx context.Context) (<-chan OrderEvent, error) {
+		return in, nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	out, err := connect(ctx)
+	assert.NoError(t, err)
+
+	// Nobody reads `out` after the cancellation, the target stream is still drained
+	cancel()
+	assert.Eventually(t, func() bool { return len(in) == 0 }, time.Second, time.Millisecond)
+	for range out {
+	}
+}
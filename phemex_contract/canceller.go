@@ -109,7 +109,9 @@ func (oc *OrderCanceller) mapCancellationStatusToError(status exchanges.OrderSta
 
 func (oc *OrderCanceller) sendCancellationRequest(ctx context.Context, phemexSymbol, orderID string) error {
 	// OPTIMIZTION: in case of order changing there is AmendOrder/ReplaceOrder method
-	oc.lim.Contract.Lim.Wait()
+	if err := oc.lim.Contract.Wait(ctx, utils.CancelPriority); err != nil {
+		return errors.Wrap(err, "rate limiter")
+	}
 	order, rateLimHeaders, err := oc.client.NewCancelOrderService().
 		OrderID(orderID).
 		Symbol(phemexSymbol).
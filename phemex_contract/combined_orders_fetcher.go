@@ -284,7 +284,9 @@ func (cof *CombinedOrdersFetcher) Start(ctx context.Context) error {
 func (cof *CombinedOrdersFetcher) unifiedGetOrderInfo(
 	ctx context.Context, svc *krisa_phemex_fork.QueryOrderService,
 ) (exchanges.OrderInfo, *orderResponse, error) {
-	cof.lim.Other.Lim.Wait()
+	if err := cof.lim.Other.Wait(ctx, utils.AccountReadPriority); err != nil {
+		return exchanges.OrderInfo{}, nil, errors.Wrap(err, "rate limiter")
+	}
 	resp, rateLimHeaders, err := svc.Do(ctx)
 	cof.lim.Apply(rateLimHeaders)
 	if IsAPINotFoundError(err) {
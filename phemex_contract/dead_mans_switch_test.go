@@ -0,0 +1,87 @@
+package phemex_contract
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// lostStreamPhemex can't connect to the orders stream, REST calls go to the venue
+type lostStreamPhemex struct {
+	*PhemexContract
+}
+
+func (lostStreamPhemex) WatchOrdersStatuses(context.Context) (<-chan exchanges.OrderEvent, error) {
+	return nil, errors.New("unable to auth")
+}
+
+func TestDeadMansSwitchWatchdogCancelsPhemexOrders(t *testing.T) {
+	var mu sync.Mutex
+	var canceled []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/orders/activeList":
+			w.Write([]byte(`{"code":0,"msg":"","data":{"rows":[{"orderID":"order-1","clOrdID":"cl-1",` +
+				`"symbol":"BTCUSD","side":"Buy","orderType":"Limit","price":20000,"orderQty":10,"ordStatus":"New"}]}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/exchange/order":
+			w.Write([]byte(`{"code":0,"msg":"","data":[{"orderID":"order-1","clOrdID":"cl-1",` +
+				`"symbol":"BTCUSD","ordStatus":"New"}]}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/orders/cancel":
+			q := r.URL.Query()
+			mu.Lock()
+			canceled = append(canceled, q.Get("symbol")+"/"+q.Get("orderID"))
+			mu.Unlock()
+			w.Write([]byte(`{"code":0,"msg":"","data":{"orderID":"order-1","symbol":"BTCUSD","ordStatus":"Canceled"}}`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	lg := zap.NewNop()
+	pc := NewPhemexContract("key", "secret", NewPhemexRateLimiter(lg), lg)
+	pc.client.BaseURL = srv.URL
+	pc.forkClient.BaseURL = srv.URL
+
+	_, native := interface{}(pc).(exchanges.DeadMansSwitchExchange)
+	assert.False(t, native, "Phemex has no cancel-all-after endpoint")
+
+	d := exchanges.NewDeadMansSwitch(lostStreamPhemex{pc}, lg)
+	d.Timeout = 100 * time.Millisecond
+	d.Interval = 10 * time.Millisecond
+	states := make(chan exchanges.DeadMansSwitchState, 10)
+	d.OnStateChange = func(s exchanges.DeadMansSwitchState) { states <- s }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go d.Run(ctx)
+
+	timeout := time.After(5 * time.Second)
+	for triggered := false; !triggered; {
+		select {
+		case s := <-states:
+			triggered = s.Triggered
+		case <-timeout:
+			require.FailNow(t, "the watchdog isn't triggered")
+		}
+	}
+
+	state := d.State()
+	assert.False(t, state.Native)
+	assert.False(t, state.Armed)
+	assert.NoError(t, state.Err)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"BTCUSD/order-1"}, canceled)
+}
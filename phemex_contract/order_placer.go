@@ -189,7 +189,9 @@ func (op *OrderPlacer) fetchAndCompare(ctx context.Context, req *orderFields) (i
 
 func (op *OrderPlacer) tryToPlaceOrder(ctx context.Context, req *orderFields) (id string, e error) {
 	// log.Printf("tryToPlaceOrder: %v", req)
-	op.lim.Contract.Lim.Wait()
+	if err := op.lim.Contract.Wait(ctx, utils.PlacePriority); err != nil {
+		return "", errors.Wrap(err, "rate limiter")
+	}
 	order, rateLimHeaders, err := req.ToAPI(op.client).Do(ctx)
 	op.lim.Apply(rateLimHeaders)
 	if op.isOrderRejectedError(err) {
@@ -20,11 +20,11 @@ var PhemexOrderClearedError = errors.New("phemex order was cleared")
 const PHEMEX_PREFIX = "PHEMEX-"
 
 func ToPhemexSymbol(symbol string) string {
-	return strings.TrimPrefix(symbol, PHEMEX_PREFIX)
+	return exchanges.NativeSymbol(PHEMEX_PREFIX, symbol)
 }
 
 func ToFullSymbol(phemexSymbol string) string {
-	return PHEMEX_PREFIX + phemexSymbol
+	return exchanges.FullSymbol(PHEMEX_PREFIX, phemexSymbol)
 }
 
 func ConvertPhemexPriceToPriceEp(phemexSymbol string, price *apd.Decimal) (priceEp int64, scale SymbolScale, e error) {
@@ -38,6 +38,8 @@ func ConvertPhemexPriceToPriceEp(phemexSymbol string, price *apd.Decimal) (price
 	return result, symbolScales, errors.Wrap(err, "PriceEp cannot be represented with int64")
 }
 
+// PhemexContract doesn't implement exchanges.DeadMansSwitchExchange: Phemex has no
+// cancel-all-after endpoint, so exchanges.DeadMansSwitch uses its local watchdog.
 type PhemexContract struct {
 	client          *phemex.Client
 	forkClient      *krisa_phemex_fork.Client
@@ -208,12 +210,30 @@ func (pc *PhemexContract) GetTradableSymbols(ctx context.Context) ([]exchanges.S
 	}
 	result := []exchanges.SymbolInfo{}
 	for _, product := range data.Products {
-		if product.Type != krisa_phemex_fork.PerpetualProductType {
+		if product.Status != krisa_phemex_fork.ListedProductStatus {
 			continue
 		}
-		if product.Status != krisa_phemex_fork.ListedProductStatus {
+		productType, ok := mapProductType(product.Type)
+		if !ok {
 			continue
 		}
+
+		// DisplaySymbol is "BTC / USD"
+		instrument := exchanges.Instrument{
+			Product: productType,
+			Quote:   product.QuoteCurrency,
+		}
+		if productType != exchanges.SpotProduct {
+			instrument.Settle = product.SettleCurrency
+		}
+		if parts := strings.Split(product.DisplaySymbol, " / "); len(parts) == 2 {
+			instrument.Base = parts[0]
+		}
+		fullSymbol := exchanges.Instruments.Register(PHEMEX_PREFIX, product.Symbol, instrument)
+		if productType != exchanges.PerpetualProduct {
+			continue // Only contracts are tradable by the adapter
+		}
+
 		filters := []map[string]interface{}{
 			{
 				"contractSize": product.ContractSize,
@@ -221,8 +241,6 @@ func (pc *PhemexContract) GetTradableSymbols(ctx context.Context) ([]exchanges.S
 				"tickSize":     product.TickSize,
 			},
 		}
-
-		fullSymbol := ToFullSymbol(product.Symbol)
 		displaySymbol := PHEMEX_PREFIX + strings.ReplaceAll(product.DisplaySymbol, " / ", "")
 		displayName := fmt.Sprintf("%s (%s-Margin)", displaySymbol, product.SettleCurrency)
 		result = append(result, exchanges.SymbolInfo{
@@ -230,11 +248,23 @@ func (pc *PhemexContract) GetTradableSymbols(ctx context.Context) ([]exchanges.S
 			Symbol:         fullSymbol,
 			OriginalSymbol: product.Symbol,
 			Filters:        filters,
+			Instrument:     &instrument,
 		})
 	}
 	return result, nil
 }
 
+// mapProductType maps "Perpetual" and "Spot" products, others aren't supported
+func mapProductType(productType string) (exchanges.ProductType, bool) {
+	switch productType {
+	case krisa_phemex_fork.PerpetualProductType:
+		return exchanges.PerpetualProduct, true
+	case krisa_phemex_fork.SpotProductType:
+		return exchanges.SpotProduct, true
+	}
+	return "", false
+}
+
 // WatchOrdersStatuses Returns control immediately
 // If error is sent then channel will be closed automatically
 // Channel will be closed in two ways: by context and by disconnection
@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	exchanges "github.com/aulaleslie/trade-exchanges"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 )
@@ -17,3 +18,16 @@ func TestGetTradableSymbols(t *testing.T) {
 
 	t.Logf("symbols: %v", symbols)
 }
+
+func TestMapProductType(t *testing.T) {
+	productType, ok := mapProductType("Perpetual")
+	assert.True(t, ok)
+	assert.Equal(t, exchanges.PerpetualProduct, productType)
+
+	productType, ok = mapProductType("Spot")
+	assert.True(t, ok)
+	assert.Equal(t, exchanges.SpotProduct, productType)
+
+	_, ok = mapProductType("Option")
+	assert.False(t, ok)
+}
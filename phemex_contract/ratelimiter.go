@@ -1,6 +1,7 @@
 package phemex_contract
 
 import (
+	"context"
 	"time"
 
 	"github.com/aulaleslie/trade-exchanges/phemex_contract/krisa_phemex_fork"
@@ -43,7 +44,8 @@ func (rl *PhemexRateLimiter) Apply(rateLimHeaders *krisa_phemex_fork.RateLimiter
 /////
 
 type PhemexGroupRateLimiter struct {
-	Lim *utils.ChangeableMinuteRateLimiter
+	Lim   *utils.SlidingWindowRateLimiter
+	Sched *utils.PriorityScheduler
 }
 
 func NewPhemexGroupRateLimiter(capacityPerMinute uint) *PhemexGroupRateLimiter {
@@ -51,13 +53,21 @@ func NewPhemexGroupRateLimiter(capacityPerMinute uint) *PhemexGroupRateLimiter {
 	// x-ratelimit-capacity-groupName    Request ratelimit capacity
 	// x-ratelimit-retry-after-groupName Reset timeout in seconds for current ratelimited user
 	return &PhemexGroupRateLimiter{
-		Lim: utils.NewChangeableMinuteRateLimiter(int(capacityPerMinute)),
+		Lim:   utils.NewSlidingWindowRateLimiter(int(capacityPerMinute), time.Minute),
+		Sched: utils.NewPriorityScheduler(utils.DefaultPrioritySchedulerConfig(int(capacityPerMinute))),
 	}
 }
 
+// Wait takes one request permit. Requests with higher priority in `ctx` go first.
+func (pgrl *PhemexGroupRateLimiter) Wait(ctx context.Context, p utils.Priority) error {
+	return pgrl.Sched.WaitLimiter(utils.WithPriority(ctx, p), pgrl.Lim, 1)
+}
+
 func (pgrl *PhemexGroupRateLimiter) Apply(rateLimHeaders *krisa_phemex_fork.RateLimiterHeaders) {
 	if rateLimHeaders.RetryAfter != nil {
-		pgrl.Lim.SetNextDuration(*rateLimHeaders.RetryAfter + time.Second)
+		pgrl.Lim.SetRetryAfter(*rateLimHeaders.RetryAfter + time.Second)
+	}
+	if rateLimHeaders.Remaining != nil {
+		pgrl.Lim.SetRemaining(*rateLimHeaders.Remaining)
 	}
-	// IMPROVEMENT: set Remaining too. But I think that this will be an excess.
 }
@@ -0,0 +1,160 @@
+package exchanges
+
+import (
+	"context"
+	"time"
+
+	"github.com/avast/retry-go"
+	"github.com/pkg/errors"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+)
+
+// TODO: move to config
+var defaultPositionEventReconnectOptions []retry.Option = []retry.Option{
+	// 2 sec * (2^(3-1)-1) = 6 sec
+	// M[U(0sec, 1sec)] * (3-1) = 1 sec / 2 * 2 = 1 sec
+	retry.Attempts(3),
+	retry.Delay(time.Second * 2),
+	retry.DelayType(retry.BackOffDelay),
+	retry.MaxJitter(time.Second),
+	retry.LastErrorOnly(true),
+}
+
+type PositionEventReconnectorFn func(context.Context) (<-chan PositionEvent, error)
+
+// PositionEventReconnector is like the generated reconnectors, but it also
+// reconnects streams which are closed without DisconnectedWithErr.
+type PositionEventReconnector struct {
+	connect          PositionEventReconnectorFn
+	reconnectOptions []retry.Option // optional
+	logger           *zap.Logger
+}
+
+func NewPositionEventReconnector(
+	connect PositionEventReconnectorFn, reconnectOpts []retry.Option, l *zap.Logger,
+) *PositionEventReconnector {
+	return &PositionEventReconnector{
+		connect:          connect,
+		reconnectOptions: reconnectOpts,
+		logger:           l.Named("PositionEventReconnector"),
+	}
+}
+
+func (r *PositionEventReconnector) getReconnectOptions(
+	ctx context.Context,
+) []retry.Option {
+	result := []retry.Option{retry.Context(ctx)}
+	if r.reconnectOptions != nil {
+		result = append(result, r.reconnectOptions...)
+	} else {
+		result = append(result, defaultPositionEventReconnectOptions...)
+	}
+	return result
+}
+
+// chanShifter sends DisconnectedWithErr if `in` is closed without it
+func (r *PositionEventReconnector) chanShifter(ctx context.Context, in <-chan PositionEvent, out chan<- PositionEvent) {
+	disconnected := false
+	for ev := range in {
+		if ev.DisconnectedWithErr != nil {
+			disconnected = true
+		}
+		select {
+		case out <- ev:
+		case <-ctx.Done():
+			for range in {
+			}
+			return
+		}
+	}
+	if disconnected || ctx.Err() != nil {
+		return
+	}
+	select {
+	case out <- PositionEvent{DisconnectedWithErr: errors.New("stream is closed")}:
+	case <-ctx.Done():
+	}
+}
+
+func (r *PositionEventReconnector) Watch(
+	ctx context.Context,
+) (<-chan PositionEvent, error) {
+	in, err := r.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	intermediate := make(chan PositionEvent, 100)
+	out := make(chan PositionEvent, 100) // TODO: extract to config
+
+	// Shifting required b/c we changing in channel
+	go r.chanShifter(ctx, in, intermediate)
+
+	send := func(ev PositionEvent) bool {
+		select {
+		case out <- ev:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	reconnectAttempt := atomic.NewUint32(0)
+	reconnect := func() bool {
+		reconnectAttempt.Inc()
+		serialAttempt := 1
+
+		r.logger.Info("Reconnecting...", zap.Uint32("reconnectAttempt", reconnectAttempt.Load()))
+		e := retry.Do(func() error {
+			defer func() { serialAttempt++ }()
+			lg := r.logger.With(
+				zap.Uint32("attempt", reconnectAttempt.Load()),
+				zap.Int("serialAttempt", serialAttempt))
+
+			in, err := r.connect(ctx)
+			if err == nil {
+				lg.Info("Reconnected successfully")
+				go r.chanShifter(ctx, in, intermediate)
+				return nil
+			}
+
+			lg.Warn("Reconnect error", zap.Error(err))
+			return err
+		}, r.getReconnectOptions(ctx)...)
+		return e == nil && send(PositionEvent{Reconnected: &struct{}{}})
+	}
+
+	go func() {
+		defer close(out)
+		for {
+			var ev PositionEvent
+			select {
+			case ev = <-intermediate:
+			case <-ctx.Done():
+				return
+			}
+
+			switch {
+			case ev.DisconnectedWithErr != nil:
+				if ctx.Err() != nil {
+					return
+				}
+				if !reconnect() {
+					send(PositionEvent{
+						DisconnectedWithErr: errors.Wrap(ev.DisconnectedWithErr, "all reconnects failed"),
+					})
+					return
+				}
+			case ev.Reconnected != nil, ev.Payload != nil, ev.Snapshot:
+				if !send(ev) {
+					return
+				}
+			default:
+				r.logger.Debug("Skipping empty event")
+			}
+		}
+	}()
+
+	return out, nil
+}
@@ -0,0 +1,45 @@
+package exchanges
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aulaleslie/trade-exchanges/utils"
+	"github.com/avast/retry-go"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+)
+
+func TestPositionReconnectorReconnectsClosedStream(t *testing.T) {
+	connects := atomic.NewUint32(0)
+	connect := func(c context.Context) (<-chan PositionEvent, error) {
+		n := connects.Inc()
+		ch := make(chan PositionEvent, 100)
+		ch <- PositionEvent{} // Empty events are skipped
+		ch <- PositionEvent{Payload: []*PositionPayload{{Symbol: "BTC", Value: utils.FromUint(uint(n))}}}
+		if n == 1 {
+			close(ch) // Closed without an error
+		}
+		return ch, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	per := NewPositionEventReconnector(connect, []retry.Option{retry.Attempts(1)}, zap.NewNop())
+	out, err := per.Watch(ctx)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "1", (<-out).Payload[0].Value.String())
+	assert.NotNil(t, (<-out).Reconnected)
+	assert.Equal(t, "2", (<-out).Payload[0].Value.String())
+
+	// Nobody reads, but the stream is closed by the context
+	cancel()
+	select {
+	case _, ok := <-out:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("stream isn't closed")
+	}
+}
@@ -2,11 +2,12 @@ package exchanges
 
 import (
 	"context"
-	"errors"
+	"sort"
 	"time"
 
 	"github.com/avast/retry-go/v3"
 	"github.com/cockroachdb/apd"
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
 )
 
@@ -46,6 +47,8 @@ type RetryeableExchange struct {
 	RetryOptions       []retry.Option // optional
 	CancelRetryOptions []retry.Option // optional
 	Logger             *zap.Logger
+	// Send missed order events after reconnection, see OrderEventRecoverer
+	RecoverOrderEvents bool
 }
 
 var _ Exchange = (*RetryeableExchange)(nil)
@@ -243,8 +246,66 @@ func (re *RetryeableExchange) GetTradableSymbols(ctx context.Context) (info []Sy
 }
 
 func (re *RetryeableExchange) WatchOrdersStatuses(ctx context.Context) (<-chan OrderEvent, error) {
-	oer := NewOrderEventReconnector(re.Target.WatchOrdersStatuses, nil, re.Logger)
-	return oer.Watch(ctx)
+	if !re.RecoverOrderEvents {
+		oer := NewOrderEventReconnector(re.Target.WatchOrdersStatuses, nil, re.Logger)
+		return oer.Watch(ctx)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	rec := NewOrderEventRecoverer(re, re.Logger)
+	oer := NewOrderEventReconnector(rec.WrapConnect(re.Target.WatchOrdersStatuses), nil, re.Logger)
+	in, err := oer.Watch(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	out := make(chan OrderEvent, 100) // TODO: move to config
+	go func() {
+		defer close(out)
+		defer func() {
+			cancel()
+			for range in {
+			}
+		}()
+
+		send := func(ev OrderEvent) bool {
+			select {
+			case out <- ev:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		for ev := range in {
+			if !send(ev) {
+				return
+			}
+			if ev.Reconnected == nil {
+				continue
+			}
+
+			recovered, err := rec.Recover(ctx)
+			if err != nil {
+				send(OrderEvent{DisconnectedWithErr: errors.Wrap(err, "can't recover order events after reconnect")})
+				return
+			}
+			for _, ev := range recovered {
+				if !send(ev) {
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// StreamOrderKey delegates to the target if it implements OrderStreamKeyer
+func (re *RetryeableExchange) StreamOrderKey(o OrderDetailInfo) (string, string) {
+	if keyer, ok := re.Target.(OrderStreamKeyer); ok {
+		return keyer.StreamOrderKey(o)
+	}
+	return o.ID, o.Symbol
 }
 
 func (re *RetryeableExchange) WatchSymbolPrice(ctx context.Context, symbol string) (<-chan PriceEvent, error) {
@@ -281,9 +342,96 @@ func (re *RetryeableExchange) PlaceSellOrderV2(ctx context.Context, isRetry bool
 	return id, e
 }
 
+// WatchAccountPositions sends snapshot of all positions after every Reconnected event
+// because position changes during the disconnection are lost.
 func (re *RetryeableExchange) WatchAccountPositions(ctx context.Context) (<-chan PositionEvent, error) {
-	// TODO make it wrap by reconnector
-	return re.Target.WatchAccountPositions(ctx)
+	ctx, cancel := context.WithCancel(ctx)
+	per := NewPositionEventReconnector(re.Target.WatchAccountPositions, nil, re.Logger)
+	in, err := per.Watch(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	out := make(chan PositionEvent, 100) // TODO: move to config
+	go func() {
+		defer close(out)
+		defer func() {
+			cancel()
+			for range in {
+			}
+		}()
+
+		send := func(ev PositionEvent) bool {
+			select {
+			case out <- ev:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		seen := map[string]struct{}{}
+		for ev := range in {
+			for _, p := range ev.Payload {
+				seen[p.Symbol] = struct{}{}
+			}
+			if !send(ev) {
+				return
+			}
+			if ev.Reconnected == nil {
+				continue
+			}
+
+			snapshot, err := re.positionsSnapshot(ctx, seen)
+			if err != nil {
+				send(PositionEvent{DisconnectedWithErr: errors.Wrap(err, "can't get positions after reconnect")})
+				return
+			}
+			if !send(snapshot) {
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// positionsSnapshot has the same values as position streams: sizes of positions
+// or the values of PositionStreamSnapshotter if the target implements it.
+// Symbols from `seen` which are absent in the account are sent with zero value.
+func (re *RetryeableExchange) positionsSnapshot(ctx context.Context, seen map[string]struct{}) (PositionEvent, error) {
+	acc, err := re.GetAccount(ctx)
+	if err != nil {
+		return PositionEvent{}, err
+	}
+
+	payload := []*PositionPayload{}
+	if snapshotter, ok := re.Target.(PositionStreamSnapshotter); ok {
+		payload = snapshotter.StreamPositions(acc)
+	} else {
+		for _, p := range acc.AccountPositions {
+			payload = append(payload, &PositionPayload{Symbol: p.Symbol, Value: p.Size})
+		}
+	}
+	present := map[string]struct{}{}
+	for _, p := range payload {
+		present[p.Symbol] = struct{}{}
+	}
+
+	closed := []string{}
+	for symbol := range seen {
+		if _, ok := present[symbol]; !ok {
+			closed = append(closed, symbol)
+		}
+	}
+	sort.Strings(closed)
+	for _, symbol := range closed {
+		payload = append(payload, &PositionPayload{Symbol: symbol, Value: apd.New(0, 0)})
+	}
+	for symbol := range present {
+		seen[symbol] = struct{}{}
+	}
+	return PositionEvent{Payload: payload, Snapshot: true}, nil
 }
 
 func (re *RetryeableExchange) GenerateClientOrderID(ctx context.Context, identifierID string) (string, error) {
@@ -8,6 +8,7 @@ import (
 	"github.com/aulaleslie/trade-exchanges/utils"
 	gomock "github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
 )
 
 func TestPlaceBuyOrder(t *testing.T) {
@@ -116,3 +117,102 @@ func TestLastError(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, errLast, err)
 }
+
+func TestWatchAccountPositionsSendsSnapshotAfterReconnect(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ex := NewMockExchange(ctrl)
+
+	re := &RetryeableExchange{Target: ex, Logger: zap.NewNop()}
+
+	first := make(chan PositionEvent, 2)
+	first <- PositionEvent{Payload: []*PositionPayload{{Symbol: "BTC", Value: utils.FromUint(1)}}}
+	first <- PositionEvent{DisconnectedWithErr: errors.New("disconnect")}
+	close(first)
+	second := make(chan PositionEvent)
+
+	gomock.InOrder(
+		ex.EXPECT().WatchAccountPositions(gomock.Any()).Return((<-chan PositionEvent)(first), nil),
+		ex.EXPECT().WatchAccountPositions(gomock.Any()).Return((<-chan PositionEvent)(second), nil),
+	)
+	ex.EXPECT().GetAccount(gomock.Any()).Return(Account{
+		AccountPositions: []AccountPosition{{Symbol: "ETH", Size: utils.FromUint(2)}},
+	}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := re.WatchAccountPositions(ctx)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "BTC", (<-ch).Payload[0].Symbol)
+	assert.NotNil(t, (<-ch).Reconnected)
+
+	snapshot := <-ch
+	assert.True(t, snapshot.Snapshot)
+	assert.Len(t, snapshot.Payload, 2)
+	assert.Equal(t, "ETH", snapshot.Payload[0].Symbol)
+	assert.Equal(t, utils.FromUint(2), snapshot.Payload[0].Value)
+	assert.Equal(t, "BTC", snapshot.Payload[1].Symbol)
+	assert.True(t, snapshot.Payload[1].Value.IsZero())
+}
+
+func TestWatchAccountPositionsSnapshotHasOnlyStreamedValues(t *testing.T) {
+	account := Account{
+		AccountPositions: []AccountPosition{{Symbol: "BINANCEFUTURES-ETHUSDT", Size: utils.FromUint(2)}},
+		AccountBalances:  []AccountBalance{{Coin: "USDT", Free: utils.FromUint(100)}},
+	}
+	watch := func(t *testing.T, target Exchange, ex *MockExchange, streamed PositionPayload) PositionEvent {
+		first := make(chan PositionEvent, 2)
+		first <- PositionEvent{Payload: []*PositionPayload{&streamed}}
+		first <- PositionEvent{DisconnectedWithErr: errors.New("disconnect")}
+		close(first)
+		gomock.InOrder(
+			ex.EXPECT().WatchAccountPositions(gomock.Any()).Return((<-chan PositionEvent)(first), nil),
+			ex.EXPECT().WatchAccountPositions(gomock.Any()).Return((<-chan PositionEvent)(make(chan PositionEvent)), nil),
+		)
+		ex.EXPECT().GetAccount(gomock.Any()).Return(account, nil)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		re := &RetryeableExchange{Target: target, Logger: zap.NewNop()}
+		ch, err := re.WatchAccountPositions(ctx)
+		assert.NoError(t, err)
+		<-ch
+		assert.NotNil(t, (<-ch).Reconnected)
+		return <-ch
+	}
+
+	t.Run("futures", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		ex := NewMockExchange(ctrl)
+
+		// ACCOUNT_UPDATE of futures streams has only positions
+		snapshot := watch(t, ex, ex, PositionPayload{Symbol: "BINANCEFUTURES-BTCUSDT", Value: utils.FromUint(1)})
+		assert.True(t, snapshot.Snapshot)
+		assert.Len(t, snapshot.Payload, 2)
+		assert.Equal(t, "BINANCEFUTURES-ETHUSDT", snapshot.Payload[0].Symbol)
+		assert.Equal(t, utils.FromUint(2), snapshot.Payload[0].Value)
+		assert.Equal(t, "BINANCEFUTURES-BTCUSDT", snapshot.Payload[1].Symbol)
+		assert.True(t, snapshot.Payload[1].Value.IsZero())
+	})
+
+	t.Run("spot", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		ex := NewMockExchange(ctrl)
+		snapshotter := NewMockPositionStreamSnapshotter(ctrl)
+		target := struct {
+			*MockExchange
+			*MockPositionStreamSnapshotter
+		}{ex, snapshotter}
+		snapshotter.EXPECT().StreamPositions(account).Return([]*PositionPayload{{Symbol: "USDT", Value: utils.FromUint(100)}})
+
+		// outboundAccountPosition of spot streams has only free balances
+		snapshot := watch(t, target, ex, PositionPayload{Symbol: "BTC", Value: utils.FromUint(1)})
+		assert.True(t, snapshot.Snapshot)
+		assert.Len(t, snapshot.Payload, 2)
+		assert.Equal(t, "USDT", snapshot.Payload[0].Symbol)
+		assert.Equal(t, utils.FromUint(100), snapshot.Payload[0].Value)
+		assert.Equal(t, "BTC", snapshot.Payload[1].Symbol)
+		assert.True(t, snapshot.Payload[1].Value.IsZero())
+	})
+}
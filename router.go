@@ -0,0 +1,431 @@
+package exchanges
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aulaleslie/trade-exchanges/utils"
+	"github.com/cockroachdb/apd"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+var UnknownVenueError = errors.New("no venue for the symbol")
+
+// VenueErrors is returned by Router's account-wide methods when some of
+// the venues failed. Results of the other venues are returned alongside.
+type VenueErrors map[string]error
+
+func (ve VenueErrors) Error() string {
+	venues := make([]string, 0, len(ve))
+	for venue := range ve {
+		venues = append(venues, venue)
+	}
+	sort.Strings(venues)
+
+	parts := make([]string, 0, len(venues))
+	for _, venue := range venues {
+		parts = append(parts, venue+": "+ve[venue].Error())
+	}
+	return "venues failed: " + strings.Join(parts, "; ")
+}
+
+// Router is the Exchange over several venues.
+// Calls with a symbol are dispatched to the venue with the longest matching prefix.
+// GetOpenOrders, GetAccount and GetTradableSymbols are sent to all venues and merged,
+// the same way streams are merged into one channel with events tagged by venue.
+//
+// A merged stream doesn't close when one venue disconnects: the tagged error is
+// forwarded and the channel is closed only after all venues are closed. So it's better
+// to wrap venues into RetryeableExchange before routing than to wrap the Router.
+type Router struct {
+	venues   []Exchange // In order of registration
+	byPrefix []Exchange // Longest prefix first
+	lgs      *zap.SugaredLogger
+}
+
+var _ Exchange = (*Router)(nil) // Type check
+
+func NewRouter(lg *zap.Logger, venues ...Exchange) (*Router, error) {
+	seen := map[string]struct{}{}
+	for _, ex := range venues {
+		prefix := ex.GetPrefix()
+		if prefix == "" {
+			return nil, errors.Errorf("venue %s has empty prefix", ex.GetName())
+		}
+		if _, ok := seen[prefix]; ok {
+			return nil, errors.Errorf("prefix %s is used by several venues", prefix)
+		}
+		seen[prefix] = struct{}{}
+	}
+
+	byPrefix := append([]Exchange{}, venues...)
+	sort.SliceStable(byPrefix, func(i, j int) bool {
+		return len(byPrefix[i].GetPrefix()) > len(byPrefix[j].GetPrefix())
+	})
+
+	return &Router{
+		venues:   append([]Exchange{}, venues...),
+		byPrefix: byPrefix,
+		lgs:      lg.Named("Router").Sugar(),
+	}, nil
+}
+
+func venueOf(ex Exchange) string {
+	return VenueFromPrefix(ex.GetPrefix())
+}
+
+func withPrefix(prefix, symbol string) string {
+	if symbol == "" || strings.HasPrefix(symbol, prefix) {
+		return symbol
+	}
+	return prefix + symbol
+}
+
+// Venue returns the exchange responsible for the symbol
+func (r *Router) Venue(symbol string) (Exchange, error) {
+	for _, ex := range r.byPrefix {
+		if strings.HasPrefix(symbol, ex.GetPrefix()) {
+			return ex, nil
+		}
+	}
+	return nil, errors.Wrapf(UnknownVenueError, "symbol %s", symbol)
+}
+
+// watchError returns the error of the venue which has no stream,
+// the venue may return nil channel without an error.
+func watchError(err error, venue string) error {
+	ve, _ := err.(VenueErrors)
+	if e := ve[venue]; e != nil {
+		return e
+	}
+	return errors.Errorf("%s returned nil channel", venue)
+}
+
+func (r *Router) Venues() []Exchange {
+	return append([]Exchange{}, r.venues...)
+}
+
+// forEachVenue calls fn for every venue concurrently.
+// Returns nil or VenueErrors.
+func (r *Router) forEachVenue(fn func(i int, ex Exchange) error) error {
+	errs := make([]error, len(r.venues))
+	wg := sync.WaitGroup{}
+	for i, ex := range r.venues {
+		wg.Add(1)
+		go func(i int, ex Exchange) {
+			defer wg.Done()
+			errs[i] = fn(i, ex)
+		}(i, ex)
+	}
+	wg.Wait()
+
+	result := VenueErrors{}
+	for i, err := range errs {
+		if err != nil {
+			result[venueOf(r.venues[i])] = err
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+func (r *Router) GetPrefix() string {
+	return ""
+}
+
+func (r *Router) GetName() string {
+	return "Router"
+}
+
+func (r *Router) RoundPrice(ctx context.Context, symbol string, price *apd.Decimal, tickSize *string) (*apd.Decimal, error) {
+	ex, err := r.Venue(symbol)
+	if err != nil {
+		return nil, err
+	}
+	return ex.RoundPrice(ctx, symbol, price, tickSize)
+}
+
+func (r *Router) RoundQuantity(ctx context.Context, symbol string, qty *apd.Decimal) (*apd.Decimal, error) {
+	ex, err := r.Venue(symbol)
+	if err != nil {
+		return nil, err
+	}
+	return ex.RoundQuantity(ctx, symbol, qty)
+}
+
+func (r *Router) PlaceBuyOrder(
+	ctx context.Context, isRetry bool, symbol string, price, qty *apd.Decimal, clientOrderID string,
+) (string, error) {
+	ex, err := r.Venue(symbol)
+	if err != nil {
+		return "", err
+	}
+	return ex.PlaceBuyOrder(ctx, isRetry, symbol, price, qty, clientOrderID)
+}
+
+func (r *Router) PlaceSellOrder(
+	ctx context.Context, isRetry bool, symbol string, price, qty *apd.Decimal, clientOrderID string,
+) (string, error) {
+	ex, err := r.Venue(symbol)
+	if err != nil {
+		return "", err
+	}
+	return ex.PlaceSellOrder(ctx, isRetry, symbol, price, qty, clientOrderID)
+}
+
+func (r *Router) PlaceBuyOrderV2(
+	ctx context.Context, isRetry bool, symbol string, price, qty *apd.Decimal, clientOrderID string, orderType string,
+) (string, error) {
+	ex, err := r.Venue(symbol)
+	if err != nil {
+		return "", err
+	}
+	return ex.PlaceBuyOrderV2(ctx, isRetry, symbol, price, qty, clientOrderID, orderType)
+}
+
+func (r *Router) PlaceSellOrderV2(
+	ctx context.Context, isRetry bool, symbol string, price, qty *apd.Decimal, clientOrderID string, orderType string,
+) (string, error) {
+	ex, err := r.Venue(symbol)
+	if err != nil {
+		return "", err
+	}
+	return ex.PlaceSellOrderV2(ctx, isRetry, symbol, price, qty, clientOrderID, orderType)
+}
+
+func (r *Router) CancelOrder(ctx context.Context, symbol, id string) error {
+	ex, err := r.Venue(symbol)
+	if err != nil {
+		return err
+	}
+	return ex.CancelOrder(ctx, symbol, id)
+}
+
+func (r *Router) ReleaseOrder(ctx context.Context, symbol, id string) error {
+	ex, err := r.Venue(symbol)
+	if err != nil {
+		return err
+	}
+	return ex.ReleaseOrder(ctx, symbol, id)
+}
+
+func (r *Router) GetPrice(ctx context.Context, symbol string) (*apd.Decimal, error) {
+	ex, err := r.Venue(symbol)
+	if err != nil {
+		return nil, err
+	}
+	return ex.GetPrice(ctx, symbol)
+}
+
+func (r *Router) GetOrderInfo(ctx context.Context, symbol, id string, createdAt *time.Time) (OrderInfo, error) {
+	ex, err := r.Venue(symbol)
+	if err != nil {
+		return OrderInfo{}, err
+	}
+	return ex.GetOrderInfo(ctx, symbol, id, createdAt)
+}
+
+func (r *Router) GetOrderInfoByClientOrderID(ctx context.Context, symbol, clientOrderID string, createdAt *time.Time) (OrderInfo, error) {
+	ex, err := r.Venue(symbol)
+	if err != nil {
+		return OrderInfo{}, err
+	}
+	return ex.GetOrderInfoByClientOrderID(ctx, symbol, clientOrderID, createdAt)
+}
+
+func (r *Router) WatchSymbolPrice(ctx context.Context, symbol string) (<-chan PriceEvent, error) {
+	ex, err := r.Venue(symbol)
+	if err != nil {
+		return nil, err
+	}
+	return ex.WatchSymbolPrice(ctx, symbol)
+}
+
+// GetOrders requires filter.Symbol because not every venue can search without it
+func (r *Router) GetOrders(ctx context.Context, filter OrderFilter) ([]OrderDetailInfo, error) {
+	if filter.Symbol == nil {
+		return nil, errors.New("Router requires filter.Symbol to choose the venue")
+	}
+	ex, err := r.Venue(*filter.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := ex.GetOrders(ctx, filter)
+	for i := range orders {
+		orders[i].Symbol = withPrefix(ex.GetPrefix(), orders[i].Symbol)
+	}
+	return orders, err
+}
+
+// GenerateClientOrderID doesn't know the venue so it can't add broker prefixes.
+// Use GenerateClientOrderIDFor if the symbol is known.
+func (r *Router) GenerateClientOrderID(ctx context.Context, identifierID string) (string, error) {
+	return utils.GenClientOrderID(identifierID)
+}
+
+func (r *Router) GenerateClientOrderIDFor(ctx context.Context, symbol, identifierID string) (string, error) {
+	ex, err := r.Venue(symbol)
+	if err != nil {
+		return "", err
+	}
+	return ex.GenerateClientOrderID(ctx, identifierID)
+}
+
+// GetTradableSymbols returns symbols of all venues, error is nil or VenueErrors
+func (r *Router) GetTradableSymbols(ctx context.Context) ([]SymbolInfo, error) {
+	perVenue := make([][]SymbolInfo, len(r.venues))
+	err := r.forEachVenue(func(i int, ex Exchange) (e error) {
+		perVenue[i], e = ex.GetTradableSymbols(ctx)
+		return
+	})
+
+	result := []SymbolInfo{}
+	for _, symbols := range perVenue {
+		result = append(result, symbols...)
+	}
+	return result, err
+}
+
+// GetOpenOrders returns open orders of all venues, error is nil or VenueErrors.
+// Symbols are prefixed to be distinguishable across venues.
+func (r *Router) GetOpenOrders(ctx context.Context) ([]OrderDetailInfo, error) {
+	perVenue := make([][]OrderDetailInfo, len(r.venues))
+	err := r.forEachVenue(func(i int, ex Exchange) (e error) {
+		perVenue[i], e = ex.GetOpenOrders(ctx)
+		return
+	})
+
+	result := []OrderDetailInfo{}
+	for i, orders := range perVenue {
+		prefix := r.venues[i].GetPrefix()
+		for _, order := range orders {
+			order.Symbol = withPrefix(prefix, order.Symbol)
+			result = append(result, order)
+		}
+	}
+	return result, err
+}
+
+// GetAccount merges accounts of all venues, error is nil or VenueErrors.
+// Balances are tagged by venue, position symbols are prefixed.
+func (r *Router) GetAccount(ctx context.Context) (Account, error) {
+	perVenue := make([]Account, len(r.venues))
+	err := r.forEachVenue(func(i int, ex Exchange) (e error) {
+		perVenue[i], e = ex.GetAccount(ctx)
+		return
+	})
+
+	result := Account{
+		AccountBalances:  []AccountBalance{},
+		AccountPositions: []AccountPosition{},
+	}
+	for i, account := range perVenue {
+		ex := r.venues[i]
+		for _, balance := range account.AccountBalances {
+			balance.Venue = venueOf(ex)
+			result.AccountBalances = append(result.AccountBalances, balance)
+		}
+		for _, position := range account.AccountPositions {
+			position.Symbol = withPrefix(ex.GetPrefix(), position.Symbol)
+			result.AccountPositions = append(result.AccountPositions, position)
+		}
+	}
+	return result, err
+}
+
+// WatchOrdersStatuses merges order streams of all venues. Venues which can't be
+// watched are reported by tagged DisconnectedWithErr events. Returns VenueErrors
+// only if no venue can be watched.
+func (r *Router) WatchOrdersStatuses(ctx context.Context) (<-chan OrderEvent, error) {
+	ins := make([]<-chan OrderEvent, len(r.venues))
+	err := r.forEachVenue(func(i int, ex Exchange) (e error) {
+		ins[i], e = ex.WatchOrdersStatuses(ctx)
+		return
+	})
+	if err != nil && len(err.(VenueErrors)) == len(r.venues) {
+		return nil, err
+	}
+
+	out := make(chan OrderEvent, 100) // TODO: move to config
+	wg := sync.WaitGroup{}
+	for i, in := range ins {
+		venue := venueOf(r.venues[i])
+		wg.Add(1)
+		go func(venue string, in <-chan OrderEvent) {
+			defer wg.Done()
+			if in == nil {
+				ev := OrderEvent{DisconnectedWithErr: watchError(err, venue), Venue: venue}
+				select {
+				case out <- ev:
+				case <-ctx.Done():
+				}
+				return
+			}
+			// Drain till the end so venue's goroutine is never stuck
+			for ev := range in {
+				ev.Venue = venue
+				select {
+				case out <- ev:
+				case <-ctx.Done():
+				}
+			}
+			r.lgs.Debugf("Orders stream of %s is closed", venue)
+		}(venue, in)
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out, nil
+}
+
+// WatchAccountPositions merges position streams the same way as WatchOrdersStatuses.
+// Payload symbols are left as is since spot venues report coins there.
+func (r *Router) WatchAccountPositions(ctx context.Context) (<-chan PositionEvent, error) {
+	ins := make([]<-chan PositionEvent, len(r.venues))
+	err := r.forEachVenue(func(i int, ex Exchange) (e error) {
+		ins[i], e = ex.WatchAccountPositions(ctx)
+		return
+	})
+	if err != nil && len(err.(VenueErrors)) == len(r.venues) {
+		return nil, err
+	}
+
+	out := make(chan PositionEvent, 100) // TODO: move to config
+	wg := sync.WaitGroup{}
+	for i, in := range ins {
+		ex := r.venues[i]
+		wg.Add(1)
+		go func(ex Exchange, in <-chan PositionEvent) {
+			defer wg.Done()
+			venue := venueOf(ex)
+			if in == nil {
+				ev := PositionEvent{DisconnectedWithErr: watchError(err, venue), Venue: venue}
+				select {
+				case out <- ev:
+				case <-ctx.Done():
+				}
+				return
+			}
+			for ev := range in {
+				ev.Venue = venue
+				select {
+				case out <- ev:
+				case <-ctx.Done():
+				}
+			}
+			r.lgs.Debugf("Positions stream of %s is closed", venue)
+		}(ex, in)
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out, nil
+}
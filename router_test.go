@@ -0,0 +1,95 @@
+package exchanges
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aulaleslie/trade-exchanges/utils"
+	gomock "github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func newRouterMocks(t *testing.T) (*Router, *MockExchange, *MockExchange) {
+	ctrl := gomock.NewController(t)
+	spot := NewMockExchange(ctrl)
+	spot.EXPECT().GetPrefix().Return("BYBIT-").AnyTimes()
+	linear := NewMockExchange(ctrl)
+	linear.EXPECT().GetPrefix().Return("BYBITLINEAR-").AnyTimes()
+
+	r, err := NewRouter(zap.NewNop(), spot, linear)
+	assert.NoError(t, err)
+	return r, spot, linear
+}
+
+func TestRouterDispatch(t *testing.T) {
+	r, spot, linear := newRouterMocks(t)
+
+	linear.EXPECT().
+		PlaceBuyOrder(gomock.Any(), false, "BYBITLINEAR-BTCUSDT", utils.FromUint(5), utils.FromUint(6), "id1").
+		Return("id1", nil).Times(1)
+	spot.EXPECT().CancelOrder(gomock.Any(), "BYBIT-BTCUSDT", "id2").Return(nil).Times(1)
+
+	id, err := r.PlaceBuyOrder(context.TODO(), false, "BYBITLINEAR-BTCUSDT", utils.FromUint(5), utils.FromUint(6), "id1")
+	assert.NoError(t, err)
+	assert.Equal(t, "id1", id)
+	assert.NoError(t, r.CancelOrder(context.TODO(), "BYBIT-BTCUSDT", "id2"))
+
+	_, err = r.GetPrice(context.TODO(), "PHEMEX-BTCUSD")
+	assert.ErrorIs(t, err, UnknownVenueError)
+}
+
+func TestRouterPartialResults(t *testing.T) {
+	r, spot, linear := newRouterMocks(t)
+
+	spot.EXPECT().GetOpenOrders(gomock.Any()).Return(nil, errors.New("timeout")).Times(1)
+	linear.EXPECT().GetOpenOrders(gomock.Any()).
+		Return([]OrderDetailInfo{{Symbol: "BTCUSDT", ID: "1"}}, nil).Times(1)
+
+	orders, err := r.GetOpenOrders(context.TODO())
+	assert.Equal(t, []OrderDetailInfo{{Symbol: "BYBITLINEAR-BTCUSDT", ID: "1"}}, orders)
+
+	venueErrs, ok := err.(VenueErrors)
+	assert.True(t, ok)
+	assert.Len(t, venueErrs, 1)
+	assert.EqualError(t, venueErrs["BYBIT"], "timeout")
+}
+
+func TestRouterMergedStream(t *testing.T) {
+	r, spot, linear := newRouterMocks(t)
+
+	spotCh := make(chan OrderEvent, 1)
+	spotCh <- OrderEvent{Payload: &OrderEventPayload{OrderID: "1", OrderStatus: NewOST}}
+	close(spotCh)
+	spot.EXPECT().WatchOrdersStatuses(gomock.Any()).Return(spotCh, nil).Times(1)
+	linear.EXPECT().WatchOrdersStatuses(gomock.Any()).Return(nil, errors.New("can't connect")).Times(1)
+
+	out, err := r.WatchOrdersStatuses(context.Background())
+	assert.NoError(t, err)
+
+	byVenue := map[string]OrderEvent{}
+	for ev := range out {
+		byVenue[ev.Venue] = ev
+	}
+	assert.Len(t, byVenue, 2)
+	assert.Equal(t, "1", byVenue["BYBIT"].Payload.OrderID)
+	assert.EqualError(t, byVenue["BYBITLINEAR"].DisconnectedWithErr, "can't connect")
+}
+
+func TestRouterMergedStreamNilChannel(t *testing.T) {
+	r, spot, linear := newRouterMocks(t)
+
+	spot.EXPECT().WatchAccountPositions(gomock.Any()).Return(nil, nil).Times(1)
+	linear.EXPECT().WatchAccountPositions(gomock.Any()).Return(nil, nil).Times(1)
+
+	out, err := r.WatchAccountPositions(context.Background())
+	assert.NoError(t, err)
+
+	byVenue := map[string]PositionEvent{}
+	for ev := range out {
+		byVenue[ev.Venue] = ev
+	}
+	assert.Len(t, byVenue, 2)
+	assert.EqualError(t, byVenue["BYBIT"].DisconnectedWithErr, "BYBIT returned nil channel")
+}
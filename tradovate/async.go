@@ -4,22 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"log"
 	"os"
-	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/aulaleslie/trade-exchanges/tradovate/http"
 	"github.com/google/uuid"
-	"github.com/gorilla/websocket"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
 )
 
-type RequestMetadata struct {
-	Endpoint string
-	SocketId string
-}
-
 type AsyncClient struct {
 	AppName    string
 	AppVersion string
@@ -34,30 +28,27 @@ type AsyncClient struct {
 	ApiKey   string
 	DeviceId string
 
-	WebsocketConnected  bool
-	WebsocketAuthorized bool
-	WebsocketConnection *websocket.Conn
-
-	MarketDataConnected  bool
-	MarketDataAuthorized bool
-	MarketDataConnection *websocket.Conn
-
-	Events chan Message
-
-	Connected bool
-
 	AccessToken     string
 	TokenExpiration time.Time
+	authMu          sync.Mutex
+	tokens          *TokenManager
+	unregisterRenew func()
+	ws              *session
+	connected       atomic.Bool // Socket is reconnected after Connect
+	synced          atomic.Bool // Sync request is sent again after reconnection
 
-	HeartbeatTicker       *time.Ticker
-	LastHeartbeatResponse time.Time
+	Logger        *zap.Logger    // optional
+	SocketOptions *SocketOptions // optional, set before Connect
 
+	// Deprecated: penalty tickets are handled by TokenManager
 	PenaltyTicket string
 
-	RequestPool   map[int64]RequestMetadata
-	LastRequestId int64
-
 	MarketData map[string][]Tick
+
+	// Quotes and DOM, connected by Connect
+	MarketDataSocket *MarketDataClient
+
+	OnUserEvent func(UserSyncEvent) // optional, called with changes after SendSyncRequest
 }
 
 func NewAsyncClient(
@@ -73,7 +64,7 @@ func NewAsyncClient(
 		mdHost = "wss://md.tradovateapi.com/v1"
 	} else if strings.ToLower(environment) == "demo" {
 		httpHost = "https://demo.tradovateapi.com/v1"
-		wsHost = "wss://md-demo.tradovateapi.com/v1"
+		wsHost = "wss://demo.tradovateapi.com/v1"
 		mdHost = "wss://md-demo.tradovateapi.com/v1"
 	}
 
@@ -99,333 +90,199 @@ func NewAsyncClient(
 		ClientId:       clientId,
 		ApiKey:         apiKey,
 		DeviceId:       deviceId.String(),
-		RequestPool:    make(map[int64]RequestMetadata),
 	}
 }
 
-func (c *AsyncClient) Connect() (err error) {
-	resp, err := http.GetAccessToken(
-		c.HttpHost, c.Username, c.Password, c.AppName, c.AppVersion,
-		c.ClientId, c.DeviceId, c.ApiKey, c.PenaltyTicket)
-
-	if err != nil {
-		switch e := err.(type) {
-		case *RateLimitError:
-			log.Println("ERROR: rate limit exceeded getting access token, retrying and attaching penalty ticket")
-			time.Sleep(time.Duration(e.PenaltyTime) * time.Second)
+func (c *AsyncClient) Tokens() *TokenManager {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	if c.tokens == nil {
+		lg := c.Logger
+		if lg == nil {
+			lg = zap.NewNop()
 		}
+		c.tokens = NewTokenManager(
+			c.HttpHost, c.Username, c.Password, c.AppName, c.AppVersion, c.ClientId, c.DeviceId, c.ApiKey, lg)
+	}
+	return c.tokens
+}
 
-		resp, err = http.GetAccessToken(
-			c.HttpHost, c.Username, c.Password, c.AppName, c.AppVersion,
-			c.ClientId, c.DeviceId, c.ApiKey, c.PenaltyTicket)
+func (c *AsyncClient) logger() *zap.Logger {
+	if c.Logger == nil {
+		return zap.NewNop()
 	}
+	return c.Logger
+}
 
-	c.Events = make(chan Message)
+func (c *AsyncClient) socket() *session {
+	tokens := c.Tokens()
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	if c.ws == nil {
+		opts := defaultSocketOptions()
+		if c.SocketOptions != nil {
+			opts = *c.SocketOptions
+		}
+		c.ws = newSession(c.WebsocketHost+CONNECT_WEBSOCKET, tokens, &opts, c.logger().Named("AsyncSocket"))
+		c.ws.onEvent = c.onEvent
+		c.ws.active = c.connected.Load
+		c.ws.restore = c.restore
+	}
+	return c.ws
+}
 
-	wsUrl := c.WebsocketHost + CONNECT_WEBSOCKET
-	// mdUrl := c.MarketDataHost + CONNECT_WEBSOCKET
+// Connect opens the sockets, they are reconnected and authorized again with every new token
+func (c *AsyncClient) Connect() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
-	ws, err := c.CreateWebsocket(wsUrl, c.Events)
+	tokens := c.Tokens()
+	token, err := tokens.Token(ctx)
 	if err != nil {
 		return err
 	}
+	c.setToken(token)
 
-	// mdWs, err := c.CreateWebsocket(mdUrl, c.Events)
-	// if err != nil {
-	// 	return err
-	// }
-
-	c.WebsocketConnection = ws
-	// c.MarketDataConnection = mdWs
-
-	// err = c.SendAuthorization("async-md", resp.AccessToken)
-	// if err != nil {
-	// 	return err
-	// }
-
-	err = c.SendAuthorization("async-ws", resp.AccessToken)
-	if err != nil {
+	if _, err := c.socket().connection(ctx); err != nil {
 		return err
 	}
+	c.connected.Store(true)
 
-	return err
-}
-
-func (c *AsyncClient) CreateWebsocket(url string, events chan Message) (*websocket.Conn, error) {
-	ws, _, err := websocket.DefaultDialer.Dial(url, nil)
-	if err != nil {
-		return nil, err
+	c.authMu.Lock()
+	if c.MarketDataSocket == nil {
+		c.MarketDataSocket = NewMarketDataClient(c.MarketDataHost, tokens, c.logger())
 	}
-
-	go AsyncListener(ws, events, c.onConnect, c.disconnect, c.onEvent)
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-connectionLoop:
-	for {
-		select {
-		case _ = <-ctx.Done():
-			return nil, errors.New("timeout while waiting for websocket to connect")
-		default:
-
-			if strings.Contains(url, "md") && c.MarketDataConnected {
-				break connectionLoop
-			} else if c.WebsocketConnected {
-				break connectionLoop
-			}
-			time.Sleep(1 * time.Second)
-		}
+	md := c.MarketDataSocket
+	if c.unregisterRenew != nil {
+		c.unregisterRenew()
+	}
+	c.unregisterRenew = tokens.OnRenew(c.setToken)
+	c.authMu.Unlock()
+	if err := md.Connect(ctx); err != nil {
+		return err
 	}
+	tokens.Start()
 
-	return ws, nil
+	return nil
 }
 
-func (c *AsyncClient) SendAuthorization(socketId string, accessToken string) error {
-	id, err := c.SendAsync(socketId, AUTHORIZE, "", accessToken)
+func (c *AsyncClient) setToken(t Token) {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	c.AccessToken = t.AccessToken
+	c.TokenExpiration = t.Expiration
+}
 
+// restore sends the sync request on the new socket
+func (c *AsyncClient) restore(ctx context.Context, conn *socket) error {
+	if !c.synced.Load() {
+		return nil
+	}
+	body, err := c.syncRequest(ctx)
 	if err != nil {
 		return err
 	}
+	_, err = conn.request(ctx, SYNC_USER, "", body)
+	return err
+}
 
-	c.RequestPool[id] = RequestMetadata{
-		Endpoint: AUTHORIZE,
-		SocketId: socketId,
+// socketFor returns the open socket by its ID: "async-ws" or "async-md"
+func (c *AsyncClient) socketFor(ctx context.Context, socketId string) (*socket, error) {
+	switch socketId {
+	case "async-ws":
+		return c.socket().connection(ctx)
+	case "async-md":
+		c.authMu.Lock()
+		md := c.MarketDataSocket
+		c.authMu.Unlock()
+		if md == nil {
+			return nil, errors.New("market data socket isn't connected")
+		}
+		return md.session.connection(ctx)
+	default:
+		return nil, errors.New("unknown socket " + socketId)
 	}
+}
 
+func (c *AsyncClient) SendAuthorization(socketId string, accessToken string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-authorizationLoop:
-	for {
-		select {
-		case _ = <-ctx.Done():
-			return errors.New("timeout while waiting for websocket to authorize")
-		default:
-
-			if socketId == "async-md" && c.MarketDataAuthorized {
-				break authorizationLoop
-			} else if socketId == "async-ws" && c.WebsocketAuthorized {
-				break authorizationLoop
-			}
-			time.Sleep(1 * time.Second)
-		}
-	}
-	return nil
+	_, err := c.Request(ctx, socketId, AUTHORIZE, "", accessToken)
+	return err
 }
 
-func (c *AsyncClient) SendPriceSubscription(symbol string) error {
-	socketId := "async-ws"
-	id, err := c.SendAsync(socketId, GET_CHART, "", symbol)
-
+// Request waits for the response until `ctx` is done, status other than 200 is APIError
+func (c *AsyncClient) Request(ctx context.Context, socketId string, endpoint string, queryParams string, body string) (Message, error) {
+	conn, err := c.socketFor(ctx, socketId)
 	if err != nil {
-		return err
-	}
-
-	c.RequestPool[id] = RequestMetadata{
-		Endpoint: GET_CHART,
-		SocketId: socketId,
+		return Message{}, err
 	}
+	return conn.request(ctx, endpoint, queryParams, body)
+}
 
+// SendAsync sends the request without waiting for the response
+func (c *AsyncClient) SendAsync(socketId string, endpoint string, queryParams string, body string) (int64, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-authorizationLoop:
-	for {
-		select {
-		case _ = <-ctx.Done():
-			return errors.New("timeout while waiting for websocket to subscribe price")
-		default:
-
-			if socketId == "async-md" && c.MarketDataAuthorized {
-				break authorizationLoop
-			} else if socketId == "async-ws" && c.WebsocketAuthorized {
-				break authorizationLoop
-			}
-			time.Sleep(1 * time.Second)
-		}
+	conn, err := c.socketFor(ctx, socketId)
+	if err != nil {
+		return 0, err
 	}
-	return nil
+	return conn.send(endpoint, queryParams, body)
 }
 
-func (c *AsyncClient) SendAsync(socketId string, endpoint string, queryParams string, body string) (id int64, err error) {
-	var sb strings.Builder
-
-	id = c.getRequestId()
-
-	sb.WriteString(endpoint)
-	sb.WriteString("\n")
-	sb.WriteString(strconv.FormatInt(id, 10))
-	sb.WriteString("\n")
-	sb.WriteString(queryParams)
-	sb.WriteString("\n")
-	sb.WriteString(body)
-
-	if socketId == "async-md" {
-		err = c.MarketDataConnection.WriteMessage(websocket.TextMessage, []byte(sb.String()))
-	} else if socketId == "async-ws" {
-		err = c.WebsocketConnection.WriteMessage(websocket.TextMessage, []byte(sb.String()))
+func (c *AsyncClient) onEvent(m Message) {
+	if m.EventType != "props" || c.OnUserEvent == nil {
+		return
 	}
-
+	ev, ok, err := decodeProps(m.Data)
 	if err != nil {
-		return id, err
+		c.logger().Warn("Can't decode user event", zap.Error(err))
+		return
 	}
-
-	return id, nil
-}
-
-func (c *AsyncClient) onConnect(m Message) {
-	if string(m.Data) == "async-md" {
-		c.MarketDataConnected = true
-		log.Println("[onConnect]", "Market Data Connected", c.MarketDataConnected)
-	} else if string(m.Data) == "async-ws" {
-		c.WebsocketConnected = true
-		log.Println("[onConnect]", "WebSocket Connected", c.WebsocketConnected)
+	if ok {
+		c.OnUserEvent(ev)
 	}
-
 }
 
-func (c *AsyncClient) disconnect(m Message) {
-	println(m.EventType)
-
-}
-
-func (c *AsyncClient) onAuthorize() {
-}
-
-func (c *AsyncClient) onEvent(m Message) {
-
-	if metadata, ok := c.RequestPool[m.Id]; ok {
-		if metadata.Endpoint == AUTHORIZE {
-
-			if metadata.SocketId == "async-md" {
-				c.MarketDataAuthorized = true
-			} else if metadata.SocketId == "async-ws" {
-				c.WebsocketAuthorized = true
-			}
-
-		}
-	}
-
-	log.Printf("%v", m)
-	var data interface{}
-	bytes, err := m.Data.MarshalJSON()
+func (c *AsyncClient) syncRequest(ctx context.Context) (string, error) {
+	token, err := c.Tokens().Token(ctx)
 	if err != nil {
-		return
+		return "", err
 	}
-
-	err = json.Unmarshal(bytes, data)
+	b, err := json.Marshal(map[string][]int64{"users": {token.UserId}})
 	if err != nil {
-		return
+		return "", err
 	}
-
-	log.Printf("%v", data)
-
+	return string(b), nil
 }
 
-func (c *AsyncClient) getRequestId() int64 {
-	id := time.Now().Unix()
-	if id == c.LastRequestId {
-		c.LastRequestId = id + 1
-		return id + 1
+// SendSyncRequest subscribes to changes of the user entities, they are passed to OnUserEvent.
+// The request is sent again after reconnection.
+func (c *AsyncClient) SendSyncRequest() (int64, error) {
+	body, err := c.syncRequest(context.Background())
+	if err != nil {
+		return 0, err
 	}
-	c.LastRequestId = id
-	return id
+	c.synced.Store(true)
+	return c.SendAsync("async-ws", SYNC_USER, "", body)
 }
 
-func AsyncListener(c *websocket.Conn, out chan Message, onConnect func(m Message), onDisconnect func(m Message), onEvent func(m Message)) {
-	heartbeatTicker := time.NewTicker(1 * time.Second)
-
-	var socketId string
+// Close closes the sockets, they aren't reconnected
+func (c *AsyncClient) Close() error {
+	c.connected.Store(false)
+	c.authMu.Lock()
+	ws, md := c.ws, c.MarketDataSocket
+	c.ws, c.MarketDataSocket = nil, nil
+	c.authMu.Unlock()
 
-	if strings.Contains(c.RemoteAddr().String(), "md") {
-		socketId = "async-md"
-	} else {
-		socketId = "async-ws"
+	if md != nil {
+		_ = md.Close()
 	}
-
-	for {
-		select {
-		case _ = <-heartbeatTicker.C:
-			err := c.WriteMessage(websocket.TextMessage, []byte("[]"))
-			if err != nil {
-				log.Println("ERROR: listener exiting ", err)
-				return
-			}
-
-		default:
-			_, msg, err := c.ReadMessage()
-
-			if err != nil {
-				log.Println("ERROR: listener exiting ", err)
-				return
-			}
-
-			// Determine Message Frame Type
-			// https://api.tradovate.com/#section/Connecting-to-the-WebSocket-Server
-			frameType, body := msg[0], msg[1:]
-
-			switch frameType {
-			case 'o':
-				//out <- Message{Status: 200, EventType: "connected", Data: []byte(socketId)}
-				onConnect(Message{Status: 200, EventType: "connected", Data: []byte(socketId)})
-			case 'h':
-				//unixTime := strconv.FormatInt(time.Now().Unix(), 10)
-				//out <- Message{Status: 200, EventType: "heartbeat", Data: []byte(unixTime)}
-			case 'a':
-				var frame []Message
-				err = json.Unmarshal(body, &frame)
-				//
-				if err != nil {
-					return
-				}
-				//
-				for _, m := range frame {
-					onEvent(m)
-					//
-					//	if m.EventType == "chart" {
-					//		reqStatus := handleChart(m)
-					//
-					//		for id, s := range reqStatus {
-					//			if s {
-					//				c.RequestPool[id] <- Message{
-					//					Id:        id,
-					//					Status:    200,
-					//					EventType: "done",
-					//					Data:      nil,
-					//				}
-					//			}
-					//		}
-					//
-					//	} else {
-					//		if req, ok := c.RequestPool[m.Id]; ok {
-					//			req <- m
-					//		}
-					//	}
-					//
-				}
-
-			case 'c':
-				//out <- Message{Status: 200, EventType: "disconnected", Data: []byte(socketId)}
-				onDisconnect(Message{Status: 200, EventType: "disconnected", Data: []byte(socketId)})
-				heartbeatTicker.Stop()
-
-				return
-			default:
-				return
-			}
-
-			//if err == nil {
-			//	err = handleResponse(resp, c, listenerType)
-			//} else {
-			//	err = handleEvent(msg, c)
-			//}
-
-			//if err != nil {
-			//	log.Error("error handling message", zap.ByteString("message", msg), zap.Error(err))
-			//	break
-			//}
-		}
+	if ws != nil {
+		return ws.close()
 	}
-
+	return nil
 }
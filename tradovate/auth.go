@@ -2,10 +2,15 @@ package tradovate
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"io/ioutil"
+	"io"
 	"net/http"
+	"sync"
 	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
 )
 
 type accessTokenRequest struct {
@@ -25,13 +30,19 @@ type accessTokenResponse struct {
 	MarketDataAccessToken     string    `json:"mdAccessToken"`
 	ExpirationTime            time.Time `json:"expirationTime"`
 	UserStatus                string    `json:"userStatus"`
-	UserId                    int       `json:"userId"`
+	UserId                    int64     `json:"userId"`
 	Name                      string    `json:"name"`
 	HasLive                   bool      `json:"hasLive"`
 	OutdatedTaC               bool      `json:"outdatedTaC"`
 	HasFunded                 bool      `json:"hasFunded"`
 	HasMarketData             bool      `json:"hasMarketData"`
 	OutdatedLiquidationPolicy bool      `json:"outdatedLiquidationPolicy"`
+
+	ErrorText string `json:"errorText"`
+
+	PenaltyTicket  string `json:"p-ticket"`
+	PenaltyTime    int64  `json:"p-time"` // Seconds
+	PenaltyCaptcha bool   `json:"p-captcha"`
 }
 
 type AuthorizationResponse struct {
@@ -39,78 +50,380 @@ type AuthorizationResponse struct {
 	StatusCode int   `json:"s"`
 }
 
-func (c *Client) GetAccessToken() error {
-	if c.AccessToken != "" && c.TokenExpiration.After(time.Now()) {
+type Token struct {
+	AccessToken           string
+	MarketDataAccessToken string // Used to authorize market data socket
+	Expiration            time.Time
+	UserId                int64 // Used by user/syncrequest
+}
+
+// TokenManager keeps the access token valid. The token is renewed before the expiration,
+// penalty tickets of the rate limiter are waited and sent back with the next request.
+type TokenManager struct {
+	host        string
+	credentials accessTokenRequest
+	lg          *zap.Logger
+
+	HTTPClient        *http.Client  // optional
+	RenewBefore       time.Duration // optional
+	RetryDelay        time.Duration // optional, delay of background renewal after failure
+	MaxPenaltyRetries int           // optional
+
+	mu       sync.Mutex
+	token    Token
+	inflight *tokenRefresh // Renewal in progress, nil if none
+	// Used only by the renewal in progress
+	penaltyTicket string
+
+	listenersMu  sync.Mutex
+	listeners    map[int]func(Token)
+	lastListener int
+
+	runMu  sync.Mutex
+	cancel context.CancelFunc
+
+	now  func() time.Time
+	wait func(context.Context, time.Duration) error
+}
+
+func NewTokenManager(
+	host, username, password, appName, appVersion, clientId, deviceId, apiKey string, lg *zap.Logger,
+) *TokenManager {
+	return &TokenManager{
+		host: host,
+		credentials: accessTokenRequest{
+			Name:       username,
+			Password:   password,
+			AppId:      appName,
+			AppVersion: appVersion,
+			ClientId:   clientId,
+			DeviceId:   deviceId,
+			ApiKey:     apiKey,
+		},
+		lg:                lg.Named("TokenManager"),
+		RenewBefore:       15 * time.Minute, // Tokens live 90 minutes
+		RetryDelay:        10 * time.Second,
+		MaxPenaltyRetries: 5,
+		listeners:         map[int]func(Token){},
+		now:               time.Now,
+		wait:              sleepCtx,
+	}
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
 		return nil
-	} else if c.AccessToken != "" && c.TokenExpiration.Before(time.Now()) {
-		// TODO: Refresh Token
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// OnRenew registers `fn` called after every new token.
+// It should be used to authorize open sockets again. Call the result to unregister.
+func (m *TokenManager) OnRenew(fn func(Token)) (unregister func()) {
+	m.listenersMu.Lock()
+	defer m.listenersMu.Unlock()
+	m.lastListener++
+	id := m.lastListener
+	m.listeners[id] = fn
+	return func() {
+		m.listenersMu.Lock()
+		defer m.listenersMu.Unlock()
+		delete(m.listeners, id)
 	}
+}
 
-	reqBody := accessTokenRequest{
-		Name:       c.Username,
-		Password:   c.Password,
-		AppId:      c.AppName,
-		AppVersion: c.AppVersion,
-		ClientId:   c.ClientId,
-		DeviceId:   c.DeviceId,
-		ApiKey:     c.ApiKey,
+func (m *TokenManager) notify(t Token) {
+	m.listenersMu.Lock()
+	listeners := make([]func(Token), 0, len(m.listeners))
+	for _, fn := range m.listeners {
+		listeners = append(listeners, fn)
 	}
+	m.listenersMu.Unlock()
 
-	if c.PenaltyTicket == "" {
-		reqBody.PenaltyTicket = c.PenaltyTicket
+	for _, fn := range listeners {
+		fn(t)
 	}
+}
 
-	b, err := json.Marshal(reqBody)
+// Token returns valid token. It is renewed if the expiration is closer than RenewBefore.
+// Failed renewal of still valid token isn't an error.
+func (m *TokenManager) Token(ctx context.Context) (Token, error) {
+	t, err := m.refresh(ctx)
+	if err != nil && t.AccessToken != "" && m.now().Before(t.Expiration) {
+		m.lg.Warn("Can't renew access token, old one is used", zap.Error(err))
+		return t, nil
+	}
+	return t, err
+}
 
-	if err != nil {
-		return err
+// tokenRefresh is a renewal shared by concurrent callers, `done` is closed when it's finished
+type tokenRefresh struct {
+	done  chan struct{}
+	token Token
+	err   error
+}
+
+// refresh returns current token and the error of renewal.
+// Only one renewal runs at a time, other callers wait for it or for their `ctx`.
+func (m *TokenManager) refresh(ctx context.Context) (Token, error) {
+	for {
+		m.mu.Lock()
+		old := m.token
+		if old.AccessToken != "" && m.now().Before(old.Expiration.Add(-m.RenewBefore)) {
+			m.mu.Unlock()
+			return old, nil
+		}
+		call := m.inflight
+		if call == nil {
+			call = &tokenRefresh{done: make(chan struct{})}
+			m.inflight = call
+			m.mu.Unlock()
+			return m.doRefresh(ctx, call, old)
+		}
+		m.mu.Unlock()
+
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return old, ctx.Err()
+		}
+		// The renewing caller has gone, try again with own context
+		if isContextErr(call.err) && ctx.Err() == nil {
+			continue
+		}
+		return call.token, call.err
 	}
+}
+
+func isContextErr(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
 
-	url := c.HttpHost + GET_ACCESS_TOKEN
+// doRefresh renews the token without the lock and shares the result by `call`
+func (m *TokenManager) doRefresh(ctx context.Context, call *tokenRefresh, old Token) (Token, error) {
+	t, err := m.renewOrRequest(ctx, old)
+
+	m.mu.Lock()
+	if err == nil {
+		m.token = t
+	} else {
+		t = old
+	}
+	call.token, call.err = t, err
+	m.inflight = nil
+	m.mu.Unlock()
+	close(call.done)
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(b))
 	if err != nil {
-		return err
+		return t, err
 	}
+	m.lg.Info("Access token is received", zap.Time("expiration", t.Expiration))
+	m.notify(t)
+	return t, nil
+}
 
-	req.Header.Set("Content-Type", "application/json")
+func (m *TokenManager) renewOrRequest(ctx context.Context, old Token) (Token, error) {
+	renewed := false
+	var resp accessTokenResponse
+	var err error
+	if old.AccessToken != "" && m.now().Before(old.Expiration) {
+		resp, err = m.renew(ctx, old.AccessToken)
+		if err != nil {
+			m.lg.Warn("Can't renew access token, requesting new one", zap.Error(err))
+		}
+		renewed = err == nil
+	}
+	if !renewed {
+		resp, err = m.request(ctx)
+	}
+	if err != nil {
+		return Token{}, err
+	}
+	return Token{
+		AccessToken:           resp.AccessToken,
+		MarketDataAccessToken: resp.MarketDataAccessToken,
+		Expiration:            resp.ExpirationTime,
+		UserId:                resp.UserId,
+	}, nil
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+// request gets new token by credentials following penalty tickets
+func (m *TokenManager) request(ctx context.Context) (accessTokenResponse, error) {
+	for try := 0; ; try++ {
+		req := m.credentials
+		req.PenaltyTicket = m.penaltyTicket
+
+		resp, err := m.send(ctx, http.MethodPost, GET_ACCESS_TOKEN, "", req)
+		if err != nil {
+			return resp, err
+		}
+		if resp.PenaltyTicket == "" {
+			m.penaltyTicket = ""
+			return resp, nil
+		}
+
+		m.penaltyTicket = resp.PenaltyTicket
+		if resp.PenaltyCaptcha {
+			return resp, &CaptchaRequiredError{PenaltyTicket: resp.PenaltyTicket}
+		}
+		penalty := &RateLimitError{
+			PenaltyTicket:     resp.PenaltyTicket,
+			PenaltyTime:       resp.PenaltyTime,
+			PenaltyExpiration: m.now().Add(time.Duration(resp.PenaltyTime) * time.Second),
+		}
+		if try >= m.MaxPenaltyRetries {
+			return resp, errors.Wrapf(penalty, "%d tries", try+1)
+		}
+
+		m.lg.Warn("Access token request is penalized", zap.Int64("seconds", resp.PenaltyTime))
+		if err := m.wait(ctx, time.Duration(resp.PenaltyTime)*time.Second); err != nil {
+			return resp, errors.Wrapf(err, "waiting for penalty of %d seconds", resp.PenaltyTime)
+		}
+	}
+}
+
+func (m *TokenManager) renew(ctx context.Context, token string) (accessTokenResponse, error) {
+	resp, err := m.send(ctx, http.MethodGet, RENEW_ACCESS_TOKEN, token, nil)
+	if err == nil && resp.AccessToken == "" {
+		return resp, errors.New("renewal is penalized")
+	}
+	return resp, err
+}
+
+func (m *TokenManager) send(ctx context.Context, method, endpoint, token string, body interface{}) (accessTokenResponse, error) {
+	resp := accessTokenResponse{}
+
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return resp, errors.Wrap(err, "can't marshal request")
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, m.host+endpoint, reqBody)
 	if err != nil {
-		return err
+		return resp, errors.Wrap(err, "can't create request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
 	}
-	defer resp.Body.Close()
 
-	respBody, _ := ioutil.ReadAll(resp.Body)
+	client := m.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	httpResp, err := client.Do(req)
+	if err != nil {
+		return resp, errors.Wrapf(err, "can't send %s request", endpoint)
+	}
+	defer httpResp.Body.Close()
 
-	var parsedResponse map[string]interface{}
-	err = json.Unmarshal(respBody, &parsedResponse)
+	respBody, err := io.ReadAll(httpResp.Body)
 	if err != nil {
-		return err
+		return resp, errors.Wrapf(err, "can't read %s response", endpoint)
 	}
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		return resp, &APIError{StatusCode: httpResp.StatusCode, Text: string(bytes.TrimSpace(respBody))}
+	}
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return resp, errors.Wrapf(err, "can't unmarshal %s response", endpoint)
+	}
+	if resp.ErrorText != "" {
+		return resp, &APIError{StatusCode: httpResp.StatusCode, Text: resp.ErrorText}
+	}
+	if resp.PenaltyTicket == "" && resp.AccessToken == "" {
+		return resp, errors.Errorf("no access token in %s response", endpoint)
+	}
+	return resp, nil
+}
 
-	if _, ok := parsedResponse["p-ticket"]; ok {
-		var rateLimitResponse map[string]interface{}
-		err = json.Unmarshal(respBody, &rateLimitResponse)
-		if err != nil {
+// Run renews the token in background until `ctx` is done.
+// It stops with CaptchaRequiredError because the renewal isn't possible without user.
+func (m *TokenManager) Run(ctx context.Context) error {
+	for {
+		t, err := m.refresh(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		var captchaErr *CaptchaRequiredError
+		if errors.As(err, &captchaErr) {
+			m.lg.Error("Access token can't be renewed", zap.Error(err))
 			return err
 		}
 
-		return &RateLimitError{
-			PenaltyTicket:     parsedResponse["p-ticket"].(string),
-			PenaltyTime:       int64(parsedResponse["p-time"].(float64)),
-			PenaltyExpiration: time.Unix(int64(parsedResponse["p-time"].(float64)), 0),
-			PenaltyCaptcha:    parsedResponse["p-captcha"].(bool),
+		delay := m.RetryDelay
+		if err != nil {
+			m.lg.Warn("Can't renew access token", zap.Error(err))
+		} else if d := t.Expiration.Add(-m.RenewBefore).Sub(m.now()); d > delay {
+			delay = d
+		}
+		if m.wait(ctx, delay) != nil {
+			return nil
+		}
+	}
+}
+
+// Start runs renewal in background if it isn't running
+func (m *TokenManager) Start() {
+	m.runMu.Lock()
+	defer m.runMu.Unlock()
+	if m.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	go func() {
+		_ = m.Run(ctx)
+		m.runMu.Lock()
+		defer m.runMu.Unlock()
+		if ctx.Err() == nil {
+			m.cancel = nil // Stopped because of captcha, can be started again
 		}
+		cancel()
+	}()
+}
+
+func (m *TokenManager) Stop() {
+	m.runMu.Lock()
+	defer m.runMu.Unlock()
+	if m.cancel != nil {
+		m.cancel()
+		m.cancel = nil
 	}
+}
 
-	c.TokenExpiration, err = time.Parse("2006-01-02T15:04:05Z", parsedResponse["expirationTime"].(string))
+func (c *Client) Tokens() *TokenManager {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	if c.tokens == nil {
+		lg := c.Logger
+		if lg == nil {
+			lg = zap.NewNop()
+		}
+		c.tokens = NewTokenManager(
+			c.HttpHost, c.Username, c.Password, c.AppName, c.AppVersion, c.ClientId, c.DeviceId, c.ApiKey, lg)
+		c.tokens.HTTPClient = c.HTTPClient
+	}
+	return c.tokens
+}
 
+func (c *Client) GetAccessToken() error {
+	t, err := c.Tokens().Token(context.Background())
 	if err != nil {
 		return err
 	}
-	c.AccessToken = parsedResponse["accessToken"].(string)
-
+	c.authMu.Lock()
+	c.AccessToken = t.AccessToken
+	c.TokenExpiration = t.Expiration
+	c.authMu.Unlock()
 	return nil
 }
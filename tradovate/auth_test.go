@@ -0,0 +1,133 @@
+package tradovate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestTokenManager(fake *fakeTradovate) (*TokenManager, *[]time.Duration) {
+	m := NewTokenManager(fake.srv.URL, "user", "password", "app", "1.0", "cid", "device", "sec", zap.NewNop())
+	waits := []time.Duration{}
+	m.wait = func(_ context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	return m, &waits
+}
+
+func TestTokenManagerFollowsPenaltyTicket(t *testing.T) {
+	fake := newFakeTradovate(t)
+	fake.penalties = []accessTokenResponse{
+		{PenaltyTicket: "ticket-1", PenaltyTime: 3},
+		{PenaltyTicket: "ticket-2", PenaltyTime: 5},
+	}
+	m, waits := newTestTokenManager(fake)
+
+	token, err := m.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, fakeToken, token.AccessToken)
+	assert.Equal(t, "md-"+fakeToken, token.MarketDataAccessToken)
+	assert.Equal(t, []time.Duration{3 * time.Second, 5 * time.Second}, *waits)
+
+	require.Len(t, fake.tokenReqs, 3)
+	assert.Empty(t, fake.tokenReqs[0].PenaltyTicket)
+	assert.Equal(t, "ticket-1", fake.tokenReqs[1].PenaltyTicket)
+	assert.Equal(t, "ticket-2", fake.tokenReqs[2].PenaltyTicket)
+	assert.Equal(t, "user", fake.tokenReqs[2].Name)
+}
+
+func TestTokenManagerCaptchaIsFatal(t *testing.T) {
+	fake := newFakeTradovate(t)
+	fake.penalties = []accessTokenResponse{{PenaltyTicket: "ticket-1", PenaltyTime: 3, PenaltyCaptcha: true}}
+	m, waits := newTestTokenManager(fake)
+
+	err := m.Run(context.Background())
+	var captchaErr *CaptchaRequiredError
+	require.True(t, errors.As(err, &captchaErr), "unexpected error %v", err)
+	assert.Equal(t, "ticket-1", captchaErr.PenaltyTicket)
+	assert.Empty(t, *waits)
+	assert.Len(t, fake.tokenReqs, 1)
+}
+
+func TestTokenManagerRenewsBeforeExpiration(t *testing.T) {
+	fake := newFakeTradovate(t)
+	m, _ := newTestTokenManager(fake)
+	ctx := context.Background()
+
+	first, err := m.Token(ctx)
+	require.NoError(t, err)
+
+	renewed := []Token{}
+	m.OnRenew(func(t Token) { renewed = append(renewed, t) })
+
+	// Still far from the expiration
+	again, err := m.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, first, again)
+	assert.Zero(t, fake.renewReqs)
+
+	m.now = func() time.Time { return first.Expiration.Add(-m.RenewBefore / 2) }
+	second, err := m.Token(ctx)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.AccessToken, second.AccessToken)
+	assert.Equal(t, 1, fake.renewReqs)
+	assert.Len(t, fake.tokenReqs, 1)
+	assert.Equal(t, []Token{second}, renewed)
+
+	// Expired token is requested by credentials
+	m.now = func() time.Time { return second.Expiration.Add(time.Minute) }
+	third, err := m.Token(ctx)
+	require.NoError(t, err)
+	assert.NotEqual(t, second.AccessToken, third.AccessToken)
+	assert.Equal(t, 1, fake.renewReqs)
+	assert.Len(t, fake.tokenReqs, 2)
+}
+
+func TestTokenManagerWaitersDontBlockOnRenewal(t *testing.T) {
+	fake := newFakeTradovate(t)
+	fake.penalties = []accessTokenResponse{{PenaltyTicket: "ticket-1", PenaltyTime: 3}}
+	m, _ := newTestTokenManager(fake)
+	started, release := make(chan struct{}), make(chan struct{})
+	m.wait = func(ctx context.Context, _ time.Duration) error {
+		close(started)
+		select {
+		case <-release:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	type result struct {
+		token Token
+		err   error
+	}
+	results := make(chan result, 2)
+	getToken := func() {
+		token, err := m.Token(context.Background())
+		results <- result{token, err}
+	}
+	go getToken()
+	<-started
+
+	// The penalty is waited by the first caller only
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := m.Token(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	go getToken()
+	close(release)
+	for i := 0; i < 2; i++ {
+		r := <-results
+		require.NoError(t, r.err)
+		assert.Equal(t, fakeToken, r.token.AccessToken)
+	}
+	assert.Len(t, fake.tokenReqs, 2)
+}
@@ -0,0 +1,263 @@
+package tradovate
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// chartTick is packed relatively to base price and timestamp of the packet
+type chartTick struct {
+	Id      int64    `json:"id"`
+	Time    int64    `json:"t"` // Milliseconds after the base timestamp
+	Price   float64  `json:"p"` // Ticks after the base price
+	Size    float64  `json:"s"`
+	Bid     *float64 `json:"b"` // Ticks after the base price
+	Ask     *float64 `json:"a"` // Ticks after the base price
+	BidSize float64  `json:"bs"`
+	AskSize float64  `json:"as"`
+}
+
+type chartPacket struct {
+	Id            int         `json:"id"`
+	EndOfHistory  bool        `json:"eoh"`
+	Source        string      `json:"s"`
+	BasePrice     float64     `json:"bp"`
+	BaseTimestamp int64       `json:"bt"` // Milliseconds
+	TickSize      float64     `json:"ts"`
+	Ticks         []chartTick `json:"tks"`
+	Bars          []Bar       `json:"bars"`
+}
+
+type chartPayload struct {
+	Charts []chartPacket `json:"charts"`
+}
+
+func (p chartPacket) ticks() []Tick {
+	res := make([]Tick, 0, len(p.Ticks))
+	for _, t := range p.Ticks {
+		tick := Tick{
+			Id:             t.Id,
+			SubscriptionId: p.Id,
+			Source:         p.Source,
+			Time:           time.UnixMilli(p.BaseTimestamp + t.Time).UTC(),
+			Price:          (p.BasePrice + t.Price) * p.TickSize,
+			Size:           t.Size,
+			BidSize:        t.BidSize,
+			AskSize:        t.AskSize,
+		}
+		if t.Bid != nil {
+			tick.Bid = (p.BasePrice + *t.Bid) * p.TickSize
+		}
+		if t.Ask != nil {
+			tick.Ask = (p.BasePrice + *t.Ask) * p.TickSize
+		}
+		res = append(res, tick)
+	}
+	return res
+}
+
+// chartStream gathers history until `eoh` packet and sends realtime updates to the watcher
+type chartStream struct {
+	sub     Subscription
+	watcher *mdWatcher // nil without realtime updates
+
+	// Guarded by MarketDataClient.mu
+	bars  []Bar
+	ticks []Tick
+
+	doneOnce sync.Once
+	done     chan struct{} // Closed after the history or failure
+	err      error         // Set before `done` is closed
+}
+
+func (s *chartStream) finish(err error) {
+	s.doneOnce.Do(func() {
+		s.err = err
+		close(s.done)
+	})
+}
+
+// GetChart returns the history of the chart.
+// Realtime updates are sent until `ctx` is done or the chart is canceled if `realtime` is true.
+func (c *MarketDataClient) GetChart(
+	ctx context.Context, req ChartRequest, realtime bool,
+) (Chart, <-chan MarketDataEvent, error) {
+	conn, err := c.session.connection(ctx)
+	if err != nil {
+		return Chart{}, nil, err
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return Chart{}, nil, errors.Wrap(err, "can't marshal chart request")
+	}
+
+	stream := &chartStream{done: make(chan struct{})}
+	if realtime {
+		stream.watcher = &mdWatcher{ctx: ctx, out: make(chan MarketDataEvent, 100)} // TODO: move to config
+	}
+	// Packets can follow the response immediately, the stream is registered before them
+	resp, err := conn.requestWith(ctx, GET_CHART, "", string(body), func(m Message) {
+		sub := Subscription{}
+		if json.Unmarshal(m.Data, &sub) != nil || sub.HistoricalId == 0 {
+			return
+		}
+		stream.sub = sub
+		c.mu.Lock()
+		c.charts[sub.HistoricalId] = stream
+		if realtime && sub.RealtimeId != 0 {
+			c.charts[sub.RealtimeId] = stream
+		}
+		c.mu.Unlock()
+	})
+	if err != nil {
+		return Chart{}, nil, errors.Wrapf(err, "can't request chart of %s", req.Symbol)
+	}
+	if stream.sub.HistoricalId == 0 {
+		failure := failureResponse{}
+		_ = json.Unmarshal(resp.Data, &failure)
+		if failure.ErrorText != "" {
+			return Chart{}, nil, &APIError{StatusCode: resp.Status, Text: failure.ErrorText}
+		}
+		return Chart{}, nil, errors.Errorf("no chart subscription in response: %s", resp.Data)
+	}
+
+	select {
+	case <-stream.done:
+	case <-ctx.Done():
+		c.cancelChart(stream.sub.HistoricalId)
+		return Chart{}, nil, errors.Wrapf(ctx.Err(), "waiting for chart of %s", req.Symbol)
+	}
+	if stream.err != nil {
+		return Chart{}, nil, errors.Wrapf(stream.err, "can't get chart of %s", req.Symbol)
+	}
+
+	c.mu.Lock()
+	chart := Chart{Subscription: stream.sub, Bars: stream.bars, Ticks: stream.ticks}
+	stream.bars, stream.ticks = nil, nil
+	c.mu.Unlock()
+	sort.SliceStable(chart.Bars, func(i, j int) bool {
+		return chart.Bars[i].Timestamp.Before(chart.Bars[j].Timestamp)
+	})
+	sort.SliceStable(chart.Ticks, func(i, j int) bool {
+		if chart.Ticks[i].Time.Equal(chart.Ticks[j].Time) {
+			return chart.Ticks[i].Id < chart.Ticks[j].Id
+		}
+		return chart.Ticks[i].Time.Before(chart.Ticks[j].Time)
+	})
+
+	if !realtime {
+		c.cancelChart(stream.sub.HistoricalId)
+		return chart, nil, nil
+	}
+	go func() {
+		<-ctx.Done()
+		c.cancelChart(stream.sub.HistoricalId)
+	}()
+	return chart, stream.watcher.out, nil
+}
+
+// CancelChart stops the chart by its historical ID
+func (c *MarketDataClient) CancelChart(ctx context.Context, historicalID int) error {
+	c.removeChart(historicalID)
+
+	conn := c.session.current()
+	if conn == nil {
+		return nil // Charts are stopped with the socket
+	}
+	body, err := json.Marshal(map[string]int{"subscriptionId": historicalID})
+	if err != nil {
+		return errors.Wrap(err, "can't marshal cancel chart request")
+	}
+	if _, err := conn.request(ctx, CANCEL_CHART, "", string(body)); err != nil {
+		return errors.Wrapf(err, "can't cancel chart %d", historicalID)
+	}
+	return nil
+}
+
+// cancelChart cancels the chart in background
+func (c *MarketDataClient) cancelChart(historicalID int) {
+	c.removeChart(historicalID)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), c.RequestTimeout)
+		defer cancel()
+		if err := c.CancelChart(ctx, historicalID); err != nil {
+			c.lg.Warn("Can't cancel chart", zap.Int("id", historicalID), zap.Error(err))
+		}
+	}()
+}
+
+func (c *MarketDataClient) removeChart(historicalID int) {
+	c.mu.Lock()
+	stream, ok := c.charts[historicalID]
+	if ok {
+		delete(c.charts, stream.sub.HistoricalId)
+		delete(c.charts, stream.sub.RealtimeId)
+	}
+	c.mu.Unlock()
+
+	if ok {
+		stream.finish(errors.New("chart is canceled"))
+		if stream.watcher != nil {
+			stream.watcher.finish(nil)
+		}
+	}
+}
+
+func (c *MarketDataClient) dispatchChart(m Message) {
+	payload := chartPayload{}
+	if err := json.Unmarshal(m.Data, &payload); err != nil {
+		c.lg.Warn("Can't unmarshal chart", zap.ByteString("data", m.Data), zap.Error(err))
+		return
+	}
+
+	for _, p := range payload.Charts {
+		c.mu.Lock()
+		stream, ok := c.charts[p.Id]
+		if !ok {
+			c.mu.Unlock()
+			continue
+		}
+		if p.Id == stream.sub.HistoricalId {
+			stream.bars = append(stream.bars, p.Bars...)
+			stream.ticks = append(stream.ticks, p.ticks()...)
+			c.mu.Unlock()
+			if p.EndOfHistory {
+				stream.finish(nil)
+			}
+			continue
+		}
+		c.mu.Unlock()
+
+		if stream.watcher != nil && (len(p.Bars) > 0 || len(p.Ticks) > 0) {
+			stream.watcher.send(MarketDataEvent{Bars: p.Bars, Ticks: p.ticks()})
+		}
+	}
+}
+
+// failCharts stops all charts of the socket, they aren't restored after reconnection
+func (c *MarketDataClient) failCharts(_ *socket, err error) {
+	if err == nil {
+		err = errSocketDisconnected
+	}
+
+	c.mu.Lock()
+	charts := c.charts
+	c.charts = map[int]*chartStream{}
+	c.mu.Unlock()
+
+	for id, stream := range charts {
+		if id != stream.sub.HistoricalId {
+			continue
+		}
+		stream.finish(err)
+		if stream.watcher != nil {
+			stream.watcher.finish(&MarketDataEvent{DisconnectedWithErr: err})
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package tradovate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientGetHistoricalTickData(t *testing.T) {
+	fake := newFakeTradovate(t)
+	client := fake.client()
+	defer client.MarketDataSocket().Close()
+
+	chart, err := client.GetHistoricalTickData("ESZ4", time.Now().Add(-time.Hour), time.Now())
+	require.NoError(t, err)
+	require.Len(t, chart.Ticks, 3)
+
+	base := time.UnixMilli(1730000000000).UTC()
+	first, second, third := chart.Ticks[0], chart.Ticks[1], chart.Ticks[2]
+	assert.Equal(t, int64(0), first.Id)
+	assert.Equal(t, base, first.Time)
+	assert.Equal(t, 5000.0, first.Price)
+	assert.Equal(t, int64(1), second.Id)
+	assert.Equal(t, 5000.25, second.Price)
+	assert.Equal(t, int64(2), third.Id)
+	assert.Equal(t, base.Add(100*time.Millisecond), third.Time)
+	assert.Equal(t, 5000.5, third.Price)
+	assert.Equal(t, 5000.25, third.Bid)
+	assert.Equal(t, 5000.75, third.Ask)
+
+	// History without realtime updates is canceled
+	assert.Eventually(t, func() bool {
+		return fake.wsRequestCount(CANCEL_CHART) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestClientSubscribeChart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fake := newFakeTradovate(t)
+	client := fake.client()
+	defer client.MarketDataSocket().Close()
+
+	chart, updates, err := client.SubscribeChart(ctx, "ESZ4", MinuteBarChart, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	require.Len(t, chart.Bars, 2)
+	assert.Equal(t, 5000.0, chart.Bars[0].Open)
+	assert.Equal(t, 5001.0, chart.Bars[1].Open)
+
+	select {
+	case ev := <-updates:
+		require.Len(t, ev.Bars, 1)
+		assert.Equal(t, 5002.0, ev.Bars[0].Close)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no realtime update")
+	}
+
+	require.NoError(t, client.CancelHistoricalTickData(chart.HistoricalId))
+	_, ok := <-updates
+	assert.False(t, ok)
+	assert.Equal(t, 1, fake.wsRequestCount(CANCEL_CHART))
+}
+
+func TestClientGetChartError(t *testing.T) {
+	fake := newFakeTradovate(t)
+	client := fake.client()
+	defer client.MarketDataSocket().Close()
+
+	_, err := client.GetChart(context.Background(), "UNKNOWN", DailyBarChart, time.Now().Add(-time.Hour), time.Now())
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr), "unexpected error %v", err)
+	assert.Equal(t, 404, apiErr.StatusCode)
+}
@@ -3,7 +3,7 @@ package tradovate
 import (
 	"context"
 	"errors"
-	"log"
+	"net/http"
 	"os"
 	"strconv"
 	"strings"
@@ -11,41 +11,37 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
 )
 
-type Connection struct {
-	Connected  bool
-	Connection *websocket.Conn
-}
-
 type Client struct {
 	AppName    string
 	AppVersion string
 
-	HttpHost      string
-	WebsocketHost string
-	Username      string
-	Password      string
-	ClientId      string
-	ApiKey        string
-	DeviceId      string
-
-	ConnectionMutex sync.Mutex
-	Connection      *websocket.Conn
-	Connected       bool
+	HttpHost       string
+	WebsocketHost  string
+	MarketDataHost string
+	Username       string
+	Password       string
+	ClientId       string
+	ApiKey         string
+	DeviceId       string
 
 	AccessToken     string
 	TokenExpiration time.Time
+	authMu          sync.Mutex
+	tokens          *TokenManager
+	unregisterRenew func()
+	ws              *session
+	marketData      *MarketDataClient
 
-	HeartbeatTicker       *time.Ticker
-	LastHeartbeatResponse time.Time
+	HTTPClient    *http.Client   // optional, for REST requests
+	Logger        *zap.Logger    // optional
+	SocketOptions *SocketOptions // optional, for sockets opened after it is set
 
+	// Deprecated: penalty tickets are handled by TokenManager
 	PenaltyTicket string
 
-	RequestPool   map[int64]chan Message
-	LastRequestId int64
-
 	MarketData map[string][]Tick
 }
 
@@ -54,13 +50,16 @@ func NewClient(
 	username string, password string, clientId string, apiKey string) *Client {
 	var httpHost string
 	var wsHost string
+	var mdHost string
 
 	if strings.ToLower(environment) == "live" {
 		httpHost = "https://live.tradovateapi.com/v1"
 		wsHost = "wss://live.tradovateapi.com/v1"
+		mdHost = "wss://md.tradovateapi.com/v1"
 	} else if strings.ToLower(environment) == "demo" {
 		httpHost = "https://demo.tradovateapi.com/v1"
-		wsHost = "wss://md-demo.tradovateapi.com/v1"
+		wsHost = "wss://demo.tradovateapi.com/v1"
+		mdHost = "wss://md-demo.tradovateapi.com/v1"
 	}
 
 	var deviceId uuid.UUID
@@ -75,141 +74,136 @@ func NewClient(
 	}
 
 	return &Client{
-		AppName:       appName,
-		AppVersion:    appVersion,
-		HttpHost:      httpHost,
-		WebsocketHost: wsHost,
-		Username:      username,
-		Password:      password,
-		ClientId:      clientId,
-		ApiKey:        apiKey,
-		DeviceId:      deviceId.String(),
-		RequestPool:   make(map[int64]chan Message),
+		AppName:        appName,
+		AppVersion:     appVersion,
+		HttpHost:       httpHost,
+		WebsocketHost:  wsHost,
+		MarketDataHost: mdHost,
+		Username:       username,
+		Password:       password,
+		ClientId:       clientId,
+		ApiKey:         apiKey,
+		DeviceId:       deviceId.String(),
 	}
 }
 
-func (c *Client) ConnectWebsocket() (err error) {
-	err = c.GetAccessToken()
-
-	if err != nil {
-		switch e := err.(type) {
-		case *RateLimitError:
-			log.Println("ERROR: rate limit exceeded getting access token, retrying and attaching penalty ticket")
-			time.Sleep(time.Duration(e.PenaltyTime) * time.Second)
-		}
-
-		err = c.GetAccessToken()
+func (c *Client) logger() *zap.Logger {
+	if c.Logger == nil {
+		return zap.NewNop()
 	}
+	return c.Logger
+}
 
-	url := c.WebsocketHost + CONNECT_WEBSOCKET
-
-	ws, _, err := websocket.DefaultDialer.Dial(url, nil)
-	if err != nil {
-		return err
+func (c *Client) socketOptions() *SocketOptions {
+	if c.SocketOptions != nil {
+		return c.SocketOptions
 	}
+	opts := defaultSocketOptions()
+	return &opts
+}
 
-	c.Connection = ws
-
-	go Listener(c)
+// socket returns the session of WebsocketHost, the socket is opened by the first request
+// and after disconnection
+func (c *Client) socket() *session {
+	tokens := c.Tokens()
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	if c.ws == nil {
+		c.ws = newSession(c.WebsocketHost+CONNECT_WEBSOCKET, tokens, c.socketOptions(), c.logger().Named("Socket"))
+	}
+	return c.ws
+}
 
+// ConnectWebsocket opens and authorizes the socket, it is authorized again with every new token
+func (c *Client) ConnectWebsocket() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-connectionLoop:
-	for {
-		select {
-		case _ = <-ctx.Done():
-			return errors.New("timeout while waiting for websocket to connect")
-		default:
-			if c.Connected {
-				break connectionLoop
-			}
-			time.Sleep(1 * time.Second)
-		}
+	if _, err := c.socket().connection(ctx); err != nil {
+		return err
 	}
 
-	err = c.SendAuthorization()
-
-	return err
+	tokens := c.Tokens()
+	c.authMu.Lock()
+	if c.unregisterRenew != nil {
+		c.unregisterRenew()
+	}
+	c.unregisterRenew = tokens.OnRenew(func(t Token) {
+		c.authMu.Lock()
+		c.AccessToken = t.AccessToken
+		c.TokenExpiration = t.Expiration
+		c.authMu.Unlock()
+	})
+	c.authMu.Unlock()
+	tokens.Start()
+
+	return nil
 }
 
+// SendAuthorization authorizes the socket with the current token
 func (c *Client) SendAuthorization() error {
-	msg, err := c.Send(AUTHORIZE, "", c.AccessToken, 30)
-
+	t, err := c.Tokens().Token(context.Background())
 	if err != nil {
 		return err
 	}
 
+	msg, err := c.Send(AUTHORIZE, "", t.AccessToken, 30)
+	if err != nil {
+		return err
+	}
 	if msg.Status != 200 {
 		return errors.New("authorization unsuccessful, code: " + strconv.Itoa(msg.Status))
 	}
-
-	log.Println("[SendAuthorization] authorization sent...")
-	log.Println("[SendAuthorization] msg response...", msg.EventType, msg.Status, msg.EventType, msg.Data)
-
-	return err
+	return nil
 }
 
+// Send waits for the response up to `timeout` seconds
 func (c *Client) Send(endpoint string, queryParams string, body string, timeout int) (*Message, error) {
-	var sb strings.Builder
-
-	id := c.getRequestId()
-
-	sb.WriteString(endpoint)
-	sb.WriteString("\n")
-	sb.WriteString(strconv.FormatInt(id, 10))
-	sb.WriteString("\n")
-	sb.WriteString(queryParams)
-	sb.WriteString("\n")
-	sb.WriteString(body)
-
-	channel := make(chan Message)
-	c.RequestPool[id] = channel
-
-	c.ConnectionMutex.Lock()
-	err := c.Connection.WriteMessage(websocket.TextMessage, []byte(sb.String()))
-	c.ConnectionMutex.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+	return c.Request(ctx, endpoint, queryParams, body)
+}
 
+// Request waits for the response until `ctx` is done.
+// Responses with status other than 200 are returned without error.
+func (c *Client) Request(ctx context.Context, endpoint string, queryParams string, body string) (*Message, error) {
+	conn, err := c.socket().connection(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	select {
-	case response := <-channel:
-		return &response, nil
-	case <-time.After(time.Duration(timeout) * time.Second):
-		return nil, errors.New("timeout exceeded")
+	msg, err := conn.request(ctx, endpoint, queryParams, body)
+	var apiErr *APIError
+	if err != nil && !errors.As(err, &apiErr) {
+		return nil, err
 	}
+	return &msg, nil
 }
 
+// SendAsync sends the request without waiting for the response
 func (c *Client) SendAsync(endpoint string, queryParams string, body string) (int64, error) {
-	var sb strings.Builder
-
-	id := c.getRequestId()
-
-	sb.WriteString(endpoint)
-	sb.WriteString("\n")
-	sb.WriteString(strconv.FormatInt(id, 10))
-	sb.WriteString("\n")
-	sb.WriteString(queryParams)
-	sb.WriteString("\n")
-	sb.WriteString(body)
-
-	err := c.Connection.WriteMessage(websocket.TextMessage, []byte(sb.String()))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
+	conn, err := c.socket().connection(ctx)
 	if err != nil {
-		return id, err
+		return 0, err
 	}
-
-	return id, nil
+	return conn.send(endpoint, queryParams, body)
 }
 
-func (c *Client) getRequestId() int64 {
-	id := time.Now().Unix()
-	if id == c.LastRequestId {
-		c.LastRequestId = id + 1
-		return id + 1
+// CloseWebsocket closes the socket and the market data socket
+func (c *Client) CloseWebsocket() error {
+	c.authMu.Lock()
+	ws, md := c.ws, c.marketData
+	c.ws, c.marketData = nil, nil
+	c.authMu.Unlock()
+
+	if md != nil {
+		_ = md.Close()
+	}
+	if ws != nil {
+		return ws.close()
 	}
-	c.LastRequestId = id
-	return id
+	return nil
 }
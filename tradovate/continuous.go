@@ -0,0 +1,280 @@
+package tradovate
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/cockroachdb/apd"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// ContractSpec describes the contract and its product
+type ContractSpec struct {
+	Contract   Contract
+	Product    Product
+	Maturity   ContractMaturity
+	TickSize   float64
+	TickValue  float64 // Value of one tick in the product currency
+	PointValue float64 // Value of one point in the product currency
+	Sessions   []ProductSession
+}
+
+// RolloverEvent is sent when continuous symbol moves to the next contract
+type RolloverEvent struct {
+	Symbol     string    // Continuous symbol, e.g. "TRADOVATE-ES!1"
+	From       string    // Previous contract, e.g. "TRADOVATE-ESZ4"
+	To         string    // Next contract, e.g. "TRADOVATE-ESH5"
+	Expiration time.Time // Expiration of the previous contract
+}
+
+// continuousContract is the contract of continuous symbol until `rollAt`
+type continuousContract struct {
+	contract Contract
+	maturity ContractMaturity
+	product  Product
+	rollAt   time.Time
+}
+
+func (t *TradovateContract) rolloverPeriod() time.Duration {
+	return time.Duration(t.RolloverDays) * 24 * time.Hour
+}
+
+func (t *TradovateContract) findProduct(ctx context.Context, name string) (Product, error) {
+	params := url.Values{}
+	params.Set("name", name)
+	product := Product{}
+	if err := t.client.Do(ctx, http.MethodGet, FIND_PRODUCT, params, nil, &product); err != nil {
+		return Product{}, errors.Wrapf(err, "can't find product %s", name)
+	}
+	return product, nil
+}
+
+// resolveContinuous returns the contract of continuous symbol like "ES!1".
+// Maturities expiring within RolloverDays are skipped.
+func (t *TradovateContract) resolveContinuous(ctx context.Context, name string) (continuousContract, error) {
+	root, n, ok := parseContinuous(name)
+	if !ok {
+		return continuousContract{}, errors.Errorf("%s isn't a continuous symbol", name)
+	}
+
+	now := time.Now()
+	t.mu.Lock()
+	cc, ok := t.continuous[name]
+	t.mu.Unlock()
+	if ok && now.Before(cc.rollAt) {
+		return cc, nil
+	}
+
+	product, err := t.findProduct(ctx, root)
+	if err != nil {
+		return continuousContract{}, err
+	}
+	params := url.Values{}
+	params.Set("masterid", strconv.FormatInt(product.Id, 10))
+	maturities := []ContractMaturity{}
+	if err := t.client.Do(ctx, http.MethodGet, GET_MATURITIES, params, nil, &maturities); err != nil {
+		return continuousContract{}, errors.Wrapf(err, "can't get maturities of %s", root)
+	}
+
+	active := []ContractMaturity{}
+	for _, m := range maturities {
+		if !m.Archived && now.Before(m.ExpirationDate.Add(-t.rolloverPeriod())) {
+			active = append(active, m)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool {
+		return active[i].ExpirationDate.Before(active[j].ExpirationDate)
+	})
+	if len(active) < n {
+		return continuousContract{}, errors.Errorf("%s has only %d active maturities", root, len(active))
+	}
+
+	params = url.Values{}
+	params.Set("masterid", strconv.FormatInt(active[n-1].Id, 10))
+	contracts := []Contract{}
+	if err := t.client.Do(ctx, http.MethodGet, GET_CONTRACTS, params, nil, &contracts); err != nil {
+		return continuousContract{}, errors.Wrapf(err, "can't get contracts of %s", name)
+	}
+	if len(contracts) == 0 {
+		return continuousContract{}, errors.Errorf("no contracts of maturity %d", active[n-1].ExpirationMonth)
+	}
+
+	// Every continuous symbol of the product rolls with the front month
+	cc = continuousContract{
+		contract: contracts[0],
+		maturity: active[n-1],
+		product:  product,
+		rollAt:   active[0].ExpirationDate.Add(-t.rolloverPeriod()),
+	}
+	t.mu.Lock()
+	t.continuous[name] = cc
+	t.contracts[cc.contract.Id] = cc.contract
+	t.mu.Unlock()
+	return cc, nil
+}
+
+// resolveName returns the contract name of native symbol, continuous symbols are resolved
+func (t *TradovateContract) resolveName(ctx context.Context, name string) (string, error) {
+	if _, _, ok := parseContinuous(name); !ok {
+		return name, nil
+	}
+	cc, err := t.resolveContinuous(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	return cc.contract.Name, nil
+}
+
+// GetContractSpec returns specs of the symbol, e.g. "TRADOVATE-ESZ4" or "TRADOVATE-ES!1"
+func (t *TradovateContract) GetContractSpec(ctx context.Context, symbol string) (ContractSpec, error) {
+	name := ToTradovateSymbol(symbol)
+	spec := ContractSpec{}
+	if _, _, ok := parseContinuous(name); ok {
+		cc, err := t.resolveContinuous(ctx, name)
+		if err != nil {
+			return ContractSpec{}, err
+		}
+		spec.Contract, spec.Maturity, spec.Product = cc.contract, cc.maturity, cc.product
+	} else {
+		contract, err := t.contractByName(ctx, name)
+		if err != nil {
+			return ContractSpec{}, err
+		}
+		spec.Contract = contract
+
+		params := url.Values{}
+		params.Set("id", strconv.FormatInt(contract.ContractMaturityId, 10))
+		if err := t.client.Do(ctx, http.MethodGet, GET_MATURITY, params, nil, &spec.Maturity); err != nil {
+			return ContractSpec{}, errors.Wrapf(err, "can't get maturity of %s", name)
+		}
+		params.Set("id", strconv.FormatInt(spec.Maturity.ProductId, 10))
+		if err := t.client.Do(ctx, http.MethodGet, GET_PRODUCT, params, nil, &spec.Product); err != nil {
+			return ContractSpec{}, errors.Wrapf(err, "can't get product of %s", name)
+		}
+	}
+
+	params := url.Values{}
+	params.Set("masterid", strconv.FormatInt(spec.Product.Id, 10))
+	if err := t.client.Do(ctx, http.MethodGet, GET_SESSIONS, params, nil, &spec.Sessions); err != nil {
+		return ContractSpec{}, errors.Wrapf(err, "can't get sessions of %s", spec.Product.Name)
+	}
+
+	spec.TickSize = spec.Product.TickSize
+	if spec.TickSize == 0 {
+		spec.TickSize = spec.Contract.ProviderTickSize
+	}
+	spec.PointValue = spec.Product.ValuePerPoint
+	spec.TickValue = spec.TickSize * spec.PointValue
+	return spec, nil
+}
+
+// WatchRollover sends an event when continuous symbol moves to the next contract,
+// it happens RolloverDays before expiration of the current one
+func (t *TradovateContract) WatchRollover(ctx context.Context, symbol string) (<-chan RolloverEvent, error) {
+	name := ToTradovateSymbol(symbol)
+	cc, err := t.resolveContinuous(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan RolloverEvent, 100) // TODO: move to config
+	go func() {
+		defer close(out)
+		wait := time.Until(cc.rollAt)
+		for {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			next, err := t.resolveContinuous(ctx, name)
+			if err != nil {
+				t.lg.Warn("Can't resolve continuous symbol", zap.String("symbol", symbol), zap.Error(err))
+				wait = time.Minute // TODO: move to config
+				continue
+			}
+			if next.contract.Id != cc.contract.Id {
+				ev := RolloverEvent{
+					Symbol:     ToTradovateFullSymbol(name),
+					From:       ToTradovateFullSymbol(cc.contract.Name),
+					To:         ToTradovateFullSymbol(next.contract.Name),
+					Expiration: cc.maturity.ExpirationDate,
+				}
+				select {
+				case out <- ev:
+				case <-ctx.Done():
+					return
+				}
+			}
+			cc = next
+			wait = time.Until(cc.rollAt)
+		}
+	}()
+	return out, nil
+}
+
+// RollPositions closes positions of the previous contract and opens the same positions
+// of the next one by market orders
+func (t *TradovateContract) RollPositions(ctx context.Context, ev RolloverEvent) error {
+	from, err := t.contractByName(ctx, ToTradovateSymbol(ev.From))
+	if err != nil {
+		return err
+	}
+	account, err := t.getAccount(ctx)
+	if err != nil {
+		return err
+	}
+	positions := []Position{}
+	if err := t.client.Do(ctx, http.MethodGet, GET_POSITIONS, nil, nil, &positions); err != nil {
+		return errors.Wrap(err, "can't get positions")
+	}
+
+	for _, p := range positions {
+		if p.AccountId != account.Id || p.ContractId != from.Id || p.NetPos == 0 {
+			continue
+		}
+		closeAction, openAction, qty := "Sell", "Buy", p.NetPos
+		if qty < 0 {
+			closeAction, openAction, qty = "Buy", "Sell", -qty
+		}
+		if _, err := t.placeOrder(ctx, false, closeAction, ev.From, nil, apd.New(qty, 0), "", "Market"); err != nil {
+			return errors.Wrapf(err, "can't close position of %s", ev.From)
+		}
+		if _, err := t.placeOrder(ctx, false, openAction, ev.To, nil, apd.New(qty, 0), "", "Market"); err != nil {
+			return errors.Wrapf(err, "can't open position of %s", ev.To)
+		}
+	}
+	return nil
+}
+
+// continuousSymbolInfo lists continuous symbol with its current contract
+func (t *TradovateContract) continuousSymbolInfo(ctx context.Context, name string) (exchanges.SymbolInfo, error) {
+	cc, err := t.resolveContinuous(ctx, name)
+	if err != nil {
+		return exchanges.SymbolInfo{}, err
+	}
+
+	instrument := contractInstrument(name)
+	fullSymbol := exchanges.Instruments.Register(TRADOVATE_PREFIX, name, instrument)
+	return exchanges.SymbolInfo{
+		DisplayName:    fullSymbol,
+		Symbol:         fullSymbol,
+		OriginalSymbol: name,
+		Filters: []map[string]interface{}{{
+			"ContractId":       cc.contract.Id,
+			"Contract":         cc.contract.Name,
+			"ProviderTickSize": cc.contract.ProviderTickSize,
+			"RollAt":           cc.rollAt,
+		}},
+		Instrument: &instrument,
+	}, nil
+}
@@ -0,0 +1,76 @@
+package tradovate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aulaleslie/trade-exchanges/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestTradovateContractContinuousSymbol(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fake := newFakeTradovate(t)
+	now := time.Now()
+	fake.products = []Product{{Id: 1, Name: "ES", ValuePerPoint: 50, TickSize: 0.25}}
+	fake.maturities = []ContractMaturity{
+		{Id: 21, ProductId: 1, ExpirationMonth: 202503, ExpirationDate: now.Add(90 * 24 * time.Hour)},
+		{Id: 20, ProductId: 1, ExpirationMonth: 202412, ExpirationDate: now.Add(48*time.Hour + 300*time.Millisecond)},
+		{Id: 19, ProductId: 1, ExpirationMonth: 202409, ExpirationDate: now.Add(-48 * time.Hour), Archived: true},
+	}
+	fake.contracts = []Contract{
+		{Id: 10, Name: "ESZ4", ContractMaturityId: 20, ProviderTickSize: 0.25},
+		{Id: 11, Name: "ESH5", ContractMaturityId: 21, ProviderTickSize: 0.25},
+	}
+	fake.sessions = []ProductSession{{Id: 1, ProductId: 1, OpenTime: "17:00", CloseTime: "16:00"}}
+	fake.positions = []Position{{Id: 700, AccountId: 1, ContractId: 10, NetPos: -2}}
+	tc := NewTradovateContract(fake.client(), zap.NewNop())
+	tc.RolloverDays = 2
+
+	spec, err := tc.GetContractSpec(ctx, "TRADOVATE-ES!1")
+	require.NoError(t, err)
+	assert.Equal(t, "ESZ4", spec.Contract.Name)
+	assert.Equal(t, int64(202412), spec.Maturity.ExpirationMonth)
+	assert.Equal(t, 0.25, spec.TickSize)
+	assert.Equal(t, 12.5, spec.TickValue)
+	assert.Equal(t, 50.0, spec.PointValue)
+	assert.Len(t, spec.Sessions, 1)
+
+	spec, err = tc.GetContractSpec(ctx, "TRADOVATE-ES!2")
+	require.NoError(t, err)
+	assert.Equal(t, "ESH5", spec.Contract.Name)
+
+	// Rollover happens 2 days before expiration of the front month
+	events, err := tc.WatchRollover(ctx, "TRADOVATE-ES!1")
+	require.NoError(t, err)
+	var ev RolloverEvent
+	select {
+	case ev = <-events:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no rollover event")
+	}
+	assert.Equal(t, "TRADOVATE-ES!1", ev.Symbol)
+	assert.Equal(t, "TRADOVATE-ESZ4", ev.From)
+	assert.Equal(t, "TRADOVATE-ESH5", ev.To)
+
+	_, err = tc.PlaceBuyOrder(ctx, false, "TRADOVATE-ES!1", utils.FromString("5000.25"), utils.FromString("1"), "")
+	require.NoError(t, err)
+	require.Len(t, fake.placeReqs, 1)
+	assert.Equal(t, "ESH5", fake.placeReqs[0].Symbol)
+
+	// Short position is bought back and sold again in the next contract
+	require.NoError(t, tc.RollPositions(ctx, ev))
+	require.Len(t, fake.placeReqs, 3)
+	closeReq, openReq := fake.placeReqs[1], fake.placeReqs[2]
+	assert.Equal(t, "ESZ4", closeReq.Symbol)
+	assert.Equal(t, "Buy", closeReq.Action)
+	assert.Equal(t, "Market", closeReq.OrderType)
+	assert.Equal(t, int64(2), closeReq.OrderQty)
+	assert.Equal(t, "ESH5", openReq.Symbol)
+	assert.Equal(t, "Sell", openReq.Action)
+	assert.Equal(t, int64(2), openReq.OrderQty)
+}
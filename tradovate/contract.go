@@ -18,9 +18,11 @@ func (c *Client) FindContract(name string) (Contract, error) {
 	params.Add("name", name)
 
 	msg, err := c.Send(FIND_CONTRACT, params.Encode(), "", 10)
-
+	if err != nil {
+		return Contract{}, err
+	}
 	if msg.Status != 200 {
-
+		return Contract{}, &APIError{StatusCode: msg.Status, Text: string(msg.Data)}
 	}
 
 	var contract Contract
@@ -1,76 +1,27 @@
 package tradovate
 
 import (
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
 	"regexp"
-	"strconv"
-	"time"
 
 	exchanges "github.com/aulaleslie/trade-exchanges"
 )
 
-const (
-	GetOrderHistoryPath = "/v5/order/history"
-	TradovateBaseURL    = "https://demo.tradovateapi.com/v1"
-)
-
-var recWindow = "5000"
-
-var priceFloorRE = regexp.MustCompile(`^[0-9]{1,20}(\.[0-9]{1,6})?`)
-
-var qtyFloorRE = regexp.MustCompile(`^[0-9]{1,20}(\.[0-9]{1,5})?`)
-
-type TradovateResponse struct {
-	RetCode int            `json:"retCode"`
-	RetMsg  string         `json:"retMsg"`
-	Result  ResultResponse `json:"result"`
-}
-type ResultResponse struct {
-	NextPageCursor string                 `json:"nextPageCursor"`
-	Category       string                 `json:"category"`
-	List           []OrderHistoryResponse `json:"list"`
-}
-type OrderHistoryResponse struct {
-	OrderId     string `json:"orderId"`
-	OrderLinkId string `json:"orderLinkId"`
-	Symbol      string `json:"symbol"`
-	Side        string `json:"side"`
-	OrderStatus string `json:"orderStatus"`
-	CreatedTime string `json:"createdTime"`
-	UpdatedTime string `json:"updatedTime"`
-}
-
-// type orderFields struct {
-// 	ClOrdID  string
-// 	OrderQty float64
-// 	PriceEp  int64
-// 	Symbol   string
-// }
-
-type SymbolFilter struct {
-	LotSizeFilter struct {
-		BasePrecision  string `json:"basePrecision"`
-		QuotePrecision string `json:"quotePrecision"`
-		MaxOrderQty    string `json:"maxOrderQty"`
-		MinOrderQty    string `json:"minOrderQty"`
-		MinOrderAmt    string `json:"minOrderAmt"`
-		MaxOrderAmt    string `json:"maxOrderAmt"`
-	} `json:"lotSizeFilter"`
-	PriceFilter struct {
-		TickSize string `json:"tickSize"`
-	} `json:"priceFilter"`
-}
+// Quantity of futures is a number of contracts
+var qtyFloorRE = regexp.MustCompile(`^[0-9]{1,20}`)
 
 // Mapping OrderStatusType
+// Working orders with fills are PartiallyFilled, see orderStatus
 var orderStatusTypeMap map[string]exchanges.OrderStatusType = map[string]exchanges.OrderStatusType{
-	"New":             exchanges.NewOST,             // NEW - The order has been accepted by the engine.
-	"PartiallyFilled": exchanges.PartiallyFilledOST, // PARTIALLY_FILLED - A part of the order has been filled.
-	"Filled":          exchanges.FilledOST,          // FILLED - The order has been completely filled.
-	"Cancelled":       exchanges.CanceledOST,        // CANCELED - The order has been canceled by the user.
-	// PENDING_CANCEL (currently unused)
-	"Rejected": exchanges.RejectedOST, // REJECTED - The order was not accepted by the engine and not processed.
+	"PendingNew":     exchanges.NewOST,
+	"Working":        exchanges.NewOST,
+	"PendingReplace": exchanges.NewOST,
+	"PendingCancel":  exchanges.NewOST,
+	"Suspended":      exchanges.NewOST,
+	"Filled":         exchanges.FilledOST,
+	"Completed":      exchanges.FilledOST,
+	"Canceled":       exchanges.CanceledOST,
+	"Rejected":       exchanges.RejectedOST,
+	"Expired":        exchanges.ExpiredOST,
 }
 
 func mapOrderStatusType(orderStatus string) exchanges.OrderStatusType {
@@ -82,10 +33,21 @@ func mapOrderStatusType(orderStatus string) exchanges.OrderStatusType {
 	}
 }
 
+func orderStatus(ordStatus string, filledQty int64) exchanges.OrderStatusType {
+	status := mapOrderStatusType(ordStatus)
+	if status == exchanges.NewOST && filledQty > 0 {
+		return exchanges.PartiallyFilledOST
+	}
+	return status
+}
+
 // Mapping OrderType
 var orderTypeMap map[string]exchanges.OrderType = map[string]exchanges.OrderType{
-	"Limit":  exchanges.LIMIT,
-	"Market": exchanges.MARKET,
+	"Limit":     exchanges.LIMIT,
+	"Market":    exchanges.MARKET,
+	"Stop":      exchanges.STOP_LOSS,
+	"StopLimit": exchanges.STOP_LOSS_LIMIT,
+	"MIT":       exchanges.MARKET_IF_TOUCHED,
 }
 
 func mapOrderType(orderType string) *exchanges.OrderType {
@@ -96,6 +58,19 @@ func mapOrderType(orderType string) *exchanges.OrderType {
 	return nil
 }
 
+// toTradovateOrderType accepts both exchanges.OrderType and Tradovate names
+func toTradovateOrderType(orderType string) (string, bool) {
+	if _, ok := orderTypeMap[orderType]; ok {
+		return orderType, true
+	}
+	for name, ot := range orderTypeMap {
+		if string(ot) == orderType {
+			return name, true
+		}
+	}
+	return "", false
+}
+
 // Mapping OrderSide
 var orderSideMap map[string]exchanges.OrderSide = map[string]exchanges.OrderSide{
 	"Buy":  exchanges.BUY,
@@ -109,15 +84,3 @@ func mapOrderSide(orderSide string) exchanges.OrderSide {
 	}
 	return exchanges.UNKNOWN_ORDER_SIDE
 }
-
-func tradovateSignatureGenerator(apikey, apisecret, query string) (string, int64) {
-	now := time.Now()
-	unixNano := now.UnixNano()
-	timeStamp := unixNano / 1000000
-
-	// Generate tradovate signature
-	hmac256 := hmac.New(sha256.New, []byte(apisecret))
-	hmac256.Write([]byte(strconv.FormatInt(timeStamp, 10) + apikey + recWindow + query))
-	signature := hex.EncodeToString(hmac256.Sum(nil))
-	return signature, timeStamp
-}
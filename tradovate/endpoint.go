@@ -1,14 +1,37 @@
 package tradovate
 
 const (
-	AUTHORIZE         = "authorize"
-	CANCEL_CHART      = "md/cancelchart"
-	CONNECT_WEBSOCKET = "/websocket"
-	FIND_CONTRACT     = "contract/find"
-	GET_ACCESS_TOKEN  = "/auth/accesstokenrequest"
-	GET_CHART         = "md/getchart"
-	UNSUBSCRIBE_QOUTE = "md/unsubscribeQuote"
-	GET_ORDERS        = "order/list"
-	GET_FILLED_ORDERS = "fill/list"
-	GET_EXCHANGE_LIST = "exchange/list"
+	AUTHORIZE          = "authorize"
+	CANCEL_CHART       = "md/cancelchart"
+	CONNECT_WEBSOCKET  = "/websocket"
+	FIND_CONTRACT      = "contract/find"
+	GET_CONTRACT       = "contract/item"
+	GET_CONTRACTS      = "contract/deps"
+	FIND_PRODUCT       = "product/find"
+	GET_PRODUCT        = "product/item"
+	GET_MATURITY       = "contractMaturity/item"
+	GET_MATURITIES     = "contractMaturity/deps"
+	GET_SESSIONS       = "productSession/deps"
+	GET_ACCESS_TOKEN   = "/auth/accesstokenrequest"
+	RENEW_ACCESS_TOKEN = "/auth/renewaccesstoken"
+	GET_CHART          = "md/getchart"
+	SUBSCRIBE_QUOTE    = "md/subscribeQuote"
+	UNSUBSCRIBE_QOUTE  = "md/unsubscribeQuote"
+	SUBSCRIBE_DOM      = "md/subscribeDOM"
+	UNSUBSCRIBE_DOM    = "md/unsubscribeDOM"
+	GET_ACCOUNTS       = "account/list"
+	PLACE_ORDER        = "order/placeorder"
+	CANCEL_ORDER       = "order/cancelorder"
+	GET_ORDER          = "order/item"
+	GET_ORDERS         = "order/list"
+	GET_ORDER_VERSIONS = "orderVersion/list"
+	GET_COMMANDS       = "command/list"
+	GET_ORDER_COMMANDS = "command/deps"
+	GET_FILLED_ORDERS  = "fill/list"
+	GET_ORDER_FILLS    = "fill/deps"
+	GET_POSITIONS      = "position/list"
+	GET_CASH_BALANCES  = "cashBalance/list"
+	GET_CURRENCIES     = "currency/list"
+	GET_EXCHANGE_LIST  = "exchange/list"
+	SYNC_USER          = "user/syncrequest"
 )
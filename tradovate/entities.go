@@ -0,0 +1,161 @@
+package tradovate
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Entities of Tradovate REST API https://api.tradovate.com
+
+type Account struct {
+	Id          int64  `json:"id"`
+	Name        string `json:"name"`
+	UserId      int64  `json:"userId"`
+	AccountType string `json:"accountType"`
+	Active      bool   `json:"active"`
+}
+
+type Product struct {
+	Id            int64   `json:"id"`
+	Name          string  `json:"name"` // e.g. "ES"
+	CurrencyId    int64   `json:"currencyId"`
+	ProductType   string  `json:"productType"` // Futures, Options, ...
+	Description   string  `json:"description,omitempty"`
+	Status        string  `json:"status"`
+	Months        string  `json:"months,omitempty"` // Month codes of maturities, e.g. "HMUZ"
+	ValuePerPoint float64 `json:"valuePerPoint"`
+	TickSize      float64 `json:"tickSize"`
+}
+
+type ContractMaturity struct {
+	Id              int64     `json:"id"`
+	ProductId       int64     `json:"productId"`
+	ExpirationMonth int64     `json:"expirationMonth"` // e.g. 202412
+	ExpirationDate  time.Time `json:"expirationDate"`
+	Archived        bool      `json:"archived"`
+	SeqNo           int64     `json:"seqNo"`
+	IsFront         bool      `json:"isFront"`
+}
+
+// ProductSession is the trading session, times are local times of the exchange like "08:30"
+type ProductSession struct {
+	Id         int64  `json:"id"`
+	ProductId  int64  `json:"productId"`
+	OpenTime   string `json:"openTime"`
+	StartTime  string `json:"startTime"`
+	StopTime   string `json:"stopTime"`
+	CloseTime  string `json:"closeTime"`
+	SundayOpen string `json:"sundayOpen,omitempty"`
+}
+
+type Order struct {
+	Id         int64     `json:"id"`
+	AccountId  int64     `json:"accountId"`
+	ContractId int64     `json:"contractId"`
+	Timestamp  time.Time `json:"timestamp"`
+	Action     string    `json:"action"`    // Buy, Sell
+	OrdStatus  string    `json:"ordStatus"` // Working, Filled, Canceled, ...
+	ParentId   int64     `json:"parentId,omitempty"`
+	LinkedId   int64     `json:"linkedId,omitempty"`
+	OcoId      int64     `json:"ocoId,omitempty"`
+	Archived   bool      `json:"archived,omitempty"`
+}
+
+type OrderVersion struct {
+	Id          int64   `json:"id"`
+	OrderId     int64   `json:"orderId"`
+	OrderQty    int64   `json:"orderQty"`
+	OrderType   string  `json:"orderType"` // Limit, Market, Stop, StopLimit, ...
+	Price       float64 `json:"price,omitempty"`
+	StopPrice   float64 `json:"stopPrice,omitempty"`
+	TimeInForce string  `json:"timeInForce,omitempty"`
+}
+
+type Command struct {
+	Id            int64     `json:"id"`
+	OrderId       int64     `json:"orderId"`
+	Timestamp     time.Time `json:"timestamp"`
+	ClOrdId       string    `json:"clOrdId,omitempty"`
+	CommandType   string    `json:"commandType"` // New, Modify, Cancel
+	CommandStatus string    `json:"commandStatus"`
+}
+
+type Fill struct {
+	Id         int64     `json:"id"`
+	OrderId    int64     `json:"orderId"`
+	ContractId int64     `json:"contractId"`
+	Timestamp  time.Time `json:"timestamp"`
+	Action     string    `json:"action"`
+	Qty        int64     `json:"qty"`
+	Price      float64   `json:"price"`
+	Active     bool      `json:"active"`
+}
+
+type ExecutionReport struct {
+	Id           int64     `json:"id"`
+	CommandId    int64     `json:"commandId"`
+	AccountId    int64     `json:"accountId"`
+	ContractId   int64     `json:"contractId"`
+	OrderId      int64     `json:"orderId"`
+	Timestamp    time.Time `json:"timestamp"`
+	ExecType     string    `json:"execType"`  // New, Trade, Canceled, Rejected, ...
+	OrdStatus    string    `json:"ordStatus"` // Working, Filled, Canceled, ...
+	Action       string    `json:"action"`
+	CumQty       int64     `json:"cumQty,omitempty"`
+	AvgPx        float64   `json:"avgPx,omitempty"`
+	LastQty      int64     `json:"lastQty,omitempty"`
+	LastPx       float64   `json:"lastPx,omitempty"`
+	RejectReason string    `json:"rejectReason,omitempty"`
+	Text         string    `json:"text,omitempty"`
+}
+
+type Position struct {
+	Id         int64     `json:"id"`
+	AccountId  int64     `json:"accountId"`
+	ContractId int64     `json:"contractId"`
+	Timestamp  time.Time `json:"timestamp"`
+	NetPos     int64     `json:"netPos"`
+	NetPrice   float64   `json:"netPrice,omitempty"`
+	Bought     int64     `json:"bought"`
+	Sold       int64     `json:"sold"`
+}
+
+type CashBalance struct {
+	Id          int64   `json:"id"`
+	AccountId   int64   `json:"accountId"`
+	CurrencyId  int64   `json:"currencyId"`
+	Amount      float64 `json:"amount"`
+	RealizedPnL float64 `json:"realizedPnL,omitempty"`
+}
+
+type Currency struct {
+	Id     int64  `json:"id"`
+	Name   string `json:"name"`
+	Symbol string `json:"symbol,omitempty"`
+}
+
+type PlaceOrderRequest struct {
+	AccountSpec string       `json:"accountSpec"`
+	AccountId   int64        `json:"accountId"`
+	ClOrdId     string       `json:"clOrdId,omitempty"`
+	Action      string       `json:"action"`
+	Symbol      string       `json:"symbol"`
+	OrderQty    int64        `json:"orderQty"`
+	OrderType   string       `json:"orderType"`
+	Price       *json.Number `json:"price,omitempty"`
+	TimeInForce string       `json:"timeInForce,omitempty"`
+	IsAutomated bool         `json:"isAutomated"`
+}
+
+type PlaceOrderResponse struct {
+	OrderId int64 `json:"orderId"`
+}
+
+type CancelOrderRequest struct {
+	OrderId     int64 `json:"orderId"`
+	IsAutomated bool  `json:"isAutomated"`
+}
+
+type CancelOrderResponse struct {
+	CommandId int64 `json:"commandId"`
+}
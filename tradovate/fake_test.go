@@ -0,0 +1,555 @@
+package tradovate
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// fakeTradovate is a local fake of Tradovate REST API
+type fakeTradovate struct {
+	t   *testing.T
+	srv *httptest.Server
+
+	mu         sync.Mutex
+	accounts   []Account
+	contracts  []Contract
+	products   []Product
+	maturities []ContractMaturity
+	sessions   []ProductSession
+	orders     []Order
+	versions   []OrderVersion
+	commands   []Command
+	fills      []Fill
+	positions  []Position
+	syncProps  []Order // Pushed as created orders before the sync response
+	balances   []CashBalance
+	currencies []Currency
+	placeReqs  []PlaceOrderRequest
+	lastID     int64
+
+	tokenReqs []accessTokenRequest
+	penalties []accessTokenResponse // Sent instead of tokens
+	renewReqs int
+	tokens    map[string]bool // Valid tokens
+
+	wsMu       sync.Mutex
+	wsConns    []*fakeWSConn
+	wsRequests []string // Endpoints and bodies of socket requests
+	lastPrice  float64  // Trade price of pushed quotes
+}
+
+type fakeWSConn struct {
+	mu sync.Mutex
+	ws *websocket.Conn
+}
+
+func (c *fakeWSConn) write(msg string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_ = c.ws.WriteMessage(websocket.TextMessage, []byte(msg))
+}
+
+const fakeToken = "fake-token"
+
+func newFakeTradovate(t *testing.T) *fakeTradovate {
+	f := &fakeTradovate{
+		t:          t,
+		accounts:   []Account{{Id: 1, Name: "DEMO1", Active: true}},
+		contracts:  []Contract{{Id: 10, Name: "ESZ4", ProviderTickSize: 0.25}},
+		currencies: []Currency{{Id: 1, Name: "USD"}},
+		lastID:     100,
+		tokens:     map[string]bool{},
+		lastPrice:  5000.25,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(GET_ACCESS_TOKEN, f.handleAccessToken)
+	mux.HandleFunc(RENEW_ACCESS_TOKEN, f.handleRenewAccessToken)
+	mux.HandleFunc(CONNECT_WEBSOCKET, f.handleWebsocket)
+	mux.HandleFunc("/", f.handleREST)
+	f.srv = httptest.NewServer(mux)
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeTradovate) client() *Client {
+	return &Client{
+		HttpHost:       f.srv.URL,
+		WebsocketHost:  "ws" + strings.TrimPrefix(f.srv.URL, "http"),
+		MarketDataHost: "ws" + strings.TrimPrefix(f.srv.URL, "http"),
+		Username:       "user",
+		Password:       "password",
+	}
+}
+
+func (f *fakeTradovate) nextID() int64 {
+	f.lastID++
+	return f.lastID
+}
+
+func (f *fakeTradovate) issueToken(w http.ResponseWriter) {
+	token := fakeToken
+	if len(f.tokens) > 0 {
+		token += "-" + strconv.Itoa(len(f.tokens))
+	}
+	f.tokens[token] = true
+	writeJSON(w, map[string]interface{}{
+		"accessToken":    token,
+		"mdAccessToken":  "md-" + token,
+		"userId":         1,
+		"expirationTime": time.Now().Add(time.Hour).UTC().Format("2006-01-02T15:04:05Z"),
+	})
+}
+
+func (f *fakeTradovate) validToken(r *http.Request) bool {
+	return f.tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+}
+
+func (f *fakeTradovate) handleAccessToken(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	req := accessTokenRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	f.tokenReqs = append(f.tokenReqs, req)
+
+	if len(f.penalties) > 0 {
+		penalty := f.penalties[0]
+		f.penalties = f.penalties[1:]
+		writeJSON(w, map[string]interface{}{
+			"p-ticket": penalty.PenaltyTicket, "p-time": penalty.PenaltyTime, "p-captcha": penalty.PenaltyCaptcha,
+		})
+		return
+	}
+	f.issueToken(w)
+}
+
+func (f *fakeTradovate) handleRenewAccessToken(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.renewReqs++
+	if !f.validToken(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	f.issueToken(w)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (f *fakeTradovate) handleREST(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.validToken(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	q := r.URL.Query()
+	id, _ := strconv.ParseInt(q.Get("id"), 10, 64)
+	masterID, _ := strconv.ParseInt(q.Get("masterid"), 10, 64)
+
+	switch strings.TrimPrefix(r.URL.Path, "/") {
+	case GET_ACCOUNTS:
+		writeJSON(w, f.accounts)
+	case FIND_CONTRACT:
+		for _, c := range f.contracts {
+			if c.Name == q.Get("name") {
+				writeJSON(w, c)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	case GET_CONTRACTS:
+		res := []Contract{}
+		for _, c := range f.contracts {
+			if c.ContractMaturityId == masterID {
+				res = append(res, c)
+			}
+		}
+		writeJSON(w, res)
+	case FIND_PRODUCT, GET_PRODUCT:
+		for _, p := range f.products {
+			if p.Name == q.Get("name") || p.Id == id {
+				writeJSON(w, p)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	case GET_MATURITY:
+		for _, m := range f.maturities {
+			if m.Id == id {
+				writeJSON(w, m)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	case GET_MATURITIES:
+		res := []ContractMaturity{}
+		for _, m := range f.maturities {
+			if m.ProductId == masterID {
+				res = append(res, m)
+			}
+		}
+		writeJSON(w, res)
+	case GET_SESSIONS:
+		res := []ProductSession{}
+		for _, s := range f.sessions {
+			if s.ProductId == masterID {
+				res = append(res, s)
+			}
+		}
+		writeJSON(w, res)
+	case GET_CONTRACT:
+		for _, c := range f.contracts {
+			if c.Id == id {
+				writeJSON(w, c)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	case PLACE_ORDER:
+		f.placeOrder(w, r)
+	case CANCEL_ORDER:
+		f.cancelOrder(w, r)
+	case GET_ORDER:
+		for _, o := range f.orders {
+			if o.Id == id {
+				writeJSON(w, o)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	case GET_ORDERS:
+		writeJSON(w, f.orders)
+	case GET_ORDER_VERSIONS:
+		writeJSON(w, f.versions)
+	case GET_COMMANDS:
+		writeJSON(w, f.commands)
+	case GET_ORDER_COMMANDS:
+		res := []Command{}
+		for _, c := range f.commands {
+			if c.OrderId == masterID {
+				res = append(res, c)
+			}
+		}
+		writeJSON(w, res)
+	case GET_FILLED_ORDERS:
+		writeJSON(w, f.fills)
+	case GET_ORDER_FILLS:
+		res := []Fill{}
+		for _, fill := range f.fills {
+			if fill.OrderId == masterID {
+				res = append(res, fill)
+			}
+		}
+		writeJSON(w, res)
+	case GET_POSITIONS:
+		writeJSON(w, f.positions)
+	case GET_CASH_BALANCES:
+		writeJSON(w, f.balances)
+	case GET_CURRENCIES:
+		writeJSON(w, f.currencies)
+	default:
+		f.t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeTradovate) placeOrder(w http.ResponseWriter, r *http.Request) {
+	req := PlaceOrderRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	f.placeReqs = append(f.placeReqs, req)
+
+	contractID := int64(0)
+	for _, c := range f.contracts {
+		if c.Name == req.Symbol {
+			contractID = c.Id
+		}
+	}
+	if contractID == 0 {
+		writeJSON(w, map[string]string{"failureReason": "UnknownReason", "failureText": "Unknown symbol"})
+		return
+	}
+
+	now := time.Now().UTC()
+	order := Order{
+		Id: f.nextID(), AccountId: req.AccountId, ContractId: contractID,
+		Timestamp: now, Action: req.Action, OrdStatus: "Working",
+	}
+	price := 0.0
+	if req.Price != nil {
+		price, _ = req.Price.Float64()
+	}
+	f.orders = append(f.orders, order)
+	f.versions = append(f.versions, OrderVersion{
+		Id: f.nextID(), OrderId: order.Id, OrderQty: req.OrderQty, OrderType: req.OrderType,
+		Price: price, TimeInForce: req.TimeInForce,
+	})
+	f.commands = append(f.commands, Command{
+		Id: f.nextID(), OrderId: order.Id, Timestamp: now, ClOrdId: req.ClOrdId,
+		CommandType: "New", CommandStatus: "ExecutionReportsReceived",
+	})
+	writeJSON(w, PlaceOrderResponse{OrderId: order.Id})
+}
+
+func (f *fakeTradovate) cancelOrder(w http.ResponseWriter, r *http.Request) {
+	req := CancelOrderRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	for i, o := range f.orders {
+		if o.Id != req.OrderId {
+			continue
+		}
+		if o.OrdStatus != "Working" {
+			writeJSON(w, map[string]string{"failureReason": "TooLate", "failureText": "Too late to cancel"})
+			return
+		}
+		f.orders[i].OrdStatus = "Canceled"
+		writeJSON(w, CancelOrderResponse{CommandId: f.nextID()})
+		return
+	}
+	w.WriteHeader(http.StatusNotFound)
+}
+
+// fill executes `qty` of the order
+func (f *fakeTradovate) fill(orderID, qty int64, price float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, o := range f.orders {
+		if o.Id != orderID {
+			continue
+		}
+		f.fills = append(f.fills, Fill{
+			Id: f.nextID(), OrderId: orderID, ContractId: o.ContractId, Timestamp: time.Now().UTC(),
+			Action: o.Action, Qty: qty, Price: price, Active: true,
+		})
+		total := int64(0)
+		for _, fill := range f.fills {
+			if fill.OrderId == orderID {
+				total += fill.Qty
+			}
+		}
+		for _, v := range f.versions {
+			if v.OrderId == orderID && total >= v.OrderQty {
+				f.orders[i].OrdStatus = "Filled"
+			}
+		}
+	}
+}
+
+var fakeUpgrader = websocket.Upgrader{}
+
+func (f *fakeTradovate) handleWebsocket(w http.ResponseWriter, r *http.Request) {
+	ws, err := fakeUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	conn := &fakeWSConn{ws: ws}
+	f.wsMu.Lock()
+	f.wsConns = append(f.wsConns, conn)
+	f.wsMu.Unlock()
+	defer ws.Close()
+
+	conn.write("o")
+	authorized := false
+	for {
+		_, msg, err := ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		parts := strings.SplitN(string(msg), "\n", 4)
+		if len(parts) < 4 {
+			continue // Heartbeat
+		}
+		endpoint, id, query, body := parts[0], parts[1], parts[2], parts[3]
+		f.wsMu.Lock()
+		f.wsRequests = append(f.wsRequests, endpoint+" "+body)
+		price := f.lastPrice
+		f.wsMu.Unlock()
+
+		if endpoint == AUTHORIZE {
+			f.mu.Lock()
+			authorized = f.tokens[strings.TrimPrefix(body, "md-")]
+			f.mu.Unlock()
+			if !authorized {
+				conn.write(`a[{"s":401,"i":` + id + `,"d":"Access is denied"}]`)
+				continue
+			}
+		} else if !authorized {
+			conn.write(`a[{"s":401,"i":` + id + `,"d":"Access is denied"}]`)
+			continue
+		}
+		if endpoint == FIND_CONTRACT {
+			f.findContract(conn, id, query)
+			continue
+		}
+		if endpoint == GET_CHART {
+			f.chart(conn, id, body)
+			continue
+		}
+		if endpoint == SYNC_USER {
+			f.mu.Lock()
+			snapshot, _ := json.Marshal(SyncSnapshot{
+				Accounts: f.accounts, Orders: f.orders, Fills: f.fills, Positions: f.positions, CashBalances: f.balances,
+			})
+			props := f.syncProps
+			f.mu.Unlock()
+			for _, o := range props {
+				b, _ := json.Marshal(o)
+				conn.write(`a[{"e":"props","d":{"entityType":"order","eventType":"Created","entity":` + string(b) + `}}]`)
+			}
+			conn.write(`a[{"s":200,"i":` + id + `,"d":` + string(snapshot) + `}]`)
+			continue
+		}
+		conn.write(`a[{"s":200,"i":` + id + `}]`)
+
+		req := struct{ Symbol string }{}
+		_ = json.Unmarshal([]byte(body), &req)
+		contractID := "0"
+		f.mu.Lock()
+		for _, c := range f.contracts {
+			if c.Name == req.Symbol {
+				contractID = strconv.FormatInt(c.Id, 10)
+			}
+		}
+		f.mu.Unlock()
+		p := strconv.FormatFloat(price, 'f', -1, 64)
+
+		switch endpoint {
+		case SUBSCRIBE_QUOTE:
+			conn.write(`a[{"e":"md","d":{"quotes":[{"id":1,"contractId":` + contractID +
+				`,"timestamp":"2024-11-01T15:04:05Z","entries":{"Bid":{"price":` + p + `,"size":3},` +
+				`"Offer":{"price":` + p + `,"size":4},"Trade":{"price":` + p + `,"size":1}}}]}}]`)
+		case SUBSCRIBE_DOM:
+			conn.write(`a[{"e":"md","d":{"doms":[{"contractId":` + contractID +
+				`,"timestamp":"2024-11-01T15:04:05Z","bids":[{"price":` + p + `,"size":3}],` +
+				`"offers":[{"price":` + p + `,"size":4}]}]}}]`)
+		}
+	}
+}
+
+// dropWS closes all sockets by close frame
+func (f *fakeTradovate) dropWS() {
+	f.wsMu.Lock()
+	conns := f.wsConns
+	f.wsConns = nil
+	f.wsMu.Unlock()
+	for _, c := range conns {
+		c.write(`c[1000,"Bye"]`)
+		_ = c.ws.Close()
+	}
+}
+
+// wsRequestCount counts socket requests starting by `prefix`
+func (f *fakeTradovate) wsRequestCount(prefix string) int {
+	f.wsMu.Lock()
+	defer f.wsMu.Unlock()
+	n := 0
+	for _, r := range f.wsRequests {
+		if strings.HasPrefix(r, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+// pushProps sends the change of the entity to all sockets
+func (f *fakeTradovate) pushProps(entityType, eventType string, entity interface{}) {
+	b, err := json.Marshal(entity)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	msg := `a[{"e":"props","d":{"entityType":"` + entityType + `","eventType":"` + eventType + `","entity":` + string(b) + `}}]`
+
+	f.wsMu.Lock()
+	conns := f.wsConns
+	f.wsMu.Unlock()
+	for _, c := range conns {
+		c.write(msg)
+	}
+}
+
+// pushQuote sends the quote of the contract to all sockets
+func (f *fakeTradovate) pushQuote(contractID int64, price float64) {
+	p := strconv.FormatFloat(price, 'f', -1, 64)
+	msg := `a[{"e":"md","d":{"quotes":[{"id":1,"contractId":` + strconv.FormatInt(contractID, 10) +
+		`,"timestamp":"2024-11-01T15:04:05Z","entries":{"Trade":{"price":` + p + `,"size":1}}}]}}]`
+
+	f.wsMu.Lock()
+	conns := f.wsConns
+	f.wsMu.Unlock()
+	for _, c := range conns {
+		c.write(msg)
+	}
+}
+
+// chart sends the subscription, history packets out of order and one realtime packet
+func (f *fakeTradovate) chart(conn *fakeWSConn, id, body string) {
+	req := ChartRequest{}
+	_ = json.Unmarshal([]byte(body), &req)
+	f.mu.Lock()
+	found := false
+	for _, c := range f.contracts {
+		found = found || c.Name == req.Symbol
+	}
+	hist, rt := strconv.FormatInt(f.nextID(), 10), strconv.FormatInt(f.nextID(), 10)
+	f.mu.Unlock()
+	if !found {
+		conn.write(`a[{"s":404,"i":` + id + `,"d":"Symbol not found"}]`)
+		return
+	}
+
+	conn.write(`a[{"s":200,"i":` + id + `,"d":{"historicalId":` + hist + `,"realtimeId":` + rt + `}}]`)
+	packet := func(chartID, source, data string) {
+		conn.write(`a[{"e":"chart","d":{"charts":[{"id":` + chartID + `,"s":"` + source + `",` + data + `}]}}]`)
+	}
+	if req.ChartDescription.UnderlyingType == TickChart {
+		base := `"bp":20000,"bt":1730000000000,"ts":0.25,`
+		packet(hist, "db", base+`"tks":[{"id":2,"t":100,"p":2,"s":1,"b":1,"a":3},{"id":1,"t":100,"p":1,"s":2}]`)
+		packet(hist, "db", base+`"tks":[{"id":0,"t":0,"p":0,"s":3}]`)
+		conn.write(`a[{"e":"chart","d":{"charts":[{"id":` + hist + `,"eoh":true}]}}]`)
+		packet(rt, "realtime", base+`"tks":[{"id":3,"t":200,"p":4,"s":1}]`)
+		return
+	}
+	packet(hist, "db", `"bars":[{"timestamp":"2024-11-01T10:01:00Z","open":5001,"high":5002,"low":5000,"close":5001.5}]`)
+	packet(hist, "db", `"bars":[{"timestamp":"2024-11-01T10:00:00Z","open":5000,"high":5001,"low":4999,"close":5001}]`)
+	conn.write(`a[{"e":"chart","d":{"charts":[{"id":` + hist + `,"eoh":true}]}}]`)
+	packet(rt, "realtime", `"bars":[{"timestamp":"2024-11-01T10:02:00Z","open":5001.5,"high":5003,"low":5001,"close":5002}]`)
+}
+
+func (f *fakeTradovate) findContract(conn *fakeWSConn, id, query string) {
+	params, _ := url.ParseQuery(query)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, c := range f.contracts {
+		if c.Name == params.Get("name") {
+			b, _ := json.Marshal(c)
+			conn.write(`a[{"s":200,"i":` + id + `,"d":` + string(b) + `}]`)
+			return
+		}
+	}
+	conn.write(`a[{"s":404,"i":` + id + `,"d":"Contract not found"}]`)
+}
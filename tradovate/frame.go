@@ -0,0 +1,65 @@
+package tradovate
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type Message struct {
+	Id        int64           `json:"i,omitempty"`
+	Status    int             `json:"s,omitempty"`
+	EventType string          `json:"e,omitempty"`
+	Data      json.RawMessage `json:"d,omitempty"`
+}
+
+// Frame types of Tradovate WebSocket
+// https://api.tradovate.com/#section/Connecting-to-the-WebSocket-Server
+const (
+	openFrame      = 'o'
+	heartbeatFrame = 'h'
+	arrayFrame     = 'a'
+	closeFrame     = 'c'
+)
+
+// Client should send heartbeat frame every 2.5 seconds
+var heartbeatMessage = []byte("[]")
+
+// decodeFrame returns type of the frame and messages of array frame
+func decodeFrame(msg []byte) (byte, []Message, error) {
+	if len(msg) == 0 {
+		return 0, nil, errors.New("empty frame")
+	}
+
+	frameType, body := msg[0], msg[1:]
+	switch frameType {
+	case openFrame, heartbeatFrame:
+		return frameType, nil, nil
+	case arrayFrame:
+		var frame []Message
+		if err := json.Unmarshal(body, &frame); err != nil {
+			return frameType, nil, errors.Wrap(err, "can't unmarshal array frame")
+		}
+		return frameType, frame, nil
+	case closeFrame:
+		// Body is `[code, "reason"]`
+		return frameType, nil, errors.Errorf("socket closed by server: %s", body)
+	default:
+		return frameType, nil, errors.Errorf("unknown frame type %q", frameType)
+	}
+}
+
+// encodeRequest builds request frame: endpoint, ID, query and body separated by new lines
+func encodeRequest(endpoint string, id int64, query, body string) []byte {
+	var sb strings.Builder
+	sb.WriteString(endpoint)
+	sb.WriteString("\n")
+	sb.WriteString(strconv.FormatInt(id, 10))
+	sb.WriteString("\n")
+	sb.WriteString(query)
+	sb.WriteString("\n")
+	sb.WriteString(body)
+	return []byte(sb.String())
+}
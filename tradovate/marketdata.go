@@ -0,0 +1,403 @@
+package tradovate
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// Market data entities https://api.tradovate.com/#tag/MarketData
+
+type QuoteEntry struct {
+	Price float64 `json:"price"`
+	Size  float64 `json:"size"`
+}
+
+type Quote struct {
+	Id         int64                 `json:"id"`
+	ContractId int64                 `json:"contractId"`
+	Timestamp  time.Time             `json:"timestamp"`
+	Entries    map[string]QuoteEntry `json:"entries"` // Bid, Offer, Trade, TotalTradeVolume, ...
+}
+
+func (q Quote) entry(name string) (QuoteEntry, bool) {
+	e, ok := q.Entries[name]
+	return e, ok
+}
+
+func (q Quote) Bid() (QuoteEntry, bool) {
+	return q.entry("Bid")
+}
+
+func (q Quote) Offer() (QuoteEntry, bool) {
+	return q.entry("Offer")
+}
+
+// Trade is the last trade
+func (q Quote) Trade() (QuoteEntry, bool) {
+	return q.entry("Trade")
+}
+
+type DOMLevel struct {
+	Price float64 `json:"price"`
+	Size  float64 `json:"size"`
+}
+
+type DOM struct {
+	ContractId int64      `json:"contractId"`
+	Timestamp  time.Time  `json:"timestamp"`
+	Bids       []DOMLevel `json:"bids"`   // Best first
+	Offers     []DOMLevel `json:"offers"` // Best first
+}
+
+type marketDataPayload struct {
+	Quotes []Quote `json:"quotes"`
+	DOMs   []DOM   `json:"doms"`
+}
+
+// Should be one of DisconnectedWithErr, Reconnected, Quote, DOM or realtime chart update
+// In case of first connection no reconnection event should be sent
+type MarketDataEvent struct {
+	DisconnectedWithErr error
+	Reconnected         *struct{}
+	Quote               *Quote
+	DOM                 *DOM
+	Bars                []Bar  // Chart update
+	Ticks               []Tick // Chart update
+}
+
+var errMDDisconnected = errors.New("market data socket disconnected")
+
+// ErrMarketDataOverflow is sent to the subscriber which doesn't read events fast enough
+var ErrMarketDataOverflow = errors.New("market data subscriber is too slow, events are dropped")
+
+type mdKind int
+
+const (
+	quoteKind mdKind = iota
+	domKind
+)
+
+func (k mdKind) endpoints() (subscribe, unsubscribe string) {
+	if k == domKind {
+		return SUBSCRIBE_DOM, UNSUBSCRIBE_DOM
+	}
+	return SUBSCRIBE_QUOTE, UNSUBSCRIBE_QOUTE
+}
+
+type mdSubKey struct {
+	kind       mdKind
+	contractID int64
+}
+
+// mdWatcher guards the output channel from sends after closing
+type mdWatcher struct {
+	ctx    context.Context
+	out    chan MarketDataEvent
+	mu     sync.Mutex
+	closed bool
+}
+
+// send never blocks the socket reader: the watcher is finished by ErrMarketDataOverflow
+// if the channel is full. It returns true in this case.
+func (w *mdWatcher) send(ev MarketDataEvent) (overflow bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return false
+	}
+	// Only the watcher sends to the channel, so the last slot is always free for the disconnection
+	if len(w.out) < cap(w.out)-1 {
+		w.out <- ev
+		return false
+	}
+	w.closed = true
+	w.out <- MarketDataEvent{DisconnectedWithErr: ErrMarketDataOverflow}
+	close(w.out)
+	return true
+}
+
+// finish sends `last` if it isn't nil and closes the channel
+func (w *mdWatcher) finish(last *MarketDataEvent) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	w.closed = true
+	if last != nil {
+		select {
+		case w.out <- *last:
+		case <-w.ctx.Done():
+		}
+	}
+	close(w.out)
+}
+
+type mdSubscription struct {
+	symbol   string
+	watchers map[*mdWatcher]struct{}
+	last     *MarketDataEvent // Replayed to new watchers, the server sends snapshot only after subscription
+}
+
+// MarketDataClient shares one market data socket between all quote and DOM subscriptions.
+// The socket is reconnected on failure and active subscriptions are restored.
+type MarketDataClient struct {
+	SocketOptions
+
+	session *session
+	lg      *zap.Logger
+
+	subMu  sync.Mutex // Keeps order of subscription requests
+	mu     sync.Mutex
+	subs   map[mdSubKey]*mdSubscription
+	charts map[int]*chartStream // By historical and realtime IDs
+}
+
+// `host` is like "wss://md.tradovateapi.com/v1"
+func NewMarketDataClient(host string, tokens *TokenManager, lg *zap.Logger) *MarketDataClient {
+	c := &MarketDataClient{
+		SocketOptions: defaultSocketOptions(),
+		lg:            lg.Named("MarketData"),
+		subs:          map[mdSubKey]*mdSubscription{},
+		charts:        map[int]*chartStream{},
+	}
+	c.session = newSession(host+CONNECT_WEBSOCKET, tokens, &c.SocketOptions, c.lg)
+	c.session.authToken = mdAccessToken
+	c.session.onEvent = c.dispatch
+	c.session.onDisconnect = c.failCharts
+	c.session.active = c.active
+	c.session.restore = c.restore
+	c.session.onReconnected = func() {
+		for w, key := range c.watchers() {
+			c.send(key, w, MarketDataEvent{Reconnected: &struct{}{}})
+		}
+	}
+	c.session.onFailed = c.failAll
+	return c
+}
+
+func mdAccessToken(t Token) string {
+	if t.MarketDataAccessToken != "" {
+		return t.MarketDataAccessToken
+	}
+	return t.AccessToken
+}
+
+// Connect opens the socket, it is opened by the first subscription otherwise
+func (c *MarketDataClient) Connect(ctx context.Context) error {
+	_, err := c.session.connection(ctx)
+	return err
+}
+
+// SubscribeQuote returns quotes of the contract until `ctx` is done.
+// `symbol` is the contract name, e.g. "ESZ4".
+func (c *MarketDataClient) SubscribeQuote(ctx context.Context, symbol string, contractID int64) (<-chan MarketDataEvent, error) {
+	return c.subscribe(ctx, mdSubKey{kind: quoteKind, contractID: contractID}, symbol)
+}
+
+// SubscribeDOM returns depth of market of the contract until `ctx` is done
+func (c *MarketDataClient) SubscribeDOM(ctx context.Context, symbol string, contractID int64) (<-chan MarketDataEvent, error) {
+	return c.subscribe(ctx, mdSubKey{kind: domKind, contractID: contractID}, symbol)
+}
+
+func (c *MarketDataClient) subscribe(ctx context.Context, key mdSubKey, symbol string) (<-chan MarketDataEvent, error) {
+	conn, err := c.session.connection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &mdWatcher{ctx: ctx, out: make(chan MarketDataEvent, 100)} // TODO: move to config
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+
+	c.mu.Lock()
+	sub, ok := c.subs[key]
+	if !ok {
+		sub = &mdSubscription{symbol: symbol, watchers: map[*mdWatcher]struct{}{}}
+		c.subs[key] = sub
+	}
+	sub.watchers[w] = struct{}{}
+	if sub.last != nil {
+		w.out <- *sub.last // Channel is empty yet
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		subscribe, _ := key.kind.endpoints()
+		if _, err := conn.request(ctx, subscribe, "", symbolBody(symbol)); err != nil {
+			c.mu.Lock()
+			delete(sub.watchers, w)
+			if c.subs[key] == sub && len(sub.watchers) == 0 {
+				delete(c.subs, key)
+			}
+			c.mu.Unlock()
+			return nil, errors.Wrapf(err, "can't subscribe to %s", symbol)
+		}
+	}
+
+	go func() {
+		<-ctx.Done()
+		c.unsubscribe(key, w)
+	}()
+	return w.out, nil
+}
+
+func (c *MarketDataClient) unsubscribe(key mdSubKey, w *mdWatcher) {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+
+	c.mu.Lock()
+	sub, ok := c.subs[key]
+	last := false
+	if ok {
+		delete(sub.watchers, w)
+		if len(sub.watchers) == 0 {
+			delete(c.subs, key)
+			last = true
+		}
+	}
+	c.mu.Unlock()
+	w.finish(nil)
+
+	if conn := c.session.current(); last && conn != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), c.RequestTimeout)
+		defer cancel()
+		_, unsubscribe := key.kind.endpoints()
+		if _, err := conn.request(ctx, unsubscribe, "", symbolBody(sub.symbol)); err != nil {
+			c.lg.Warn("Can't unsubscribe", zap.String("symbol", sub.symbol), zap.Error(err))
+		}
+	}
+}
+
+func symbolBody(symbol string) string {
+	b, _ := json.Marshal(map[string]string{"symbol": symbol})
+	return string(b)
+}
+
+func (c *MarketDataClient) dispatch(m Message) {
+	switch m.EventType {
+	case "md":
+		c.dispatchQuotes(m)
+	case "chart":
+		c.dispatchChart(m)
+	}
+}
+
+func (c *MarketDataClient) dispatchQuotes(m Message) {
+	payload := marketDataPayload{}
+	if err := json.Unmarshal(m.Data, &payload); err != nil {
+		c.lg.Warn("Can't unmarshal market data", zap.ByteString("data", m.Data), zap.Error(err))
+		return
+	}
+	for i := range payload.Quotes {
+		q := payload.Quotes[i]
+		c.publish(mdSubKey{kind: quoteKind, contractID: q.ContractId}, MarketDataEvent{Quote: &q})
+	}
+	for i := range payload.DOMs {
+		dom := payload.DOMs[i]
+		c.publish(mdSubKey{kind: domKind, contractID: dom.ContractId}, MarketDataEvent{DOM: &dom})
+	}
+}
+
+func (c *MarketDataClient) watchers() map[*mdWatcher]mdSubKey {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	res := map[*mdWatcher]mdSubKey{}
+	for key, sub := range c.subs {
+		for w := range sub.watchers {
+			res[w] = key
+		}
+	}
+	return res
+}
+
+// send drops the watcher if it doesn't keep up with the socket
+func (c *MarketDataClient) send(key mdSubKey, w *mdWatcher, ev MarketDataEvent) {
+	if w.send(ev) {
+		c.lg.Warn("Subscriber is too slow, dropping it", zap.Int64("contractID", key.contractID))
+		go c.unsubscribe(key, w) // Takes subMu, which can be held by slow subscribe
+	}
+}
+
+func (c *MarketDataClient) publish(key mdSubKey, ev MarketDataEvent) {
+	c.mu.Lock()
+	sub, ok := c.subs[key]
+	if !ok {
+		c.mu.Unlock()
+		return
+	}
+	sub.last = &ev
+	watchers := make([]*mdWatcher, 0, len(sub.watchers))
+	for w := range sub.watchers {
+		watchers = append(watchers, w)
+	}
+	c.mu.Unlock()
+
+	for _, w := range watchers {
+		c.send(key, w, ev)
+	}
+}
+
+// active reports whether the socket should be reconnected
+func (c *MarketDataClient) active() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.subs) > 0
+}
+
+// restore subscribes again to all subscriptions
+func (c *MarketDataClient) restore(ctx context.Context, conn *socket) error {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+
+	c.mu.Lock()
+	subs := make(map[mdSubKey]string, len(c.subs))
+	for key, sub := range c.subs {
+		subs[key] = sub.symbol
+	}
+	c.mu.Unlock()
+
+	for key, symbol := range subs {
+		subscribe, _ := key.kind.endpoints()
+		if _, err := conn.request(ctx, subscribe, "", symbolBody(symbol)); err != nil {
+			return errors.Wrapf(err, "can't subscribe to %s", symbol)
+		}
+	}
+	return nil
+}
+
+func (c *MarketDataClient) failAll(err error) {
+	c.mu.Lock()
+	subs := c.subs
+	c.subs = map[mdSubKey]*mdSubscription{}
+	c.mu.Unlock()
+
+	for _, sub := range subs {
+		for w := range sub.watchers {
+			w.finish(&MarketDataEvent{DisconnectedWithErr: err})
+		}
+	}
+}
+
+// Close closes the socket and all subscriptions
+func (c *MarketDataClient) Close() error {
+	err := c.session.close()
+
+	c.mu.Lock()
+	subs := c.subs
+	c.subs = map[mdSubKey]*mdSubscription{}
+	c.mu.Unlock()
+
+	for _, sub := range subs {
+		for w := range sub.watchers {
+			w.finish(nil)
+		}
+	}
+	c.failCharts(nil, errors.New("market data client is closed"))
+	return err
+}
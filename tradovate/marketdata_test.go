@@ -0,0 +1,128 @@
+package tradovate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestTradovateContractQuoteAndDepth(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeTradovate(t)
+	tc := NewTradovateContract(fake.client(), zap.NewNop())
+	defer tc.getMarketData().Close()
+
+	price, err := tc.GetPrice(ctx, "TRADOVATE-ESZ4")
+	require.NoError(t, err)
+	assert.Equal(t, "5000.25", price.String())
+
+	bid, ask, err := tc.GetBestBidAsk(ctx, "TRADOVATE-ESZ4")
+	require.NoError(t, err)
+	assert.Equal(t, "5000.25", bid.String())
+	assert.Equal(t, "5000.25", ask.String())
+
+	dom, err := tc.GetDepth(ctx, "TRADOVATE-ESZ4")
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), dom.ContractId)
+	require.Len(t, dom.Bids, 1)
+	assert.Equal(t, 3.0, dom.Bids[0].Size)
+	require.Len(t, dom.Offers, 1)
+	assert.Equal(t, 4.0, dom.Offers[0].Size)
+
+	// Market data token is used and subscriptions are released
+	assert.Equal(t, 1, fake.wsRequestCount(AUTHORIZE+" md-"+fakeToken))
+	assert.Eventually(t, func() bool {
+		// The second quote request can share the first subscription
+		return fake.wsRequestCount(UNSUBSCRIBE_QOUTE) == fake.wsRequestCount(SUBSCRIBE_QUOTE) &&
+			fake.wsRequestCount(UNSUBSCRIBE_DOM) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestTradovateContractWatchSymbolPriceReconnects(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fake := newFakeTradovate(t)
+	tc := NewTradovateContract(fake.client(), zap.NewNop())
+	md := tc.getMarketData()
+	md.ReconnectDelay = 10 * time.Millisecond
+	defer md.Close()
+
+	events, err := tc.WatchSymbolPrice(ctx, "TRADOVATE-ESZ4")
+	require.NoError(t, err)
+
+	next := func() string {
+		select {
+		case ev, ok := <-events:
+			require.True(t, ok, "channel is closed")
+			return ev.String()
+		case <-time.After(5 * time.Second):
+			t.Fatal("no price event")
+			return ""
+		}
+	}
+	first := next()
+	assert.Contains(t, first, "5000.25")
+
+	fake.wsMu.Lock()
+	fake.lastPrice = 5001.5
+	fake.wsMu.Unlock()
+	fake.dropWS()
+
+	// Subscription is restored on the new socket
+	// The new quote can come before the notification
+	after := []string{next(), next()}
+	assert.Contains(t, after, "{Reconnected}")
+	assert.Contains(t, after, "{Payload = 5001.5}")
+	assert.Equal(t, 2, fake.wsRequestCount(SUBSCRIBE_QUOTE))
+	assert.Equal(t, 2, fake.wsRequestCount(AUTHORIZE))
+}
+
+func TestMarketDataClientDropsSlowSubscriber(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fake := newFakeTradovate(t)
+	md := NewTradovateContract(fake.client(), zap.NewNop()).getMarketData()
+	defer md.Close()
+
+	slow, err := md.SubscribeQuote(ctx, "ESZ4", 10)
+	require.NoError(t, err)
+	fast, err := md.SubscribeQuote(ctx, "ESZ4", 10)
+	require.NoError(t, err)
+
+	next := func() MarketDataEvent {
+		select {
+		case ev, ok := <-fast:
+			require.True(t, ok, "channel is closed")
+			return ev
+		case <-time.After(5 * time.Second):
+			t.Fatal("no quote")
+			return MarketDataEvent{}
+		}
+	}
+	next() // Snapshot
+	for batch := 0; batch < 3; batch++ {
+		for i := 0; i < 50; i++ {
+			fake.pushQuote(10, float64(batch*50+i))
+		}
+		for i := 0; i < 50; i++ {
+			ev := next()
+			require.NotNil(t, ev.Quote)
+			trade, _ := ev.Quote.Trade()
+			assert.Equal(t, float64(batch*50+i), trade.Price)
+		}
+	}
+
+	// The slow subscriber is dropped with the error instead of blocking the socket
+	var last MarketDataEvent
+	n := 0
+	for ev := range slow {
+		last = ev
+		n++
+	}
+	assert.Equal(t, 100, n)
+	assert.ErrorIs(t, last.DisconnectedWithErr, ErrMarketDataOverflow)
+}
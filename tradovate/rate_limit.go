@@ -12,3 +12,13 @@ type RateLimitError struct {
 func (e *RateLimitError) Error() string {
 	return "rate limit exceeded"
 }
+
+// CaptchaRequiredError means the access token can't be received without solving a captcha,
+// e.g. by logging in to Tradovate web application. Retrying doesn't help.
+type CaptchaRequiredError struct {
+	PenaltyTicket string
+}
+
+func (e *CaptchaRequiredError) Error() string {
+	return "captcha is required to get access token"
+}
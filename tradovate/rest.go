@@ -0,0 +1,115 @@
+package tradovate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// APIError is returned for not successful HTTP responses and
+// for responses with `errorText` or `failureReason`
+type APIError struct {
+	StatusCode int
+	Text       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("tradovate API error (HTTP %d): %s", e.StatusCode, e.Text)
+}
+
+func IsNotFoundError(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+type failureResponse struct {
+	ErrorText     string `json:"errorText"`
+	FailureReason string `json:"failureReason"`
+	FailureText   string `json:"failureText"`
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	t, err := c.Tokens().Token(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "can't get access token")
+	}
+	return t.AccessToken, nil
+}
+
+// Do sends REST request to `endpoint` (e.g. "order/list") and decodes the response into `out`.
+// `body` is sent as JSON if it isn't nil, `out` can be nil.
+func (c *Client) Do(ctx context.Context, method, endpoint string, query url.Values, body, out interface{}) error {
+	token, err := c.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	u := c.HttpHost + "/" + strings.TrimPrefix(endpoint, "/")
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return errors.Wrap(err, "can't marshal request")
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reqBody)
+	if err != nil {
+		return errors.Wrap(err, "can't create request")
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "can't send %s request", endpoint)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrapf(err, "can't read %s response", endpoint)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &APIError{StatusCode: resp.StatusCode, Text: strings.TrimSpace(string(respBody))}
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(respBody), []byte("{")) {
+		failure := failureResponse{}
+		if json.Unmarshal(respBody, &failure) == nil {
+			switch {
+			case failure.ErrorText != "":
+				return &APIError{StatusCode: resp.StatusCode, Text: failure.ErrorText}
+			case failure.FailureReason != "" && failure.FailureReason != "Success":
+				return &APIError{StatusCode: resp.StatusCode, Text: failure.FailureReason + ": " + failure.FailureText}
+			}
+		}
+	}
+
+	if out == nil {
+		return nil
+	}
+	return errors.Wrapf(json.Unmarshal(respBody, out), "can't unmarshal %s response", endpoint)
+}
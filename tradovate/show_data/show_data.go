@@ -234,20 +234,17 @@ func (m *Tradovate) FollowWSPrice() {
 		log.Fatalf("Error Connect: %v", err)
 	}
 
-	go m.tickListener()
-
-	for _, m := range m.tradovateClient.RequestPool {
-		for msg := range m {
-			log.Printf("%v", msg)
-		}
-	}
-
+	m.tickListener()
 }
 
 func (m *Tradovate) tickListener() {
-	log.Println("[tickListener] sleep 20 seconds....")
-	time.Sleep(20 * time.Second)
 	log.Println("[tickListener] started...")
 
-	m.tradovateClient.GetHistoricalTickData("MNQZ3", time.Now().Add(-1*time.Hour), time.Now().Add(1*time.Hour))
+	chart, err := m.tradovateClient.GetHistoricalTickData("MNQZ3", time.Now().Add(-1*time.Hour), time.Now().Add(1*time.Hour))
+	if err != nil {
+		log.Fatalf("Error GetHistoricalTickData: %v", err)
+	}
+	for _, tick := range chart.Ticks {
+		log.Printf("%v", tick)
+	}
 }
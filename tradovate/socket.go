@@ -0,0 +1,447 @@
+package tradovate
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+)
+
+// SocketOptions are shared by Tradovate WebSocket clients
+type SocketOptions struct {
+	Dialer            *websocket.Dialer // optional
+	HeartbeatInterval time.Duration     // optional
+	ReadTimeout       time.Duration     // optional, server sends heartbeats every 2.5 seconds
+	RequestTimeout    time.Duration     // optional
+	ReconnectDelay    time.Duration     // optional, doubled with every try
+	MaxReconnectTries int               // optional, 0 means infinite
+}
+
+func defaultSocketOptions() SocketOptions {
+	return SocketOptions{
+		Dialer:            websocket.DefaultDialer,
+		HeartbeatInterval: 2500 * time.Millisecond,
+		ReadTimeout:       10 * time.Second,
+		RequestTimeout:    10 * time.Second,
+		ReconnectDelay:    time.Second,
+	}
+}
+
+var errSocketDisconnected = errors.New("socket disconnected")
+
+// socket is one open connection. Responses are matched with requests by ID,
+// all frames are written by one writer goroutine.
+type socket struct {
+	ws     *websocket.Conn
+	opts   *SocketOptions
+	lg     *zap.Logger
+	lastID atomic.Int64
+
+	writes chan outgoingFrame
+	done   chan struct{} // Closed when the reading is finished
+
+	mu      sync.Mutex
+	pending map[int64]*pendingRequest
+	stopped bool
+}
+
+type pendingRequest struct {
+	ch         chan Message
+	onResponse func(Message) // optional, called by the reader before next messages
+}
+
+type outgoingFrame struct {
+	msg []byte
+	res chan error
+}
+
+func newSocket(ws *websocket.Conn, opts *SocketOptions, lg *zap.Logger) *socket {
+	s := &socket{
+		ws:      ws,
+		opts:    opts,
+		lg:      lg,
+		writes:  make(chan outgoingFrame),
+		done:    make(chan struct{}),
+		pending: map[int64]*pendingRequest{},
+	}
+	go s.writer()
+	return s
+}
+
+// writer sends requests and heartbeats until the reading is finished
+func (s *socket) writer() {
+	ticker := time.NewTicker(s.opts.HeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case f := <-s.writes:
+			_ = s.ws.SetWriteDeadline(time.Now().Add(s.opts.RequestTimeout))
+			f.res <- s.ws.WriteMessage(websocket.TextMessage, f.msg)
+		case <-ticker.C:
+			_ = s.ws.SetWriteDeadline(time.Now().Add(s.opts.RequestTimeout))
+			if err := s.ws.WriteMessage(websocket.TextMessage, heartbeatMessage); err != nil {
+				s.lg.Warn("Can't send heartbeat", zap.Error(err)) // Reader fails by timeout
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *socket) write(msg []byte) error {
+	f := outgoingFrame{msg: msg, res: make(chan error, 1)}
+	select {
+	case s.writes <- f:
+	case <-s.done:
+		return errSocketDisconnected
+	}
+	return <-f.res
+}
+
+// send writes the request without waiting for the response
+func (s *socket) send(endpoint, query, body string) (int64, error) {
+	id := s.lastID.Inc()
+	if err := s.write(encodeRequest(endpoint, id, query, body)); err != nil {
+		return id, errors.Wrapf(err, "can't send %s", endpoint)
+	}
+	return id, nil
+}
+
+// request waits for the response, status other than 200 is APIError.
+// RequestTimeout is used if `ctx` has no deadline.
+func (s *socket) request(ctx context.Context, endpoint, query, body string) (Message, error) {
+	return s.requestWith(ctx, endpoint, query, body, nil)
+}
+
+// requestWith calls `onResponse` with successful response before events following it are read
+func (s *socket) requestWith(
+	ctx context.Context, endpoint, query, body string, onResponse func(Message),
+) (Message, error) {
+	id := s.lastID.Inc()
+	ch := make(chan Message, 1)
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return Message{}, errSocketDisconnected
+	}
+	s.pending[id] = &pendingRequest{ch: ch, onResponse: onResponse}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, id)
+		s.mu.Unlock()
+	}()
+
+	if err := s.write(encodeRequest(endpoint, id, query, body)); err != nil {
+		return Message{}, errors.Wrapf(err, "can't send %s", endpoint)
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.opts.RequestTimeout)
+		defer cancel()
+	}
+	select {
+	case m, ok := <-ch:
+		if !ok {
+			return Message{}, errSocketDisconnected
+		}
+		if m.Status != 200 {
+			return m, &APIError{StatusCode: m.Status, Text: string(m.Data)}
+		}
+		return m, nil
+	case <-ctx.Done():
+		return Message{}, errors.Wrapf(ctx.Err(), "no response to %s", endpoint)
+	}
+}
+
+// listen reads until failure, pending requests are closed after it
+func (s *socket) listen(onEvent func(Message)) (err error) {
+	defer func() {
+		s.mu.Lock()
+		s.stopped = true
+		for id, req := range s.pending {
+			close(req.ch)
+			delete(s.pending, id)
+		}
+		s.mu.Unlock()
+		close(s.done)
+	}()
+
+	for {
+		_ = s.ws.SetReadDeadline(time.Now().Add(s.opts.ReadTimeout))
+		var msg []byte
+		if _, msg, err = s.ws.ReadMessage(); err != nil {
+			return err
+		}
+		var frameType byte
+		var frame []Message
+		if frameType, frame, err = decodeFrame(msg); err != nil {
+			return err
+		}
+		if frameType != arrayFrame {
+			continue
+		}
+		for _, m := range frame {
+			if m.EventType != "" {
+				onEvent(m)
+				continue
+			}
+			s.mu.Lock()
+			req, ok := s.pending[m.Id]
+			delete(s.pending, m.Id)
+			s.mu.Unlock()
+			if !ok {
+				continue
+			}
+			if req.onResponse != nil && m.Status == 200 {
+				req.onResponse(m)
+			}
+			req.ch <- m
+		}
+	}
+}
+
+// session keeps an authorized socket. It is reconnected after failure while `active` returns true,
+// `restore` subscribes again on the new socket.
+type session struct {
+	url       string
+	tokens    *TokenManager
+	authToken func(Token) string
+	opts      *SocketOptions
+	lg        *zap.Logger
+
+	onEvent       func(Message)
+	onDisconnect  func(*socket, error) // Called after every disconnection
+	active        func() bool
+	restore       func(context.Context, *socket) error
+	onReconnected func()
+	onFailed      func(error)
+
+	connectMu    sync.Mutex // Only one connection at a time
+	mu           sync.Mutex
+	conn         *socket
+	closed       bool
+	reconnecting bool
+
+	unregisterRenew func()
+}
+
+func newSession(url string, tokens *TokenManager, opts *SocketOptions, lg *zap.Logger) *session {
+	s := &session{
+		url:           url,
+		tokens:        tokens,
+		authToken:     func(t Token) string { return t.AccessToken },
+		opts:          opts,
+		lg:            lg,
+		onEvent:       func(Message) {},
+		onDisconnect:  func(*socket, error) {},
+		active:        func() bool { return false },
+		restore:       func(context.Context, *socket) error { return nil },
+		onReconnected: func() {},
+		onFailed:      func(error) {},
+	}
+	s.unregisterRenew = tokens.OnRenew(s.reauthorize)
+	return s
+}
+
+// reauthorize authorizes the open socket with renewed token, subscriptions are kept
+func (s *session) reauthorize(t Token) {
+	conn := s.current()
+	if conn == nil {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), s.opts.RequestTimeout)
+		defer cancel()
+		if _, err := conn.request(ctx, AUTHORIZE, "", s.authToken(t)); err != nil {
+			s.lg.Warn("Can't authorize socket with renewed token", zap.Error(err))
+		}
+	}()
+}
+
+func (s *session) current() *socket {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conn
+}
+
+// connection returns open socket, it is connected if needed
+func (s *session) connection(ctx context.Context) (*socket, error) {
+	s.connectMu.Lock()
+	defer s.connectMu.Unlock()
+
+	s.mu.Lock()
+	conn, closed := s.conn, s.closed
+	s.mu.Unlock()
+	if closed {
+		return nil, errors.New("socket client is closed")
+	}
+	if conn != nil {
+		return conn, nil
+	}
+	return s.connect(ctx)
+}
+
+func (s *session) connect(ctx context.Context) (*socket, error) {
+	token, err := s.tokens.Token(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't get access token")
+	}
+
+	ws, _, err := s.opts.Dialer.DialContext(ctx, s.url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't connect to socket")
+	}
+
+	// Server opens the session by `o` frame
+	_ = ws.SetReadDeadline(time.Now().Add(s.opts.ReadTimeout))
+	_, msg, err := ws.ReadMessage()
+	if err == nil {
+		var frameType byte
+		frameType, _, err = decodeFrame(msg)
+		if err == nil && frameType != openFrame {
+			err = errors.Errorf("unexpected frame %q instead of open frame", frameType)
+		}
+	}
+	if err != nil {
+		_ = ws.Close()
+		return nil, errors.Wrap(err, "socket wasn't opened")
+	}
+
+	conn := newSocket(ws, s.opts, s.lg)
+	s.mu.Lock()
+	s.conn = conn
+	s.mu.Unlock()
+	go func() {
+		err := conn.listen(s.onEvent)
+		s.disconnected(conn, err)
+	}()
+
+	if _, err := conn.request(ctx, AUTHORIZE, "", s.authToken(token)); err != nil {
+		s.drop(conn)
+		return nil, errors.Wrap(err, "socket wasn't authorized")
+	}
+	return conn, nil
+}
+
+// drop closes the connection, the reader reconnects if the session is active
+func (s *session) drop(conn *socket) {
+	s.mu.Lock()
+	if s.conn == conn {
+		s.conn = nil
+	}
+	s.mu.Unlock()
+	_ = conn.ws.Close()
+}
+
+func (s *session) disconnected(conn *socket, err error) {
+	_ = conn.ws.Close()
+	s.onDisconnect(conn, err)
+	active := s.active()
+
+	s.mu.Lock()
+	if s.conn == conn {
+		s.conn = nil
+	}
+	reconnect := active && !s.closed && !s.reconnecting
+	if reconnect {
+		s.reconnecting = true
+	}
+	s.mu.Unlock()
+
+	if reconnect {
+		s.lg.Warn("Socket disconnected", zap.Error(err))
+		go s.reconnect(err)
+	}
+}
+
+func (s *session) reconnect(cause error) {
+	delay := s.opts.ReconnectDelay
+	for try := 1; ; try++ {
+		time.Sleep(delay)
+		if delay *= 2; delay > 30*time.Second {
+			delay = 30 * time.Second
+		}
+
+		s.mu.Lock()
+		closed := s.closed
+		if closed {
+			s.reconnecting = false
+		}
+		s.mu.Unlock()
+		if closed {
+			return
+		}
+
+		err := s.reopen()
+		if err == nil {
+			s.lg.Info("Socket reconnected", zap.Int("try", try))
+			done := s.finishReconnect()
+			s.onReconnected()
+			if done {
+				return
+			}
+			s.lg.Warn("Socket disconnected right after reconnection")
+			try, delay = 0, s.opts.ReconnectDelay
+			continue
+		}
+
+		s.lg.Warn("Can't reconnect socket", zap.Int("try", try), zap.Error(err))
+		if s.opts.MaxReconnectTries > 0 && try >= s.opts.MaxReconnectTries {
+			s.mu.Lock()
+			s.reconnecting = false
+			s.mu.Unlock()
+			s.onFailed(errors.Wrapf(err, "can't reconnect after %v", cause))
+			return
+		}
+	}
+}
+
+// finishReconnect clears the reconnection flag. It returns false if the new socket
+// is lost already: its reader has skipped the reconnection while the flag was set.
+func (s *session) finishReconnect() bool {
+	active := s.active()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil && active && !s.closed {
+		return false
+	}
+	s.reconnecting = false
+	return true
+}
+
+func (s *session) reopen() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.opts.RequestTimeout)
+	defer cancel()
+
+	conn, err := s.connection(ctx)
+	if err != nil {
+		return err
+	}
+	if err := s.restore(ctx, conn); err != nil {
+		s.drop(conn)
+		return err
+	}
+	return nil
+}
+
+func (s *session) close() error {
+	s.unregisterRenew()
+
+	s.mu.Lock()
+	s.closed = true
+	conn := s.conn
+	s.conn = nil
+	s.mu.Unlock()
+
+	if conn != nil {
+		return conn.ws.Close()
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package tradovate
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestClientConcurrentRequests(t *testing.T) {
+	fake := newFakeTradovate(t)
+	fake.contracts = append(fake.contracts, Contract{Id: 11, Name: "NQZ4"})
+	client := fake.client()
+	defer client.CloseWebsocket()
+	require.NoError(t, client.ConnectWebsocket())
+
+	// Every caller gets the response to its own request
+	var wg sync.WaitGroup
+	errs := make(chan error, 50)
+	for i := 0; i < 50; i++ {
+		name, id := "ESZ4", int64(10)
+		if i%2 == 1 {
+			name, id = "NQZ4", 11
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c, err := client.FindContract(name)
+			if err == nil && c.Id != id {
+				err = errors.Errorf("%s has ID %d", name, c.Id)
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	_, err := client.FindContract("UNKNOWN")
+	assert.Error(t, err)
+}
+
+func TestAsyncClientSyncAfterReconnection(t *testing.T) {
+	fake := newFakeTradovate(t)
+	fc := fake.client()
+	opts := defaultSocketOptions()
+	opts.ReconnectDelay = 10 * time.Millisecond
+	events := make(chan UserSyncEvent, 10)
+	client := &AsyncClient{
+		HttpHost:       fc.HttpHost,
+		WebsocketHost:  fc.WebsocketHost,
+		MarketDataHost: fc.MarketDataHost,
+		Username:       fc.Username,
+		Password:       fc.Password,
+		SocketOptions:  &opts,
+		OnUserEvent:    func(ev UserSyncEvent) { events <- ev },
+	}
+	defer client.Close()
+	require.NoError(t, client.Connect())
+
+	_, err := client.SendSyncRequest()
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return fake.wsRequestCount(SYNC_USER) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	fake.dropWS()
+	assert.Eventually(t, func() bool {
+		return fake.wsRequestCount(SYNC_USER) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	fake.pushProps("order", "Created", Order{Id: 201, AccountId: 1, ContractId: 10, OrdStatus: "Working"})
+	select {
+	case ev := <-events:
+		require.NotNil(t, ev.Order)
+		assert.Equal(t, int64(201), ev.Order.Id)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no user event")
+	}
+}
+
+func TestSessionReconnectsSocketLostRightAfterRestore(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fake := newFakeTradovate(t)
+	md := NewTradovateContract(fake.client(), zap.NewNop()).getMarketData()
+	md.ReconnectDelay = 10 * time.Millisecond
+	defer md.Close()
+
+	// The new socket dies while the first reconnection isn't finished yet
+	restore := md.session.restore
+	var once sync.Once
+	md.session.restore = func(ctx context.Context, conn *socket) error {
+		if err := restore(ctx, conn); err != nil {
+			return err
+		}
+		once.Do(func() {
+			fake.dropWS()
+			assert.Eventually(t, func() bool {
+				return md.session.current() == nil
+			}, time.Second, time.Millisecond)
+		})
+		return nil
+	}
+
+	events, err := md.SubscribeQuote(ctx, "ESZ4", 10)
+	require.NoError(t, err)
+	fake.dropWS()
+
+	assert.Eventually(t, func() bool {
+		return fake.wsRequestCount(SUBSCRIBE_QUOTE) == 3 && md.session.current() != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	fake.pushQuote(10, 5002)
+	for {
+		select {
+		case ev := <-events:
+			if ev.Quote == nil {
+				continue
+			}
+			if trade, _ := ev.Quote.Trade(); trade.Price == 5002 {
+				return
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("no quote after reconnection")
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package tradovate
+
+import (
+	"regexp"
+	"strconv"
+
+	exchanges "github.com/aulaleslie/trade-exchanges"
+)
+
+const TRADOVATE_PREFIX = "TRADOVATE-"
+
+func ToTradovateFullSymbol(symbol string) string {
+	return exchanges.FullSymbol(TRADOVATE_PREFIX, symbol)
+}
+
+func ToTradovateSymbol(symbol string) string {
+	return exchanges.NativeSymbol(TRADOVATE_PREFIX, symbol)
+}
+
+// Contract name is the product root with the CME month code and the year, e.g. "ESZ4" or "MNQH25"
+var contractNameRE = regexp.MustCompile(`^([A-Z0-9]+?)([FGHJKMNQUVXZ])(\d{1,2})$`)
+
+// Continuous symbol is the product root with the number of the maturity,
+// e.g. "ES!1" is the front month and "ES!2" is the next one
+var continuousRE = regexp.MustCompile(`^([A-Z0-9]+)!([1-9][0-9]*)$`)
+
+// parseContinuous returns "ES" and 1 for "ES!1"
+func parseContinuous(name string) (product string, n int, ok bool) {
+	m := continuousRE.FindStringSubmatch(name)
+	if m == nil {
+		return "", 0, false
+	}
+	n, err := strconv.Atoi(m[2])
+	if err != nil {
+		return "", 0, false
+	}
+	return m[1], n, true
+}
+
+// productOfContract returns "ES" for "ESZ4" and "ES!1" or the name itself if it isn't a dated contract
+func productOfContract(name string) string {
+	if product, _, ok := parseContinuous(name); ok {
+		return product
+	}
+	m := contractNameRE.FindStringSubmatch(name)
+	if m == nil {
+		return name
+	}
+	return m[1]
+}
+
+func contractInstrument(name string) exchanges.Instrument {
+	return exchanges.Instrument{
+		Product: exchanges.FutureProduct,
+		Base:    productOfContract(name),
+		Quote:   "USD",
+	}
+}
@@ -1,7 +1,7 @@
 package tradovate
 
 import (
-	"encoding/json"
+	"context"
 	"time"
 )
 
@@ -11,6 +11,13 @@ type ChartRequest struct {
 	TimeRange        TimeRange        `json:"timeRange"`
 }
 
+// Underlying types of ChartDescription
+const (
+	TickChart      = "Tick"
+	MinuteBarChart = "MinuteBar"
+	DailyBarChart  = "DailyBar"
+)
+
 type ChartDescription struct {
 	// Tick, DailyBar, MinuteBar, Custom, DOM
 	UnderlyingType string `json:"underlyingType,omitempty"`
@@ -21,10 +28,10 @@ type ChartDescription struct {
 }
 
 type TimeRange struct {
-	ClosestTimestamp Time `json:"closestTimestamp,omitempty"`
-	ClosestTickId    int  `json:"closestTickId,omitempty"`
-	AsFarAsTimestamp Time `json:"asFarAsTimestamp,omitempty"`
-	AsMuchAsElement  int  `json:"asMuchAsElement,omitempty"`
+	ClosestTimestamp *Time `json:"closestTimestamp,omitempty"`
+	ClosestTickId    int   `json:"closestTickId,omitempty"`
+	AsFarAsTimestamp *Time `json:"asFarAsTimestamp,omitempty"`
+	AsMuchAsElements int   `json:"asMuchAsElements,omitempty"`
 }
 
 type Subscription struct {
@@ -33,89 +40,97 @@ type Subscription struct {
 	RealtimeId   int    `json:"realtimeId"`
 }
 
+// Chart is the history of the subscription ordered by time
 type Chart struct {
+	Subscription
+	Bars  []Bar
+	Ticks []Tick
+}
+
+type Bar struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Open        float64   `json:"open"`
+	High        float64   `json:"high"`
+	Low         float64   `json:"low"`
+	Close       float64   `json:"close"`
+	UpVolume    float64   `json:"upVolume"`
+	DownVolume  float64   `json:"downVolume"`
+	UpTicks     float64   `json:"upTicks"`
+	DownTicks   float64   `json:"downTicks"`
+	BidVolume   float64   `json:"bidVolume"`
+	OfferVolume float64   `json:"offerVolume"`
 }
 
 type Tick struct {
-	SubscriptionId string    `json:"id"`
-	Source         string    `json:"source"`
+	Id             int64     `json:"id"`
+	SubscriptionId int       `json:"subscriptionId"`
+	Source         string    `json:"source"` // Source of the packet, e.g. "db" or "realtime"
 	Time           time.Time `json:"time"`
 	Price          float64   `json:"price"`
-	Bid            float64   `json:"bid"`
-	Ask            float64   `json:"ask"`
-	AskSize        int       `json:"ask_size"`
-	BidSize        int       `json:"bid_size"`
+	Size           float64   `json:"size"`
+	Bid            float64   `json:"bid,omitempty"`
+	Ask            float64   `json:"ask,omitempty"`
+	BidSize        float64   `json:"bid_size,omitempty"`
+	AskSize        float64   `json:"ask_size,omitempty"`
 }
 
-func (c *Client) GetHistoricalTickData(symbol string, start time.Time, end time.Time) (Subscription, error) {
-	chartReq := ChartRequest{
+func chartRequest(symbol, chartType string, start time.Time, end *time.Time) ChartRequest {
+	req := ChartRequest{
 		Symbol: symbol,
 		ChartDescription: ChartDescription{
-			UnderlyingType:  "Tick",
+			UnderlyingType:  chartType,
 			ElementSize:     1,
 			ElementSizeUnit: "UnderlyingUnits",
 			WithHistogram:   false,
 		},
 		TimeRange: TimeRange{
-			ClosestTimestamp: Time{end},
-			AsFarAsTimestamp: Time{start},
+			AsFarAsTimestamp: &Time{start},
 		},
 	}
-
-	b, err := json.Marshal(chartReq)
-
-	msg, err := c.Send(GET_CHART, "", string(b), 10)
-
-	if msg.Status != 200 {
-
+	if end != nil {
+		req.TimeRange.ClosestTimestamp = &Time{*end}
 	}
-
-	var subscription Subscription
-	err = json.Unmarshal(msg.Data, &subscription)
-
-	return subscription, err
+	return req
 }
 
-func (c *Client) CancelHistoricalTickData(id int) error {
-	cancelChartReq := struct {
-		SubscriptionId int `json:"subscriptionId"`
-	}{
-		SubscriptionId: id,
-	}
-
-	b, err := json.Marshal(cancelChartReq)
-
-	if err != nil {
-
+// MarketDataSocket returns the socket used for charts, it is connected by the first request
+func (c *Client) MarketDataSocket() *MarketDataClient {
+	tokens := c.Tokens()
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	if c.marketData == nil {
+		c.marketData = NewMarketDataClient(c.MarketDataHost, tokens, c.logger())
+		if c.SocketOptions != nil {
+			c.marketData.SocketOptions = *c.SocketOptions
+		}
 	}
+	return c.marketData
+}
 
-	msg, err := c.Send(CANCEL_CHART, "", string(b), 10)
+// GetChart returns bars or ticks of the contract between `start` and `end`.
+// `chartType` is TickChart, MinuteBarChart or DailyBarChart.
+func (c *Client) GetChart(ctx context.Context, symbol, chartType string, start, end time.Time) (Chart, error) {
+	chart, _, err := c.MarketDataSocket().GetChart(ctx, chartRequest(symbol, chartType, start, &end), false)
+	return chart, err
+}
 
-	if msg.Status != 200 {
+// SubscribeChart returns the history since `start` and sends realtime updates until `ctx` is done
+// or the chart is canceled by CancelHistoricalTickData
+func (c *Client) SubscribeChart(
+	ctx context.Context, symbol, chartType string, start time.Time,
+) (Chart, <-chan MarketDataEvent, error) {
+	return c.MarketDataSocket().GetChart(ctx, chartRequest(symbol, chartType, start, nil), true)
+}
 
-	}
+func (c *Client) GetHistoricalTickData(symbol string, start time.Time, end time.Time) (Chart, error) {
+	return c.GetChart(context.Background(), symbol, TickChart, start, end)
+}
 
-	return err
+// CancelHistoricalTickData cancels the chart by its historical ID, realtime updates are stopped
+func (c *Client) CancelHistoricalTickData(id int) error {
+	return c.MarketDataSocket().CancelChart(context.Background(), id)
 }
 
 func (c *Client) UnsubscribeRealtimeData(id int) error {
-	cancelChartReq := struct {
-		SubscriptionId int `json:"subscriptionId"`
-	}{
-		SubscriptionId: id,
-	}
-
-	b, err := json.Marshal(cancelChartReq)
-
-	if err != nil {
-
-	}
-
-	msg, err := c.Send(CANCEL_CHART, "", string(b), 10)
-
-	if msg.Status != 200 {
-
-	}
-
-	return err
+	return c.CancelHistoricalTickData(id)
 }
@@ -1,507 +1,709 @@
 package tradovate
 
-// import (
-// 	"context"
-// 	"encoding/json"
-// 	"fmt"
-// 	"io/ioutil"
-// 	"net/http"
-// 	"net/url"
-// 	"regexp"
-// 	"strconv"
-// 	"strings"
-// 	"time"
-
-// 	"github.com/cockroachdb/apd"
-// 	"github.com/fatih/structs"
-// 	tradovate "github.com/hirokisan/bybit/v2"
-// 	"github.com/pkg/errors"
-// 	exchanges "github.com/aulaleslie/trade-exchanges"
-// 	"github.com/aulaleslie/trade-exchanges/utils"
-// 	"go.uber.org/zap"
-// )
-
-// const TRADOVATE_PREFIX = "TRADOVATE-"
-
-// type TradovateContract struct {
-// 	client     *tradovate.Client
-// 	wsClient   *tradovate.WebSocketClient
-// 	httpClient *http.Client
-// 	lg         *zap.Logger
-// 	key        string
-// 	secret     string
-// }
-
-// // TODO: don't forget to check time
-// var _ exchanges.Exchange = (*TradovateContract)(nil) // Type check
-
-// func NewTradovateContract(apiKey, secretKey, host string, lg *zap.Logger) *TradovateContract {
-// 	lg = lg.Named("Tradovate")
-
-// 	t := &TradovateContract{}
-
-// 	t.client = NewTradovateRestClient(apiKey, secretKey, lg)
-// 	t.wsClient = NewTradovateWSClient(apiKey, secretKey, lg)
-// 	t.httpClient = NewHTTPClient(time.Second * 10)
-// 	t.key = apiKey
-// 	t.secret = secretKey
-// 	t.lg = lg
-
-// 	return t
-// }
-
-// func ToTradovateFullSymbol(symbol string) string {
-// 	return TRADOVATE_PREFIX + symbol
-// }
-
-// func ToTradovateSymbol(symbol string) string {
-// 	return strings.TrimPrefix(symbol, TRADOVATE_PREFIX)
-// }
-
-// func (t *TradovateContract) GetOrders(ctx context.Context, filter exchanges.OrderFilter) (res []exchanges.OrderDetailInfo, err error) {
-// 	var symbol *tradovate.SymbolV5
-
-// 	if filter.Symbol != nil {
-// 		tradovateSymbol := ToTradovateSymbol(*filter.Symbol)
-// 		symbol = (*tradovate.SymbolV5)(&tradovateSymbol)
-// 	}
-
-// 	params := tradovate.V5GetHistoryOrdersParam{
-// 		Symbol:   symbol,
-// 		Category: tradovate.CategoryV5Spot,
-// 		OrderID:  filter.OrderID,
-// 	}
-
-// 	historyOrdersResponse, err := t.client.V5().Order().GetHistoryOrders(params)
-
-// 	if err != nil {
-// 		return
-// 	}
-
-// 	for _, historyOrder := range historyOrdersResponse.Result.List {
-// 		price := utils.FromString(historyOrder.Price)
-// 		quantity := utils.FromString(historyOrder.Qty)
-// 		executedQuantity := utils.Sub(quantity, utils.FromString(historyOrder.LeavesQty))
-
-// 		fmt.Println(price, executedQuantity)
-
-// 		orderStatusType := mapOrderStatusType(string(historyOrder.OrderStatus))
-// 		orderSide := mapOrderSide(string(historyOrder.Side))
-// 		orderType := mapOrderType(string(historyOrder.OrderType))
-
-// 		createdTime, err := strconv.ParseInt(historyOrder.CreatedTime, 10, 64)
-// 		if err != nil {
-// 			return nil, err
-// 		}
-// 		orderDetailInfo := exchanges.OrderDetailInfo{
-// 			ID:            historyOrder.OrderID,
-// 			Symbol:        string(historyOrder.Symbol),
-// 			ClientOrderID: &historyOrder.OrderLinkID,
-// 			Price:         price,
-// 			Quantity:      quantity,
-// 			ExecutedQty:   executedQuantity,
-// 			Status:        orderStatusType,
-// 			OrderType:     orderType,
-// 			Time:          createdTime,
-// 			OrderSide:     orderSide,
-// 			TimeInForce:   nil,
-// 			StopPrice:     nil,
-// 			QuoteQuantity: nil,
-// 		}
-
-// 		res = append(res, orderDetailInfo)
-// 	}
-
-// 	return
-// }
-
-// func (t *TradovateContract) GetOpenOrders(ctx context.Context) (res []exchanges.OrderDetailInfo, err error) {
-// 	input := tradovate.V5GetOpenOrdersParam{
-// 		Category: tradovate.CategoryV5Spot,
-// 	}
-
-// 	openOrders, err := t.client.V5().Order().GetOpenOrders(input)
-// 	if err != nil {
-// 		return
-// 	}
-
-// 	// TODO: marshal map
-// 	for _, openOrder := range openOrders.Result.List {
-// 		price := utils.FromString(openOrder.Price)
-// 		quantity := utils.FromString(openOrder.Qty)
-// 		executedQuantity := utils.Sub(quantity, utils.FromString(openOrder.LeavesQty))
-
-// 		fmt.Println(price, executedQuantity)
-
-// 		orderStatusType := mapOrderStatusType(string(openOrder.OrderStatus))
-// 		orderSide := mapOrderSide(string(openOrder.Side))
-// 		orderType := mapOrderType(string(openOrder.OrderType))
-
-// 		createdTime, err := strconv.ParseInt(openOrder.CreatedTime, 10, 64)
-// 		if err != nil {
-// 			return nil, err
-// 		}
-// 		orderDetailInfo := exchanges.OrderDetailInfo{
-// 			ID:            openOrder.OrderID,
-// 			Symbol:        string(openOrder.Symbol),
-// 			ClientOrderID: &openOrder.OrderLinkID,
-// 			Price:         price,
-// 			Quantity:      quantity,
-// 			ExecutedQty:   executedQuantity,
-// 			Status:        orderStatusType,
-// 			OrderType:     orderType,
-// 			Time:          createdTime,
-// 			OrderSide:     orderSide,
-// 			TimeInForce:   nil,
-// 			StopPrice:     nil,
-// 			QuoteQuantity: nil,
-// 		}
-
-// 		res = append(res, orderDetailInfo)
-// 	}
-
-// 	return
-// }
-
-// func (t *TradovateContract) GetAccount(ctx context.Context) (res exchanges.Account, err error) {
-// 	balances, err := t.client.V5().Account().GetWalletBalance(tradovate.AccountType(tradovate.AccountTypeV5UNIFIED), nil)
-// 	if err != nil {
-// 		return res, errors.Wrap(err, "unable to do GetAccount request")
-// 	}
-
-// 	accountBalances := make([]exchanges.AccountBalance, 0)
-// 	for _, balance := range balances.Result.List[0].Coin {
-// 		free := utils.FromString(balance.WalletBalance)
-// 		locked := utils.FromString(balance.Locked)
-// 		accountBalances = append(accountBalances, exchanges.AccountBalance{
-// 			Coin:   string(balance.Coin),
-// 			Free:   free,
-// 			Locked: locked,
-// 		})
-// 	}
-// 	res.AccountBalances = accountBalances
-// 	return res, nil
-// }
-
-// func (t *TradovateContract) RoundPrice(_ context.Context, symbol string, price *apd.Decimal, tickSize *string) (*apd.Decimal, error) {
-// 	// TODO: handle this more accurately at bot side
-// 	str := price.Text('f')
-// 	rgx := priceFloorRE
-// 	if tickSize != nil {
-// 		precision := utils.FindPrecisionFromTickSize(*tickSize)
-// 		if precision != nil {
-// 			rgx = regexp.MustCompile(fmt.Sprintf(`^[0-9]{1,20}(\.[0-9]{1,%v})?`, *precision))
-// 		}
-// 	}
-// 	substr := rgx.FindString(str)
-// 	if substr == "" {
-// 		return nil, errors.Errorf("invalid price %v", price)
-// 	}
-// 	return utils.FromStringErr(substr)
-// }
-
-// func (t *TradovateContract) RoundQuantity(_ context.Context, symbol string, qty *apd.Decimal) (*apd.Decimal, error) {
-// 	// TODO: handle this more accurately at bot side
-// 	str := qty.Text('f')
-// 	substr := qtyFloorRE.FindString(str)
-// 	if substr == "" {
-// 		return nil, errors.Errorf("invalid quantity %v", qty)
-// 	}
-// 	return utils.FromStringErr(str)
-// }
-
-// func (t *TradovateContract) GetPrefix() string {
-// 	return TRADOVATE_PREFIX
-// }
-
-// func (t *TradovateContract) GetName() string {
-// 	return "Tradovate"
-// }
-
-// func (t *TradovateContract) PlaceBuyOrder(ctx context.Context,
-// 	_ bool, symbol string, price, quantity *apd.Decimal, prefferedID string,
-// ) (id string, e error) {
-// 	return t.PlaceBuyOrderV2(ctx, true, symbol, price, quantity, prefferedID, string(tradovate.OrderTypeLimit))
-// }
-
-// func (t *TradovateContract) PlaceSellOrder(ctx context.Context,
-// 	_ bool, symbol string, price, quantity *apd.Decimal, prefferedID string,
-// ) (id string, e error) {
-// 	return t.PlaceSellOrderV2(ctx, true, symbol, price, quantity, prefferedID, string(tradovate.OrderTypeLimit))
-// }
-
-// func (t *TradovateContract) CancelOrder(ctx context.Context, symbol, id string) error {
-// 	input := tradovate.V5CancelOrderParam{
-// 		Symbol:   tradovate.SymbolV5(symbol),
-// 		OrderID:  &id,
-// 		Category: tradovate.CategoryV5Spot,
-// 	}
-// 	_, err := t.client.V5().Order().CancelOrder(input)
-// 	if err != nil {
-// 		return errors.Wrap(err, "unable to do CancelOrder on orderId ")
-// 	}
-
-// 	return nil
-// }
-
-// func (t *TradovateContract) ReleaseOrder(_ context.Context, symbol, id string) error {
-// 	// _, err := b.client.V5().Order().
-// 	return nil
-// }
-
-// func (t *TradovateContract) GetOrderInfo(ctx context.Context, symbol, id string, _ *time.Time) (exchanges.OrderInfo, error) {
-
-// 	orderInfo := exchanges.OrderInfo{}
-
-// 	baseURL := TradovateBaseURL + GetOrderHistoryPath
-// 	params := url.Values{}
-// 	params.Set("category", string(tradovate.CategoryV5Spot))
-// 	params.Set("symbol", symbol)
-// 	params.Set("orderId", id)
-
-// 	reqURL, err := url.Parse(baseURL)
-// 	if err != nil {
-// 		return orderInfo, errors.Wrap(err, "unable to create http request")
-// 	}
-// 	reqURL.RawQuery = params.Encode()
-
-// 	signature, timestamp := tradovateSignatureGenerator(t.key, t.secret, reqURL.RawQuery)
-
-// 	req, err := http.NewRequest("GET", reqURL.String(), nil)
-// 	if err != nil {
-// 		return orderInfo, errors.Wrap(err, "unable to create http request")
-// 	}
-// 	req.Header.Set("Content-Type", "application/json")
-// 	req.Header.Set("X-BAPI-SIGN-TYPE", "2")
-// 	req.Header.Set("X-BAPI-SIGN", signature)
-// 	req.Header.Set("X-BAPI-API-KEY", t.key)
-// 	req.Header.Set("X-BAPI-TIMESTAMP", strconv.FormatInt(timestamp, 10))
-// 	req.Header.Set("X-BAPI-RECV-WINDOW", recWindow)
-
-// 	resp, err := t.httpClient.Do(req)
-// 	if err != nil {
-// 		return orderInfo, errors.Wrap(err, "unable to get order history")
-// 	}
-// 	defer resp.Body.Close()
-
-// 	body, err := ioutil.ReadAll(resp.Body)
-// 	if err != nil {
-// 		return orderInfo, errors.Wrap(err, "unable to read response order history")
-// 	}
-
-// 	var orderRespon TradovateResponse
-// 	err = json.Unmarshal(body, &orderRespon)
-// 	if err != nil {
-// 		return orderInfo, errors.Wrap(err, "unable to unmarshall response order history")
-// 	}
-// 	fmt.Println(orderRespon)
-
-// 	if len(orderRespon.Result.List) == 0 {
-// 		return orderInfo, errors.Wrap(err, "order not found")
-// 	}
-// 	orderInfo.ID = orderRespon.Result.List[0].OrderId
-// 	orderInfo.ClientOrderID = &orderRespon.Result.List[0].OrderLinkId
-// 	orderInfo.Status = mapOrderStatusType(string(orderRespon.Result.List[0].OrderStatus))
-
-// 	return orderInfo, nil
-
-// }
-
-// func (t *TradovateContract) GetOrderInfoByClientOrderID(ctx context.Context, symbol, clientOrderID string, _ *time.Time) (exchanges.OrderInfo, error) {
-// 	orderInfo := exchanges.OrderInfo{}
-
-// 	baseURL := TradovateBaseURL + GetOrderHistoryPath
-// 	params := url.Values{}
-// 	params.Set("category", string(tradovate.CategoryV5Spot))
-// 	params.Set("symbol", symbol)
-// 	params.Set("orderLinkId", clientOrderID)
-
-// 	reqURL, err := url.Parse(baseURL)
-// 	if err != nil {
-// 		return orderInfo, errors.Wrap(err, "unable to create http request")
-// 	}
-// 	reqURL.RawQuery = params.Encode()
-
-// 	signature, timestamp := tradovateSignatureGenerator(t.key, t.secret, reqURL.RawQuery)
-
-// 	req, err := http.NewRequest("GET", reqURL.String(), nil)
-// 	if err != nil {
-// 		return orderInfo, errors.Wrap(err, "unable to create http request")
-// 	}
-// 	req.Header.Set("Content-Type", "application/json")
-// 	req.Header.Set("X-BAPI-SIGN-TYPE", "2")
-// 	req.Header.Set("X-BAPI-SIGN", signature)
-// 	req.Header.Set("X-BAPI-API-KEY", t.key)
-// 	req.Header.Set("X-BAPI-TIMESTAMP", strconv.FormatInt(timestamp, 10))
-// 	req.Header.Set("X-BAPI-RECV-WINDOW", recWindow)
-
-// 	resp, err := t.httpClient.Do(req)
-// 	if err != nil {
-// 		return orderInfo, errors.Wrap(err, "unable to get order history")
-// 	}
-// 	defer resp.Body.Close()
-
-// 	body, err := ioutil.ReadAll(resp.Body)
-// 	if err != nil {
-// 		return orderInfo, errors.Wrap(err, "unable to read response order history")
-// 	}
-
-// 	var orderRespon TradovateResponse
-// 	err = json.Unmarshal(body, &orderRespon)
-// 	if err != nil {
-// 		return orderInfo, errors.Wrap(err, "unable to unmarshall response order history")
-// 	}
-// 	fmt.Println(orderRespon)
-
-// 	if len(orderRespon.Result.List) == 0 {
-// 		return orderInfo, errors.Wrap(err, "order not found")
-// 	}
-// 	orderInfo.ID = orderRespon.Result.List[0].OrderId
-// 	orderInfo.ClientOrderID = &orderRespon.Result.List[0].OrderLinkId
-// 	orderInfo.Status = mapOrderStatusType(string(orderRespon.Result.List[0].OrderStatus))
-
-// 	return orderInfo, nil
-// }
-
-// func (t *TradovateContract) GetPrice(ctx context.Context, symbol string) (*apd.Decimal, error) {
-// 	tradovateSmbl := ToTradovateSymbol(symbol)
-
-// 	symbl := tradovate.SymbolV5(tradovateSmbl)
-// 	input := tradovate.V5GetTickersParam{
-// 		Symbol:   &symbl,
-// 		Category: tradovate.CategoryV5Spot,
-// 	}
-// 	res, err := t.client.V5().Market().GetTickers(input)
-// 	if err != nil {
-// 		return utils.Zero, err
-// 	}
-// 	price := utils.FromString(res.Result.Spot.List[0].LastPrice)
-// 	return price, nil
-// }
-
-// type Server struct {
-// 	Name        string
-// 	ID          int
-// 	Enabled     bool
-// 	http.Server // embedded
-// }
-
-// func (t *TradovateContract) GetTradableSymbols(ctx context.Context) ([]exchanges.SymbolInfo, error) {
-// 	input := tradovate.V5GetInstrumentsInfoParam{
-// 		Category: tradovate.CategoryV5Spot,
-// 	}
-// 	res, err := t.client.V5().Market().GetInstrumentsInfo(input)
-// 	if err != nil {
-// 		return nil, errors.Wrap(err, "unable to do GetTradableSymbols request")
-// 	}
-
-// 	result := []exchanges.SymbolInfo{}
-// 	for _, symbol := range res.Result.Spot.List {
-// 		fullSymbol := ToTradovateFullSymbol(string(symbol.Symbol))
-// 		f := &SymbolFilter{
-// 			LotSizeFilter: symbol.LotSizeFilter,
-// 			PriceFilter:   symbol.PriceFilter,
-// 		}
-// 		fltrMap := structs.Map(f)
-// 		sInfo := exchanges.SymbolInfo{
-// 			DisplayName:    fullSymbol,
-// 			Symbol:         fullSymbol,
-// 			OriginalSymbol: string(symbol.Symbol),
-// 			Filters:        []map[string]interface{}{fltrMap},
-// 		}
-// 		result = append(result, sInfo)
-// 	}
-// 	return result, nil
-// }
-
-// // WatchOrdersStatuses Returns control immediately
-// func (t *TradovateContract) WatchOrdersStatuses(ctx context.Context) (<-chan exchanges.OrderEvent, error) {
-// 	return SubscribeToOrders(ctx, t.wsClient, t.lg, tradovate.CategoryV5Spot)
-// }
-
-// // // WatchOrdersStatuses Returns control immediately
-// // func (b *TradovateContract) WatchFills(ctx context.Context) (<-chan exchanges.FillsEvent, error) {
-// // 	// 	wsEndpoint := rest.WS_ENDPOINT
-// // 	// 	if strings.Contains(b.client.Host, rest.ENDPOINT_US) {
-// // 	// 		wsEndpoint = rest.WS_ENDPOINT_US
-// // 	// 	}
-
-// // 	// 	return SubscribeToFills(ctx, wsEndpoint, b.key, b.secret, b.lg)
-// // 	return nil, nil
-
-// // }
-
-// // WatchSymbolPrice
-// func (t *TradovateContract) WatchSymbolPrice(ctx context.Context, symbol string) (<-chan exchanges.PriceEvent, error) {
-// 	tradovateSymbol := ToTradovateSymbol(symbol)
-// 	in, err := SubscribeToPrices(ctx, t.wsClient, t.lg, tradovateSymbol)
-// 	if err != nil {
-// 		return nil, err
-// 	}
-
-// 	return in, nil
-// }
-
-// // PlaceBuyOrderV2 Place Buy Order with OrderType param
-// func (t *TradovateContract) PlaceBuyOrderV2(ctx context.Context, _ bool, symbol string, price, qty *apd.Decimal, preferredID string, orderType string) (id string, e error) {
-// 	qttString := utils.ToFlatString(qty)
-// 	priceString := utils.ToFlatString(price)
-// 	symbol = ToTradovateSymbol(symbol)
-// 	input := tradovate.V5CreateOrderParam{
-// 		Category:    tradovate.CategoryV5Spot,
-// 		Symbol:      tradovate.SymbolV5(symbol),
-// 		Side:        tradovate.SideBuy,
-// 		OrderType:   tradovate.OrderType(orderType),
-// 		Qty:         qttString,
-// 		Price:       &priceString,
-// 		OrderLinkID: &preferredID,
-// 	}
-// 	res, err := t.client.V5().Order().CreateOrder(input)
-// 	if err != nil {
-// 		return "", errors.Wrap(err, "unable to do PlaceBuyOrderV2 request")
-// 	}
-
-// 	return res.Result.OrderID, nil
-// }
-
-// // PlaceSellOrderV2 Place Sell Order with OrderType param
-// func (t *TradovateContract) PlaceSellOrderV2(ctx context.Context, _ bool, symbol string, price, qty *apd.Decimal, preferredID string, orderType string) (id string, e error) {
-// 	qttString := utils.ToFlatString(qty)
-// 	priceString := utils.ToFlatString(price)
-// 	symbol = ToTradovateSymbol(symbol)
-// 	input := tradovate.V5CreateOrderParam{
-// 		Category:    tradovate.CategoryV5Spot,
-// 		Symbol:      tradovate.SymbolV5(symbol),
-// 		Side:        tradovate.SideSell,
-// 		OrderType:   tradovate.OrderType(orderType),
-// 		Qty:         qttString,
-// 		Price:       &priceString,
-// 		OrderLinkID: &preferredID,
-// 	}
-// 	res, err := t.client.V5().Order().CreateOrder(input)
-// 	if err != nil {
-// 		return "", errors.Wrap(err, "unable to do PlaceSellOrderV2 request")
-// 	}
-// 	return res.Result.OrderID, nil
-
-// }
-
-// func (t *TradovateContract) WatchAccountPositions(ctx context.Context) (<-chan exchanges.PositionEvent, error) {
-// 	in, err := SubscribeToPositions(ctx, t.wsClient, t.lg, tradovate.CategoryV5Spot)
-// 	if err != nil {
-// 		return nil, err
-// 	}
-
-// 	out := make(chan exchanges.PositionEvent, 100)
-// 	go func() {
-// 		defer close(out)
-// 		for ev := range in {
-// 			out <- ev
-// 		}
-// 	}()
-// 	return out, nil
-// }
-
-// func (t *TradovateContract) GenerateClientOrderID(ctx context.Context, identifierID string) (string, error) {
-// 	return utils.GenClientOrderID(identifierID)
-// }
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/aulaleslie/trade-exchanges/utils"
+	"github.com/cockroachdb/apd"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// TradovateContract trades CME futures through Tradovate REST API.
+// Native symbols are contract names, e.g. "TRADOVATE-ESZ4" is "ESZ4",
+// or continuous symbols resolved to the current contract, e.g. "TRADOVATE-ES!1".
+type TradovateContract struct {
+	client *Client
+	lg     *zap.Logger
+
+	// Name of the account to trade, optional. The first active account is used by default.
+	AccountName string
+	// Contract names or continuous symbols returned by GetTradableSymbols, optional
+	Symbols []string
+	// Continuous symbols move to the next contract this number of days before expiration, optional
+	RolloverDays int
+
+	mu         sync.Mutex
+	account    *Account
+	contracts  map[int64]Contract // By ID
+	continuous map[string]continuousContract
+	marketData *MarketDataClient
+	userSync   *UserSyncClient
+}
+
+var _ exchanges.Exchange = (*TradovateContract)(nil) // Type check
+
+func NewTradovateContract(client *Client, lg *zap.Logger) *TradovateContract {
+	return &TradovateContract{
+		client:     client,
+		lg:         lg.Named("Tradovate"),
+		contracts:  map[int64]Contract{},
+		continuous: map[string]continuousContract{},
+	}
+}
+
+func (t *TradovateContract) GetPrefix() string {
+	return TRADOVATE_PREFIX
+}
+
+func (t *TradovateContract) GetName() string {
+	return "Tradovate"
+}
+
+func (t *TradovateContract) getAccount(ctx context.Context) (Account, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.account != nil {
+		return *t.account, nil
+	}
+
+	accounts := []Account{}
+	if err := t.client.Do(ctx, http.MethodGet, GET_ACCOUNTS, nil, nil, &accounts); err != nil {
+		return Account{}, errors.Wrap(err, "can't get accounts")
+	}
+	for i, a := range accounts {
+		if (t.AccountName == "" && a.Active) || (t.AccountName != "" && a.Name == t.AccountName) {
+			t.account = &accounts[i]
+			return a, nil
+		}
+	}
+	if t.AccountName != "" {
+		return Account{}, errors.Errorf("account %s not found", t.AccountName)
+	}
+	return Account{}, errors.New("no active accounts")
+}
+
+func (t *TradovateContract) findContract(ctx context.Context, name string) (Contract, error) {
+	params := url.Values{}
+	params.Set("name", name)
+	contract := Contract{}
+	if err := t.client.Do(ctx, http.MethodGet, FIND_CONTRACT, params, nil, &contract); err != nil {
+		return Contract{}, errors.Wrapf(err, "can't find contract %s", name)
+	}
+
+	t.mu.Lock()
+	t.contracts[contract.Id] = contract
+	t.mu.Unlock()
+	return contract, nil
+}
+
+func (t *TradovateContract) getContract(ctx context.Context, id int64) (Contract, error) {
+	t.mu.Lock()
+	contract, ok := t.contracts[id]
+	t.mu.Unlock()
+	if ok {
+		return contract, nil
+	}
+
+	params := url.Values{}
+	params.Set("id", strconv.FormatInt(id, 10))
+	if err := t.client.Do(ctx, http.MethodGet, GET_CONTRACT, params, nil, &contract); err != nil {
+		return Contract{}, errors.Wrapf(err, "can't get contract %d", id)
+	}
+
+	t.mu.Lock()
+	t.contracts[id] = contract
+	t.mu.Unlock()
+	return contract, nil
+}
+
+func (t *TradovateContract) contractSymbol(ctx context.Context, contractID int64) (string, error) {
+	contract, err := t.getContract(ctx, contractID)
+	if err != nil {
+		return "", err
+	}
+	return ToTradovateFullSymbol(contract.Name), nil
+}
+
+func (t *TradovateContract) GetTradableSymbols(ctx context.Context) ([]exchanges.SymbolInfo, error) {
+	result := []exchanges.SymbolInfo{}
+	for _, name := range t.Symbols {
+		if _, _, ok := parseContinuous(name); ok {
+			info, err := t.continuousSymbolInfo(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, info)
+			continue
+		}
+
+		contract, err := t.findContract(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+
+		instrument := contractInstrument(contract.Name)
+		fullSymbol := exchanges.Instruments.Register(TRADOVATE_PREFIX, contract.Name, instrument)
+		result = append(result, exchanges.SymbolInfo{
+			DisplayName:    fullSymbol,
+			Symbol:         fullSymbol,
+			OriginalSymbol: contract.Name,
+			Filters: []map[string]interface{}{{
+				"ContractId":       contract.Id,
+				"ProviderTickSize": contract.ProviderTickSize,
+			}},
+			Instrument: &instrument,
+		})
+	}
+	return result, nil
+}
+
+// RoundPrice rounds the price down (towards zero) to the tick size.
+// The tick size of the contract spec is used if `tickSize` isn't passed.
+func (t *TradovateContract) RoundPrice(ctx context.Context, symbol string, price *apd.Decimal, tickSize *string) (*apd.Decimal, error) {
+	var tick *apd.Decimal
+	if tickSize != nil {
+		var err error
+		tick, err = utils.FromStringErr(*tickSize)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid tick size %s", *tickSize)
+		}
+	} else {
+		spec, err := t.GetContractSpec(ctx, symbol)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't get tick size of %s", symbol)
+		}
+		tick = utils.FromFloat64(spec.TickSize)
+	}
+	if tick.Sign() <= 0 {
+		return nil, errors.Errorf("invalid tick size %v of %s", tick, symbol)
+	}
+	return utils.Sub(price, utils.Mod(price, tick)), nil
+}
+
+func (t *TradovateContract) RoundQuantity(_ context.Context, symbol string, qty *apd.Decimal) (*apd.Decimal, error) {
+	substr := qtyFloorRE.FindString(qty.Text('f'))
+	if substr == "" {
+		return nil, errors.Errorf("invalid quantity %v", qty)
+	}
+	return utils.FromStringErr(substr)
+}
+
+func (t *TradovateContract) PlaceBuyOrder(ctx context.Context,
+	isRetry bool, symbol string, price, qty *apd.Decimal, clientOrderID string,
+) (id string, e error) {
+	return t.PlaceBuyOrderV2(ctx, isRetry, symbol, price, qty, clientOrderID, string(exchanges.LIMIT))
+}
+
+func (t *TradovateContract) PlaceSellOrder(ctx context.Context,
+	isRetry bool, symbol string, price, qty *apd.Decimal, clientOrderID string,
+) (id string, e error) {
+	return t.PlaceSellOrderV2(ctx, isRetry, symbol, price, qty, clientOrderID, string(exchanges.LIMIT))
+}
+
+func (t *TradovateContract) PlaceBuyOrderV2(ctx context.Context,
+	isRetry bool, symbol string, price, qty *apd.Decimal, clientOrderID string, orderType string,
+) (id string, e error) {
+	return t.placeOrder(ctx, isRetry, "Buy", symbol, price, qty, clientOrderID, orderType)
+}
+
+func (t *TradovateContract) PlaceSellOrderV2(ctx context.Context,
+	isRetry bool, symbol string, price, qty *apd.Decimal, clientOrderID string, orderType string,
+) (id string, e error) {
+	return t.placeOrder(ctx, isRetry, "Sell", symbol, price, qty, clientOrderID, orderType)
+}
+
+// Tradovate returns ID of the order only, so ID is used instead of `clientOrderID`
+func (t *TradovateContract) placeOrder(ctx context.Context,
+	isRetry bool, action, symbol string, price, qty *apd.Decimal, clientOrderID string, orderType string,
+) (string, error) {
+	if isRetry {
+		orderID, err := t.findOrderIDByClientOrderID(ctx, clientOrderID)
+		switch {
+		case err == nil:
+			return strconv.FormatInt(orderID, 10), nil
+		case !errors.Is(err, exchanges.OrderNotFoundError):
+			return "", errors.Wrap(err, "can't check previous try")
+		}
+	}
+
+	tradovateType, ok := toTradovateOrderType(orderType)
+	if !ok {
+		return "", errors.Errorf("unsupported order type %s", orderType)
+	}
+	orderQty, err := qty.Int64()
+	if err != nil || orderQty <= 0 {
+		return "", errors.Errorf("quantity %v isn't a positive integer", qty)
+	}
+	account, err := t.getAccount(ctx)
+	if err != nil {
+		return "", err
+	}
+	name, err := t.resolveName(ctx, ToTradovateSymbol(symbol))
+	if err != nil {
+		return "", err
+	}
+
+	req := PlaceOrderRequest{
+		AccountSpec: account.Name,
+		AccountId:   account.Id,
+		ClOrdId:     clientOrderID,
+		Action:      action,
+		Symbol:      name,
+		OrderQty:    orderQty,
+		OrderType:   tradovateType,
+		TimeInForce: "GTC",
+		IsAutomated: true,
+	}
+	if tradovateType != "Market" {
+		p := json.Number(utils.ToFlatString(price))
+		req.Price = &p
+	}
+
+	resp := PlaceOrderResponse{}
+	if err := t.client.Do(ctx, http.MethodPost, PLACE_ORDER, nil, req, &resp); err != nil {
+		return "", errors.Wrapf(err, "unable to place %s order", action)
+	}
+	return strconv.FormatInt(resp.OrderId, 10), nil
+}
+
+func (t *TradovateContract) CancelOrder(ctx context.Context, symbol, id string) error {
+	orderID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return errors.Wrapf(err, "invalid order ID %s", id)
+	}
+
+	req := CancelOrderRequest{OrderId: orderID, IsAutomated: true}
+	cancelErr := t.client.Do(ctx, http.MethodPost, CANCEL_ORDER, nil, req, &CancelOrderResponse{})
+	if cancelErr == nil {
+		return nil
+	}
+
+	// Order can be already executed or canceled
+	info, err := t.GetOrderInfo(ctx, symbol, id, nil)
+	if err != nil {
+		return utils.ReplaceError(errors.Wrap(cancelErr, "unable to cancel order"), err)
+	}
+	switch info.Status {
+	case exchanges.FilledOST:
+		return exchanges.OrderExecutedError
+	case exchanges.CanceledOST, exchanges.ExpiredOST, exchanges.RejectedOST:
+		return nil
+	}
+	return errors.Wrap(cancelErr, "unable to cancel order")
+}
+
+func (t *TradovateContract) ReleaseOrder(_ context.Context, symbol, id string) error {
+	return nil
+}
+
+func (t *TradovateContract) GetOrderInfo(ctx context.Context, symbol, id string, _ *time.Time) (exchanges.OrderInfo, error) {
+	params := url.Values{}
+	params.Set("id", id)
+	order := Order{}
+	if err := t.client.Do(ctx, http.MethodGet, GET_ORDER, params, nil, &order); err != nil {
+		if IsNotFoundError(err) {
+			return exchanges.OrderInfo{}, exchanges.OrderNotFoundError
+		}
+		return exchanges.OrderInfo{}, errors.Wrap(err, "can't get order")
+	}
+	if order.Id == 0 {
+		return exchanges.OrderInfo{}, exchanges.OrderNotFoundError
+	}
+
+	deps := url.Values{}
+	deps.Set("masterid", id)
+	fills := []Fill{}
+	if err := t.client.Do(ctx, http.MethodGet, GET_ORDER_FILLS, deps, nil, &fills); err != nil {
+		return exchanges.OrderInfo{}, errors.Wrap(err, "can't get order fills")
+	}
+	commands := []Command{}
+	if err := t.client.Do(ctx, http.MethodGet, GET_ORDER_COMMANDS, deps, nil, &commands); err != nil {
+		return exchanges.OrderInfo{}, errors.Wrap(err, "can't get order commands")
+	}
+
+	info := exchanges.OrderInfo{
+		ID:     id,
+		Status: orderStatus(order.OrdStatus, filledQty(fills)),
+	}
+	if clOrdID := clientOrderIDOf(commands); clOrdID != "" {
+		info.ClientOrderID = &clOrdID
+	}
+	return info, nil
+}
+
+func (t *TradovateContract) GetOrderInfoByClientOrderID(ctx context.Context, symbol, clientOrderID string, createdAt *time.Time) (exchanges.OrderInfo, error) {
+	orderID, err := t.findOrderIDByClientOrderID(ctx, clientOrderID)
+	if err != nil {
+		return exchanges.OrderInfo{}, err
+	}
+	return t.GetOrderInfo(ctx, symbol, strconv.FormatInt(orderID, 10), createdAt)
+}
+
+func (t *TradovateContract) findOrderIDByClientOrderID(ctx context.Context, clientOrderID string) (int64, error) {
+	commands := []Command{}
+	if err := t.client.Do(ctx, http.MethodGet, GET_COMMANDS, nil, nil, &commands); err != nil {
+		return 0, errors.Wrap(err, "can't get commands")
+	}
+	for _, c := range commands {
+		if c.ClOrdId == clientOrderID {
+			return c.OrderId, nil
+		}
+	}
+	return 0, exchanges.OrderNotFoundError
+}
+
+func filledQty(fills []Fill) int64 {
+	var qty int64
+	for _, f := range fills {
+		if f.Active {
+			qty += f.Qty
+		}
+	}
+	return qty
+}
+
+// clientOrderIDOf returns `clOrdId` of the earliest command with it
+func clientOrderIDOf(commands []Command) string {
+	var first *Command
+	for i, c := range commands {
+		if c.ClOrdId != "" && (first == nil || c.Id < first.Id) {
+			first = &commands[i]
+		}
+	}
+	if first == nil {
+		return ""
+	}
+	return first.ClOrdId
+}
+
+func (t *TradovateContract) GetOpenOrders(ctx context.Context) ([]exchanges.OrderDetailInfo, error) {
+	orders, err := t.GetOrders(ctx, exchanges.OrderFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	res := []exchanges.OrderDetailInfo{}
+	for _, o := range orders {
+		if !o.Status.IsFinalStatus() {
+			res = append(res, o)
+		}
+	}
+	return res, nil
+}
+
+func (t *TradovateContract) GetOrders(ctx context.Context, filter exchanges.OrderFilter) ([]exchanges.OrderDetailInfo, error) {
+	account, err := t.getAccount(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	orders := []Order{}
+	if err := t.client.Do(ctx, http.MethodGet, GET_ORDERS, nil, nil, &orders); err != nil {
+		return nil, errors.Wrap(err, "can't get orders")
+	}
+	versions := []OrderVersion{}
+	if err := t.client.Do(ctx, http.MethodGet, GET_ORDER_VERSIONS, nil, nil, &versions); err != nil {
+		return nil, errors.Wrap(err, "can't get order versions")
+	}
+	fills := []Fill{}
+	if err := t.client.Do(ctx, http.MethodGet, GET_FILLED_ORDERS, nil, nil, &fills); err != nil {
+		return nil, errors.Wrap(err, "can't get fills")
+	}
+	commands := []Command{}
+	if err := t.client.Do(ctx, http.MethodGet, GET_COMMANDS, nil, nil, &commands); err != nil {
+		return nil, errors.Wrap(err, "can't get commands")
+	}
+
+	lastVersions := map[int64]OrderVersion{}
+	for _, v := range versions {
+		if last, ok := lastVersions[v.OrderId]; !ok || v.Id > last.Id {
+			lastVersions[v.OrderId] = v
+		}
+	}
+	fillsByOrder := map[int64][]Fill{}
+	for _, f := range fills {
+		fillsByOrder[f.OrderId] = append(fillsByOrder[f.OrderId], f)
+	}
+	commandsByOrder := map[int64][]Command{}
+	for _, c := range commands {
+		commandsByOrder[c.OrderId] = append(commandsByOrder[c.OrderId], c)
+	}
+
+	res := []exchanges.OrderDetailInfo{}
+	for _, o := range orders {
+		if o.AccountId != account.Id {
+			continue
+		}
+		id := strconv.FormatInt(o.Id, 10)
+		if filter.OrderID != nil && *filter.OrderID != id {
+			continue
+		}
+		clOrdID := clientOrderIDOf(commandsByOrder[o.Id])
+		if filter.ClientOrderID != nil && *filter.ClientOrderID != clOrdID {
+			continue
+		}
+		symbol, err := t.contractSymbol(ctx, o.ContractId)
+		if err != nil {
+			return nil, err
+		}
+		if filter.Symbol != nil && *filter.Symbol != symbol {
+			continue
+		}
+
+		executed := filledQty(fillsByOrder[o.Id])
+		detail := exchanges.OrderDetailInfo{
+			Symbol:      symbol,
+			ID:          id,
+			ExecutedQty: apd.New(executed, 0),
+			Status:      orderStatus(o.OrdStatus, executed),
+			Time:        o.Timestamp.UnixMilli(),
+			OrderSide:   mapOrderSide(o.Action),
+		}
+		if clOrdID != "" {
+			detail.ClientOrderID = &clOrdID
+		}
+		if v, ok := lastVersions[o.Id]; ok {
+			detail.Quantity = apd.New(v.OrderQty, 0)
+			detail.Price = utils.FromFloat64(v.Price)
+			detail.StopPrice = utils.FromFloat64(v.StopPrice)
+			detail.OrderType = mapOrderType(v.OrderType)
+			if v.TimeInForce != "" {
+				tif := exchanges.OrderTimeInForce(v.TimeInForce)
+				detail.TimeInForce = &tif
+			}
+		}
+		res = append(res, detail)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Time < res[j].Time
+	})
+	return res, nil
+}
+
+func (t *TradovateContract) GetAccount(ctx context.Context) (exchanges.Account, error) {
+	account, err := t.getAccount(ctx)
+	if err != nil {
+		return exchanges.Account{}, err
+	}
+
+	positions := []Position{}
+	if err := t.client.Do(ctx, http.MethodGet, GET_POSITIONS, nil, nil, &positions); err != nil {
+		return exchanges.Account{}, errors.Wrap(err, "can't get positions")
+	}
+	balances := []CashBalance{}
+	if err := t.client.Do(ctx, http.MethodGet, GET_CASH_BALANCES, nil, nil, &balances); err != nil {
+		return exchanges.Account{}, errors.Wrap(err, "can't get cash balances")
+	}
+	currencies := []Currency{}
+	if err := t.client.Do(ctx, http.MethodGet, GET_CURRENCIES, nil, nil, &currencies); err != nil {
+		return exchanges.Account{}, errors.Wrap(err, "can't get currencies")
+	}
+
+	res := exchanges.Account{
+		AccountBalances:  []exchanges.AccountBalance{},
+		AccountPositions: []exchanges.AccountPosition{},
+	}
+	for _, p := range positions {
+		if p.AccountId != account.Id || p.NetPos == 0 {
+			continue
+		}
+		symbol, err := t.contractSymbol(ctx, p.ContractId)
+		if err != nil {
+			return exchanges.Account{}, err
+		}
+		side := "Buy"
+		if p.NetPos < 0 {
+			side = "Sell"
+		}
+		res.AccountPositions = append(res.AccountPositions, exchanges.AccountPosition{
+			Symbol:     symbol,
+			Size:       apd.New(p.NetPos, 0),
+			EntryPrice: utils.FromFloat64(p.NetPrice),
+			Side:       side,
+			Category:   string(exchanges.FutureProduct),
+		})
+	}
+
+	currencyNames := map[int64]string{}
+	for _, c := range currencies {
+		currencyNames[c.Id] = c.Name
+	}
+	for _, b := range balances {
+		if b.AccountId != account.Id {
+			continue
+		}
+		coin, ok := currencyNames[b.CurrencyId]
+		if !ok {
+			coin = strconv.FormatInt(b.CurrencyId, 10)
+		}
+		res.AccountBalances = append(res.AccountBalances, exchanges.AccountBalance{
+			Coin:   coin,
+			Free:   utils.FromFloat64(b.Amount),
+			Locked: utils.NewZero(),
+		})
+	}
+	return res, nil
+}
+
+func (t *TradovateContract) getMarketData() *MarketDataClient {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.marketData == nil {
+		t.marketData = NewMarketDataClient(t.client.MarketDataHost, t.client.Tokens(), t.lg)
+	}
+	return t.marketData
+}
+
+func (t *TradovateContract) contractByName(ctx context.Context, name string) (Contract, error) {
+	if _, _, ok := parseContinuous(name); ok {
+		cc, err := t.resolveContinuous(ctx, name)
+		return cc.contract, err
+	}
+
+	t.mu.Lock()
+	for _, c := range t.contracts {
+		if c.Name == name {
+			t.mu.Unlock()
+			return c, nil
+		}
+	}
+	t.mu.Unlock()
+	return t.findContract(ctx, name)
+}
+
+// WatchQuotes returns raw quotes of the symbol until `ctx` is done
+func (t *TradovateContract) WatchQuotes(ctx context.Context, symbol string) (<-chan MarketDataEvent, error) {
+	contract, err := t.contractByName(ctx, ToTradovateSymbol(symbol))
+	if err != nil {
+		return nil, err
+	}
+	return t.getMarketData().SubscribeQuote(ctx, contract.Name, contract.Id)
+}
+
+// WatchDepth returns depth of market of the symbol until `ctx` is done
+func (t *TradovateContract) WatchDepth(ctx context.Context, symbol string) (<-chan MarketDataEvent, error) {
+	contract, err := t.contractByName(ctx, ToTradovateSymbol(symbol))
+	if err != nil {
+		return nil, err
+	}
+	return t.getMarketData().SubscribeDOM(ctx, contract.Name, contract.Id)
+}
+
+// firstEvent waits for the first event matching `ok`, disconnection is an error
+func firstEvent(ctx context.Context, events <-chan MarketDataEvent, ok func(MarketDataEvent) bool) (MarketDataEvent, error) {
+	for {
+		select {
+		case ev, open := <-events:
+			if !open {
+				return ev, errMDDisconnected
+			}
+			if ev.DisconnectedWithErr != nil {
+				return ev, ev.DisconnectedWithErr
+			}
+			if ok(ev) {
+				return ev, nil
+			}
+		case <-ctx.Done():
+			return MarketDataEvent{}, ctx.Err()
+		}
+	}
+}
+
+func (t *TradovateContract) GetPrice(ctx context.Context, symbol string) (*apd.Decimal, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second) // TODO: move to config
+	defer cancel()
+
+	events, err := t.WatchQuotes(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	ev, err := firstEvent(ctx, events, func(ev MarketDataEvent) bool {
+		if ev.Quote == nil {
+			return false
+		}
+		_, ok := ev.Quote.Trade()
+		return ok
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't get price of %s", symbol)
+	}
+	trade, _ := ev.Quote.Trade()
+	return utils.FromFloat64(trade.Price), nil
+}
+
+// GetBestBidAsk returns the best bid and offer prices, nil if the side is empty
+func (t *TradovateContract) GetBestBidAsk(ctx context.Context, symbol string) (bid, ask *apd.Decimal, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second) // TODO: move to config
+	defer cancel()
+
+	events, err := t.WatchQuotes(ctx, symbol)
+	if err != nil {
+		return nil, nil, err
+	}
+	ev, err := firstEvent(ctx, events, func(ev MarketDataEvent) bool { return ev.Quote != nil })
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "can't get quote of %s", symbol)
+	}
+	if e, ok := ev.Quote.Bid(); ok {
+		bid = utils.FromFloat64(e.Price)
+	}
+	if e, ok := ev.Quote.Offer(); ok {
+		ask = utils.FromFloat64(e.Price)
+	}
+	return bid, ask, nil
+}
+
+// GetDepth returns snapshot of depth of market
+func (t *TradovateContract) GetDepth(ctx context.Context, symbol string) (DOM, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second) // TODO: move to config
+	defer cancel()
+
+	events, err := t.WatchDepth(ctx, symbol)
+	if err != nil {
+		return DOM{}, err
+	}
+	ev, err := firstEvent(ctx, events, func(ev MarketDataEvent) bool { return ev.DOM != nil })
+	if err != nil {
+		return DOM{}, errors.Wrapf(err, "can't get depth of %s", symbol)
+	}
+	return *ev.DOM, nil
+}
+
+func (t *TradovateContract) WatchSymbolPrice(ctx context.Context, symbol string) (<-chan exchanges.PriceEvent, error) {
+	events, err := t.WatchQuotes(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan exchanges.PriceEvent, 100) // TODO: move to config
+	go func() {
+		defer close(out)
+		for ev := range events {
+			var priceEv exchanges.PriceEvent
+			switch {
+			case ev.DisconnectedWithErr != nil:
+				priceEv.DisconnectedWithErr = ev.DisconnectedWithErr
+			case ev.Reconnected != nil:
+				priceEv.Reconnected = ev.Reconnected
+			case ev.Quote != nil:
+				trade, ok := ev.Quote.Trade()
+				if !ok {
+					continue
+				}
+				priceEv.Payload = utils.FromFloat64(trade.Price)
+			default:
+				continue
+			}
+
+			select {
+			case out <- priceEv:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (t *TradovateContract) GenerateClientOrderID(ctx context.Context, identifierID string) (string, error) {
+	return utils.GenClientOrderID(identifierID)
+}
@@ -0,0 +1,166 @@
+package tradovate
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/aulaleslie/trade-exchanges/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestTradovateContractPlaceAndCancelOrder(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeTradovate(t)
+	tc := NewTradovateContract(fake.client(), zap.NewNop())
+
+	id, err := tc.PlaceBuyOrder(ctx, false, "TRADOVATE-ESZ4", utils.FromString("5000.25"), utils.FromString("2"), "RUN1-abc")
+	require.NoError(t, err)
+	require.Len(t, fake.placeReqs, 1)
+	req := fake.placeReqs[0]
+	assert.Equal(t, "DEMO1", req.AccountSpec)
+	assert.Equal(t, "ESZ4", req.Symbol)
+	assert.Equal(t, "Buy", req.Action)
+	assert.Equal(t, "Limit", req.OrderType)
+	assert.Equal(t, int64(2), req.OrderQty)
+	assert.Equal(t, "RUN1-abc", req.ClOrdId)
+
+	// Retry finds the order placed by the first try
+	retryID, err := tc.PlaceBuyOrder(ctx, true, "TRADOVATE-ESZ4", utils.FromString("5000.25"), utils.FromString("2"), "RUN1-abc")
+	require.NoError(t, err)
+	assert.Equal(t, id, retryID)
+	assert.Len(t, fake.placeReqs, 1)
+
+	info, err := tc.GetOrderInfoByClientOrderID(ctx, "TRADOVATE-ESZ4", "RUN1-abc", nil)
+	require.NoError(t, err)
+	assert.Equal(t, id, info.ID)
+	assert.Equal(t, exchanges.NewOST, info.Status)
+	require.NotNil(t, info.ClientOrderID)
+	assert.Equal(t, "RUN1-abc", *info.ClientOrderID)
+
+	require.NoError(t, tc.CancelOrder(ctx, "TRADOVATE-ESZ4", id))
+	info, err = tc.GetOrderInfo(ctx, "TRADOVATE-ESZ4", id, nil)
+	require.NoError(t, err)
+	assert.Equal(t, exchanges.CanceledOST, info.Status)
+
+	// Second cancellation is too late but the order is canceled already
+	assert.NoError(t, tc.CancelOrder(ctx, "TRADOVATE-ESZ4", id))
+
+	_, err = tc.GetOrderInfo(ctx, "TRADOVATE-ESZ4", "999", nil)
+	assert.ErrorIs(t, err, exchanges.OrderNotFoundError)
+	assert.Len(t, fake.tokenReqs, 1)
+}
+
+func TestTradovateContractCancelExecutedOrder(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeTradovate(t)
+	tc := NewTradovateContract(fake.client(), zap.NewNop())
+
+	id, err := tc.PlaceSellOrder(ctx, false, "TRADOVATE-ESZ4", utils.FromString("5000"), utils.FromString("1"), "RUN1-def")
+	require.NoError(t, err)
+	orderID, _ := strconv.ParseInt(id, 10, 64)
+	fake.fill(orderID, 1, 5000)
+
+	assert.ErrorIs(t, tc.CancelOrder(ctx, "TRADOVATE-ESZ4", id), exchanges.OrderExecutedError)
+}
+
+func TestTradovateContractGetOrdersAndAccount(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeTradovate(t)
+	fake.positions = []Position{
+		{Id: 1, AccountId: 1, ContractId: 10, NetPos: -3, NetPrice: 4999.5},
+		{Id: 2, AccountId: 1, ContractId: 10, NetPos: 0},
+		{Id: 3, AccountId: 2, ContractId: 10, NetPos: 5}, // Other account
+	}
+	fake.balances = []CashBalance{{Id: 1, AccountId: 1, CurrencyId: 1, Amount: 50000.5}}
+	tc := NewTradovateContract(fake.client(), zap.NewNop())
+	tc.Symbols = []string{"ESZ4"}
+
+	symbols, err := tc.GetTradableSymbols(ctx)
+	require.NoError(t, err)
+	require.Len(t, symbols, 1)
+	assert.Equal(t, "TRADOVATE-ESZ4", symbols[0].Symbol)
+	assert.Equal(t, "ES", symbols[0].Instrument.Base)
+	assert.Equal(t, exchanges.FutureProduct, symbols[0].Instrument.Product)
+
+	id, err := tc.PlaceBuyOrderV2(ctx, false, "TRADOVATE-ESZ4", utils.FromString("5000.5"), utils.FromString("4"), "RUN1-ghi", "Limit")
+	require.NoError(t, err)
+	orderID, _ := strconv.ParseInt(id, 10, 64)
+	fake.fill(orderID, 1, 5000.5)
+
+	_, err = tc.PlaceSellOrderV2(ctx, false, "TRADOVATE-ESZ4", nil, utils.FromString("1"), "RUN1-jkl", string(exchanges.MARKET))
+	require.NoError(t, err)
+	assert.Nil(t, fake.placeReqs[1].Price)
+	assert.Equal(t, "Market", fake.placeReqs[1].OrderType)
+
+	open, err := tc.GetOpenOrders(ctx)
+	require.NoError(t, err)
+	require.Len(t, open, 2)
+	assert.Equal(t, id, open[0].ID)
+	assert.Equal(t, "TRADOVATE-ESZ4", open[0].Symbol)
+	assert.Equal(t, exchanges.PartiallyFilledOST, open[0].Status)
+	assert.Equal(t, exchanges.BUY, open[0].OrderSide)
+	assert.Equal(t, "5000.5", open[0].Price.String())
+	assert.Equal(t, "4", open[0].Quantity.String())
+	assert.Equal(t, "1", open[0].ExecutedQty.String())
+	require.NotNil(t, open[0].ClientOrderID)
+	assert.Equal(t, "RUN1-ghi", *open[0].ClientOrderID)
+
+	clOrdID := "RUN1-jkl"
+	orders, err := tc.GetOrders(ctx, exchanges.OrderFilter{ClientOrderID: &clOrdID})
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	assert.Equal(t, exchanges.SELL, orders[0].OrderSide)
+
+	account, err := tc.GetAccount(ctx)
+	require.NoError(t, err)
+	require.Len(t, account.AccountPositions, 1)
+	assert.Equal(t, "TRADOVATE-ESZ4", account.AccountPositions[0].Symbol)
+	assert.Equal(t, "-3", account.AccountPositions[0].Size.String())
+	assert.Equal(t, "Sell", account.AccountPositions[0].Side)
+	require.Len(t, account.AccountBalances, 1)
+	assert.Equal(t, "USD", account.AccountBalances[0].Coin)
+	assert.Equal(t, "50000.5", account.AccountBalances[0].Free.String())
+}
+
+func TestTradovateContractRejectsFractionalQuantity(t *testing.T) {
+	fake := newFakeTradovate(t)
+	tc := NewTradovateContract(fake.client(), zap.NewNop())
+
+	qty, err := tc.RoundQuantity(context.Background(), "TRADOVATE-ESZ4", utils.FromString("2.7"))
+	require.NoError(t, err)
+	assert.Equal(t, "2", qty.String())
+
+	_, err = tc.PlaceBuyOrder(context.Background(), false, "TRADOVATE-ESZ4", utils.FromString("5000"), utils.FromString("2.5"), "RUN1-mno")
+	assert.Error(t, err)
+	assert.Empty(t, fake.placeReqs)
+}
+
+func TestTradovateContractRoundPrice(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeTradovate(t)
+	fake.products = []Product{{Id: 1, Name: "ES", ValuePerPoint: 50, TickSize: 0.25}}
+	fake.maturities = []ContractMaturity{{Id: 20, ProductId: 1, ExpirationMonth: 202412}}
+	fake.contracts = []Contract{{Id: 10, Name: "ESZ4", ContractMaturityId: 20, ProviderTickSize: 0.25}}
+	tc := NewTradovateContract(fake.client(), zap.NewNop())
+
+	for price, expected := range map[string]string{
+		"5000.37": "5000.25",
+		"5000.5":  "5000.5",
+		"5000.24": "5000",
+		"5000":    "5000",
+	} {
+		rounded, err := tc.RoundPrice(ctx, "TRADOVATE-ESZ4", utils.FromString(price), nil)
+		require.NoError(t, err)
+		assert.Equal(t, expected, rounded.Text('f'), price)
+	}
+
+	// Passed tick size is used as is
+	tickSize := "10"
+	rounded, err := tc.RoundPrice(ctx, "TRADOVATE-ESZ4", utils.FromString("5007.75"), &tickSize)
+	require.NoError(t, err)
+	assert.Equal(t, "5000", rounded.Text('f'))
+}
@@ -0,0 +1,386 @@
+package tradovate
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/aulaleslie/trade-exchanges/utils"
+	"github.com/cockroachdb/apd"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+type FillPayload struct {
+	FillID   string
+	OrderID  string
+	Symbol   string
+	Side     exchanges.OrderSide
+	Quantity *apd.Decimal
+	Price    *apd.Decimal
+	Time     time.Time
+}
+
+// Should be one of three
+// In case of first connection no reconnection event should be sent
+type FillEvent struct {
+	DisconnectedWithErr error
+	Reconnected         *struct{}
+	Payload             *FillPayload
+}
+
+// orderTracker keeps the state of account orders to send only changed statuses
+type orderTracker struct {
+	accountID int64
+	ordStatus map[int64]string // By order ID
+	contracts map[int64]int64  // By order ID
+	cumQty    map[int64]int64  // By order ID, from execution reports
+	fillQty   map[int64]int64  // By order ID, sum of fills
+	fills     map[int64]bool   // Known fill IDs
+	statuses  map[int64]exchanges.OrderStatusType
+}
+
+func newOrderTracker(accountID int64) *orderTracker {
+	return &orderTracker{
+		accountID: accountID,
+		ordStatus: map[int64]string{},
+		contracts: map[int64]int64{},
+		cumQty:    map[int64]int64{},
+		fillQty:   map[int64]int64{},
+		fills:     map[int64]bool{},
+		statuses:  map[int64]exchanges.OrderStatusType{},
+	}
+}
+
+// knows reports whether the order belongs to the account
+func (tr *orderTracker) knows(orderID int64) bool {
+	_, ok := tr.ordStatus[orderID]
+	return ok
+}
+
+func (tr *orderTracker) applyOrder(o Order) bool {
+	if o.AccountId != tr.accountID {
+		return false
+	}
+	tr.ordStatus[o.Id] = o.OrdStatus
+	tr.contracts[o.Id] = o.ContractId
+	return true
+}
+
+func (tr *orderTracker) applyReport(r ExecutionReport) bool {
+	if r.AccountId != tr.accountID {
+		return false
+	}
+	if r.OrdStatus != "" {
+		tr.ordStatus[r.OrderId] = r.OrdStatus
+	}
+	tr.contracts[r.OrderId] = r.ContractId
+	if r.CumQty > tr.cumQty[r.OrderId] {
+		tr.cumQty[r.OrderId] = r.CumQty
+	}
+	return true
+}
+
+// applyFill returns false for known fills and fills of other accounts
+func (tr *orderTracker) applyFill(f Fill) bool {
+	if tr.fills[f.Id] || !tr.knows(f.OrderId) {
+		return false
+	}
+	tr.fills[f.Id] = true
+	if f.Active {
+		tr.fillQty[f.OrderId] += f.Qty
+	}
+	return true
+}
+
+// applySnapshot returns new fills of the account
+func (tr *orderTracker) applySnapshot(s *SyncSnapshot) []Fill {
+	for _, o := range s.Orders {
+		tr.applyOrder(o)
+	}
+	for _, r := range s.ExecutionReports {
+		tr.applyReport(r)
+	}
+	fills := []Fill{}
+	for _, f := range s.Fills {
+		if tr.applyFill(f) {
+			fills = append(fills, f)
+		}
+	}
+	return fills
+}
+
+// update returns the status of the order if it is changed
+func (tr *orderTracker) update(orderID int64) (exchanges.OrderStatusType, bool) {
+	ordStatus, ok := tr.ordStatus[orderID]
+	if !ok {
+		return exchanges.UnknownOST, false
+	}
+	filled := tr.fillQty[orderID]
+	if tr.cumQty[orderID] > filled {
+		filled = tr.cumQty[orderID]
+	}
+	status := orderStatus(ordStatus, filled)
+	if last, ok := tr.statuses[orderID]; ok && last == status {
+		return status, false
+	}
+	tr.statuses[orderID] = status
+	return status, true
+}
+
+// updateAll remembers statuses of all orders
+func (tr *orderTracker) updateAll() {
+	for id := range tr.ordStatus {
+		tr.update(id)
+	}
+}
+
+func (t *TradovateContract) getUserSync() *UserSyncClient {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.userSync == nil {
+		// Trading socket has the same host as REST API
+		host := strings.Replace(t.client.HttpHost, "http", "ws", 1)
+		t.userSync = NewUserSyncClient(host, t.client.Tokens(), t.lg)
+	}
+	return t.userSync
+}
+
+// watchUser returns user events and ID of the traded account
+func (t *TradovateContract) watchUser(ctx context.Context) (<-chan UserSyncEvent, int64, error) {
+	account, err := t.getAccount(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	events, err := t.getUserSync().Subscribe(ctx)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "can't subscribe to user events")
+	}
+	return events, account.Id, nil
+}
+
+func (t *TradovateContract) WatchOrdersStatuses(ctx context.Context) (<-chan exchanges.OrderEvent, error) {
+	events, accountID, err := t.watchUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan exchanges.OrderEvent, 100) // TODO: move to config
+	go func() {
+		defer close(out)
+		send := func(ev exchanges.OrderEvent) bool {
+			select {
+			case out <- ev:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		tracker := newOrderTracker(accountID)
+		sendStatus := func(orderID int64) bool {
+			status, changed := tracker.update(orderID)
+			if !changed {
+				return true
+			}
+			payload := &exchanges.OrderEventPayload{
+				OrderID:     strconv.FormatInt(orderID, 10),
+				OrderStatus: status,
+			}
+			if symbol, err := t.contractSymbol(ctx, tracker.contracts[orderID]); err == nil {
+				payload.Symbol = &symbol
+			} else {
+				t.lg.Warn("Can't get symbol of order", zap.Int64("orderID", orderID), zap.Error(err))
+			}
+			return send(exchanges.OrderEvent{Payload: payload})
+		}
+
+		first := true
+		for ev := range events {
+			ok := true
+			switch {
+			case ev.DisconnectedWithErr != nil:
+				send(exchanges.OrderEvent{DisconnectedWithErr: ev.DisconnectedWithErr})
+				return
+			case ev.Reconnected != nil:
+				ok = send(exchanges.OrderEvent{Reconnected: ev.Reconnected})
+			case ev.Snapshot != nil:
+				tracker.applySnapshot(ev.Snapshot)
+				if first {
+					first = false
+					tracker.updateAll() // Only changes are sent
+					break
+				}
+				for id := range tracker.ordStatus {
+					if ok = sendStatus(id); !ok {
+						break
+					}
+				}
+			case ev.Order != nil:
+				if tracker.applyOrder(*ev.Order) {
+					ok = sendStatus(ev.Order.Id)
+				}
+			case ev.ExecutionReport != nil:
+				if tracker.applyReport(*ev.ExecutionReport) {
+					ok = sendStatus(ev.ExecutionReport.OrderId)
+				}
+			case ev.Fill != nil:
+				if tracker.applyFill(*ev.Fill) {
+					ok = sendStatus(ev.Fill.OrderId)
+				}
+			}
+			if !ok {
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// WatchFills returns trades of the account orders until `ctx` is done.
+// Fills missed during disconnection are sent after Reconnected.
+func (t *TradovateContract) WatchFills(ctx context.Context) (<-chan FillEvent, error) {
+	events, accountID, err := t.watchUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan FillEvent, 100) // TODO: move to config
+	go func() {
+		defer close(out)
+		send := func(ev FillEvent) bool {
+			select {
+			case out <- ev:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		sendFill := func(f Fill) bool {
+			symbol, err := t.contractSymbol(ctx, f.ContractId)
+			if err != nil {
+				t.lg.Warn("Can't get symbol of fill", zap.Int64("fillID", f.Id), zap.Error(err))
+			}
+			return send(FillEvent{Payload: &FillPayload{
+				FillID:   strconv.FormatInt(f.Id, 10),
+				OrderID:  strconv.FormatInt(f.OrderId, 10),
+				Symbol:   symbol,
+				Side:     mapOrderSide(f.Action),
+				Quantity: apd.New(f.Qty, 0),
+				Price:    utils.FromFloat64(f.Price),
+				Time:     f.Timestamp,
+			}})
+		}
+
+		tracker := newOrderTracker(accountID)
+		first := true
+		for ev := range events {
+			switch {
+			case ev.DisconnectedWithErr != nil:
+				send(FillEvent{DisconnectedWithErr: ev.DisconnectedWithErr})
+				return
+			case ev.Reconnected != nil:
+				if !send(FillEvent{Reconnected: ev.Reconnected}) {
+					return
+				}
+			case ev.Snapshot != nil:
+				fills := tracker.applySnapshot(ev.Snapshot)
+				if first {
+					first = false
+					continue
+				}
+				for _, f := range fills {
+					if !sendFill(f) {
+						return
+					}
+				}
+			case ev.Order != nil:
+				tracker.applyOrder(*ev.Order)
+			case ev.ExecutionReport != nil:
+				tracker.applyReport(*ev.ExecutionReport)
+			case ev.Fill != nil:
+				if tracker.applyFill(*ev.Fill) && !sendFill(*ev.Fill) {
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (t *TradovateContract) positionPayload(ctx context.Context, p Position) (*exchanges.PositionPayload, error) {
+	symbol, err := t.contractSymbol(ctx, p.ContractId)
+	if err != nil {
+		return nil, err
+	}
+	return &exchanges.PositionPayload{Symbol: symbol, Value: apd.New(p.NetPos, 0)}, nil
+}
+
+// WatchAccountPositions sends net positions of contracts, negative for short.
+// All positions are sent as snapshot after Reconnected.
+func (t *TradovateContract) WatchAccountPositions(ctx context.Context) (<-chan exchanges.PositionEvent, error) {
+	events, accountID, err := t.watchUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan exchanges.PositionEvent, 100) // TODO: move to config
+	go func() {
+		defer close(out)
+		send := func(ev exchanges.PositionEvent) bool {
+			select {
+			case out <- ev:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		first := true
+		for ev := range events {
+			switch {
+			case ev.DisconnectedWithErr != nil:
+				send(exchanges.PositionEvent{DisconnectedWithErr: ev.DisconnectedWithErr})
+				return
+			case ev.Reconnected != nil:
+				if !send(exchanges.PositionEvent{Reconnected: ev.Reconnected}) {
+					return
+				}
+			case ev.Snapshot != nil:
+				if first {
+					first = false
+					continue
+				}
+				payload := []*exchanges.PositionPayload{}
+				for _, p := range ev.Snapshot.Positions {
+					if p.AccountId != accountID || p.NetPos == 0 {
+						continue
+					}
+					pp, err := t.positionPayload(ctx, p)
+					if err != nil {
+						t.lg.Warn("Can't get symbol of position", zap.Int64("contractID", p.ContractId), zap.Error(err))
+						continue
+					}
+					payload = append(payload, pp)
+				}
+				if !send(exchanges.PositionEvent{Payload: payload, Snapshot: true}) {
+					return
+				}
+			case ev.Position != nil:
+				if ev.Position.AccountId != accountID {
+					continue
+				}
+				pp, err := t.positionPayload(ctx, *ev.Position)
+				if err != nil {
+					t.lg.Warn("Can't get symbol of position", zap.Int64("contractID", ev.Position.ContractId), zap.Error(err))
+					continue
+				}
+				if !send(exchanges.PositionEvent{Payload: []*exchanges.PositionPayload{pp}}) {
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
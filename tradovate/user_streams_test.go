@@ -0,0 +1,227 @@
+package tradovate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	exchanges "github.com/aulaleslie/trade-exchanges"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func nextOrderEvent(t *testing.T, events <-chan exchanges.OrderEvent) exchanges.OrderEvent {
+	t.Helper()
+	select {
+	case ev, ok := <-events:
+		require.True(t, ok, "channel is closed")
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("no order event")
+		return exchanges.OrderEvent{}
+	}
+}
+
+func TestTradovateContractWatchOrdersStatuses(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fake := newFakeTradovate(t)
+	fake.orders = []Order{{Id: 200, AccountId: 1, ContractId: 10, Action: "Buy", OrdStatus: "Working"}}
+	tc := NewTradovateContract(fake.client(), zap.NewNop())
+	us := tc.getUserSync()
+	us.ReconnectDelay = 10 * time.Millisecond
+	defer us.Close()
+
+	events, err := tc.WatchOrdersStatuses(ctx)
+	require.NoError(t, err)
+
+	// Orders of the snapshot aren't sent, only changes
+	order := Order{Id: 201, AccountId: 1, ContractId: 10, Action: "Sell", OrdStatus: "Working"}
+	fake.pushProps("order", "Created", order)
+	ev := nextOrderEvent(t, events)
+	require.NotNil(t, ev.Payload)
+	assert.Equal(t, "201", ev.Payload.OrderID)
+	assert.Equal(t, exchanges.NewOST, ev.Payload.OrderStatus)
+	require.NotNil(t, ev.Payload.Symbol)
+	assert.Equal(t, "TRADOVATE-ESZ4", *ev.Payload.Symbol)
+
+	// Other accounts and repeated statuses are skipped
+	fake.pushProps("order", "Created", Order{Id: 300, AccountId: 2, ContractId: 10, OrdStatus: "Working"})
+	fake.pushProps("order", "Updated", order)
+	fake.pushProps("fill", "Created", Fill{Id: 501, OrderId: 201, ContractId: 10, Qty: 1, Price: 5000, Active: true})
+	ev = nextOrderEvent(t, events)
+	require.NotNil(t, ev.Payload)
+	assert.Equal(t, "201", ev.Payload.OrderID)
+	assert.Equal(t, exchanges.PartiallyFilledOST, ev.Payload.OrderStatus)
+
+	fake.pushProps("executionReport", "Created", ExecutionReport{
+		Id: 601, AccountId: 1, ContractId: 10, OrderId: 201, ExecType: "Trade", OrdStatus: "Filled", CumQty: 2,
+	})
+	ev = nextOrderEvent(t, events)
+	require.NotNil(t, ev.Payload)
+	assert.Equal(t, exchanges.FilledOST, ev.Payload.OrderStatus)
+
+	// Changes missed during disconnection are found in the new snapshot
+	fake.mu.Lock()
+	fake.orders[0].OrdStatus = "Canceled"
+	fake.mu.Unlock()
+	fake.dropWS()
+
+	ev = nextOrderEvent(t, events)
+	assert.NotNil(t, ev.Reconnected)
+	ev = nextOrderEvent(t, events)
+	require.NotNil(t, ev.Payload)
+	assert.Equal(t, "200", ev.Payload.OrderID)
+	assert.Equal(t, exchanges.CanceledOST, ev.Payload.OrderStatus)
+}
+
+func TestTradovateContractWatchPositionsAndFills(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fake := newFakeTradovate(t)
+	fake.orders = []Order{{Id: 200, AccountId: 1, ContractId: 10, Action: "Buy", OrdStatus: "Working"}}
+	tc := NewTradovateContract(fake.client(), zap.NewNop())
+	defer tc.getUserSync().Close()
+
+	positions, err := tc.WatchAccountPositions(ctx)
+	require.NoError(t, err)
+	fills, err := tc.WatchFills(ctx)
+	require.NoError(t, err)
+
+	fake.pushProps("fill", "Created", Fill{
+		Id: 501, OrderId: 200, ContractId: 10, Action: "Buy", Qty: 2, Price: 5000.25, Active: true,
+	})
+	fake.pushProps("position", "Updated", Position{Id: 700, AccountId: 2, ContractId: 10, NetPos: 5})
+	fake.pushProps("position", "Updated", Position{Id: 701, AccountId: 1, ContractId: 10, NetPos: -2})
+
+	select {
+	case ev := <-fills:
+		require.NotNil(t, ev.Payload)
+		assert.Equal(t, "501", ev.Payload.FillID)
+		assert.Equal(t, "200", ev.Payload.OrderID)
+		assert.Equal(t, "TRADOVATE-ESZ4", ev.Payload.Symbol)
+		assert.Equal(t, exchanges.BUY, ev.Payload.Side)
+		assert.Equal(t, "2", ev.Payload.Quantity.String())
+		assert.Equal(t, "5000.25", ev.Payload.Price.String())
+	case <-time.After(5 * time.Second):
+		t.Fatal("no fill event")
+	}
+
+	select {
+	case ev := <-positions:
+		require.Len(t, ev.Payload, 1)
+		assert.Equal(t, "TRADOVATE-ESZ4", ev.Payload[0].Symbol)
+		assert.Equal(t, "-2", ev.Payload[0].Value.String())
+	case <-time.After(5 * time.Second):
+		t.Fatal("no position event")
+	}
+}
+
+func TestUserSyncSubscribeWithLargeBacklog(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fake := newFakeTradovate(t)
+	// More events than the channel can hold are received before the snapshot
+	for i := 0; i < 150; i++ {
+		fake.syncProps = append(fake.syncProps, Order{Id: int64(1000 + i), AccountId: 1, ContractId: 10, OrdStatus: "Working"})
+	}
+	tc := NewTradovateContract(fake.client(), zap.NewNop())
+	us := tc.getUserSync()
+	defer us.Close()
+
+	subscribed := make(chan (<-chan UserSyncEvent), 1)
+	go func() {
+		events, err := us.Subscribe(ctx)
+		assert.NoError(t, err)
+		subscribed <- events
+	}()
+	var events <-chan UserSyncEvent
+	select {
+	case events = <-subscribed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscription is blocked")
+	}
+
+	ev := <-events
+	require.NotNil(t, ev.Snapshot)
+	for i := 0; i < 150; i++ {
+		select {
+		case ev = <-events:
+		case <-time.After(5 * time.Second):
+			t.Fatal("no user event")
+		}
+		require.NotNil(t, ev.Order)
+		assert.Equal(t, int64(1000+i), ev.Order.Id)
+	}
+}
+
+func TestUserSyncStreamsCashBalances(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fake := newFakeTradovate(t)
+	fake.balances = []CashBalance{{Id: 50, AccountId: 1, CurrencyId: 1, Amount: 1000}}
+	us := NewTradovateContract(fake.client(), zap.NewNop()).getUserSync()
+	defer us.Close()
+
+	events, err := us.Subscribe(ctx)
+	require.NoError(t, err)
+	ev := <-events
+	require.NotNil(t, ev.Snapshot)
+	assert.Equal(t, fake.balances, ev.Snapshot.CashBalances)
+
+	fake.pushProps("cashBalance", "Updated", CashBalance{Id: 50, AccountId: 1, CurrencyId: 1, Amount: 1250.5})
+	select {
+	case ev = <-events:
+		require.NotNil(t, ev.CashBalance)
+		assert.Equal(t, "Updated", ev.EventType)
+		assert.Equal(t, 1250.5, ev.CashBalance.Amount)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no balance event")
+	}
+}
+
+func TestUserSyncDropsSlowSubscriber(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fake := newFakeTradovate(t)
+	us := NewTradovateContract(fake.client(), zap.NewNop()).getUserSync()
+	defer us.Close()
+
+	slow, err := us.Subscribe(ctx)
+	require.NoError(t, err)
+	fast, err := us.Subscribe(ctx)
+	require.NoError(t, err)
+
+	next := func() UserSyncEvent {
+		select {
+		case ev, ok := <-fast:
+			require.True(t, ok, "channel is closed")
+			return ev
+		case <-time.After(5 * time.Second):
+			t.Fatal("no user event")
+			return UserSyncEvent{}
+		}
+	}
+	require.NotNil(t, next().Snapshot)
+	for batch := 0; batch < 3; batch++ {
+		for i := 0; i < 50; i++ {
+			fake.pushProps("order", "Updated", Order{Id: int64(batch*50 + i), AccountId: 1, ContractId: 10, OrdStatus: "Working"})
+		}
+		for i := 0; i < 50; i++ {
+			ev := next()
+			require.NotNil(t, ev.Order)
+			assert.Equal(t, int64(batch*50+i), ev.Order.Id)
+		}
+	}
+
+	// The slow subscriber is dropped with the error instead of blocking the socket
+	var last UserSyncEvent
+	n := 0
+	for ev := range slow {
+		last = ev
+		n++
+	}
+	assert.Equal(t, 101, n) // Snapshot, held events and the error
+	assert.ErrorIs(t, last.DisconnectedWithErr, ErrUserSyncOverflow)
+}
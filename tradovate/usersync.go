@@ -0,0 +1,356 @@
+package tradovate
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// SyncSnapshot is the state of the user returned by user/syncrequest
+type SyncSnapshot struct {
+	Accounts         []Account         `json:"accounts"`
+	Orders           []Order           `json:"orders"`
+	ExecutionReports []ExecutionReport `json:"executionReports"`
+	Fills            []Fill            `json:"fills"`
+	Positions        []Position        `json:"positions"`
+	CashBalances     []CashBalance     `json:"cashBalances"`
+}
+
+// propsEvent is a change of the entity https://api.tradovate.com/#section/Subscriptions
+type propsEvent struct {
+	EntityType string          `json:"entityType"`
+	EventType  string          `json:"eventType"` // Created, Updated, Deleted
+	Entity     json.RawMessage `json:"entity"`
+}
+
+// Should be one of DisconnectedWithErr, Reconnected, Snapshot or an entity.
+// Snapshot is sent after subscription and after every reconnection.
+type UserSyncEvent struct {
+	DisconnectedWithErr error
+	Reconnected         *struct{}
+	Snapshot            *SyncSnapshot
+
+	EventType       string // Created, Updated or Deleted entity
+	Order           *Order
+	ExecutionReport *ExecutionReport
+	Fill            *Fill
+	Position        *Position
+	CashBalance     *CashBalance
+}
+
+// decodeProps returns false for not supported entities
+func decodeProps(data []byte) (UserSyncEvent, bool, error) {
+	props := propsEvent{}
+	if err := json.Unmarshal(data, &props); err != nil {
+		return UserSyncEvent{}, false, errors.Wrap(err, "can't unmarshal props event")
+	}
+
+	ev := UserSyncEvent{EventType: props.EventType}
+	var entity interface{}
+	switch props.EntityType {
+	case "order":
+		ev.Order = &Order{}
+		entity = ev.Order
+	case "executionReport":
+		ev.ExecutionReport = &ExecutionReport{}
+		entity = ev.ExecutionReport
+	case "fill":
+		ev.Fill = &Fill{}
+		entity = ev.Fill
+	case "position":
+		ev.Position = &Position{}
+		entity = ev.Position
+	case "cashBalance":
+		ev.CashBalance = &CashBalance{}
+		entity = ev.CashBalance
+	default:
+		return ev, false, nil
+	}
+	if err := json.Unmarshal(props.Entity, entity); err != nil {
+		return ev, false, errors.Wrapf(err, "can't unmarshal %s", props.EntityType)
+	}
+	return ev, true, nil
+}
+
+// ErrUserSyncOverflow is sent to the subscriber which doesn't read events fast enough
+var ErrUserSyncOverflow = errors.New("user sync subscriber is too slow, events are dropped")
+
+// syncWatcher holds events until the snapshot and the held events are sent to keep the order
+type syncWatcher struct {
+	ctx     context.Context
+	out     chan UserSyncEvent
+	synced  bool            // Guarded by UserSyncClient.mu
+	backlog []UserSyncEvent // Guarded by UserSyncClient.mu
+	gen     int             // Snapshot generation, guarded by UserSyncClient.mu
+
+	flushMu sync.Mutex // Keeps order of snapshots
+	mu      sync.Mutex
+	closed  bool
+}
+
+// send never blocks the socket reader: the watcher is finished by ErrUserSyncOverflow
+// if the channel is full. It returns true in this case.
+func (w *syncWatcher) send(ev UserSyncEvent) (overflow bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return false
+	}
+	select {
+	case w.out <- ev:
+		return false
+	default:
+	}
+	// The channel can be filled by the snapshot, so the error is sent after held events
+	w.closed = true
+	go func() {
+		select {
+		case w.out <- UserSyncEvent{DisconnectedWithErr: ErrUserSyncOverflow}:
+		case <-w.ctx.Done():
+		}
+		close(w.out)
+	}()
+	return true
+}
+
+// sendWait waits for the subscriber, it returns false if the watcher is finished
+func (w *syncWatcher) sendWait(ev UserSyncEvent) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return false
+	}
+	select {
+	case w.out <- ev:
+		return true
+	case <-w.ctx.Done():
+		return false
+	}
+}
+
+// finish sends `last` if it isn't nil and closes the channel
+func (w *syncWatcher) finish(last *UserSyncEvent) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	w.closed = true
+	if last != nil {
+		select {
+		case w.out <- *last:
+		case <-w.ctx.Done():
+		}
+	}
+	close(w.out)
+}
+
+// UserSyncClient streams changes of orders, fills, positions and balances of the user.
+// The socket is reconnected on failure and the snapshot is requested again.
+type UserSyncClient struct {
+	SocketOptions
+
+	session *session
+	lg      *zap.Logger
+
+	mu       sync.Mutex
+	watchers map[*syncWatcher]struct{}
+}
+
+// `host` is like "wss://live.tradovateapi.com/v1"
+func NewUserSyncClient(host string, tokens *TokenManager, lg *zap.Logger) *UserSyncClient {
+	c := &UserSyncClient{
+		SocketOptions: defaultSocketOptions(),
+		lg:            lg.Named("UserSync"),
+		watchers:      map[*syncWatcher]struct{}{},
+	}
+	c.session = newSession(host+CONNECT_WEBSOCKET, tokens, &c.SocketOptions, c.lg)
+	c.session.onEvent = c.dispatch
+	c.session.active = c.active
+	c.session.restore = c.restore
+	c.session.onFailed = c.failAll
+	return c
+}
+
+// Subscribe returns the snapshot followed by changes until `ctx` is done
+func (c *UserSyncClient) Subscribe(ctx context.Context) (<-chan UserSyncEvent, error) {
+	conn, err := c.session.connection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &syncWatcher{ctx: ctx, out: make(chan UserSyncEvent, 100)} // TODO: move to config
+	c.mu.Lock()
+	c.watchers[w] = struct{}{}
+	gen := w.gen
+	c.mu.Unlock()
+
+	snapshot, err := c.sync(ctx, conn)
+	if err != nil {
+		c.remove(w)
+		return nil, err
+	}
+	go func() {
+		// The channel is returned first: the snapshot and held events can overflow it
+		c.flush(w, gen, nil, snapshot)
+	}()
+	go func() {
+		<-ctx.Done()
+		c.remove(w)
+	}()
+	return w.out, nil
+}
+
+func (c *UserSyncClient) remove(w *syncWatcher) {
+	c.mu.Lock()
+	delete(c.watchers, w)
+	c.mu.Unlock()
+	w.finish(nil)
+}
+
+func (c *UserSyncClient) sync(ctx context.Context, conn *socket) (*SyncSnapshot, error) {
+	token, err := c.session.tokens.Token(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't get access token")
+	}
+	body, err := json.Marshal(map[string][]int64{"users": {token.UserId}})
+	if err != nil {
+		return nil, errors.Wrap(err, "can't marshal sync request")
+	}
+
+	resp, err := conn.request(ctx, SYNC_USER, "", string(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "can't sync user")
+	}
+	snapshot := &SyncSnapshot{}
+	if err := json.Unmarshal(resp.Data, snapshot); err != nil {
+		return nil, errors.Wrap(err, "can't unmarshal sync response")
+	}
+	return snapshot, nil
+}
+
+// flush sends `first` event and the snapshot followed by held events.
+// The watcher isn't synced until all held events are sent, the socket reader holds
+// new events meanwhile. Flushing of `gen` stops if a newer snapshot is requested.
+func (c *UserSyncClient) flush(w *syncWatcher, gen int, first *UserSyncEvent, snapshot *SyncSnapshot) {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	events := make([]UserSyncEvent, 0, 2)
+	if first != nil {
+		events = append(events, *first)
+	}
+	events = append(events, UserSyncEvent{Snapshot: snapshot})
+	for {
+		for _, ev := range events {
+			if !w.sendWait(ev) {
+				return
+			}
+		}
+
+		c.mu.Lock()
+		if _, ok := c.watchers[w]; !ok || w.gen != gen {
+			c.mu.Unlock()
+			return
+		}
+		events = w.backlog
+		w.backlog = nil
+		if len(events) == 0 {
+			w.synced = true
+			c.mu.Unlock()
+			return
+		}
+		c.mu.Unlock()
+	}
+}
+
+func (c *UserSyncClient) dispatch(m Message) {
+	if m.EventType != "props" {
+		return
+	}
+	ev, ok, err := decodeProps(m.Data)
+	if err != nil {
+		c.lg.Warn("Can't decode user event", zap.ByteString("data", m.Data), zap.Error(err))
+		return
+	}
+	if !ok {
+		return
+	}
+
+	c.mu.Lock()
+	watchers := make([]*syncWatcher, 0, len(c.watchers))
+	for w := range c.watchers {
+		if w.synced {
+			watchers = append(watchers, w)
+		} else {
+			w.backlog = append(w.backlog, ev)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, w := range watchers {
+		if w.send(ev) {
+			c.lg.Warn("Subscriber is too slow, dropping it")
+			c.remove(w)
+		}
+	}
+}
+
+// active reports whether the socket should be reconnected
+func (c *UserSyncClient) active() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.watchers) > 0
+}
+
+// restore requests the snapshot again, events of the old socket are replaced by it
+func (c *UserSyncClient) restore(ctx context.Context, conn *socket) error {
+	c.mu.Lock()
+	watchers := make(map[*syncWatcher]int, len(c.watchers))
+	for w := range c.watchers {
+		w.synced = false
+		w.backlog = nil
+		w.gen++
+		watchers[w] = w.gen
+	}
+	c.mu.Unlock()
+
+	snapshot, err := c.sync(ctx, conn)
+	if err != nil {
+		return err
+	}
+	// Slow subscribers don't delay the reconnection
+	for w, gen := range watchers {
+		go c.flush(w, gen, &UserSyncEvent{Reconnected: &struct{}{}}, snapshot)
+	}
+	return nil
+}
+
+func (c *UserSyncClient) failAll(err error) {
+	c.mu.Lock()
+	watchers := c.watchers
+	c.watchers = map[*syncWatcher]struct{}{}
+	c.mu.Unlock()
+
+	for w := range watchers {
+		w.finish(&UserSyncEvent{DisconnectedWithErr: err})
+	}
+}
+
+// Close closes the socket and all subscriptions
+func (c *UserSyncClient) Close() error {
+	err := c.session.close()
+
+	c.mu.Lock()
+	watchers := c.watchers
+	c.watchers = map[*syncWatcher]struct{}{}
+	c.mu.Unlock()
+
+	for w := range watchers {
+		w.finish(nil)
+	}
+	return err
+}
@@ -9,6 +9,7 @@ import (
 	// "github.com/sirupsen/logrus"
 )
 
+// Deprecated: use SlidingWindowRateLimiter, Wait blocks other callers and can't be cancelled.
 type MinuteRateLimiter struct {
 	maxPerMinute        int
 	lock                *sync.Mutex
@@ -67,6 +68,7 @@ type RemainingForMinute struct {
 	minute    time.Time
 }
 
+// Deprecated: use SlidingWindowRateLimiter.
 type ChangeableMinuteRateLimiter struct {
 	maxPerMinute int
 
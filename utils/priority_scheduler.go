@@ -0,0 +1,241 @@
+package utils
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Priority of the request to the exchange. Lower value means higher priority.
+type Priority int
+
+const (
+	CancelPriority Priority = iota
+	PlacePriority           // Place and amend
+	AccountReadPriority
+	MarketDataPriority
+
+	PriorityCount = int(MarketDataPriority) + 1
+)
+
+func (p Priority) String() string {
+	switch p {
+	case CancelPriority:
+		return "cancel"
+	case PlacePriority:
+		return "place"
+	case AccountReadPriority:
+		return "account"
+	case MarketDataPriority:
+		return "market"
+	default:
+		return "unknown"
+	}
+}
+
+type priorityCtxKey struct{}
+
+// WithPriority marks requests made with the context by `p`
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityCtxKey{}, p)
+}
+
+func PriorityFromContext(ctx context.Context) (Priority, bool) {
+	p, ok := ctx.Value(priorityCtxKey{}).(Priority)
+	if !ok || p < 0 || int(p) >= PriorityCount {
+		return 0, false
+	}
+	return p, true
+}
+
+var ErrQueueFull = errors.New("rate limiter queue is full")
+
+// TryFunc tries to take the capacity leaving `floor` of it untouched. It returns zero
+// on success or how long to wait before the next try. Error fails the request.
+type TryFunc func(floor int) (time.Duration, error)
+
+type PrioritySchedulerConfig struct {
+	// Max count of waiting requests per priority
+	QueueSize int
+	// Capacity which can't be used by the priority. It should be growing with
+	// lowering priority, so that reads never take the last capacity from cancels.
+	Reserve [PriorityCount]int
+	// Used for contexts without priority
+	DefaultPriority Priority
+}
+
+// DefaultPrioritySchedulerConfig reserves 5% of capacity for cancels,
+// 10% for cancels and placement and 20% for everything but market data.
+func DefaultPrioritySchedulerConfig(capacity int) PrioritySchedulerConfig {
+	return PrioritySchedulerConfig{
+		QueueSize: 100, // TODO: move to config
+		Reserve: [PriorityCount]int{
+			CancelPriority:      0,
+			PlacePriority:       capacity / 20,
+			AccountReadPriority: capacity / 10,
+			MarketDataPriority:  capacity / 5,
+		},
+		DefaultPriority: AccountReadPriority,
+	}
+}
+
+type PriorityStats struct {
+	QueueDepth int
+	Served     int
+	Rejected   int // Because of full queue
+	TotalWait  time.Duration
+	MaxWait    time.Duration
+}
+
+func (s PriorityStats) AvgWait() time.Duration {
+	if s.Served == 0 {
+		return 0
+	}
+	return s.TotalWait / time.Duration(s.Served)
+}
+
+type scheduledRequest struct {
+	ctx      context.Context
+	try      TryFunc
+	enqueued time.Time
+
+	done chan struct{}
+	err  error
+}
+
+// PriorityScheduler orders requests to a rate limiter by priority.
+// Every priority has own FIFO queue. Heads of queues are tried from the highest priority,
+// so a lower priority request passes only when there is capacity above its reserve.
+type PriorityScheduler struct {
+	mu     sync.Mutex
+	cfg    PrioritySchedulerConfig
+	queues [PriorityCount][]*scheduledRequest
+	stats  [PriorityCount]PriorityStats
+	timer  *time.Timer
+
+	now func() time.Time
+}
+
+func NewPriorityScheduler(cfg PrioritySchedulerConfig) *PriorityScheduler {
+	return &PriorityScheduler{cfg: cfg, now: time.Now}
+}
+
+// Do blocks until `try` succeeds. Priority is taken from the context.
+func (s *PriorityScheduler) Do(ctx context.Context, try TryFunc) error {
+	p, ok := PriorityFromContext(ctx)
+	if !ok {
+		p = s.cfg.DefaultPriority
+	}
+
+	req := &scheduledRequest{ctx: ctx, try: try, done: make(chan struct{})}
+	s.mu.Lock()
+	if s.cfg.QueueSize > 0 && len(s.queues[p]) >= s.cfg.QueueSize {
+		s.stats[p].Rejected++
+		s.mu.Unlock()
+		return errors.Wrapf(ErrQueueFull, "priority %v", p)
+	}
+	req.enqueued = s.now()
+	s.queues[p] = append(s.queues[p], req)
+	s.dispatchLocked()
+	s.mu.Unlock()
+
+	select {
+	case <-req.done:
+		return req.err
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-req.done: // Finished concurrently
+		return req.err
+	default:
+	}
+	s.remove(p, req)
+	s.dispatchLocked()
+	return ctx.Err()
+}
+
+// WaitLimiter is Do for SlidingWindowRateLimiter
+func (s *PriorityScheduler) WaitLimiter(ctx context.Context, lim *SlidingWindowRateLimiter, cost int) error {
+	if cost > lim.Capacity() {
+		return errors.Errorf("cost %d is more than capacity %d", cost, lim.Capacity())
+	}
+	return s.Do(ctx, func(floor int) (time.Duration, error) {
+		return lim.TryReserve(cost, floor), nil
+	})
+}
+
+func (s *PriorityScheduler) Stats() map[Priority]PriorityStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res := make(map[Priority]PriorityStats, PriorityCount)
+	for i := range s.stats {
+		st := s.stats[i]
+		st.QueueDepth = len(s.queues[i])
+		res[Priority(i)] = st
+	}
+	return res
+}
+
+func (s *PriorityScheduler) remove(p Priority, req *scheduledRequest) {
+	q := s.queues[p]
+	for i, r := range q {
+		if r == req {
+			s.queues[p] = append(q[:i:i], q[i+1:]...)
+			return
+		}
+	}
+}
+
+func (s *PriorityScheduler) dispatch() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dispatchLocked()
+}
+
+func (s *PriorityScheduler) dispatchLocked() {
+	var nextTry time.Duration
+	for p := range s.queues {
+		for len(s.queues[p]) > 0 {
+			req := s.queues[p][0]
+			wait, err := req.try(s.cfg.Reserve[p])
+			if err == nil && wait > 0 {
+				if deadline, ok := req.ctx.Deadline(); ok && wait > time.Until(deadline) {
+					err = errors.Wrapf(ErrWaitExceedsDeadline, "wait %v", wait)
+				}
+			}
+			if err == nil && wait > 0 {
+				if nextTry == 0 || wait < nextTry {
+					nextTry = wait
+				}
+				break
+			}
+
+			s.queues[p] = s.queues[p][1:]
+			if err == nil {
+				waited := s.now().Sub(req.enqueued)
+				st := &s.stats[p]
+				st.Served++
+				st.TotalWait += waited
+				if waited > st.MaxWait {
+					st.MaxWait = waited
+				}
+			}
+			req.err = err
+			close(req.done)
+		}
+	}
+
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if nextTry > 0 {
+		s.timer = time.AfterFunc(nextTry, s.dispatch)
+	}
+}
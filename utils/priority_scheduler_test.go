@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrioritySchedulerCancelGoesFirst(t *testing.T) {
+	lim := NewSlidingWindowRateLimiter(2, 100*time.Millisecond)
+	s := NewPriorityScheduler(PrioritySchedulerConfig{
+		QueueSize: 1,
+		Reserve:   [PriorityCount]int{0, 0, 1, 1},
+	})
+	cancelCtx := WithPriority(context.Background(), CancelPriority)
+	readCtx := WithPriority(context.Background(), MarketDataPriority)
+
+	assert.NoError(t, s.WaitLimiter(cancelCtx, lim, 2))
+
+	order := make(chan Priority, 2)
+	go func() {
+		assert.NoError(t, s.WaitLimiter(readCtx, lim, 1))
+		order <- MarketDataPriority
+	}()
+	time.Sleep(10 * time.Millisecond)
+	go func() {
+		assert.NoError(t, s.WaitLimiter(cancelCtx, lim, 1))
+		order <- CancelPriority
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	assert.Equal(t, 1, s.Stats()[MarketDataPriority].QueueDepth)
+	err := s.WaitLimiter(readCtx, lim, 1)
+	assert.True(t, errors.Is(err, ErrQueueFull))
+
+	assert.Equal(t, CancelPriority, <-order)
+	assert.Equal(t, MarketDataPriority, <-order)
+
+	stats := s.Stats()
+	assert.Equal(t, 2, stats[CancelPriority].Served)
+	assert.Equal(t, 1, stats[MarketDataPriority].Served)
+	assert.Equal(t, 1, stats[MarketDataPriority].Rejected)
+	assert.True(t, stats[MarketDataPriority].MaxWait > stats[CancelPriority].MaxWait)
+}
+
+func TestPrioritySchedulerDeadline(t *testing.T) {
+	lim := NewSlidingWindowRateLimiter(1, time.Minute)
+	s := NewPriorityScheduler(DefaultPrioritySchedulerConfig(1))
+	assert.NoError(t, s.WaitLimiter(context.Background(), lim, 1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := s.WaitLimiter(ctx, lim, 1)
+	assert.True(t, errors.Is(err, ErrWaitExceedsDeadline))
+	assert.Equal(t, 0, s.Stats()[AccountReadPriority].QueueDepth)
+}
+
+func TestDefaultPrioritySchedulerConfigReservesGrow(t *testing.T) {
+	cfg := DefaultPrioritySchedulerConfig(1200)
+	for p := 1; p < PriorityCount; p++ {
+		assert.Greater(t, cfg.Reserve[p], cfg.Reserve[p-1], Priority(p).String())
+	}
+}
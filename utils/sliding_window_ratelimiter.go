@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrWaitExceedsDeadline = errors.New("rate limiter wait exceeds context deadline")
+
+type costEntry struct {
+	at   time.Time
+	cost int
+}
+
+// SlidingWindowRateLimiter allows `capacity` units of cost during any `window` long interval.
+// Unlike MinuteRateLimiter it doesn't allow double bursts at the minute boundary,
+// waits outside of the lock and can be cancelled by context.
+//
+// Server feedback is applied with SetRemaining and SetRetryAfter.
+type SlidingWindowRateLimiter struct {
+	mu           sync.Mutex
+	capacity     int
+	window       time.Duration
+	entries      []costEntry // Sorted by time
+	used         int         // Sum of entries
+	blockedUntil time.Time
+
+	now func() time.Time
+}
+
+func NewSlidingWindowRateLimiter(capacity int, window time.Duration) *SlidingWindowRateLimiter {
+	return &SlidingWindowRateLimiter{
+		capacity: capacity,
+		window:   window,
+		now:      time.Now,
+	}
+}
+
+func (r *SlidingWindowRateLimiter) Capacity() int {
+	return r.capacity
+}
+
+func (r *SlidingWindowRateLimiter) Window() time.Duration {
+	return r.window
+}
+
+func (r *SlidingWindowRateLimiter) prune(now time.Time) {
+	border := now.Add(-r.window)
+	i := 0
+	for ; i < len(r.entries) && !r.entries[i].at.After(border); i++ {
+		r.used -= r.entries[i].cost
+	}
+	r.entries = r.entries[i:]
+}
+
+// reserve takes the cost and returns zero or returns how long to wait before the next try
+func (r *SlidingWindowRateLimiter) reserve(cost int) time.Duration {
+	return r.TryReserve(cost, 0)
+}
+
+// TryReserve is non-blocking reserve which leaves at least `floor` of the capacity untouched.
+// It's used by PriorityScheduler to keep the capacity for higher priorities.
+func (r *SlidingWindowRateLimiter) TryReserve(cost, floor int) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	if now.Before(r.blockedUntil) {
+		return r.blockedUntil.Sub(now)
+	}
+
+	r.prune(now)
+	available := r.capacity - floor
+	if r.used+cost <= available {
+		r.entries = append(r.entries, costEntry{at: now, cost: cost})
+		r.used += cost
+		return 0
+	}
+
+	// Find the moment when enough of the cost leaves the window
+	excess := r.used + cost - available
+	for _, e := range r.entries {
+		excess -= e.cost
+		if excess <= 0 {
+			return e.at.Add(r.window).Sub(now)
+		}
+	}
+	return r.window // Unreachable while cost+floor <= capacity
+}
+
+// Wait is WaitN with cost 1
+func (r *SlidingWindowRateLimiter) Wait(ctx context.Context) error {
+	return r.WaitN(ctx, 1)
+}
+
+// WaitN blocks until `cost` fits into the window. It returns ErrWaitExceedsDeadline
+// immediately if it's already known that context ends earlier.
+func (r *SlidingWindowRateLimiter) WaitN(ctx context.Context, cost int) error {
+	if cost > r.capacity {
+		return errors.Errorf("cost %d is more than capacity %d", cost, r.capacity)
+	}
+	for {
+		wait := r.reserve(cost)
+		if wait == 0 {
+			return nil
+		}
+		if deadline, ok := ctx.Deadline(); ok && wait > time.Until(deadline) {
+			return errors.Wrapf(ErrWaitExceedsDeadline, "wait %v", wait)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Remaining returns locally known capacity left in the current window
+func (r *SlidingWindowRateLimiter) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prune(r.now())
+	return r.capacity - r.used
+}
+
+// SetRemaining applies remaining capacity reported by the server.
+// Only decreases local estimate: the server doesn't know requests which are in flight.
+func (r *SlidingWindowRateLimiter) SetRemaining(remaining int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	r.prune(now)
+	if missing := r.capacity - remaining - r.used; missing > 0 {
+		r.entries = append(r.entries, costEntry{at: now, cost: missing})
+		r.used += missing
+	}
+}
+
+// SetRetryAfter blocks all requests for `d`
+func (r *SlidingWindowRateLimiter) SetRetryAfter(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if until := r.now().Add(d); until.After(r.blockedUntil) {
+		r.blockedUntil = until
+	}
+}
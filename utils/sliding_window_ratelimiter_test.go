@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSlidingWindowRateLimiter(capacity int, window time.Duration) (*SlidingWindowRateLimiter, *time.Time) {
+	now := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+	r := NewSlidingWindowRateLimiter(capacity, window)
+	r.now = func() time.Time { return now }
+	return r, &now
+}
+
+func TestSlidingWindowRateLimiterNoBoundaryBurst(t *testing.T) {
+	r, now := newTestSlidingWindowRateLimiter(3, time.Minute)
+	*now = now.Add(59 * time.Second)
+
+	assert.Equal(t, time.Duration(0), r.reserve(2))
+	assert.Equal(t, time.Duration(0), r.reserve(1))
+
+	// The next minute has started but the window still contains 3 requests
+	*now = now.Add(2 * time.Second)
+	assert.Equal(t, 58*time.Second, r.reserve(1))
+
+	*now = now.Add(58 * time.Second)
+	assert.Equal(t, time.Duration(0), r.reserve(2))
+	assert.Equal(t, 1, r.Remaining())
+}
+
+func TestSlidingWindowRateLimiterServerFeedback(t *testing.T) {
+	r, now := newTestSlidingWindowRateLimiter(10, time.Minute)
+	assert.Equal(t, time.Duration(0), r.reserve(1))
+
+	r.SetRemaining(2)
+	assert.Equal(t, 2, r.Remaining())
+	r.SetRemaining(5) // Doesn't increase
+	assert.Equal(t, 2, r.Remaining())
+
+	r.SetRetryAfter(10 * time.Second)
+	assert.Equal(t, 10*time.Second, r.reserve(1))
+	*now = now.Add(10 * time.Second)
+	assert.Equal(t, time.Duration(0), r.reserve(1))
+}
+
+func TestSlidingWindowRateLimiterWaitIsCancellable(t *testing.T) {
+	r := NewSlidingWindowRateLimiter(1, time.Minute)
+	assert.NoError(t, r.Wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := r.Wait(ctx)
+	assert.True(t, errors.Is(err, ErrWaitExceedsDeadline))
+
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	assert.Equal(t, context.Canceled, r.Wait(ctx))
+
+	assert.Error(t, r.WaitN(context.Background(), 2))
+}